@@ -1,14 +1,33 @@
 package main
 
 import (
+	"auth-service/internal/alerts"
+	"auth-service/internal/balance"
+	"auth-service/internal/cache"
+	"auth-service/internal/catalog"
 	"auth-service/internal/config"
+	"auth-service/internal/conversations"
+	"auth-service/internal/embeddings"
+	"auth-service/internal/files"
+	"auth-service/internal/flags"
 	"auth-service/internal/handlers"
+	"auth-service/internal/librechat"
+	"auth-service/internal/lists"
+	"auth-service/internal/messages"
 	"auth-service/internal/middleware"
+	"auth-service/internal/password"
+	"auth-service/internal/presets"
+	"auth-service/internal/resilience"
+	"auth-service/internal/search"
+	"auth-service/internal/services"
+	"auth-service/internal/tools"
 	"auth-service/pkg/logger"
 	"context"
+	"errors"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
@@ -24,6 +43,8 @@ func main() {
 	if err != nil {
 		logger.Fatalf("Failed to load configuration: %v", err)
 	}
+	// Secret fields are redacted when logged
+	logger.WithField("config", cfg).Debug("Configuration loaded")
 
 	// Set Gin mode based on config
 	if cfg.Server.Mode == "release" {
@@ -39,12 +60,191 @@ func main() {
 	// Add global middleware
 	r.Use(middleware.LoggerMiddleware(logger))
 	r.Use(middleware.CORSMiddleware(cfg))
+	r.Use(middleware.SecurityHeadersMiddleware(cfg))
 	r.Use(middleware.InputValidationMiddleware())
 
+	// Keycloak client shared by the handlers and the auth middleware, behind a
+	// cache of token validation results and profiles
+	authCache, err := cache.New(cfg.Cache)
+	if err != nil {
+		logger.Fatalf("Failed to create cache: %v", err)
+	}
+	keycloak := services.NewKeycloakService(cfg.Keycloak, logger)
+	keycloakService := services.NewCachedService(keycloak, authCache, cfg.Cache, logger)
+
+	// Feature flags, read through flags.Enabled by handlers
+	flags.SetDefault(flags.NewService(cfg.Flags))
+
+	// Password policy shared by registration, password change and the frontend config
+	passwordPolicy := password.NewPolicy(cfg.Password, password.NewMemoryHistory(), logger)
+
+	// AI endpoint registry served to the chat client
+	registry, err := librechat.LoadRegistry(context.Background(), cfg.LibreChat.ConfigPath, cfg.SecretProvider())
+	if errors.Is(err, os.ErrNotExist) {
+		logger.Warnf("Endpoint registry %s not found, no AI endpoints are available", cfg.LibreChat.ConfigPath)
+		registry = &librechat.Registry{}
+	} else if err != nil {
+		logger.Fatalf("Failed to load endpoint registry: %v", err)
+	}
+
+	// File-backed chat data
+	conversationStore, err := conversations.NewFileStore(cfg.Storage.Dir)
+	if err != nil {
+		logger.Fatalf("Failed to open conversation store: %v", err)
+	}
+	messageStore, err := messages.NewFileStore(cfg.Storage.Dir)
+	if err != nil {
+		logger.Fatalf("Failed to open message store: %v", err)
+	}
+	presetStore, err := presets.NewFileStore(cfg.Storage.Dir)
+	if err != nil {
+		logger.Fatalf("Failed to open preset store: %v", err)
+	}
+
+	// Full-text search, indexed as conversations and messages are written
+	searchIndex, err := search.NewFileIndex(cfg.Storage.Dir, cfg.Search.CachedUsers)
+	if err != nil {
+		logger.Fatalf("Failed to open search index: %v", err)
+	}
+	indexedConversations := search.NewConversations(conversationStore, searchIndex, logger)
+	indexedMessages := search.NewMessages(messageStore, searchIndex, logger)
+
+	// Uploaded files: content on local disk, metadata with the chat data
+	blobStore, err := files.NewLocalBlobStore(filepath.Join(cfg.Storage.Dir, "uploads"))
+	if err != nil {
+		logger.Fatalf("Failed to open upload storage: %v", err)
+	}
+	fileStore, err := files.NewFileStore(cfg.Storage.Dir)
+	if err != nil {
+		logger.Fatalf("Failed to open file store: %v", err)
+	}
+	fileService := files.NewService(cfg.Files, blobStore, fileStore)
+
+	// Assistant replies, generated through each endpoint's API (or locally
+	// with LLM_PROVIDER=echo)
+	providers, err := messages.NewProviderFactory(cfg.LLM)
+	if err != nil {
+		logger.Fatalf("Failed to configure LLM provider: %v", err)
+	}
+
+	// Credit ledger; replies are only charged with BALANCE_ENABLED
+	balanceStore, err := balance.NewFileStore(cfg.Storage.Dir)
+	if err != nil {
+		logger.Fatalf("Failed to open balance store: %v", err)
+	}
+	ledger := balance.NewLedger(balanceStore, cfg.Balance.StartBalance)
+	var meter messages.Meter
+	if cfg.Balance.Enabled {
+		meter = balance.NewMeter(ledger, cfg.Balance.ReserveTokens)
+	}
+
+	// Product catalog, filled by importing the feeds defined in CATALOG_FEEDS_PATH;
+	// every import adds to the price history
+	priceHistory, err := catalog.NewFileHistory(cfg.Storage.Dir, cfg.Catalog.HistoryDays)
+	if err != nil {
+		logger.Fatalf("Failed to open price history: %v", err)
+	}
+	productCatalog, err := catalog.NewCatalog(cfg.Storage.Dir, cfg.Catalog, priceHistory, logger)
+	if err != nil {
+		logger.Fatalf("Failed to open product catalog: %v", err)
+	}
+	feeds, err := catalog.LoadFeeds(cfg.Catalog.FeedsPath, cfg.Catalog.Currency)
+	if errors.Is(err, os.ErrNotExist) {
+		logger.Warnf("Feeds file %s not found, no feeds can be imported", cfg.Catalog.FeedsPath)
+		feeds = &catalog.Feeds{}
+	} else if err != nil {
+		logger.Fatalf("Failed to load feeds: %v", err)
+	}
+
+	// Semantic product search with EMBEDDINGS_PROVIDER set. Products are
+	// embedded in the background: at startup those the index is missing, then
+	// those each import changes.
+	embedder, err := embeddings.NewEmbedder(cfg.Embeddings)
+	if err != nil {
+		logger.Fatalf("Failed to configure embeddings: %v", err)
+	}
+	if embedder != nil {
+		productVectors, err := embeddings.OpenIndex(cfg.Storage.Dir, "products", embedder.Model())
+		if err != nil {
+			logger.Fatalf("Failed to open product embeddings: %v", err)
+		}
+		productCatalog.EnableSemanticSearch(embedder, productVectors, cfg.Embeddings.MinSimilarity)
+	}
+	embeddingsCtx, stopEmbeddings := context.WithCancel(context.Background())
+	defer stopEmbeddings()
+	go productCatalog.RunEmbeddings(embeddingsCtx)
+
+	// Price-drop alerts, evaluated in the background after every import that
+	// changes offers
+	watchStore, err := alerts.NewFileStore(cfg.Storage.Dir)
+	if err != nil {
+		logger.Fatalf("Failed to open watchlists: %v", err)
+	}
+	inbox, err := alerts.NewFileInbox(cfg.Storage.Dir)
+	if err != nil {
+		logger.Fatalf("Failed to open notifications: %v", err)
+	}
+	notifier, err := alerts.NewNotifier(cfg.Alerts, cfg.Storage.Dir, inbox)
+	if err != nil {
+		logger.Fatalf("Failed to configure alert notifiers: %v", err)
+	}
+	evaluator := alerts.NewEvaluator(watchStore, productCatalog, notifier, logger)
+	productCatalog.AddImportListener(evaluator)
+	alertsCtx, stopAlerts := context.WithCancel(context.Background())
+	defer stopAlerts()
+	go evaluator.Run(alertsCtx)
+
+	// Shopping lists, with changes pushed to the members following them.
+	// Invitations are signed with their own secret, never a default one.
+	if cfg.Lists.InviteSecret == "" {
+		logger.Fatal("LISTS_INVITE_SECRET is required to sign shopping list invitations")
+	}
+	fileLists, err := lists.NewFileStore(cfg.Storage.Dir)
+	if err != nil {
+		logger.Fatalf("Failed to open shopping lists: %v", err)
+	}
+	listHub := lists.NewHub()
+	listStore := lists.NewBroadcast(fileLists, listHub)
+
+	// Tools the assistant may call while replying, as the user it replies to
+	toolbox, err := tools.NewRegistry(
+		tools.SearchProducts(productCatalog),
+		tools.GetOffers(productCatalog),
+		tools.AddToList(listStore, productCatalog),
+	).Enabled(cfg.LLM.Tools)
+	if err != nil {
+		logger.Fatalf("Failed to configure LLM tools: %v", err)
+	}
+
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(cfg, logger)
-	userHandler := handlers.NewUserHandler(cfg, logger)
-	frontendHandler := handlers.NewFrontendHandler(cfg, logger)
+	authHandler := handlers.NewAuthHandler(cfg, logger, keycloakService, passwordPolicy)
+	if cfg.Password.SyncKeycloak {
+		// Password resets wait for the realm policy, retried until Keycloak is up
+		go func() {
+			for delay := time.Second; ; delay = min(2*delay, 5*time.Minute) {
+				err := keycloak.SyncPasswordPolicy(passwordPolicy.KeycloakPolicy())
+				if err == nil {
+					authHandler.PasswordPolicySynced()
+					return
+				}
+				logger.WithError(err).Warnf("Failed to sync password policy to Keycloak, retrying in %s", delay)
+				time.Sleep(delay)
+			}
+		}()
+	}
+	userHandler := handlers.NewUserHandler(cfg, logger, keycloakService, passwordPolicy)
+	frontendHandler := handlers.NewFrontendHandler(cfg, logger, passwordPolicy)
+	securityHandler := handlers.NewSecurityHandler(cfg, logger)
+	libreChatHandler := librechat.NewHandler(cfg, logger, registry)
+	conversationHandler := handlers.NewConversationHandler(cfg, logger, indexedConversations, indexedMessages)
+	messageHandler := handlers.NewMessageHandler(cfg, logger, registry, indexedConversations, indexedMessages, providers, meter, toolbox)
+	presetHandler := handlers.NewPresetHandler(cfg, logger, registry, presetStore)
+	fileHandler := handlers.NewFileHandler(cfg, logger, fileService)
+	balanceHandler := handlers.NewBalanceHandler(cfg, logger, ledger)
+	searchHandler := handlers.NewSearchHandler(cfg, logger, searchIndex)
+	catalogHandler := handlers.NewCatalogHandler(cfg, logger, productCatalog, feeds)
+	alertHandler := handlers.NewAlertHandler(cfg, logger, watchStore, inbox, productCatalog)
+	listHandler := handlers.NewListHandler(cfg, logger, listStore, listHub, productCatalog)
 
 	// Register routes
 	api := r.Group("/api/v1")
@@ -52,21 +252,166 @@ func main() {
 		// Public auth routes with rate limiting
 		auth := api.Group("/auth")
 		auth.Use(middleware.AuthRateLimitMiddleware()) // Rate limit for auth endpoints
+		auth.Use(flags.Require(flags.Auth))
 		{
 			auth.POST("/login", authHandler.Login)
 			auth.POST("/register", authHandler.Register)
-			auth.POST("/refresh", authHandler.Refresh)
-			auth.POST("/logout", authHandler.Logout)
+			auth.POST("/password/forgot", authHandler.ForgotPassword)
+			auth.GET("/csrf", authHandler.CSRFToken)
+			// Cookie-authenticated in cookie session mode, so CSRF-protected
+			auth.POST("/refresh", middleware.CSRFMiddleware(cfg), authHandler.Refresh)
+			auth.POST("/logout", middleware.CSRFMiddleware(cfg), authHandler.Logout)
 		}
 
 		// Protected routes (authentication required)
 		protected := api.Group("/user")
-		protected.Use(middleware.AuthMiddleware(cfg, logger))
+		protected.Use(middleware.AuthMiddleware(cfg, logger, keycloakService))
+		protected.Use(flags.Require(flags.Auth))
+		protected.Use(middleware.NoStore()) // profile data is per-user
 		{
 			protected.GET("/profile", userHandler.GetProfile)
 			protected.PUT("/profile", userHandler.UpdateProfile)
 			protected.POST("/change-password", userHandler.ChangePassword)
 		}
+
+		// Product catalog, shared by every user
+		products := api.Group("/products")
+		products.Use(middleware.AuthMiddleware(cfg, logger, keycloakService))
+		products.Use(flags.Require(flags.Shopping))
+		{
+			products.GET("/search", catalogHandler.Search)
+			products.GET("/categories", catalogHandler.Categories)
+			products.GET("/:productId", catalogHandler.Get)
+			products.GET("/:productId/offers", catalogHandler.Offers)
+			products.GET("/:productId/price-history", catalogHandler.PriceHistory)
+		}
+
+		// Watchlist and the notifications its alerts send, per user
+		watchlist := api.Group("/watchlist")
+		watchlist.Use(middleware.AuthMiddleware(cfg, logger, keycloakService))
+		watchlist.Use(flags.Require(flags.Shopping))
+		watchlist.Use(middleware.NoStore())
+		{
+			watchlist.GET("", alertHandler.ListWatches)
+			watchlist.POST("", alertHandler.CreateWatch)
+			watchlist.PUT("/:watchId", alertHandler.UpdateWatch)
+			watchlist.DELETE("/:watchId", alertHandler.DeleteWatch)
+		}
+		notifications := api.Group("/notifications")
+		notifications.Use(middleware.AuthMiddleware(cfg, logger, keycloakService))
+		notifications.Use(flags.Require(flags.Shopping))
+		notifications.Use(middleware.NoStore())
+		{
+			notifications.GET("", alertHandler.ListNotifications)
+			notifications.POST("/read", alertHandler.MarkRead)
+		}
+
+		// Shopping lists, versioned through ETag and If-Match, their carts,
+		// sharing and live updates
+		shoppingLists := api.Group("/lists")
+		shoppingLists.Use(middleware.AuthMiddleware(cfg, logger, keycloakService))
+		shoppingLists.Use(flags.Require(flags.Shopping))
+		shoppingLists.Use(middleware.NoStore())
+		{
+			shoppingLists.GET("", listHandler.ListLists)
+			shoppingLists.POST("", listHandler.CreateList)
+			shoppingLists.GET("/:listId", listHandler.GetList)
+			shoppingLists.PUT("/:listId", listHandler.RenameList)
+			shoppingLists.DELETE("/:listId", listHandler.DeleteList)
+			shoppingLists.POST("/:listId/items", listHandler.AddItem)
+			shoppingLists.PUT("/:listId/items/:itemId", listHandler.UpdateItem)
+			shoppingLists.DELETE("/:listId/items/:itemId", listHandler.DeleteItem)
+			shoppingLists.GET("/:listId/cart", listHandler.Cart)
+			shoppingLists.GET("/:listId/events", listHandler.Events)
+			shoppingLists.POST("/:listId/invites", listHandler.Invite)
+			shoppingLists.DELETE("/:listId/invites/:inviteId", listHandler.RevokeInvite)
+			shoppingLists.PUT("/:listId/members/:userId", listHandler.UpdateMember)
+			shoppingLists.DELETE("/:listId/members/:userId", listHandler.RemoveMember)
+			shoppingLists.POST("/invites/accept", listHandler.AcceptInvite)
+		}
+	}
+
+	// Conversations (LibreChat API), scoped to the authenticated user
+	convos := r.Group("/api/convos")
+	convos.Use(middleware.AuthMiddleware(cfg, logger, keycloakService))
+	convos.Use(flags.Require(flags.AI))
+	convos.Use(middleware.NoStore())
+	{
+		convos.GET("", conversationHandler.List)
+		convos.POST("", conversationHandler.Create)
+		convos.DELETE("", conversationHandler.Delete)
+		convos.GET("/:conversationId", conversationHandler.Get)
+		convos.POST("/update", conversationHandler.Update)
+		convos.POST("/archive", conversationHandler.Archive)
+	}
+
+	// Messages (LibreChat API); POST streams the reply as Server-Sent Events
+	msgs := r.Group("/api/messages")
+	msgs.Use(middleware.AuthMiddleware(cfg, logger, keycloakService))
+	msgs.Use(flags.Require(flags.AI))
+	msgs.Use(middleware.NoStore())
+	{
+		msgs.POST("", messageHandler.Send)
+		msgs.POST("/abort", messageHandler.Abort)
+		msgs.GET("/:conversationId", messageHandler.List)
+	}
+
+	// Presets (LibreChat API); POST creates or updates
+	presetRoutes := r.Group("/api/presets")
+	presetRoutes.Use(middleware.AuthMiddleware(cfg, logger, keycloakService))
+	presetRoutes.Use(flags.Require(flags.AI))
+	presetRoutes.Use(middleware.NoStore())
+	{
+		presetRoutes.GET("", presetHandler.List)
+		presetRoutes.POST("", presetHandler.Save)
+		presetRoutes.POST("/delete", presetHandler.Delete)
+		presetRoutes.POST("/default", presetHandler.SetDefault)
+	}
+
+	// Files (LibreChat API); downloads are checked against the owner in the URL
+	fileRoutes := r.Group("/api/files")
+	fileRoutes.Use(middleware.AuthMiddleware(cfg, logger, keycloakService))
+	fileRoutes.Use(flags.Require(flags.AI))
+	fileRoutes.Use(middleware.NoStore())
+	{
+		fileRoutes.GET("", fileHandler.List)
+		fileRoutes.POST("", fileHandler.Upload)
+		fileRoutes.POST("/images", fileHandler.Upload)
+		fileRoutes.DELETE("", fileHandler.Delete)
+		fileRoutes.GET("/config", fileHandler.Config)
+		fileRoutes.GET("/download/:userId/:fileId", fileHandler.Download)
+		fileRoutes.GET("/download/:userId/:fileId/thumbnail", fileHandler.Thumbnail)
+	}
+
+	// Search over the user's own conversations and messages
+	searchRoutes := r.Group("/api/search")
+	searchRoutes.Use(middleware.AuthMiddleware(cfg, logger, keycloakService))
+	searchRoutes.Use(flags.Require(flags.AI))
+	searchRoutes.Use(middleware.NoStore())
+	{
+		searchRoutes.GET("", searchHandler.Search)
+		searchRoutes.GET("/enable", searchHandler.Enabled)
+	}
+
+	// Credit balance (LibreChat API) and its administration
+	r.GET("/api/balance", middleware.AuthMiddleware(cfg, logger, keycloakService), flags.Require(flags.AI), middleware.NoStore(), balanceHandler.Get)
+	balanceAdmin := r.Group("/api/admin/balance")
+	balanceAdmin.Use(middleware.AuthMiddleware(cfg, logger, keycloakService))
+	balanceAdmin.Use(middleware.RequireRole(cfg.Balance.AdminRole))
+	balanceAdmin.Use(middleware.NoStore())
+	{
+		balanceAdmin.GET("/:userId", balanceHandler.GetAccount)
+		balanceAdmin.POST("/:userId/credits", balanceHandler.Grant)
+	}
+
+	// Catalog feed imports
+	catalogAdmin := r.Group("/api/admin/catalog")
+	catalogAdmin.Use(middleware.AuthMiddleware(cfg, logger, keycloakService))
+	catalogAdmin.Use(middleware.RequireRole(cfg.Catalog.AdminRole))
+	catalogAdmin.Use(middleware.NoStore())
+	{
+		catalogAdmin.GET("/feeds", catalogHandler.Feeds)
+		catalogAdmin.POST("/feeds/:feedId/import", catalogHandler.Import)
 	}
 
 	// Mock endpoints for frontend compatibility
@@ -80,35 +425,30 @@ func main() {
 		})
 	})
 
-	r.GET("/api/config", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"message": "Config endpoint - placeholder for ShopMindAI",
-			"data": gin.H{
-				"app_name": "ShopMindAI",
-				"version": "1.0.0-mvp",
-				"features": []string{"auth", "ai", "shopping"},
-				"emailEnabled": false,
-				"registrationEnabled": true,
-				"socialLogins": gin.H{
-					"google": false,
-					"facebook": false,
-					"discord": false,
-					"github": false,
-				},
-				"turnstile": gin.H{
-					"siteKey": "",
-				},
-			},
-		})
-	})
+	// Client config generated from the feature flags, evaluated for the caller
+	r.GET("/api/config", middleware.OptionalAuthMiddleware(logger, keycloakService), frontendHandler.GetClientConfig)
 
 	// Health check endpoint
+	// Stays 200 while Keycloak is down so the service itself is not restarted;
+	// "degraded" tells load balancers and dashboards that logins will fail fast
 	r.GET("/health", func(c *gin.Context) {
+		status := "healthy"
+		idpState := resilience.StateClosed
+		if breaker, ok := resilience.Breakers.Get(services.KeycloakBreaker); ok {
+			idpState = breaker.State()
+		}
+		if idpState != resilience.StateClosed {
+			status = "degraded"
+		}
+
 		c.JSON(200, gin.H{
-			"status":    "healthy",
+			"status":    status,
 			"service":   "auth-service",
 			"timestamp": time.Now().UTC().Format(time.RFC3339),
 			"version":   "1.0.0-mvp",
+			"identity_provider": gin.H{
+				"circuit_breaker": idpState.String(),
+			},
 		})
 	})
 
@@ -120,6 +460,7 @@ func main() {
 				"uptime": "running",
 				"requests": 0,
 				"errors": 0,
+				"circuit_breakers": resilience.Breakers.Stats(),
 			},
 		})
 	})
@@ -127,49 +468,16 @@ func main() {
 	// Frontend endpoints
 	r.GET("/api/auth/config", frontendHandler.GetAuthConfig)
 	r.GET("/api/app/info", frontendHandler.GetAppInfo)
+	r.GET("/api/validation/rules", frontendHandler.GetValidationRules)
 	r.GET("/api/health/detailed", frontendHandler.GetHealthStatus)
 
-	// AI Endpoints endpoint (required by librechat-data-provider)
-	r.GET("/api/endpoints", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"message": "AI Endpoints - placeholder for ShopMindAI",
-			"data": gin.H{
-				"xAI": nil,
-				"openAI": nil,
-				"azureOpenAI": nil,
-				"azureAssistants": nil,
-				"assistants": nil,
-				"agents": nil,
-				"chatGPTBrowser": nil,
-				"gptPlugins": nil,
-				"google": nil,
-				"anthropic": nil,
-				"custom": nil,
-			},
-		})
-	})
+	// CSP violation report collector (browsers post here in report-only mode)
+	r.POST("/api/csp-report", securityHandler.CSPReport)
 
-	// Startup config endpoint (required by librechat-data-provider)
-	r.GET("/api/startup", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"message": "Startup config - placeholder for ShopMindAI",
-			"data": gin.H{
-				"app_name": "ShopMindAI",
-				"version": "1.0.0-mvp",
-				"emailEnabled": false,
-				"registrationEnabled": true,
-				"socialLogins": gin.H{
-					"google": false,
-					"facebook": false,
-					"discord": false,
-					"github": false,
-				},
-				"turnstile": gin.H{
-					"siteKey": "",
-				},
-			},
-		})
-	})
+	// LibreChat client config (required by librechat-data-provider)
+	r.GET("/api/endpoints", middleware.OptionalAuthMiddleware(logger, keycloakService), flags.Require(flags.AI), libreChatHandler.GetEndpoints)
+	r.GET("/api/models", middleware.AuthMiddleware(cfg, logger, keycloakService), flags.Require(flags.AI), middleware.NoStore(), libreChatHandler.GetModels)
+	r.GET("/api/startup", middleware.OptionalAuthMiddleware(logger, keycloakService), libreChatHandler.GetStartupConfig)
 
 	// Create HTTP server with proper timeouts
 	srv := &http.Server{
@@ -0,0 +1,220 @@
+package alerts
+
+import (
+	"auth-service/internal/catalog"
+	"auth-service/internal/models"
+	"auth-service/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// NotificationPriceDrop is the type of price-drop alerts
+const NotificationPriceDrop = "price_drop"
+
+// Catalog is the part of the product catalog that alerts read prices from
+type Catalog interface {
+	Offers(ctx context.Context, productID, variantID string) (*models.ProductOffers, error)
+}
+
+// Evaluator checks watches against the current prices and sends the alerts
+// due. Imports queue the products they changed; Run evaluates them in the
+// background, so imports never wait for notifiers.
+type Evaluator struct {
+	watches  WatchStore
+	catalog  Catalog
+	notifier Notifier
+	logger   *logger.Logger
+	mutex    sync.Mutex
+	pending  map[string]bool
+	wake     chan struct{}
+	now      func() time.Time
+}
+
+// NewEvaluator creates an evaluator sending alerts through notifier
+func NewEvaluator(watches WatchStore, catalog Catalog, notifier Notifier, logger *logger.Logger) *Evaluator {
+	return &Evaluator{
+		watches:  watches,
+		catalog:  catalog,
+		notifier: notifier,
+		logger:   logger,
+		pending:  map[string]bool{},
+		wake:     make(chan struct{}, 1),
+		now:      time.Now,
+	}
+}
+
+// Imported queues products for evaluation. It implements
+// catalog.ImportListener.
+func (e *Evaluator) Imported(ctx context.Context, productIDs []string) {
+	e.mutex.Lock()
+	for _, productID := range productIDs {
+		e.pending[productID] = true
+	}
+	e.mutex.Unlock()
+
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run evaluates the queued products until ctx is done. Products queued
+// while an evaluation runs are evaluated together afterwards.
+func (e *Evaluator) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.wake:
+		}
+
+		e.mutex.Lock()
+		productIDs := make([]string, 0, len(e.pending))
+		for productID := range e.pending {
+			productIDs = append(productIDs, productID)
+		}
+		e.pending = map[string]bool{}
+		e.mutex.Unlock()
+
+		sort.Strings(productIDs)
+		if err := e.Evaluate(ctx, productIDs); err != nil {
+			e.logger.WithError(err).Warn("Price alert evaluation failed")
+		}
+	}
+}
+
+// Evaluate checks every watch of the products. An alert whose delivery fails
+// is not recorded, so it is sent again on the next evaluation under the same
+// DedupKey.
+func (e *Evaluator) Evaluate(ctx context.Context, productIDs []string) error {
+	var errs []error
+	for _, productID := range productIDs {
+		watches, err := e.watches.ForProduct(ctx, productID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, watch := range watches {
+			if err := e.evaluate(ctx, watch); err != nil {
+				errs = append(errs, fmt.Errorf("watch %s: %w", watch.ID, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (e *Evaluator) evaluate(ctx context.Context, watch models.Watch) error {
+	offers, err := e.catalog.Offers(ctx, watch.ProductID, watch.VariantID)
+	if errors.Is(err, catalog.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	state, notification := check(watch, offers.Best, e.now().UTC())
+	if notification != nil {
+		if err := e.notifier.Notify(ctx, *notification); err != nil {
+			return err
+		}
+	}
+	if reflect.DeepEqual(state, watch.State) {
+		return nil
+	}
+	watch.State = state
+	if err := e.watches.SetState(ctx, watch); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Prime sets the state of a new or changed watch from the current best
+// offer, so only later drops alert: a price already at the target, or the
+// price a percent drop starts from, is not news to the user.
+func Prime(watch *models.Watch, offers *models.ProductOffers) {
+	state := models.WatchState{Alerts: watch.State.Alerts, LastAlertAt: watch.State.LastAlertAt}
+	if best := offers.Best; best != nil {
+		state.Price = best.Total
+		state.Reference = best.Total
+		if watch.TargetPrice != nil && best.Total.Amount <= *watch.TargetPrice {
+			state.Alerted = best.Total
+		}
+	}
+	watch.State = state
+}
+
+// check applies the best offer to the state of a watch and returns the new
+// state and the alert due, if any
+func check(watch models.Watch, best *models.RankedOffer, now time.Time) (models.WatchState, *models.Notification) {
+	state := watch.State
+	if best == nil {
+		// Nothing in stock: no price to compare, keep waiting
+		state.Price = nil
+		return state, nil
+	}
+	price := *best.Total
+	state.Price = &price
+
+	var previous *models.Money
+	switch {
+	case watch.TargetPrice != nil:
+		if price.Amount > *watch.TargetPrice {
+			state.Alerted = nil
+			return state, nil
+		}
+		if state.Alerted != nil {
+			return state, nil
+		}
+		previous = &models.Money{Amount: *watch.TargetPrice, Currency: price.Currency}
+	case watch.PercentDrop != nil:
+		if state.Reference == nil || price.Amount > state.Reference.Amount || price.Currency != state.Reference.Currency {
+			state.Reference = &price
+			state.Alerted = nil
+			return state, nil
+		}
+		if float64(price.Amount)*100 > float64(state.Reference.Amount)*(100-*watch.PercentDrop) {
+			return state, nil
+		}
+		previous = state.Reference
+		state.Reference = &price
+	default:
+		return state, nil
+	}
+
+	state.Alerted = &price
+	state.Alerts++
+	state.LastAlertAt = &now
+	notification := &models.Notification{
+		UserID:        watch.UserID,
+		Type:          NotificationPriceDrop,
+		Title:         fmt.Sprintf("%s is down to %s", watch.ProductName, formatMoney(price)),
+		ProductID:     watch.ProductID,
+		WatchID:       watch.ID,
+		Price:         &price,
+		PreviousPrice: previous,
+		URL:           best.URL,
+		Email:         watch.Email,
+		DedupKey:      fmt.Sprintf("%s:%d", watch.ID, state.Alerts),
+		CreatedAt:     now,
+	}
+	if watch.TargetPrice != nil {
+		notification.Body = fmt.Sprintf("%s costs %s at %s, at or below your target of %s.",
+			watch.ProductName, formatMoney(price), best.Retailer.Name, formatMoney(*previous))
+	} else {
+		drop := math.Round(float64(previous.Amount-price.Amount) * 100 / float64(previous.Amount))
+		notification.Body = fmt.Sprintf("%s costs %s at %s, %.0f%% less than %s.",
+			watch.ProductName, formatMoney(price), best.Retailer.Name, drop, formatMoney(*previous))
+	}
+	return state, notification
+}
+
+// formatMoney formats an amount in minor units, such as "12.99 EUR"
+func formatMoney(money models.Money) string {
+	return fmt.Sprintf("%d.%02d %s", money.Amount/100, money.Amount%100, money.Currency)
+}
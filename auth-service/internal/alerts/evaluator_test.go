@@ -0,0 +1,213 @@
+package alerts
+
+import (
+	"auth-service/internal/catalog"
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/pkg/logger"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFeeds = `
+feeds:
+  - id: acme
+    retailer: {name: ACME}
+    format: csv
+    fields: {sku: sku, gtin: gtin, name: name, price: price, availability: stock}
+`
+
+var testLogger = &logger.Logger{Logger: logrus.New()}
+
+// recorder is a notifier keeping what it was sent; it fails while fail is set
+type recorder struct {
+	mutex         sync.Mutex
+	fail          bool
+	notifications []models.Notification
+	sent          chan struct{}
+}
+
+func (r *recorder) Notify(ctx context.Context, notification models.Notification) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.fail {
+		return errors.New("notifier down")
+	}
+	r.notifications = append(r.notifications, notification)
+	if r.sent != nil {
+		r.sent <- struct{}{}
+	}
+	return nil
+}
+
+type testSetup struct {
+	catalog   *catalog.Catalog
+	feed      *catalog.Feed
+	watches   *FileStore
+	notifier  *recorder
+	evaluator *Evaluator
+	productID string
+}
+
+func newTestSetup(t *testing.T) *testSetup {
+	dir := t.TempDir()
+	products, err := catalog.NewCatalog(dir, config.CatalogConfig{Currency: "EUR"}, nil, testLogger)
+	require.NoError(t, err)
+	feeds, err := catalog.ParseFeeds([]byte(testFeeds), "EUR")
+	require.NoError(t, err)
+	feed, _ := feeds.Get("acme")
+	watches, err := NewFileStore(dir)
+	require.NoError(t, err)
+	notifier := &recorder{}
+
+	s := &testSetup{catalog: products, feed: feed, watches: watches, notifier: notifier}
+	s.evaluator = NewEvaluator(watches, products, notifier, testLogger)
+	s.importPrice(t, "100.00", "in stock")
+	page, err := products.Search(context.Background(), catalog.SearchQuery{})
+	require.NoError(t, err)
+	s.productID = page.Products[0].ID
+	return s
+}
+
+func (s *testSetup) importPrice(t *testing.T, price, stock string) {
+	content := "sku,gtin,name,price,stock\nA-1,,Kettle," + price + "," + stock + "\n"
+	_, err := s.catalog.Import(context.Background(), s.feed, strings.NewReader(content))
+	require.NoError(t, err)
+}
+
+// reprice imports a new price and evaluates the product
+func (s *testSetup) reprice(t *testing.T, price, stock string) {
+	s.importPrice(t, price, stock)
+	require.NoError(t, s.evaluator.Evaluate(context.Background(), []string{s.productID}))
+}
+
+func (s *testSetup) watch(t *testing.T, watch models.Watch) *models.Watch {
+	watch.ProductID = s.productID
+	watch.ProductName = "Kettle"
+	watch.Email = "user@example.com"
+	offers, err := s.catalog.Offers(context.Background(), s.productID, "")
+	require.NoError(t, err)
+	Prime(&watch, offers)
+	_, err = s.watches.Save(context.Background(), "user-1", &watch)
+	require.NoError(t, err)
+	return &watch
+}
+
+func (s *testSetup) sent() []models.Notification {
+	s.notifier.mutex.Lock()
+	defer s.notifier.mutex.Unlock()
+	return append([]models.Notification{}, s.notifier.notifications...)
+}
+
+func TestEvaluator_TargetPriceAlertsOncePerDrop(t *testing.T) {
+	s := newTestSetup(t)
+	target := int64(9000)
+	watch := s.watch(t, models.Watch{TargetPrice: &target})
+
+	s.reprice(t, "95.00", "in stock")
+	assert.Empty(t, s.sent())
+
+	s.reprice(t, "89.00", "in stock")
+	s.reprice(t, "85.00", "in stock")
+	s.reprice(t, "85.00", "out of stock")
+	s.reprice(t, "88.00", "in stock")
+	sent := s.sent()
+	require.Len(t, sent, 1, "staying below the target is one drop")
+	assert.Equal(t, "user-1", sent[0].UserID)
+	assert.Equal(t, "Kettle is down to 89.00 EUR", sent[0].Title)
+	assert.Equal(t, "Kettle costs 89.00 EUR at ACME, at or below your target of 90.00 EUR.", sent[0].Body)
+	assert.Equal(t, watch.ID+":1", sent[0].DedupKey)
+	assert.Equal(t, "user@example.com", sent[0].Email)
+
+	// Going back above the target arms the watch again
+	s.reprice(t, "99.00", "in stock")
+	s.reprice(t, "90.00", "in stock")
+	sent = s.sent()
+	require.Len(t, sent, 2)
+	assert.Equal(t, watch.ID+":2", sent[1].DedupKey)
+
+	stored, err := s.watches.Get(context.Background(), "user-1", watch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.State.Alerts)
+	assert.Equal(t, int64(9000), stored.State.Price.Amount)
+}
+
+func TestEvaluator_PercentDropFromHighestPrice(t *testing.T) {
+	s := newTestSetup(t)
+	drop := 10.0
+	s.watch(t, models.Watch{PercentDrop: &drop})
+
+	s.reprice(t, "91.00", "in stock")
+	assert.Empty(t, s.sent())
+	s.reprice(t, "90.00", "in stock")
+	require.Len(t, s.sent(), 1)
+	assert.Equal(t, "Kettle costs 90.00 EUR at ACME, 10% less than 100.00 EUR.", s.sent()[0].Body)
+
+	// The next alert needs another drop from the alerted price, or from a
+	// higher price seen since
+	s.reprice(t, "85.00", "in stock")
+	assert.Len(t, s.sent(), 1)
+	s.reprice(t, "120.00", "in stock")
+	s.reprice(t, "108.00", "in stock")
+	sent := s.sent()
+	require.Len(t, sent, 2)
+	assert.Equal(t, &models.Money{Amount: 12000, Currency: "EUR"}, sent[1].PreviousPrice)
+}
+
+func TestEvaluator_RetriesFailedDeliveries(t *testing.T) {
+	s := newTestSetup(t)
+	target := int64(9000)
+	watch := s.watch(t, models.Watch{TargetPrice: &target})
+
+	s.notifier.fail = true
+	s.importPrice(t, "80.00", "in stock")
+	assert.Error(t, s.evaluator.Evaluate(context.Background(), []string{s.productID}))
+	stored, err := s.watches.Get(context.Background(), "user-1", watch.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.State.Alerted, "a failed alert is not recorded")
+
+	s.notifier.fail = false
+	require.NoError(t, s.evaluator.Evaluate(context.Background(), []string{s.productID}))
+	require.NoError(t, s.evaluator.Evaluate(context.Background(), []string{s.productID}))
+	sent := s.sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, watch.ID+":1", sent[0].DedupKey)
+}
+
+func TestEvaluator_RunsAfterImports(t *testing.T) {
+	s := newTestSetup(t)
+	s.notifier.sent = make(chan struct{}, 1)
+	s.catalog.AddImportListener(s.evaluator)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.evaluator.Run(ctx)
+
+	target := int64(9000)
+	s.watch(t, models.Watch{TargetPrice: &target})
+	s.importPrice(t, "75.00", "in stock")
+
+	select {
+	case <-s.notifier.sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no alert after the import")
+	}
+	assert.Equal(t, int64(7500), s.sent()[0].Price.Amount)
+}
+
+func TestPrime_PriceAlreadyAtTarget(t *testing.T) {
+	s := newTestSetup(t)
+	target := int64(10000)
+	watch := s.watch(t, models.Watch{TargetPrice: &target})
+	assert.Equal(t, int64(10000), watch.State.Alerted.Amount, "the current price is not news")
+
+	s.reprice(t, "99.00", "in stock")
+	assert.Empty(t, s.sent())
+}
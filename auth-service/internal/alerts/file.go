@@ -0,0 +1,221 @@
+package alerts
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/storage"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileStore keeps each user's watchlist in one JSON file under dir, and an
+// in-memory index of the users watching each product, built when it opens
+type FileStore struct {
+	dir   string
+	mutex sync.Mutex
+	// watchers holds the users watching each product
+	watchers map[string]map[string]bool
+	now      func() time.Time
+}
+
+// userWatches is the document stored per user
+type userWatches struct {
+	Watches []models.Watch `json:"watches"`
+}
+
+// NewFileStore creates a store in dir/watchlists
+func NewFileStore(dir string) (*FileStore, error) {
+	dir = filepath.Join(dir, "watchlists")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	s := &FileStore{dir: dir, watchers: map[string]map[string]bool{}, now: time.Now}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		doc := &userWatches{}
+		if _, err := storage.ReadJSON(filepath.Join(dir, entry.Name()), doc); err != nil {
+			return nil, err
+		}
+		for _, watch := range doc.Watches {
+			s.index(watch.UserID, watch.ProductID)
+		}
+	}
+	return s, nil
+}
+
+// List returns the user's watches, the most recently created first
+func (s *FileStore) List(ctx context.Context, userID string) ([]models.Watch, error) {
+	s.mutex.Lock()
+	doc, err := s.load(userID)
+	s.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	sortWatches(doc.Watches)
+	return doc.Watches, nil
+}
+
+// Get returns one of the user's watches
+func (s *FileStore) Get(ctx context.Context, userID, watchID string) (*models.Watch, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return nil, err
+	}
+	i := doc.find(watchID)
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	return &doc.Watches[i], nil
+}
+
+// Save creates or replaces a watch
+func (s *FileStore) Save(ctx context.Context, userID string, watch *models.Watch) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, other := range doc.Watches {
+		if other.ID != watch.ID && other.ProductID == watch.ProductID && other.VariantID == watch.VariantID {
+			return false, ErrExists
+		}
+	}
+
+	now := s.now().UTC()
+	watch.UserID = userID
+	watch.UpdatedAt = now
+	created := false
+	if i := doc.find(watch.ID); i >= 0 {
+		watch.CreatedAt = doc.Watches[i].CreatedAt
+		doc.Watches[i] = *watch
+	} else {
+		if len(doc.Watches) >= MaxWatches {
+			return false, ErrLimit
+		}
+		if watch.ID == "" {
+			watch.ID = storage.NewID()
+		}
+		watch.CreatedAt = now
+		doc.Watches = append(doc.Watches, *watch)
+		created = true
+	}
+	if err := s.save(userID, doc); err != nil {
+		return false, err
+	}
+	s.index(userID, watch.ProductID)
+	return created, nil
+}
+
+// Delete removes one of the user's watches
+func (s *FileStore) Delete(ctx context.Context, userID, watchID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return err
+	}
+	i := doc.find(watchID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	doc.Watches = append(doc.Watches[:i], doc.Watches[i+1:]...)
+	return s.save(userID, doc)
+}
+
+// ForProduct returns every user's watches of a product
+func (s *FileStore) ForProduct(ctx context.Context, productID string) ([]models.Watch, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	userIDs := make([]string, 0, len(s.watchers[productID]))
+	for userID := range s.watchers[productID] {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+
+	var watches []models.Watch
+	for _, userID := range userIDs {
+		doc, err := s.load(userID)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, watch := range doc.Watches {
+			if watch.ProductID == productID {
+				watches = append(watches, watch)
+				found = true
+			}
+		}
+		if !found {
+			delete(s.watchers[productID], userID)
+		}
+	}
+	return watches, nil
+}
+
+// SetState stores the state of a watch unless it changed since it was read
+func (s *FileStore) SetState(ctx context.Context, watch models.Watch) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(watch.UserID)
+	if err != nil {
+		return err
+	}
+	i := doc.find(watch.ID)
+	if i < 0 || !doc.Watches[i].UpdatedAt.Equal(watch.UpdatedAt) {
+		return ErrNotFound
+	}
+	doc.Watches[i].State = watch.State
+	return s.save(watch.UserID, doc)
+}
+
+// index records that the user watches the product. Entries of deleted
+// watches are dropped by ForProduct.
+func (s *FileStore) index(userID, productID string) {
+	if s.watchers[productID] == nil {
+		s.watchers[productID] = map[string]bool{}
+	}
+	s.watchers[productID][userID] = true
+}
+
+func (s *FileStore) load(userID string) (*userWatches, error) {
+	doc := &userWatches{Watches: []models.Watch{}}
+	if _, err := storage.ReadJSON(storage.UserFile(s.dir, userID), doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (s *FileStore) save(userID string, doc *userWatches) error {
+	return storage.WriteJSON(storage.UserFile(s.dir, userID), doc)
+}
+
+func (doc *userWatches) find(watchID string) int {
+	if watchID == "" {
+		return -1
+	}
+	for i := range doc.Watches {
+		if doc.Watches[i].ID == watchID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,108 @@
+package alerts
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore_WatchesByProduct(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	target := int64(1000)
+	first := &models.Watch{ProductID: "p-1", TargetPrice: &target}
+	created, err := store.Save(ctx, "user-1", first)
+	require.NoError(t, err)
+	assert.True(t, created)
+	_, err = store.Save(ctx, "user-2", &models.Watch{ProductID: "p-1", TargetPrice: &target})
+	require.NoError(t, err)
+	_, err = store.Save(ctx, "user-1", &models.Watch{ProductID: "p-1", TargetPrice: &target})
+	assert.ErrorIs(t, err, ErrExists)
+	_, err = store.Save(ctx, "user-1", &models.Watch{ProductID: "p-1", VariantID: "v-1", TargetPrice: &target})
+	require.NoError(t, err, "a variant is watched on its own")
+
+	// The index is rebuilt from the files
+	reopened, err := NewFileStore(dir)
+	require.NoError(t, err)
+	watches, err := reopened.ForProduct(ctx, "p-1")
+	require.NoError(t, err)
+	assert.Len(t, watches, 3)
+
+	require.NoError(t, reopened.Delete(ctx, "user-2", watches[2].ID))
+	watches, err = reopened.ForProduct(ctx, "p-1")
+	require.NoError(t, err)
+	assert.Len(t, watches, 2)
+	assert.ErrorIs(t, reopened.Delete(ctx, "user-2", "missing"), ErrNotFound)
+	_, err = reopened.Get(ctx, "user-2", first.ID)
+	assert.ErrorIs(t, err, ErrNotFound, "watches of other users do not exist")
+}
+
+func TestFileStore_SetStateSkipsChangedWatches(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	drop := 10.0
+	watch := &models.Watch{ProductID: "p-1", PercentDrop: &drop}
+	_, err = store.Save(ctx, "user-1", watch)
+	require.NoError(t, err)
+
+	read := *watch
+	read.State.Alerts = 1
+	require.NoError(t, store.SetState(ctx, read))
+
+	// The user changes the rule while an evaluation runs
+	now = now.Add(time.Minute)
+	_, err = store.Save(ctx, "user-1", watch)
+	require.NoError(t, err)
+	read.State.Alerts = 2
+	assert.ErrorIs(t, store.SetState(ctx, read), ErrNotFound)
+
+	stored, err := store.Get(ctx, "user-1", watch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.State.Alerts)
+}
+
+func TestFileInbox_ReadAndLimit(t *testing.T) {
+	inbox, err := NewFileInbox(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for i := 0; i < MaxNotifications+5; i++ {
+		added, err := inbox.Add(ctx, &models.Notification{UserID: "user-1", Title: fmt.Sprint(i), DedupKey: fmt.Sprint("key-", i)})
+		require.NoError(t, err)
+		require.True(t, added)
+	}
+	added, err := inbox.Add(ctx, &models.Notification{UserID: "user-1", DedupKey: "key-10"})
+	require.NoError(t, err)
+	assert.False(t, added)
+
+	list, err := inbox.List(ctx, "user-1", false)
+	require.NoError(t, err)
+	require.Len(t, list, MaxNotifications, "the oldest are dropped")
+	assert.Equal(t, fmt.Sprint(MaxNotifications+4), list[0].Title, "newest first")
+
+	marked, err := inbox.MarkRead(ctx, "user-1", []string{list[0].ID, list[1].ID, "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, marked)
+	unread, err := inbox.List(ctx, "user-1", true)
+	require.NoError(t, err)
+	assert.Len(t, unread, MaxNotifications-2)
+
+	marked, err = inbox.MarkRead(ctx, "user-1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, MaxNotifications-2, marked)
+	other, err := inbox.List(ctx, "user-2", false)
+	require.NoError(t, err)
+	assert.Empty(t, other)
+}
@@ -0,0 +1,118 @@
+package alerts
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/storage"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileInbox keeps each user's notifications in one JSON file under dir
+type FileInbox struct {
+	dir   string
+	mutex sync.Mutex
+	now   func() time.Time
+}
+
+// userNotifications is the document stored per user, oldest first
+type userNotifications struct {
+	Notifications []models.Notification `json:"notifications"`
+}
+
+// NewFileInbox creates an inbox in dir/notifications
+func NewFileInbox(dir string) (*FileInbox, error) {
+	dir = filepath.Join(dir, "notifications")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileInbox{dir: dir, now: time.Now}, nil
+}
+
+// Add stores a notification unless the user has one with the same DedupKey
+func (s *FileInbox) Add(ctx context.Context, notification *models.Notification) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(notification.UserID)
+	if err != nil {
+		return false, err
+	}
+	for _, existing := range doc.Notifications {
+		if notification.DedupKey != "" && existing.DedupKey == notification.DedupKey {
+			return false, nil
+		}
+	}
+
+	if notification.ID == "" {
+		notification.ID = storage.NewID()
+	}
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = s.now().UTC()
+	}
+	doc.Notifications = append(doc.Notifications, *notification)
+	if extra := len(doc.Notifications) - MaxNotifications; extra > 0 {
+		doc.Notifications = doc.Notifications[extra:]
+	}
+	return true, s.save(notification.UserID, doc)
+}
+
+// List returns the user's notifications, newest first
+func (s *FileInbox) List(ctx context.Context, userID string, unreadOnly bool) ([]models.Notification, error) {
+	s.mutex.Lock()
+	doc, err := s.load(userID)
+	s.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]models.Notification, 0, len(doc.Notifications))
+	for i := len(doc.Notifications) - 1; i >= 0; i-- {
+		if !unreadOnly || doc.Notifications[i].ReadAt == nil {
+			list = append(list, doc.Notifications[i])
+		}
+	}
+	return list, nil
+}
+
+// MarkRead marks the user's notifications as read, or all of them
+func (s *FileInbox) MarkRead(ctx context.Context, userID string, ids []string) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return 0, err
+	}
+	selected := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+	now := s.now().UTC()
+	marked := 0
+	for i := range doc.Notifications {
+		notification := &doc.Notifications[i]
+		if notification.ReadAt == nil && (len(ids) == 0 || selected[notification.ID]) {
+			notification.ReadAt = &now
+			marked++
+		}
+	}
+	if marked == 0 {
+		return 0, nil
+	}
+	return marked, s.save(userID, doc)
+}
+
+func (s *FileInbox) load(userID string) (*userNotifications, error) {
+	doc := &userNotifications{Notifications: []models.Notification{}}
+	if _, err := storage.ReadJSON(storage.UserFile(s.dir, userID), doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (s *FileInbox) save(userID string, doc *userNotifications) error {
+	return storage.WriteJSON(storage.UserFile(s.dir, userID), doc)
+}
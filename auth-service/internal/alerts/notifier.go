@@ -0,0 +1,80 @@
+package alerts
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+)
+
+// Notifier names, as listed in ALERTS_NOTIFIERS
+const (
+	NotifierInApp   = "in_app"
+	NotifierEmail   = "email"
+	NotifierWebhook = "webhook"
+)
+
+// Notifier delivers a notification. Deliveries may be repeated after a
+// failure; notifiers use DedupKey to drop or mark repeats.
+type Notifier interface {
+	Notify(ctx context.Context, notification models.Notification) error
+}
+
+// NewNotifier creates the notifiers listed in cfg.Notifiers. In-app
+// notifications go to inbox; the email outbox is kept in dir/outbox.
+func NewNotifier(cfg config.AlertsConfig, dir string, inbox NotificationStore) (Notifier, error) {
+	var notifiers Notifiers
+	for _, name := range cfg.Notifiers {
+		switch name {
+		case NotifierInApp:
+			notifiers = append(notifiers, NewInAppNotifier(inbox))
+		case NotifierEmail:
+			outbox, err := NewEmailOutbox(filepath.Join(dir, "outbox"), cfg.EmailFrom)
+			if err != nil {
+				return nil, err
+			}
+			notifiers = append(notifiers, outbox)
+		case NotifierWebhook:
+			if cfg.WebhookURL == "" {
+				return nil, errors.New("the webhook notifier needs ALERTS_WEBHOOK_URL")
+			}
+			notifiers = append(notifiers, NewWebhookNotifier(cfg.WebhookURL, cfg.WebhookSecret.Value(), &http.Client{Timeout: cfg.WebhookTimeout}))
+		default:
+			return nil, fmt.Errorf("unknown notifier %q; use %s, %s or %s", name, NotifierInApp, NotifierEmail, NotifierWebhook)
+		}
+	}
+	return notifiers, nil
+}
+
+// Notifiers delivers to every notifier, even when some fail
+type Notifiers []Notifier
+
+// Notify delivers to every notifier and joins their errors
+func (n Notifiers) Notify(ctx context.Context, notification models.Notification) error {
+	var errs []error
+	for _, notifier := range n {
+		if err := notifier.Notify(ctx, notification); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// InAppNotifier adds notifications to the inbox served by the API
+type InAppNotifier struct {
+	inbox NotificationStore
+}
+
+// NewInAppNotifier creates a notifier adding to inbox
+func NewInAppNotifier(inbox NotificationStore) *InAppNotifier {
+	return &InAppNotifier{inbox: inbox}
+}
+
+// Notify adds the notification unless the user already has it
+func (n *InAppNotifier) Notify(ctx context.Context, notification models.Notification) error {
+	_, err := n.inbox.Add(ctx, &notification)
+	return err
+}
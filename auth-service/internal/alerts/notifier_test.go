@@ -0,0 +1,104 @@
+package alerts
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNotification() models.Notification {
+	return models.Notification{
+		UserID:   "user-1",
+		Type:     NotificationPriceDrop,
+		Title:    "Kettle is down to 89.00 EUR",
+		Body:     "Kettle costs 89.00 EUR at ACME.",
+		URL:      "https://acme.example/kettle",
+		Email:    "user@example.com",
+		DedupKey: "watch-1:1",
+	}
+}
+
+func TestNewNotifier_AllChannels(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("s3cret"))
+		mac.Write(body)
+		assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), r.Header.Get("X-Signature-256"))
+		assert.Equal(t, "watch-1:1", r.Header.Get("Idempotency-Key"))
+		var received models.Notification
+		assert.NoError(t, json.Unmarshal(body, &received))
+		assert.Empty(t, received.Email, "the address is not sent to webhooks")
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	inbox, err := NewFileInbox(dir)
+	require.NoError(t, err)
+	cfg := config.AlertsConfig{
+		Notifiers:     []string{NotifierInApp, NotifierEmail, NotifierWebhook},
+		EmailFrom:     "alerts@example.com",
+		WebhookURL:    server.URL,
+		WebhookSecret: "s3cret",
+	}
+	notifier, err := NewNotifier(cfg, dir, inbox)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, notifier.Notify(ctx, testNotification()))
+	require.NoError(t, notifier.Notify(ctx, testNotification()))
+	assert.Equal(t, int32(3), calls.Load(), "the 503 is retried, and repeats are left to the receiver")
+
+	list, err := inbox.List(ctx, "user-1", false)
+	require.NoError(t, err)
+	assert.Len(t, list, 1, "the inbox drops repeats")
+
+	files, err := os.ReadDir(filepath.Join(dir, "outbox"))
+	require.NoError(t, err)
+	require.Len(t, files, 1, "a repeat replaces the waiting email")
+	data, err := os.ReadFile(filepath.Join(dir, "outbox", files[0].Name()))
+	require.NoError(t, err)
+	var email Email
+	require.NoError(t, json.Unmarshal(data, &email))
+	assert.Equal(t, "user@example.com", email.To)
+	assert.Equal(t, "alerts@example.com", email.From)
+	assert.Equal(t, "Kettle costs 89.00 EUR at ACME.\n\nhttps://acme.example/kettle", email.Text)
+}
+
+func TestNewNotifier_Validation(t *testing.T) {
+	_, err := NewNotifier(config.AlertsConfig{Notifiers: []string{"sms"}}, t.TempDir(), nil)
+	assert.ErrorContains(t, err, `unknown notifier "sms"`)
+
+	_, err = NewNotifier(config.AlertsConfig{Notifiers: []string{NotifierWebhook}}, t.TempDir(), nil)
+	assert.ErrorContains(t, err, "ALERTS_WEBHOOK_URL")
+}
+
+func TestWebhookNotifier_DoesNotRetryRejections(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(server.URL, "", server.Client()).Notify(context.Background(), testNotification())
+	assert.ErrorIs(t, err, errWebhookRejected)
+	assert.Equal(t, int32(1), calls.Load())
+}
@@ -0,0 +1,65 @@
+package alerts
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/storage"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Email is a message waiting in the outbox
+type Email struct {
+	ID        string    `json:"id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Subject   string    `json:"subject"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EmailOutbox writes each email as a JSON file in dir, for a mail relay to
+// send and delete. Files are named after the notification's DedupKey, so a
+// repeated delivery replaces the waiting email instead of adding another.
+type EmailOutbox struct {
+	dir  string
+	from string
+}
+
+// NewEmailOutbox creates an outbox in dir sending from the address from
+func NewEmailOutbox(dir, from string) (*EmailOutbox, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &EmailOutbox{dir: dir, from: from}, nil
+}
+
+// Notify writes the notification as an email to its recipient. Users without
+// an email address are skipped.
+func (o *EmailOutbox) Notify(ctx context.Context, notification models.Notification) error {
+	if notification.Email == "" {
+		return nil
+	}
+	key := notification.DedupKey
+	if key == "" {
+		key = notification.ID
+	}
+	sum := sha256.Sum256([]byte(key))
+	id := hex.EncodeToString(sum[:16])
+
+	text := notification.Body
+	if notification.URL != "" {
+		text += "\n\n" + notification.URL
+	}
+	return storage.WriteJSON(filepath.Join(o.dir, id+".json"), Email{
+		ID:        id,
+		From:      o.from,
+		To:        notification.Email,
+		Subject:   notification.Title,
+		Text:      text,
+		CreatedAt: notification.CreatedAt,
+	})
+}
@@ -0,0 +1,64 @@
+// Package alerts keeps each user's watchlist of products and tells them when
+// a watched product gets cheaper. Prices are evaluated after every catalog
+// import, and alerts go out through pluggable notifiers: the in-app
+// notification inbox, an email outbox and a webhook.
+package alerts
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"sort"
+)
+
+var (
+	// ErrNotFound is returned for unknown watches and for those of other users
+	ErrNotFound = errors.New("watch not found")
+	// ErrExists is returned when saving a second watch for the same product
+	// and variant
+	ErrExists = errors.New("product already watched")
+	// ErrLimit is returned when creating a watch beyond MaxWatches
+	ErrLimit = errors.New("watch limit reached")
+)
+
+// MaxWatches is the number of products a user may watch
+const MaxWatches = 100
+
+// MaxNotifications is the number of notifications kept per user; older ones
+// are dropped
+const MaxNotifications = 200
+
+// WatchStore persists watchlists
+type WatchStore interface {
+	// List returns the user's watches, the most recently created first
+	List(ctx context.Context, userID string) ([]models.Watch, error)
+	// Get returns one of the user's watches
+	Get(ctx context.Context, userID, watchID string) (*models.Watch, error)
+	// Save creates or replaces a watch, filling in its ID, owner and
+	// timestamps, and reports whether it was created
+	Save(ctx context.Context, userID string, watch *models.Watch) (bool, error)
+	// Delete removes one of the user's watches
+	Delete(ctx context.Context, userID, watchID string) error
+	// ForProduct returns every user's watches of a product
+	ForProduct(ctx context.Context, productID string) ([]models.Watch, error)
+	// SetState stores the state of a watch unless the watch was deleted or
+	// saved again since watch was read, which returns ErrNotFound
+	SetState(ctx context.Context, watch models.Watch) error
+}
+
+// NotificationStore persists the in-app notifications
+type NotificationStore interface {
+	// Add stores a notification unless the user already has one with the
+	// same DedupKey, and reports whether it was added
+	Add(ctx context.Context, notification *models.Notification) (bool, error)
+	// List returns the user's notifications, newest first
+	List(ctx context.Context, userID string, unreadOnly bool) ([]models.Notification, error)
+	// MarkRead marks the user's notifications as read, or all of them when
+	// ids is empty, and returns how many were unread
+	MarkRead(ctx context.Context, userID string, ids []string) (int, error)
+}
+
+// sortWatches orders watches as List returns them
+func sortWatches(list []models.Watch) {
+	sort.SliceStable(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+}
@@ -0,0 +1,79 @@
+package alerts
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/resilience"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// webhookRetry retries deliveries the receiver failed or never answered
+var webhookRetry = resilience.RetryPolicy{MaxRetries: 2, Backoff: time.Second, MaxBackoff: 5 * time.Second}
+
+// errWebhookRejected marks responses that are not worth retrying
+var errWebhookRejected = errors.New("webhook rejected the notification")
+
+// WebhookNotifier posts each notification as JSON to a URL. The
+// Idempotency-Key header carries the DedupKey so receivers can drop
+// repeats; with a secret, X-Signature-256 is "sha256=" and the hex
+// HMAC-SHA256 of the body.
+type WebhookNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url
+func NewWebhookNotifier(url, secret string, client *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{url: url, secret: secret, client: client}
+}
+
+// Notify posts the notification, retrying network errors and 5xx responses
+func (n *WebhookNotifier) Notify(ctx context.Context, notification models.Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	return resilience.Retry(ctx, webhookRetry, func(err error) bool {
+		return !errors.Is(err, errWebhookRejected)
+	}, func(ctx context.Context) error {
+		return n.post(ctx, notification.DedupKey, body)
+	})
+}
+
+func (n *WebhookNotifier) post(ctx context.Context, key string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %w", errWebhookRejected, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	if n.secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	switch {
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("%w: %s", errWebhookRejected, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package balance
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/storage"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore keeps each user's ledger in one JSON file under dir
+type FileStore struct {
+	dir   string
+	mutex sync.Mutex
+}
+
+// userLedger is the document stored per user
+type userLedger struct {
+	Entries []models.LedgerEntry `json:"entries"`
+}
+
+// NewFileStore creates a store in dir/balance
+func NewFileStore(dir string) (*FileStore, error) {
+	dir = filepath.Join(dir, "balance")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Entries returns the user's entries, oldest first
+func (s *FileStore) Entries(ctx context.Context, userID string) ([]models.LedgerEntry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Entries, nil
+}
+
+// Append adds entries if the ledger is still at version
+func (s *FileStore) Append(ctx context.Context, userID string, version int64, entries []models.LedgerEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return err
+	}
+	if int64(len(doc.Entries)) != version {
+		return ErrConflict
+	}
+	doc.Entries = append(doc.Entries, entries...)
+	return storage.WriteJSON(storage.UserFile(s.dir, userID), doc)
+}
+
+func (s *FileStore) load(userID string) (*userLedger, error) {
+	doc := &userLedger{Entries: []models.LedgerEntry{}}
+	if _, err := storage.ReadJSON(storage.UserFile(s.dir, userID), doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
@@ -0,0 +1,143 @@
+package balance
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/storage"
+	"context"
+	"errors"
+	"time"
+)
+
+// maxAttempts bounds how often a write that lost a race is rebuilt and retried
+const maxAttempts = 5
+
+// Ledger moves credits by appending entries to a LedgerStore. Users without
+// entries have the start balance, which is written as their first entry the
+// first time credits move.
+type Ledger struct {
+	store        LedgerStore
+	startBalance int64
+	now          func() time.Time
+}
+
+// Reservation holds credits until it is settled
+type Reservation struct {
+	ID     string
+	UserID string
+	Amount int64
+}
+
+// NewLedger creates a ledger giving new users startBalance credits
+func NewLedger(store LedgerStore, startBalance int64) *Ledger {
+	return &Ledger{store: store, startBalance: startBalance, now: time.Now}
+}
+
+// Balance returns the user's credits
+func (l *Ledger) Balance(ctx context.Context, userID string) (int64, error) {
+	entries, err := l.store.Entries(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return l.startBalance, nil
+	}
+	return total(entries), nil
+}
+
+// Entries returns the user's ledger, oldest first
+func (l *Ledger) Entries(ctx context.Context, userID string) ([]models.LedgerEntry, error) {
+	return l.store.Entries(ctx, userID)
+}
+
+// Grant credits the user with amount on behalf of actor
+func (l *Ledger) Grant(ctx context.Context, userID string, amount int64, actor, note string) (*models.LedgerEntry, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	written, err := l.write(ctx, userID, func(balance int64) ([]models.LedgerEntry, error) {
+		return []models.LedgerEntry{{Type: TypeCredit, Amount: amount, Reason: ReasonGrant, Actor: actor, Note: note}}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &written[len(written)-1], nil
+}
+
+// Reserve debits amount ahead of a charge whose final cost is not known yet.
+// It fails with ErrInsufficientFunds when the balance is lower than amount.
+func (l *Ledger) Reserve(ctx context.Context, userID string, amount int64) (*Reservation, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	reservation := &Reservation{ID: storage.NewID(), UserID: userID, Amount: amount}
+	_, err := l.write(ctx, userID, func(balance int64) ([]models.LedgerEntry, error) {
+		if balance < amount {
+			return nil, ErrInsufficientFunds
+		}
+		return []models.LedgerEntry{{Type: TypeDebit, Amount: amount, Reason: ReasonReservation, Reference: reservation.ID}}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// Settle charges used credits for a reservation: the rest is refunded, and
+// any use beyond the reservation is debited even if the balance goes below
+// zero. Settle each reservation once.
+func (l *Ledger) Settle(ctx context.Context, reservation *Reservation, used int64) error {
+	diff := reservation.Amount - max(used, 0)
+	if diff == 0 {
+		return nil
+	}
+	entry := models.LedgerEntry{Type: TypeRefund, Amount: diff, Reason: ReasonSettlement, Reference: reservation.ID}
+	if diff < 0 {
+		entry.Type, entry.Amount = TypeDebit, -diff
+	}
+	_, err := l.write(ctx, reservation.UserID, func(balance int64) ([]models.LedgerEntry, error) {
+		return []models.LedgerEntry{entry}, nil
+	})
+	return err
+}
+
+// write appends the entries built from the current balance, rebuilding them
+// when another write got there first. It returns the entries written.
+func (l *Ledger) write(ctx context.Context, userID string, build func(balance int64) ([]models.LedgerEntry, error)) ([]models.LedgerEntry, error) {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		existing, err := l.store.Entries(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		version := int64(len(existing))
+		balance := total(existing)
+
+		var pending []models.LedgerEntry
+		if version == 0 && l.startBalance > 0 {
+			pending = append(pending, models.LedgerEntry{Type: TypeCredit, Amount: l.startBalance, Reason: ReasonStart})
+		}
+		built, err := build(balance + total(pending))
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, built...)
+
+		now := l.now().UTC()
+		for i := range pending {
+			balance += signed(pending[i])
+			pending[i].ID = storage.NewID()
+			pending[i].Sequence = version + int64(i) + 1
+			pending[i].Balance = balance
+			pending[i].CreatedAt = now
+		}
+
+		err = l.store.Append(ctx, userID, version, pending)
+		if errors.Is(err, ErrConflict) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return pending, nil
+	}
+	return nil, ErrConflict
+}
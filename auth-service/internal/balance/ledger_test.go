@@ -0,0 +1,161 @@
+package balance
+
+import (
+	"auth-service/internal/messages"
+	"auth-service/internal/models"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLedger(t *testing.T, startBalance int64) (*Ledger, *FileStore) {
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	return NewLedger(store, startBalance), store
+}
+
+func TestLedger_StartBalanceAndGrant(t *testing.T) {
+	ledger, store := newTestLedger(t, 100)
+	ctx := context.Background()
+
+	credits, err := ledger.Balance(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), credits)
+	entries, err := store.Entries(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, entries, "reading the balance writes nothing")
+
+	entry, err := ledger.Grant(ctx, "user-1", 50, "admin-1", "welcome bonus")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), entry.Sequence)
+	assert.Equal(t, int64(150), entry.Balance)
+	assert.Equal(t, "admin-1", entry.Actor)
+
+	entries, err = ledger.Entries(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, ReasonStart, entries[0].Reason)
+	assert.Equal(t, ReasonGrant, entries[1].Reason)
+
+	_, err = ledger.Grant(ctx, "user-1", 0, "admin-1", "")
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+func TestLedger_ReserveAndSettle(t *testing.T) {
+	ledger, _ := newTestLedger(t, 1000)
+	ctx := context.Background()
+
+	reservation, err := ledger.Reserve(ctx, "user-1", 300)
+	require.NoError(t, err)
+	credits, _ := ledger.Balance(ctx, "user-1")
+	assert.Equal(t, int64(700), credits)
+
+	require.NoError(t, ledger.Settle(ctx, reservation, 120))
+	credits, _ = ledger.Balance(ctx, "user-1")
+	assert.Equal(t, int64(880), credits, "the unused 180 are refunded")
+
+	// Use beyond the reservation is still charged
+	reservation, err = ledger.Reserve(ctx, "user-1", 100)
+	require.NoError(t, err)
+	require.NoError(t, ledger.Settle(ctx, reservation, 150))
+	credits, _ = ledger.Balance(ctx, "user-1")
+	assert.Equal(t, int64(730), credits)
+
+	entries, _ := ledger.Entries(ctx, "user-1")
+	types := make([]string, len(entries))
+	for i, entry := range entries {
+		types[i] = entry.Type
+		assert.Equal(t, int64(i+1), entry.Sequence)
+	}
+	assert.Equal(t, []string{TypeCredit, TypeDebit, TypeRefund, TypeDebit, TypeDebit}, types)
+	assert.Equal(t, entries[1].Reference, entries[2].Reference, "a settlement refers to its reservation")
+	assert.Equal(t, int64(730), entries[4].Balance)
+
+	_, err = ledger.Reserve(ctx, "user-1", 731)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+// racingStore appends an entry of its own before the first Append, as a
+// concurrent writer would
+type racingStore struct {
+	*FileStore
+	raced bool
+}
+
+func (s *racingStore) Append(ctx context.Context, userID string, version int64, entries []models.LedgerEntry) error {
+	if !s.raced {
+		s.raced = true
+		other := models.LedgerEntry{Type: TypeDebit, Amount: 60, Reason: ReasonReservation}
+		if err := s.FileStore.Append(ctx, userID, version, []models.LedgerEntry{other}); err != nil {
+			return err
+		}
+	}
+	return s.FileStore.Append(ctx, userID, version, entries)
+}
+
+func TestLedger_RetriesOnConflict(t *testing.T) {
+	files, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, files.Append(ctx, "user-1", 0, []models.LedgerEntry{{Type: TypeCredit, Amount: 100}}))
+	ledger := NewLedger(&racingStore{FileStore: files}, 0)
+
+	// The retry sees the concurrent debit and no longer fits
+	_, err = ledger.Reserve(ctx, "user-1", 50)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	reservation, err := ledger.Reserve(ctx, "user-1", 40)
+	require.NoError(t, err)
+	assert.Equal(t, int64(40), reservation.Amount)
+	credits, _ := ledger.Balance(ctx, "user-1")
+	assert.Equal(t, int64(0), credits)
+}
+
+func TestLedger_ConcurrentReservationsNeverOverdraw(t *testing.T) {
+	ledger, _ := newTestLedger(t, 100)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	granted := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ledger.Reserve(ctx, "user-1", 10); err == nil {
+				mutex.Lock()
+				granted++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	credits, err := ledger.Balance(ctx, "user-1")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, credits, int64(0))
+	assert.Equal(t, int64(100-10*granted), credits)
+}
+
+func TestMeter_ChargesEstimatedUsage(t *testing.T) {
+	ledger, _ := newTestLedger(t, 1000)
+	meter := NewMeter(ledger, 200)
+	ctx := context.Background()
+
+	req := messages.CompletionRequest{Messages: []messages.ChatMessage{{Role: messages.RoleUser, Content: "Find running shoes under 100 euros"}}}
+	settle, err := meter.Reserve(ctx, "user-1", req)
+	require.NoError(t, err)
+	credits, _ := ledger.Balance(ctx, "user-1")
+	assert.Equal(t, int64(1000-9-200), credits)
+
+	require.NoError(t, settle(ctx, messages.Usage{PromptTokens: 9, CompletionTokens: 25}))
+	credits, _ = ledger.Balance(ctx, "user-1")
+	assert.Equal(t, int64(1000-34), credits)
+
+	poor := NewMeter(ledger, 10000)
+	_, err = poor.Reserve(ctx, "user-1", req)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+}
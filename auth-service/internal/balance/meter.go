@@ -0,0 +1,31 @@
+package balance
+
+import (
+	"auth-service/internal/messages"
+	"context"
+)
+
+// Meter charges replies to the ledger at one credit per token. A reply
+// reserves its estimated prompt plus reserveTokens for the completion, and
+// settles at its estimated usage.
+type Meter struct {
+	ledger        *Ledger
+	reserveTokens int64
+}
+
+// NewMeter creates a meter charging to ledger
+func NewMeter(ledger *Ledger, reserveTokens int64) *Meter {
+	return &Meter{ledger: ledger, reserveTokens: reserveTokens}
+}
+
+// Reserve holds credits for a reply to req; ErrInsufficientFunds when the
+// user cannot afford it
+func (m *Meter) Reserve(ctx context.Context, userID string, req messages.CompletionRequest) (func(ctx context.Context, usage messages.Usage) error, error) {
+	reservation, err := m.ledger.Reserve(ctx, userID, messages.EstimatePromptTokens(req)+m.reserveTokens)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, usage messages.Usage) error {
+		return m.ledger.Settle(ctx, reservation, usage.PromptTokens+usage.CompletionTokens)
+	}, nil
+}
@@ -0,0 +1,62 @@
+// Package balance keeps each user's AI credits as a ledger of immutable
+// entries. The balance is never stored on its own: it is the sum of the
+// entries, and writes append to the ledger only if no other write got there
+// first (optimistic concurrency on the ledger version).
+package balance
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+)
+
+var (
+	// ErrConflict is returned by LedgerStore.Append when the ledger moved past
+	// the version the entries were built on
+	ErrConflict = errors.New("ledger changed concurrently")
+	// ErrInsufficientFunds is returned when a reservation exceeds the balance
+	ErrInsufficientFunds = errors.New("insufficient balance")
+	// ErrInvalidAmount is returned for amounts that are not positive
+	ErrInvalidAmount = errors.New("amount must be positive")
+)
+
+// Entry types
+const (
+	TypeCredit = "credit"
+	TypeDebit  = "debit"
+	TypeRefund = "refund"
+)
+
+// Entry reasons
+const (
+	ReasonStart       = "start"
+	ReasonGrant       = "grant"
+	ReasonReservation = "reservation"
+	ReasonSettlement  = "settlement"
+)
+
+// LedgerStore persists ledger entries
+type LedgerStore interface {
+	// Entries returns the user's entries, oldest first
+	Entries(ctx context.Context, userID string) ([]models.LedgerEntry, error)
+	// Append adds entries to the user's ledger if it is still at version
+	// (the number of entries it holds) and fails with ErrConflict otherwise
+	Append(ctx context.Context, userID string, version int64, entries []models.LedgerEntry) error
+}
+
+// signed returns the change an entry makes to the balance
+func signed(entry models.LedgerEntry) int64 {
+	if entry.Type == TypeDebit {
+		return -entry.Amount
+	}
+	return entry.Amount
+}
+
+// total derives the balance from entries
+func total(entries []models.LedgerEntry) int64 {
+	var balance int64
+	for _, entry := range entries {
+		balance += signed(entry)
+	}
+	return balance
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"auth-service/internal/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Cache is a byte-oriented key/value store with per-entry expiry.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored under key and whether it was found
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes keys; missing keys are ignored
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// New creates the cache selected by cfg.Backend
+func New(cfg config.CacheConfig) (Cache, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return NewMemoryCache(cfg.MaxEntries), nil
+	case "redis":
+		return NewRedisCache(RedisOptions{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword.Value(),
+			DB:       cfg.RedisDB,
+			Prefix:   cfg.RedisPrefix,
+			Timeout:  cfg.RedisTimeout,
+		}), nil
+	case "none":
+		return Noop{}, nil
+	}
+	return nil, fmt.Errorf("unknown cache backend %q", cfg.Backend)
+}
+
+// GetJSON decodes the JSON value under key into dst and reports whether it was found
+func GetJSON(ctx context.Context, c Cache, key string, dst interface{}) (bool, error) {
+	data, ok, err := c.Get(ctx, key)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		// Treat undecodable entries (e.g. from an older version) as misses
+		return false, nil
+	}
+	return true, nil
+}
+
+// SetJSON stores value under key as JSON
+func SetJSON(ctx context.Context, c Cache, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.Set(ctx, key, data, ttl)
+}
+
+// Noop is a Cache that stores nothing, for running with caching disabled
+type Noop struct{}
+
+// Get always misses
+func (Noop) Get(context.Context, string) ([]byte, bool, error) { return nil, false, nil }
+
+// Set discards the value
+func (Noop) Set(context.Context, string, []byte, time.Duration) error { return nil }
+
+// Delete does nothing
+func (Noop) Delete(context.Context, ...string) error { return nil }
@@ -0,0 +1,230 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCache_TTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(0, 0)
+	c := NewMemoryCache(10)
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Second))
+	value, ok, err := c.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	now = now.Add(time.Second)
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok, "entry should expire")
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestMemoryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(2)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), time.Minute))
+	_, _, _ = c.Get(ctx, "a") // a is now more recent than b
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), time.Minute))
+
+	_, ok, _ := c.Get(ctx, "b")
+	assert.False(t, ok)
+	_, ok, _ = c.Get(ctx, "a")
+	assert.True(t, ok)
+	_, ok, _ = c.Get(ctx, "c")
+	assert.True(t, ok)
+
+	require.NoError(t, c.Delete(ctx, "a", "missing"))
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok)
+}
+
+func TestJSONHelpers(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(10)
+
+	require.NoError(t, SetJSON(ctx, c, "k", map[string]int{"n": 1}, time.Minute))
+	var got map[string]int
+	ok, err := GetJSON(ctx, c, "k", &got)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, got["n"])
+
+	require.NoError(t, c.Set(ctx, "bad", []byte("not json"), time.Minute))
+	ok, err = GetJSON(ctx, c, "bad", &got)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestGroup_SharesInFlightCalls(t *testing.T) {
+	var g Group
+	var calls int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _, _ = g.Do("key", func() (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return "value", nil
+			})
+		}(i)
+	}
+
+	// Let the goroutines pile up behind the first call
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls)
+	for _, r := range results {
+		assert.Equal(t, "value", r)
+	}
+
+	// Once finished, the next call runs again
+	_, _, shared := g.Do("key", func() (interface{}, error) { return nil, nil })
+	assert.False(t, shared)
+}
+
+// fakeRedis is a minimal in-memory server for GET, SET PX, DEL, AUTH and SELECT
+type fakeRedis struct {
+	mutex    sync.Mutex
+	data     map[string]string
+	commands []string
+}
+
+func startFakeRedis(t *testing.T) (*fakeRedis, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeRedis{data: make(map[string]string)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server, listener.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		args := make([]string, count)
+		for i := range args {
+			header, _ := reader.ReadString('\n')
+			size, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+			buf := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, buf); err != nil {
+				return
+			}
+			args[i] = string(buf[:size])
+		}
+
+		f.mutex.Lock()
+		f.commands = append(f.commands, strings.Join(args, " "))
+		var reply string
+		switch strings.ToUpper(args[0]) {
+		case "AUTH", "SELECT":
+			reply = "+OK\r\n"
+		case "GET":
+			if value, ok := f.data[args[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+			} else {
+				reply = "$-1\r\n"
+			}
+		case "SET":
+			f.data[args[1]] = args[2]
+			reply = "+OK\r\n"
+		case "DEL":
+			deleted := 0
+			for _, key := range args[1:] {
+				if _, ok := f.data[key]; ok {
+					delete(f.data, key)
+					deleted++
+				}
+			}
+			reply = fmt.Sprintf(":%d\r\n", deleted)
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		f.mutex.Unlock()
+		conn.Write([]byte(reply))
+	}
+}
+
+func TestRedisCache(t *testing.T) {
+	server, addr := startFakeRedis(t)
+	ctx := context.Background()
+	c := NewRedisCache(RedisOptions{Addr: addr, Password: "secret", DB: 2, Prefix: "test:"})
+	defer c.Close()
+
+	_, ok, err := c.Get(ctx, "missing")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	value := []byte("binary\r\nvalue")
+	require.NoError(t, c.Set(ctx, "k", value, 1500*time.Millisecond))
+	got, ok, err := c.Get(ctx, "k")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, value, got)
+
+	require.NoError(t, c.Delete(ctx, "k", "other"))
+	_, ok, _ = c.Get(ctx, "k")
+	assert.False(t, ok)
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	assert.Equal(t, []string{
+		"AUTH secret",
+		"SELECT 2",
+		"GET test:missing",
+		"SET test:k binary\r\nvalue PX 1500",
+		"GET test:k",
+		"DEL test:k test:other",
+		"GET test:k",
+	}, server.commands, "one pooled connection, prefixed keys")
+}
+
+func TestRedisCache_ServerError(t *testing.T) {
+	_, addr := startFakeRedis(t)
+	c := NewRedisCache(RedisOptions{Addr: addr})
+	defer c.Close()
+
+	_, err := c.do(context.Background(), "FLUSHALL")
+	var redisErr RedisError
+	assert.ErrorAs(t, err, &redisErr)
+	assert.Equal(t, "redis: ERR unknown command", err.Error())
+}
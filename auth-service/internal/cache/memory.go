@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryCache is an in-process LRU cache with per-entry TTL. When full, the
+// least recently used entry is evicted; expired entries are dropped on access.
+type MemoryCache struct {
+	maxEntries int
+	now        func() time.Time
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // front is most recently used
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryCache creates a cache holding at most maxEntries entries
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get returns the value under key if present and not expired
+func (m *MemoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*memoryEntry)
+	if !m.now().Before(entry.expiresAt) {
+		m.remove(element)
+		return nil, false, nil
+	}
+
+	m.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+// Set stores value under key for ttl. A non-positive ttl deletes the key.
+func (m *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		m.remove(element)
+	}
+	if ttl <= 0 {
+		return nil
+	}
+
+	// Copy so callers can reuse their buffer
+	stored := make([]byte, len(value))
+	copy(stored, value)
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, value: stored, expiresAt: m.now().Add(ttl)})
+
+	for m.order.Len() > m.maxEntries {
+		m.remove(m.order.Back())
+	}
+	return nil
+}
+
+// Delete removes keys
+func (m *MemoryCache) Delete(_ context.Context, keys ...string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, key := range keys {
+		if element, ok := m.entries[key]; ok {
+			m.remove(element)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of stored entries, including expired ones not yet dropped
+func (m *MemoryCache) Len() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.order.Len()
+}
+
+// remove drops an entry. The caller must hold the mutex.
+func (m *MemoryCache) remove(element *list.Element) {
+	m.order.Remove(element)
+	delete(m.entries, element.Value.(*memoryEntry).key)
+}
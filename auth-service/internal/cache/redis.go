@@ -0,0 +1,264 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// RedisOptions configures a RedisCache
+type RedisOptions struct {
+	Addr     string // host:port
+	Password string
+	DB       int
+	// Prefix is prepended to every key so several services can share a database
+	Prefix string
+	// Timeout bounds dialing and each command
+	Timeout time.Duration
+	// PoolSize is the number of idle connections kept open
+	PoolSize int
+}
+
+// RedisCache is a Cache backed by any server speaking the Redis protocol
+// (Redis, Valkey, KeyDB, Dragonfly). It uses GET, SET PX and DEL over a
+// small connection pool.
+type RedisCache struct {
+	opts RedisOptions
+	dial func(ctx context.Context) (net.Conn, error)
+	idle chan *redisConn
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	writer *bufio.Writer
+}
+
+// errNil marks a Redis nil reply (missing key)
+var errNil = errors.New("redis: nil")
+
+// RedisError is an error reply from the server
+type RedisError string
+
+func (e RedisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// NewRedisCache creates a cache for the server at opts.Addr. Connections are
+// opened lazily.
+func NewRedisCache(opts RedisOptions) *RedisCache {
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	return &RedisCache{
+		opts: opts,
+		dial: func(ctx context.Context) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", opts.Addr)
+		},
+		idle: make(chan *redisConn, opts.PoolSize),
+	}
+}
+
+// Get returns the value under key
+func (r *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", r.opts.Prefix+key)
+	if errors.Is(err, errNil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+	return value, true, nil
+}
+
+// Set stores value under key with a millisecond expiry
+func (r *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return r.Delete(ctx, key)
+	}
+	ms := ttl.Milliseconds()
+	if ms == 0 {
+		ms = 1
+	}
+	_, err := r.do(ctx, "SET", r.opts.Prefix+key, value, "PX", strconv.FormatInt(ms, 10))
+	return err
+}
+
+// Delete removes keys
+func (r *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		args = append(args, r.opts.Prefix+key)
+	}
+	_, err := r.do(ctx, "DEL", args...)
+	return err
+}
+
+// Close closes the idle connections
+func (r *RedisCache) Close() error {
+	for {
+		select {
+		case c := <-r.idle:
+			c.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do sends one command and reads its reply
+func (r *RedisCache) do(ctx context.Context, command string, args ...interface{}) (interface{}, error) {
+	c, err := r.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := append([]interface{}{command}, args...)
+	reply, err := c.roundTrip(ctx, r.opts.Timeout, parts...)
+	var redisErr RedisError
+	if err != nil && !errors.Is(err, errNil) && !errors.As(err, &redisErr) {
+		// The connection state is unknown after an I/O error
+		c.conn.Close()
+		return nil, err
+	}
+	r.put(c)
+	return reply, err
+}
+
+func (r *RedisCache) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case c := <-r.idle:
+		return c, nil
+	default:
+	}
+
+	conn, err := r.dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("redis: dial %s: %w", r.opts.Addr, err)
+	}
+	c := &redisConn{conn: conn, reader: bufio.NewReader(conn), writer: bufio.NewWriter(conn)}
+
+	if r.opts.Password != "" {
+		if _, err := c.roundTrip(ctx, r.opts.Timeout, "AUTH", r.opts.Password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis: auth: %w", err)
+		}
+	}
+	if r.opts.DB != 0 {
+		if _, err := c.roundTrip(ctx, r.opts.Timeout, "SELECT", strconv.Itoa(r.opts.DB)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis: select: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func (r *RedisCache) put(c *redisConn) {
+	select {
+	case r.idle <- c:
+	default:
+		c.conn.Close()
+	}
+}
+
+func (c *redisConn) roundTrip(ctx context.Context, timeout time.Duration, parts ...interface{}) (interface{}, error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	// Commands are sent as RESP arrays of bulk strings
+	fmt.Fprintf(c.writer, "*%d\r\n", len(parts))
+	for _, part := range parts {
+		var data []byte
+		switch v := part.(type) {
+		case string:
+			data = []byte(v)
+		case []byte:
+			data = v
+		default:
+			return nil, fmt.Errorf("redis: unsupported argument type %T", part)
+		}
+		fmt.Fprintf(c.writer, "$%d\r\n", len(data))
+		c.writer.Write(data)
+		c.writer.WriteString("\r\n")
+	}
+	if err := c.writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	return readReply(c.reader)
+}
+
+// readReply parses one RESP2 reply. Bulk strings are returned as []byte,
+// integers as int64, simple strings as string and arrays as []interface{}.
+func readReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, RedisError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", payload)
+		}
+		if size < 0 {
+			return nil, errNil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return data[:size], nil
+	case '*':
+		count, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed array length %q", payload)
+		}
+		if count < 0 {
+			return nil, errNil
+		}
+		items := make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			item, err := readReply(reader)
+			if err != nil && !errors.Is(err, errNil) {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
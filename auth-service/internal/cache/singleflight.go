@@ -0,0 +1,44 @@
+package cache
+
+import "sync"
+
+// Group deduplicates concurrent loads of the same key: while a load is in
+// flight, other callers for that key wait for it and share its result, so a
+// cache miss on a hot key reaches the backend once.
+type Group struct {
+	mutex sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// Do runs fn for key unless a call for key is already running, in which case
+// it waits for that call. shared reports whether the result came from another caller.
+func (g *Group) Do(key string, fn func() (interface{}, error)) (value interface{}, err error, shared bool) {
+	g.mutex.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mutex.Unlock()
+		<-c.done
+		return c.value, c.err, true
+	}
+	c := &call{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mutex.Unlock()
+
+	defer func() {
+		g.mutex.Lock()
+		delete(g.calls, key)
+		g.mutex.Unlock()
+		close(c.done)
+	}()
+
+	c.value, c.err = fn()
+	return c.value, c.err, false
+}
@@ -0,0 +1,79 @@
+package catalog
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"sort"
+)
+
+// CartLine is a product, and how many of it, to price in a cart
+type CartLine struct {
+	ItemID    string
+	ProductID string
+	VariantID string
+	Name      string
+	Quantity  int
+}
+
+// Cart prices each line at its best offer, as ranked by Offers, and groups
+// the lines by the retailer of that offer. Lines of products that are gone
+// or in stock nowhere are listed as unavailable.
+func (c *Catalog) Cart(ctx context.Context, listID string, lines []CartLine) (*models.Cart, error) {
+	cart := &models.Cart{
+		ListID:      listID,
+		Currency:    c.currency,
+		Retailers:   []models.RetailerCart{},
+		Unavailable: []string{},
+		Total:       models.Money{Currency: c.currency},
+	}
+	byRetailer := map[string]*models.RetailerCart{}
+	for _, line := range lines {
+		offers, err := c.Offers(ctx, line.ProductID, line.VariantID)
+		if errors.Is(err, ErrNotFound) || err == nil && offers.Best == nil {
+			cart.Unavailable = append(cart.Unavailable, line.ItemID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		best := offers.Best
+		unit, _ := c.rates.Convert(best.Price)
+		shipping := models.Money{Currency: c.currency}
+		if best.Shipping != nil {
+			shipping, _ = c.rates.Convert(*best.Shipping)
+		}
+
+		group, ok := byRetailer[best.Retailer.ID]
+		if !ok {
+			group = &models.RetailerCart{
+				Retailer: best.Retailer,
+				Items:    []models.CartItem{},
+				Subtotal: models.Money{Currency: c.currency},
+				Shipping: models.Money{Currency: c.currency},
+			}
+			byRetailer[best.Retailer.ID] = group
+		}
+		price := models.Money{Amount: unit.Amount * int64(line.Quantity), Currency: c.currency}
+		group.Items = append(group.Items, models.CartItem{
+			ItemID:    line.ItemID,
+			ProductID: line.ProductID,
+			VariantID: best.VariantID,
+			Name:      line.Name,
+			Quantity:  line.Quantity,
+			UnitPrice: unit,
+			Price:     price,
+			URL:       best.URL,
+		})
+		group.Subtotal.Amount += price.Amount
+		group.Shipping.Amount = max(group.Shipping.Amount, shipping.Amount)
+	}
+
+	for _, group := range byRetailer {
+		group.Total = models.Money{Amount: group.Subtotal.Amount + group.Shipping.Amount, Currency: c.currency}
+		cart.Total.Amount += group.Total.Amount
+		cart.Retailers = append(cart.Retailers, *group)
+	}
+	sort.Slice(cart.Retailers, func(i, j int) bool { return cart.Retailers[i].Retailer.ID < cart.Retailers[j].Retailer.ID })
+	return cart, nil
+}
@@ -0,0 +1,52 @@
+package catalog
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog_CartGroupsBestOffersByRetailer(t *testing.T) {
+	c, _, feeds := newComparingCatalog(t)
+	importFeed(t, c, feeds, "euro-shop", "sku,gtin,name,price,shipping,stock\nE-1,4006381333931,Kettle,40.00,5.95,in stock\nE-2,,Toaster,20.00,3.00,in stock\nE-3,,Mug,5.00,4.50,in stock\n")
+	importFeed(t, c, feeds, "us-shop", "sku,gtin,name,price,shipping,stock\nU-1,4006381333931,Kettle,60.00,2.00,in stock\nU-2,,Teapot,30.00,0,out of stock\n")
+
+	ids := map[string]string{}
+	page, err := c.Search(context.Background(), SearchQuery{})
+	require.NoError(t, err)
+	for _, product := range page.Products {
+		ids[product.Name] = product.ID
+	}
+
+	cart, err := c.Cart(context.Background(), "list-1", []CartLine{
+		{ItemID: "i-1", ProductID: ids["Kettle"], Name: "Kettle", Quantity: 2},
+		{ItemID: "i-2", ProductID: ids["Toaster"], Name: "Toaster", Quantity: 1},
+		{ItemID: "i-3", ProductID: ids["Mug"], Name: "Mug", Quantity: 4},
+		{ItemID: "i-4", ProductID: ids["Teapot"], Name: "Teapot", Quantity: 1},
+		{ItemID: "i-5", ProductID: "gone", Name: "Gone", Quantity: 1},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "list-1", cart.ListID)
+	assert.Equal(t, []string{"i-4", "i-5"}, cart.Unavailable)
+	require.Len(t, cart.Retailers, 2)
+
+	euro := cart.Retailers[0]
+	assert.Equal(t, "euro-shop", euro.Retailer.ID)
+	require.Len(t, euro.Items, 2)
+	assert.Equal(t, models.Money{Amount: 2000, Currency: "EUR"}, euro.Items[1].Price)
+	assert.Equal(t, models.Money{Amount: 4000, Currency: "EUR"}, euro.Subtotal)
+	assert.Equal(t, models.Money{Amount: 450, Currency: "EUR"}, euro.Shipping, "an order ships once, at the highest rate")
+
+	us := cart.Retailers[1]
+	assert.Equal(t, "us-shop", us.Retailer.ID)
+	require.Len(t, us.Items, 1)
+	assert.Equal(t, models.Money{Amount: 3000, Currency: "EUR"}, us.Items[0].UnitPrice, "prices are converted")
+	assert.Equal(t, models.Money{Amount: 6000, Currency: "EUR"}, us.Items[0].Price)
+	assert.Equal(t, models.Money{Amount: 6100, Currency: "EUR"}, us.Total)
+
+	assert.Equal(t, models.Money{Amount: 4450 + 6100, Currency: "EUR"}, cart.Total)
+}
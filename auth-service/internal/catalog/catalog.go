@@ -0,0 +1,224 @@
+// Package catalog holds the product catalog: products and their variants,
+// the retailers' offers for them, and the category tree. It is filled by
+// importing retailer feeds, searched with keyword queries and facets,
+// optionally ranked by meaning too, and keeps the history of the prices seen
+// in imports.
+package catalog
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/storage"
+	"auth-service/pkg/logger"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned for a product that does not exist
+	ErrNotFound = errors.New("product not found")
+	// ErrInvalidFeed is returned for a feed that cannot be read at all
+	ErrInvalidFeed = errors.New("invalid feed")
+	// ErrInvalidQuery is returned for a search with invalid parameters
+	ErrInvalidQuery = errors.New("invalid search query")
+)
+
+// Catalog keeps the whole catalog in memory and in one JSON file under dir.
+// Imports rewrite the file; reads never touch it.
+type Catalog struct {
+	path      string
+	currency  string
+	rates     Rates
+	history   PriceHistory
+	listeners []ImportListener
+	semantic  *semantic
+	logger    *logger.Logger
+	mutex     sync.RWMutex
+	data      catalogData
+	index     *productIndex
+	now       func() time.Time
+}
+
+// ImportListener is told which products had offers added, changed or
+// removed by an import, once the import is stored. It is called on the
+// importing request, so it must not block.
+type ImportListener interface {
+	Imported(ctx context.Context, productIDs []string)
+}
+
+// catalogData is the document stored on disk
+type catalogData struct {
+	Products   []*models.Product `json:"products"`
+	Retailers  []models.Retailer `json:"retailers"`
+	Categories []models.Category `json:"categories"`
+}
+
+// NewCatalog opens the catalog in dir/catalog. Prices are compared in
+// cfg.Currency. Imports record prices in history, which may be nil.
+func NewCatalog(dir string, cfg config.CatalogConfig, history PriceHistory, logger *logger.Logger) (*Catalog, error) {
+	dir = filepath.Join(dir, "catalog")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	c := &Catalog{
+		path:     filepath.Join(dir, "catalog.json"),
+		currency: cfg.Currency,
+		rates:    NewRates(cfg.Currency, cfg.ExchangeRates),
+		history:  history,
+		logger:   logger,
+		now:      time.Now,
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// AddImportListener adds a listener to every following import. Listeners
+// must be added before the catalog is shared.
+func (c *Catalog) AddImportListener(listener ImportListener) {
+	c.listeners = append(c.listeners, listener)
+}
+
+// Currency returns the currency prices are compared in
+func (c *Catalog) Currency() string {
+	return c.currency
+}
+
+// Product returns a product with its category path and the retailers of its offers
+func (c *Catalog) Product(ctx context.Context, id string) (*models.ProductDetail, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	product, ok := c.index.products[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	detail := &models.ProductDetail{Product: *product, CategoryPath: c.index.categoryPath(product.CategoryID), Retailers: []models.Retailer{}}
+	seen := map[string]bool{}
+	for _, variant := range product.Variants {
+		for _, offer := range variant.Offers {
+			if retailer, ok := c.index.retailers[offer.RetailerID]; ok && !seen[offer.RetailerID] {
+				seen[offer.RetailerID] = true
+				detail.Retailers = append(detail.Retailers, retailer)
+			}
+		}
+	}
+	sort.Slice(detail.Retailers, func(i, j int) bool { return detail.Retailers[i].ID < detail.Retailers[j].ID })
+	return detail, nil
+}
+
+// Offers returns a product's offers ranked by total cost: in stock first,
+// then by price plus shipping in the catalog currency. A variantID keeps
+// the offers of one variant.
+func (c *Catalog) Offers(ctx context.Context, productID, variantID string) (*models.ProductOffers, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	product, ok := c.index.products[productID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	result := &models.ProductOffers{ProductID: productID, Offers: []models.RankedOffer{}}
+	for _, variant := range product.Variants {
+		if variantID != "" && variant.ID != variantID {
+			continue
+		}
+		for _, offer := range variant.Offers {
+			ranked := models.RankedOffer{
+				Retailer:    c.index.retailers[offer.RetailerID],
+				VariantID:   variant.ID,
+				VariantName: variant.Name,
+				SKU:         offer.SKU,
+				Price:       offer.Price,
+				Shipping:    offer.Shipping,
+				InStock:     offer.InStock,
+				URL:         offer.URL,
+				UpdatedAt:   offer.UpdatedAt,
+			}
+			if total, ok := c.rates.total(offer); ok {
+				ranked.Total = &total
+			}
+			result.Offers = append(result.Offers, ranked)
+		}
+	}
+
+	sort.SliceStable(result.Offers, func(i, j int) bool {
+		a, b := result.Offers[i], result.Offers[j]
+		if a.InStock != b.InStock {
+			return a.InStock
+		}
+		if (a.Total == nil) != (b.Total == nil) {
+			return a.Total != nil
+		}
+		if a.Total != nil && a.Total.Amount != b.Total.Amount {
+			return a.Total.Amount < b.Total.Amount
+		}
+		return a.Retailer.ID < b.Retailer.ID
+	})
+	if len(result.Offers) > 0 && result.Offers[0].InStock && result.Offers[0].Total != nil {
+		best := result.Offers[0]
+		result.Best = &best
+	}
+	return result, nil
+}
+
+// PriceHistory returns a product's daily prices from one day to another,
+// both inclusive. An empty retailerID merges every retailer.
+func (c *Catalog) PriceHistory(ctx context.Context, productID, retailerID string, from, to time.Time) (*models.PriceHistoryResponse, error) {
+	c.mutex.RLock()
+	_, ok := c.index.products[productID]
+	c.mutex.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	response := &models.PriceHistoryResponse{ProductID: productID, Currency: c.currency, Buckets: []models.PriceBucket{}}
+	if c.history == nil {
+		return response, nil
+	}
+	buckets, err := c.history.Daily(ctx, productID, retailerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	response.Buckets = buckets
+	return response, nil
+}
+
+// Categories returns the category tree, ordered by ID so parents come first
+func (c *Catalog) Categories(ctx context.Context) []models.Category {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return append([]models.Category{}, c.data.Categories...)
+}
+
+func (c *Catalog) load() error {
+	data := catalogData{}
+	if _, err := storage.ReadJSON(c.path, &data); err != nil {
+		return err
+	}
+	c.data = data
+	c.index = newProductIndex(data, c.rates)
+	return nil
+}
+
+// save writes the catalog and rebuilds the index. If the write fails, the
+// catalog is reloaded so memory matches the file again.
+func (c *Catalog) save() error {
+	sort.Slice(c.data.Products, func(i, j int) bool { return c.data.Products[i].ID < c.data.Products[j].ID })
+	sort.Slice(c.data.Retailers, func(i, j int) bool { return c.data.Retailers[i].ID < c.data.Retailers[j].ID })
+	sort.Slice(c.data.Categories, func(i, j int) bool { return c.data.Categories[i].ID < c.data.Categories[j].ID })
+	if err := storage.WriteJSON(c.path, c.data); err != nil {
+		if loadErr := c.load(); loadErr != nil {
+			return errors.Join(err, loadErr)
+		}
+		return err
+	}
+	c.index = newProductIndex(c.data, c.rates)
+	return nil
+}
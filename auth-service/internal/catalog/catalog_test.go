@@ -0,0 +1,343 @@
+package catalog
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/pkg/logger"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFeeds = `
+feeds:
+  - id: acme
+    retailer:
+      name: ACME Sports
+      url: https://acme.example
+    format: csv
+    delimiter: ";"
+    fields:
+      sku: Article
+      gtin: EAN
+      group_id: Parent
+      name: Title
+      variant_name: Variant
+      brand: Brand
+      category: Category
+      price: Price
+      availability: Stock
+    attributes:
+      size: Size
+  - id: shoply
+    format: json
+    items: data.products
+    fields:
+      sku: id
+      gtin: barcode
+      name: name
+      brand: brand
+      category: category
+      price: offer.price
+      currency: offer.currency
+      url: link
+`
+
+const acmeFeed = "\xef\xbb\xbfArticle;EAN;Parent;Title;Variant;Brand;Category;Price;Stock;Size\n" +
+	"A-1;4006381333931;P-1;Trail Runner 2;Size 42;Stridex;Sports > Running Shoes;89,99;in stock;42\n" +
+	"A-2;;P-1;Trail Runner 2;Size 43;Stridex;Sports > Running Shoes;94,99;out of stock;43\n" +
+	"A-3;;;Yoga Mat;;Zenly;Sports > Yoga;24.50;in_stock;\n" +
+	"A-4;;;Broken Price;;Zenly;Sports;free;;\n"
+
+const shoplyFeed = `{"data": {"products": [
+	{"id": "S-9", "barcode": "04006381333931", "name": "Stridex Trail Runner II", "brand": "Stridex",
+	 "category": "Shoes > Running", "offer": {"price": 84.5, "currency": "eur"}, "link": "https://shoply.example/s-9"},
+	{"id": "S-10", "name": "Espresso Cup", "category": "Kitchen", "offer": {"price": "7.90"}},
+	{"id": "S-11", "name": "Imported Kettle", "offer": {"price": 30, "currency": "USD"}}
+]}}`
+
+var testLogger = &logger.Logger{Logger: logrus.New()}
+
+func newTestCatalog(t *testing.T) (*Catalog, *Feeds, string) {
+	dir := t.TempDir()
+	c, err := NewCatalog(dir, config.CatalogConfig{Currency: "EUR"}, nil, testLogger)
+	require.NoError(t, err)
+	feeds, err := ParseFeeds([]byte(testFeeds), "EUR")
+	require.NoError(t, err)
+	return c, feeds, dir
+}
+
+func importFeed(t *testing.T, c *Catalog, feeds *Feeds, id, content string) *models.ImportResult {
+	feed, ok := feeds.Get(id)
+	require.True(t, ok)
+	result, err := c.Import(context.Background(), feed, strings.NewReader(content))
+	require.NoError(t, err)
+	return result
+}
+
+func TestParsePrice(t *testing.T) {
+	tests := map[string]int64{
+		"12.99":     1299,
+		"12,99 €":   1299,
+		"EUR 7.9":   790,
+		"1299":      129900,
+		"1.299,00":  129900,
+		"1,299.00":  129900,
+		"1,299":     129900,
+		"0.5":       50,
+		"$1 234.56": 123456,
+	}
+	for value, want := range tests {
+		got, err := ParsePrice(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+	for _, value := range []string{"", "free", "0.00", "-5"} {
+		_, err := ParsePrice(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestNormalizeGTIN(t *testing.T) {
+	for _, gtin := range []string{"4006381333931", "04006381333931"} {
+		normalized, ok := NormalizeGTIN(gtin)
+		assert.True(t, ok, gtin)
+		assert.Equal(t, "04006381333931", normalized)
+	}
+	normalized, ok := NormalizeGTIN("036000291452")
+	assert.True(t, ok, "UPC-A")
+	assert.Equal(t, "00036000291452", normalized)
+
+	for _, gtin := range []string{"4006381333932", "12345", "40063813339x1"} {
+		_, ok := NormalizeGTIN(gtin)
+		assert.False(t, ok, gtin)
+	}
+}
+
+func TestParseFeeds_Validation(t *testing.T) {
+	_, err := ParseFeeds([]byte("feeds:\n  - id: a\n    format: xml\n"), "EUR")
+	assert.ErrorContains(t, err, "format")
+
+	_, err = ParseFeeds([]byte("feeds:\n  - id: a\n    format: csv\n    fields: {sku: id, name: title}\n"), "EUR")
+	assert.ErrorContains(t, err, `"price" must be mapped`)
+
+	_, err = ParseFeeds([]byte("feeds:\n  - id: a\n    format: csv\n    fields: {sku: id, name: t, price: p, colour: c}\n"), "EUR")
+	assert.ErrorContains(t, err, `unknown field "colour"`)
+
+	feeds, err := ParseFeeds([]byte(testFeeds), "EUR")
+	require.NoError(t, err)
+	feed, _ := feeds.Get("shoply")
+	assert.Equal(t, "shoply", feed.Retailer.ID, "the retailer defaults to the feed")
+	assert.Equal(t, "EUR", feed.Currency)
+	assert.Len(t, feeds.List(), 2)
+}
+
+func TestCatalog_ImportGroupsVariantsAndDedupsByGTIN(t *testing.T) {
+	c, feeds, _ := newTestCatalog(t)
+
+	result := importFeed(t, c, feeds, "acme", acmeFeed)
+	assert.Equal(t, 4, result.Items)
+	assert.Equal(t, 3, result.Created)
+	assert.Equal(t, 1, result.Skipped)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, 5, result.Errors[0].Row)
+	assert.Contains(t, result.Errors[0].Message, "price")
+
+	result = importFeed(t, c, feeds, "shoply", shoplyFeed)
+	assert.Equal(t, 2, result.Created)
+	assert.Equal(t, 1, result.Skipped, "prices in a currency without an exchange rate are skipped")
+
+	page, err := c.Search(context.Background(), SearchQuery{Text: "trail runner"})
+	require.NoError(t, err)
+	require.Equal(t, 1, page.Total, "the same GTIN from two retailers is one product")
+	summary := page.Products[0]
+	assert.Equal(t, "Trail Runner 2", summary.Name, "the first feed's details are kept")
+	assert.Equal(t, int64(8450), summary.MinPrice.Amount)
+	assert.Equal(t, int64(9499), summary.MaxPrice.Amount)
+	assert.Equal(t, 3, summary.OfferCount)
+
+	product, err := c.Product(context.Background(), summary.ID)
+	require.NoError(t, err)
+	require.Len(t, product.Variants, 2, "items of one group are variants of one product")
+	assert.Equal(t, "04006381333931", product.Variants[0].GTIN)
+	assert.Len(t, product.Variants[0].Offers, 2)
+	assert.Equal(t, map[string]string{"size": "43"}, product.Variants[1].Attributes)
+	assert.False(t, product.Variants[1].Offers[0].InStock)
+	assert.Equal(t, []string{"sports", "sports/running-shoes"}, categoryIDs(product.CategoryPath))
+	require.Len(t, product.Retailers, 2)
+	assert.Equal(t, "ACME Sports", product.Retailers[0].Name)
+
+	_, err = c.Product(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func categoryIDs(categories []models.Category) []string {
+	ids := make([]string, len(categories))
+	for i, category := range categories {
+		ids[i] = category.ID
+	}
+	return ids
+}
+
+func TestCatalog_ReimportUpdatesAndRemovesOffers(t *testing.T) {
+	c, feeds, dir := newTestCatalog(t)
+	importFeed(t, c, feeds, "acme", acmeFeed)
+	c.now = func() time.Time { return time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	// A-1 is cheaper, A-2 is unchanged, A-3 is gone and A-4 is still broken
+	updated := "Article;EAN;Parent;Title;Variant;Brand;Category;Price;Stock;Size\n" +
+		"A-1;4006381333931;P-1;Trail Runner 2;Size 42;Stridex;Sports > Running Shoes;79,99;in stock;42\n" +
+		"A-2;;P-1;Trail Runner 2;Size 43;Stridex;Sports > Running Shoes;94,99;out of stock;43\n" +
+		"A-4;;;Broken Price;;Zenly;Sports;free;;\n"
+	result := importFeed(t, c, feeds, "acme", updated)
+	assert.Equal(t, 0, result.Created)
+	assert.Equal(t, 1, result.Updated)
+	assert.Equal(t, 1, result.Unchanged)
+	assert.Equal(t, 1, result.Removed)
+
+	// The catalog is read back from disk
+	reopened, err := NewCatalog(dir, config.CatalogConfig{Currency: "EUR"}, nil, testLogger)
+	require.NoError(t, err)
+	page, err := reopened.Search(context.Background(), SearchQuery{Sort: SortPriceAsc})
+	require.NoError(t, err)
+	require.Equal(t, 2, page.Total, "products without offers stay in the catalog")
+	assert.Equal(t, "Trail Runner 2", page.Products[0].Name)
+	assert.Equal(t, int64(7999), page.Products[0].MinPrice.Amount)
+	assert.Equal(t, "Yoga Mat", page.Products[1].Name)
+	assert.Nil(t, page.Products[1].MinPrice)
+
+	product, err := reopened.Product(context.Background(), page.Products[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2030, product.Variants[0].Offers[0].UpdatedAt.Year(), "changed offers are stamped")
+	assert.NotEqual(t, 2030, product.Variants[1].Offers[0].UpdatedAt.Year(), "unchanged offers are not")
+}
+
+// listener records the products each import reports
+type listener struct {
+	imports [][]string
+}
+
+func (l *listener) Imported(ctx context.Context, productIDs []string) {
+	l.imports = append(l.imports, productIDs)
+}
+
+func TestCatalog_TellsListenersAboutRepricedProducts(t *testing.T) {
+	c, feeds, _ := newTestCatalog(t)
+	l := &listener{}
+	c.AddImportListener(l)
+
+	importFeed(t, c, feeds, "acme", acmeFeed)
+	require.Len(t, l.imports, 1)
+	assert.Len(t, l.imports[0], 2, "both new products")
+
+	importFeed(t, c, feeds, "acme", acmeFeed)
+	assert.Len(t, l.imports, 1, "an unchanged feed reprices nothing")
+
+	// A-1 is cheaper and A-3 is gone; A-2 belongs to the same product as A-1
+	updated := "Article;EAN;Parent;Title;Variant;Brand;Category;Price;Stock;Size\n" +
+		"A-1;4006381333931;P-1;Trail Runner 2;Size 42;Stridex;Sports > Running Shoes;79,99;in stock;42\n" +
+		"A-2;;P-1;Trail Runner 2;Size 43;Stridex;Sports > Running Shoes;94,99;out of stock;43\n"
+	importFeed(t, c, feeds, "acme", updated)
+	require.Len(t, l.imports, 2)
+	assert.Len(t, l.imports[1], 2)
+}
+
+func TestCatalog_ImportRejectsUnreadableFeeds(t *testing.T) {
+	c, feeds, _ := newTestCatalog(t)
+	acme, _ := feeds.Get("acme")
+	shoply, _ := feeds.Get("shoply")
+
+	_, err := c.Import(context.Background(), acme, strings.NewReader("Article;Title\nA-1;Shoe\n"))
+	assert.ErrorIs(t, err, ErrInvalidFeed, "mapped columns must exist")
+	_, err = c.Import(context.Background(), acme, strings.NewReader("Article;EAN;Parent;Title;Variant;Brand;Category;Price;Stock;Size\n"))
+	assert.ErrorIs(t, err, ErrInvalidFeed, "an empty feed would remove every offer")
+	_, err = c.Import(context.Background(), shoply, strings.NewReader(`{"data": {}}`))
+	assert.ErrorIs(t, err, ErrInvalidFeed)
+
+	result := importFeed(t, c, feeds, "acme", "Article;EAN;Parent;Title;Variant;Brand;Category;Price;Stock;Size\n"+
+		"A-1;;;Shoe;;;;10;;\n"+
+		"A-1;;;Shoe again;;;;12;;\n"+
+		"A-2;123;;Sock;;;;3;;\n"+
+		"A-3;;;Short row\n")
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 3, result.Skipped)
+	messages := []string{}
+	for _, e := range result.Errors {
+		messages = append(messages, e.Message)
+	}
+	assert.Equal(t, []string{`duplicate sku "A-1", first on row 2`, `gtin "123" is not a valid GTIN`, "wrong number of fields"}, messages)
+}
+
+func TestCatalog_SearchFacetsSortingAndPaging(t *testing.T) {
+	c, feeds, _ := newTestCatalog(t)
+	importFeed(t, c, feeds, "acme", acmeFeed)
+	importFeed(t, c, feeds, "shoply", shoplyFeed)
+	ctx := context.Background()
+
+	page, err := c.Search(ctx, SearchQuery{Text: "stri"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, page.Total, "the last word matches as a prefix")
+
+	page, err = c.Search(ctx, SearchQuery{Text: "yoga"})
+	require.NoError(t, err)
+	require.Equal(t, 1, page.Total, "category names are searchable")
+	assert.Equal(t, "Yoga Mat", page.Products[0].Name)
+
+	page, err = c.Search(ctx, SearchQuery{Text: "4006381333931"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, page.Total, "GTINs are searchable")
+
+	page, err = c.Search(ctx, SearchQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, page.Total)
+	assert.Equal(t, []models.FacetValue{
+		{Value: "sports", Label: "Sports", Count: 2},
+		{Value: "kitchen", Label: "Kitchen", Count: 1},
+	}, page.Facets.Categories)
+	assert.Equal(t, []models.FacetValue{
+		{Value: "stridex", Label: "Stridex", Count: 1},
+		{Value: "zenly", Label: "Zenly", Count: 1},
+	}, page.Facets.Brands)
+	require.Len(t, page.Facets.PriceRanges, 2)
+	assert.Equal(t, models.PriceRangeFacet{Min: 0, Max: ptr(int64(2500)), Count: 2}, page.Facets.PriceRanges[0])
+	assert.Equal(t, 1, page.Facets.PriceRanges[1].Count)
+
+	// Choosing a category drills down, and the category facet ignores its own filter
+	page, err = c.Search(ctx, SearchQuery{Category: "sports", Brands: []string{"ZENLY"}})
+	require.NoError(t, err)
+	require.Equal(t, 1, page.Total)
+	assert.Equal(t, "Yoga Mat", page.Products[0].Name)
+	assert.Equal(t, []models.FacetValue{{Value: "sports/yoga", Label: "Yoga", Count: 1}}, page.Facets.Categories)
+	assert.Len(t, page.Facets.Brands, 2, "the brand facet ignores the brand filter")
+
+	page, err = c.Search(ctx, SearchQuery{MinPrice: ptr(int64(1000)), MaxPrice: ptr(int64(8450))})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Trail Runner 2", "Yoga Mat"}, names(page.Products), "both bounds are inclusive")
+
+	page, err = c.Search(ctx, SearchQuery{Sort: SortPriceDesc, PageSize: 2, Page: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, page.Total)
+	assert.Equal(t, []string{"Espresso Cup"}, names(page.Products))
+
+	_, err = c.Search(ctx, SearchQuery{Sort: "cheapest"})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+	_, err = c.Search(ctx, SearchQuery{Text: "?!"})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func names(products []models.ProductSummary) []string {
+	result := make([]string, len(products))
+	for i, product := range products {
+		result[i] = product.Name
+	}
+	return result
+}
@@ -0,0 +1,349 @@
+package catalog
+
+import (
+	"auth-service/internal/models"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Feed formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Fields an item of a feed can be mapped to
+const (
+	FieldSKU          = "sku"
+	FieldGTIN         = "gtin"
+	FieldGroupID      = "group_id"
+	FieldName         = "name"
+	FieldVariantName  = "variant_name"
+	FieldBrand        = "brand"
+	FieldDescription  = "description"
+	FieldCategory     = "category"
+	FieldPrice        = "price"
+	FieldCurrency     = "currency"
+	FieldShipping     = "shipping"
+	FieldURL          = "url"
+	FieldImageURL     = "image_url"
+	FieldAvailability = "availability"
+)
+
+var knownFields = map[string]bool{
+	FieldSKU: true, FieldGTIN: true, FieldGroupID: true, FieldName: true, FieldVariantName: true,
+	FieldBrand: true, FieldDescription: true, FieldCategory: true, FieldPrice: true,
+	FieldCurrency: true, FieldShipping: true, FieldURL: true, FieldImageURL: true, FieldAvailability: true,
+}
+
+// requiredFields must be mapped by every feed
+var requiredFields = []string{FieldSKU, FieldName, FieldPrice}
+
+// Feed is a retailer's product feed and how its items map to the catalog
+type Feed struct {
+	ID       string          `yaml:"id"`
+	Retailer models.Retailer `yaml:"retailer"`
+	Format   string          `yaml:"format"`
+	// Delimiter separates CSV fields; a comma by default
+	Delimiter string `yaml:"delimiter"`
+	// Items is the dot-separated path of the item array in a JSON feed;
+	// empty when the document is the array
+	Items string `yaml:"items"`
+	// Currency of the prices, unless an item has a currency field
+	Currency string `yaml:"currency"`
+	// CategorySeparator splits category paths such as "Audio > Headphones"
+	CategorySeparator string `yaml:"category_separator"`
+	// Fields maps catalog fields to the feed's columns, or to dot-separated
+	// paths in JSON items
+	Fields map[string]string `yaml:"fields"`
+	// Attributes maps variant attributes, such as color or size, the same way
+	Attributes map[string]string `yaml:"attributes"`
+}
+
+// Feeds is the set of feeds defined in the feeds file. The zero Feeds has no feeds.
+type Feeds struct {
+	feeds map[string]*Feed
+}
+
+type feedsFile struct {
+	Feeds []*Feed `yaml:"feeds"`
+}
+
+// LoadFeeds reads the feeds file at path. Feeds default to currency. A
+// missing file returns an error wrapping os.ErrNotExist.
+func LoadFeeds(path, currency string) (*Feeds, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	feeds, err := ParseFeeds(data, currency)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return feeds, nil
+}
+
+// ParseFeeds parses a feeds file
+func ParseFeeds(data []byte, currency string) (*Feeds, error) {
+	var file feedsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	feeds := &Feeds{feeds: map[string]*Feed{}}
+	for i, feed := range file.Feeds {
+		if feed.ID == "" {
+			return nil, fmt.Errorf("feed %d has no id", i+1)
+		}
+		if feeds.feeds[feed.ID] != nil {
+			return nil, fmt.Errorf("feed %q is defined twice", feed.ID)
+		}
+		if err := feed.normalize(currency); err != nil {
+			return nil, fmt.Errorf("feed %q: %w", feed.ID, err)
+		}
+		feeds.feeds[feed.ID] = feed
+	}
+	return feeds, nil
+}
+
+func (f *Feed) normalize(currency string) error {
+	if f.Retailer.ID == "" {
+		f.Retailer.ID = f.ID
+	}
+	if f.Retailer.Name == "" {
+		f.Retailer.Name = f.Retailer.ID
+	}
+	f.Format = strings.ToLower(f.Format)
+	if f.Format != FormatCSV && f.Format != FormatJSON {
+		return fmt.Errorf("format must be %s or %s", FormatCSV, FormatJSON)
+	}
+	if f.Delimiter == "" {
+		f.Delimiter = ","
+	}
+	if utf8.RuneCountInString(f.Delimiter) != 1 {
+		return errors.New("delimiter must be one character")
+	}
+	if f.Currency == "" {
+		f.Currency = currency
+	}
+	f.Currency = strings.ToUpper(f.Currency)
+	if f.CategorySeparator == "" {
+		f.CategorySeparator = ">"
+	}
+	for field := range f.Fields {
+		if !knownFields[field] {
+			return fmt.Errorf("unknown field %q", field)
+		}
+	}
+	for _, field := range requiredFields {
+		if f.Fields[field] == "" {
+			return fmt.Errorf("field %q must be mapped", field)
+		}
+	}
+	return nil
+}
+
+// Get returns a feed by ID
+func (f *Feeds) Get(id string) (*Feed, bool) {
+	feed, ok := f.feeds[id]
+	return feed, ok
+}
+
+// List returns the feeds ordered by ID
+func (f *Feeds) List() []*Feed {
+	list := make([]*Feed, 0, len(f.feeds))
+	for _, feed := range f.feeds {
+		list = append(list, feed)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// record is one item of a feed, with its values mapped to catalog fields
+type record struct {
+	row        int
+	fields     map[string]string
+	attributes map[string]string
+}
+
+// bom is the UTF-8 byte order mark some feeds start with
+var bom = []byte("\xef\xbb\xbf")
+
+// readRecords reads every item of the feed. Items that cannot be mapped are
+// returned as import errors; a feed that cannot be read fails with ErrInvalidFeed.
+func (f *Feed) readRecords(r io.Reader) ([]record, []models.ImportError, error) {
+	buffered := bufio.NewReader(r)
+	if head, err := buffered.Peek(len(bom)); err == nil && bytes.Equal(head, bom) {
+		buffered.Discard(len(bom))
+	}
+	if f.Format == FormatJSON {
+		return f.readJSON(buffered)
+	}
+	return f.readCSV(buffered)
+}
+
+func (f *Feed) readCSV(r io.Reader) ([]record, []models.ImportError, error) {
+	reader := csv.NewReader(r)
+	reader.Comma, _ = utf8.DecodeRuneInString(f.Delimiter)
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: read header: %w", ErrInvalidFeed, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	column := func(name string) (int, error) {
+		i, ok := columns[name]
+		if !ok {
+			return 0, fmt.Errorf("%w: column %q not found", ErrInvalidFeed, name)
+		}
+		return i, nil
+	}
+	fieldColumns, err := mapColumns(f.Fields, column)
+	if err != nil {
+		return nil, nil, err
+	}
+	attributeColumns, err := mapColumns(f.Attributes, column)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var records []record
+	var rowErrors []models.ImportError
+	for {
+		values, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(err, csv.ErrFieldCount) {
+			rowErrors = append(rowErrors, models.ImportError{Row: parseErr.StartLine, Message: "wrong number of fields"})
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidFeed, err)
+		}
+		row, _ := reader.FieldPos(0)
+		rec := record{row: row, fields: map[string]string{}, attributes: map[string]string{}}
+		for field, i := range fieldColumns {
+			rec.fields[field] = strings.TrimSpace(values[i])
+		}
+		for attribute, i := range attributeColumns {
+			rec.attributes[attribute] = strings.TrimSpace(values[i])
+		}
+		records = append(records, rec)
+	}
+	return records, rowErrors, nil
+}
+
+func mapColumns(mapping map[string]string, column func(string) (int, error)) (map[string]int, error) {
+	columns := make(map[string]int, len(mapping))
+	for field, name := range mapping {
+		if name == "" {
+			continue
+		}
+		i, err := column(name)
+		if err != nil {
+			return nil, err
+		}
+		columns[field] = i
+	}
+	return columns, nil
+}
+
+func (f *Feed) readJSON(r io.Reader) ([]record, []models.ImportError, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidFeed, err)
+	}
+	items, ok := lookup(document, f.Items).([]interface{})
+	if !ok {
+		if f.Items == "" {
+			return nil, nil, fmt.Errorf("%w: the document is not an array", ErrInvalidFeed)
+		}
+		return nil, nil, fmt.Errorf("%w: %q is not an array", ErrInvalidFeed, f.Items)
+	}
+
+	var records []record
+	var rowErrors []models.ImportError
+	for i, item := range items {
+		rec := record{row: i + 1, fields: map[string]string{}, attributes: map[string]string{}}
+		if _, ok := item.(map[string]interface{}); !ok {
+			rowErrors = append(rowErrors, models.ImportError{Row: rec.row, Message: "item is not an object"})
+			continue
+		}
+		err := mapValues(item, f.Fields, rec.fields)
+		if err == nil {
+			err = mapValues(item, f.Attributes, rec.attributes)
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportError{Row: rec.row, Message: err.Error()})
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, rowErrors, nil
+}
+
+func mapValues(item interface{}, mapping map[string]string, values map[string]string) error {
+	for field, path := range mapping {
+		if path == "" {
+			continue
+		}
+		switch v := lookup(item, path).(type) {
+		case nil:
+		case string:
+			values[field] = strings.TrimSpace(v)
+		case json.Number:
+			values[field] = v.String()
+		case bool:
+			values[field] = strconv.FormatBool(v)
+		default:
+			return fmt.Errorf("%s is not a string, number or boolean", path)
+		}
+	}
+	return nil
+}
+
+// lookup follows a dot-separated path of object keys
+func lookup(value interface{}, path string) interface{} {
+	if path == "" {
+		return value
+	}
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// inStock reads an availability value, such as "in stock", "in_stock" or
+// "https://schema.org/InStock". Items without one are in stock.
+func inStock(value string) bool {
+	value = value[strings.LastIndex(value, "/")+1:]
+	value = strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(value))
+	switch value {
+	case "", "instock", "available", "true", "yes", "1", "limitedavailability", "preorder":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package catalog
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/storage"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Observation is a price seen for a product in a feed import
+type Observation struct {
+	ProductID  string
+	RetailerID string
+	// Price is in minor units of the catalog currency
+	Price int64
+	At    time.Time
+}
+
+// PriceHistory records the prices seen in imports as daily buckets
+type PriceHistory interface {
+	// Record adds observations to their products' history
+	Record(ctx context.Context, observations []Observation) error
+	// Daily returns a product's buckets from one day to another (both
+	// inclusive, UTC), oldest first. An empty retailerID merges every retailer.
+	Daily(ctx context.Context, productID, retailerID string, from, to time.Time) ([]models.PriceBucket, error)
+}
+
+// dailyPrices is the prices one retailer had for a product on one day
+type dailyPrices struct {
+	Date       string `json:"date"`
+	RetailerID string `json:"retailer_id"`
+	Min        int64  `json:"min"`
+	Max        int64  `json:"max"`
+	Sum        int64  `json:"sum"`
+	Count      int    `json:"count"`
+}
+
+func (d *dailyPrices) add(price int64) {
+	if d.Count == 0 || price < d.Min {
+		d.Min = price
+	}
+	if d.Count == 0 || price > d.Max {
+		d.Max = price
+	}
+	d.Sum += price
+	d.Count++
+}
+
+// productHistory is the document stored per product
+type productHistory struct {
+	Days []dailyPrices `json:"days"`
+}
+
+// FileHistory keeps each product's history in one JSON file under dir and
+// drops days older than its retention
+type FileHistory struct {
+	dir       string
+	retention int
+	mutex     sync.Mutex
+	now       func() time.Time
+}
+
+// NewFileHistory creates a history in dir/prices keeping retentionDays days
+func NewFileHistory(dir string, retentionDays int) (*FileHistory, error) {
+	dir = filepath.Join(dir, "prices")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileHistory{dir: dir, retention: retentionDays, now: time.Now}, nil
+}
+
+// Record adds observations to their products' history
+func (h *FileHistory) Record(ctx context.Context, observations []Observation) error {
+	byProduct := map[string][]Observation{}
+	for _, o := range observations {
+		byProduct[o.ProductID] = append(byProduct[o.ProductID], o)
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	oldest := h.now().UTC().AddDate(0, 0, -h.retention).Format(time.DateOnly)
+	for productID, observed := range byProduct {
+		path := storage.UserFile(h.dir, productID)
+		history := &productHistory{}
+		if _, err := storage.ReadJSON(path, history); err != nil {
+			return err
+		}
+
+		days := map[[2]string]*dailyPrices{}
+		for i := range history.Days {
+			d := &history.Days[i]
+			days[[2]string{d.Date, d.RetailerID}] = d
+		}
+		for _, o := range observed {
+			key := [2]string{o.At.UTC().Format(time.DateOnly), o.RetailerID}
+			d, ok := days[key]
+			if !ok {
+				d = &dailyPrices{Date: key[0], RetailerID: key[1]}
+				days[key] = d
+			}
+			d.add(o.Price)
+		}
+
+		kept := make([]dailyPrices, 0, len(days))
+		for _, d := range days {
+			if d.Date > oldest {
+				kept = append(kept, *d)
+			}
+		}
+		sort.Slice(kept, func(i, j int) bool {
+			if kept[i].Date != kept[j].Date {
+				return kept[i].Date < kept[j].Date
+			}
+			return kept[i].RetailerID < kept[j].RetailerID
+		})
+		if err := storage.WriteJSON(path, productHistory{Days: kept}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Daily returns a product's buckets from one day to another, oldest first
+func (h *FileHistory) Daily(ctx context.Context, productID, retailerID string, from, to time.Time) ([]models.PriceBucket, error) {
+	h.mutex.Lock()
+	history := &productHistory{}
+	_, err := storage.ReadJSON(storage.UserFile(h.dir, productID), history)
+	h.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	first, last := from.UTC().Format(time.DateOnly), to.UTC().Format(time.DateOnly)
+	buckets := []models.PriceBucket{}
+	var merged *dailyPrices
+	flush := func() {
+		if merged != nil {
+			buckets = append(buckets, models.PriceBucket{
+				Date:  merged.Date,
+				Min:   merged.Min,
+				Max:   merged.Max,
+				Avg:   (merged.Sum + int64(merged.Count)/2) / int64(merged.Count),
+				Count: merged.Count,
+			})
+		}
+	}
+	for _, d := range history.Days {
+		if d.Date < first || d.Date > last || retailerID != "" && d.RetailerID != retailerID {
+			continue
+		}
+		if merged == nil || merged.Date != d.Date {
+			flush()
+			merged = &dailyPrices{Date: d.Date, Min: d.Min, Max: d.Max}
+		}
+		merged.Min = min(merged.Min, d.Min)
+		merged.Max = max(merged.Max, d.Max)
+		merged.Sum += d.Sum
+		merged.Count += d.Count
+	}
+	flush()
+	return buckets, nil
+}
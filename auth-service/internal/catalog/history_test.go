@@ -0,0 +1,146 @@
+package catalog
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const compareFeeds = `
+feeds:
+  - id: euro-shop
+    format: csv
+    fields: {sku: sku, gtin: gtin, name: name, price: price, shipping: shipping, availability: stock}
+  - id: us-shop
+    format: csv
+    currency: USD
+    fields: {sku: sku, gtin: gtin, name: name, price: price, shipping: shipping, availability: stock}
+  - id: uk-shop
+    format: csv
+    currency: GBP
+    fields: {sku: sku, gtin: gtin, name: name, price: price}
+`
+
+func newComparingCatalog(t *testing.T) (*Catalog, *FileHistory, *Feeds) {
+	dir := t.TempDir()
+	history, err := NewFileHistory(dir, 30)
+	require.NoError(t, err)
+	cfg := config.CatalogConfig{Currency: "EUR", ExchangeRates: map[string]float64{"USD": 0.5, "GBP": 1.2}}
+	c, err := NewCatalog(dir, cfg, history, testLogger)
+	require.NoError(t, err)
+	feeds, err := ParseFeeds([]byte(compareFeeds), "EUR")
+	require.NoError(t, err)
+	return c, history, feeds
+}
+
+func setClock(c *Catalog, history *FileHistory, now time.Time) {
+	c.now = func() time.Time { return now }
+	history.now = func() time.Time { return now }
+}
+
+func TestCatalog_OffersRankedByTotalCost(t *testing.T) {
+	c, _, feeds := newComparingCatalog(t)
+	importFeed(t, c, feeds, "euro-shop", "sku,gtin,name,price,shipping,stock\nE-1,4006381333931,Kettle,40.00,5.95,in stock\n")
+	importFeed(t, c, feeds, "us-shop", "sku,gtin,name,price,shipping,stock\nU-1,4006381333931,Kettle,80.00,free,in stock\nU-2,4006381333931,Kettle,20.00,0,out of stock\n")
+	importFeed(t, c, feeds, "uk-shop", "sku,gtin,name,price\nK-1,4006381333931,Kettle,37.50\n")
+
+	page, err := c.Search(context.Background(), SearchQuery{Text: "kettle"})
+	require.NoError(t, err)
+	require.Equal(t, 1, page.Total)
+	assert.Equal(t, &models.Money{Amount: 1000, Currency: "EUR"}, page.Products[0].MinPrice, "search prices are converted")
+	assert.Equal(t, 4, page.Products[0].OfferCount)
+
+	offers, err := c.Offers(context.Background(), page.Products[0].ID, "")
+	require.NoError(t, err)
+	type ranked struct {
+		sku   string
+		total int64
+	}
+	var got []ranked
+	for _, offer := range offers.Offers {
+		got = append(got, ranked{offer.SKU, offer.Total.Amount})
+	}
+	// In stock first, then by price plus shipping in EUR; unknown shipping counts as free
+	assert.Equal(t, []ranked{{"U-1", 4000}, {"K-1", 4500}, {"E-1", 4595}, {"U-2", 1000}}, got)
+	require.NotNil(t, offers.Best)
+	assert.Equal(t, "U-1", offers.Best.SKU)
+	assert.Equal(t, models.Money{Amount: 8000, Currency: "USD"}, offers.Best.Price, "prices keep their currency")
+	assert.Nil(t, offers.Offers[1].Shipping)
+
+	_, err = c.Offers(context.Background(), "missing", "")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestCatalog_RecordsDailyPriceHistory(t *testing.T) {
+	c, history, feeds := newComparingCatalog(t)
+	ctx := context.Background()
+	day := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	imports := []struct {
+		at   time.Time
+		feed string
+		body string
+	}{
+		{day, "euro-shop", "sku,gtin,name,price,shipping,stock\nE-1,4006381333931,Kettle,40.00,,\n"},
+		{day.Add(6 * time.Hour), "euro-shop", "sku,gtin,name,price,shipping,stock\nE-1,4006381333931,Kettle,35.00,,\n"},
+		{day.Add(7 * time.Hour), "us-shop", "sku,gtin,name,price,shipping,stock\nU-1,4006381333931,Kettle,90.00,,\n"},
+		{day.AddDate(0, 0, 2), "euro-shop", "sku,gtin,name,price,shipping,stock\nE-1,4006381333931,Kettle,38.00,,\n"},
+	}
+	for _, imp := range imports {
+		setClock(c, history, imp.at)
+		importFeed(t, c, feeds, imp.feed, imp.body)
+	}
+	page, err := c.Search(ctx, SearchQuery{})
+	require.NoError(t, err)
+	productID := page.Products[0].ID
+
+	prices, err := c.PriceHistory(ctx, productID, "", day, day.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", prices.Currency)
+	assert.Equal(t, []models.PriceBucket{
+		{Date: "2024-03-01", Min: 3500, Max: 4500, Avg: 4000, Count: 3},
+		{Date: "2024-03-03", Min: 3800, Max: 3800, Avg: 3800, Count: 1},
+	}, prices.Buckets)
+
+	prices, err = c.PriceHistory(ctx, productID, "euro-shop", day, day)
+	require.NoError(t, err)
+	assert.Equal(t, []models.PriceBucket{{Date: "2024-03-01", Min: 3500, Max: 4000, Avg: 3750, Count: 2}}, prices.Buckets)
+
+	// Days older than the retention are dropped on the next import
+	setClock(c, history, day.AddDate(0, 0, 31))
+	importFeed(t, c, feeds, "euro-shop", "sku,gtin,name,price,shipping,stock\nE-1,4006381333931,Kettle,30.00,,\n")
+	prices, err = c.PriceHistory(ctx, productID, "", day, day.AddDate(0, 0, 31))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2024-03-03", "2024-04-01"}, bucketDates(prices.Buckets))
+
+	_, err = c.PriceHistory(ctx, "missing", "", day, day)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func bucketDates(buckets []models.PriceBucket) []string {
+	dates := make([]string, len(buckets))
+	for i, bucket := range buckets {
+		dates[i] = bucket.Date
+	}
+	return dates
+}
+
+func TestCatalog_RejectsCurrenciesWithoutRates(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewCatalog(dir, config.CatalogConfig{Currency: "EUR"}, nil, testLogger)
+	require.NoError(t, err)
+	feeds, err := ParseFeeds([]byte(compareFeeds), "EUR")
+	require.NoError(t, err)
+	feed, _ := feeds.Get("us-shop")
+
+	result, err := c.Import(context.Background(), feed, strings.NewReader("sku,gtin,name,price,shipping,stock\nU-1,,Kettle,80.00,,\n"))
+	require.NoError(t, err)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, `there is no exchange rate for currency "USD"`, result.Errors[0].Message)
+}
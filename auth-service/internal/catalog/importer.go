@@ -0,0 +1,511 @@
+package catalog
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/search"
+	"auth-service/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"sort"
+	"strings"
+)
+
+// maxImportErrors bounds the errors listed in an import result; Skipped
+// still counts them all
+const maxImportErrors = 100
+
+// item is a feed record checked and converted to catalog values
+type item struct {
+	row         int
+	sku         string
+	gtin        string
+	groupID     string
+	name        string
+	variantName string
+	brand       string
+	description string
+	category    []string
+	price       models.Money
+	shipping    *models.Money
+	url         string
+	imageURL    string
+	inStock     bool
+	attributes  map[string]string
+}
+
+// location is where an offer or GTIN is in the catalog
+type location struct {
+	productID string
+	variantID string
+}
+
+// Import reads a feed and merges it into the catalog. Items are matched to
+// variants by GTIN first, then by the retailer's SKU, and to products by the
+// retailer's group ID, so one product sold by several retailers is listed
+// once; its details come from the first feed and are only filled in by the
+// others. A feed is a full snapshot: the retailer's offers missing from it are
+// removed. Items that cannot be read are skipped and listed in the result.
+// The price of every imported item is added to the price history, a sync of
+// the embeddings for semantic search is queued, and the import listeners are
+// told which products' offers changed.
+func (c *Catalog) Import(ctx context.Context, feed *Feed, r io.Reader) (*models.ImportResult, error) {
+	records, rowErrors, err := feed.readRecords(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(records)+len(rowErrors) == 0 {
+		return nil, fmt.Errorf("%w: the feed has no items", ErrInvalidFeed)
+	}
+
+	result := &models.ImportResult{FeedID: feed.ID, Items: len(records) + len(rowErrors)}
+	// SKUs of every item, even skipped ones, so their offers are kept
+	listed := map[string]int{}
+	var items []item
+	for _, rec := range records {
+		sku := rec.fields[FieldSKU]
+		if first, ok := listed[sku]; ok && sku != "" {
+			rowErrors = append(rowErrors, models.ImportError{Row: rec.row, Message: fmt.Sprintf("duplicate sku %q, first on row %d", sku, first)})
+			continue
+		}
+		listed[sku] = rec.row
+		it, err := c.newItem(feed, rec)
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportError{Row: rec.row, Message: err.Error()})
+			continue
+		}
+		items = append(items, it)
+	}
+
+	c.mutex.Lock()
+	m := c.newMerge(feed.Retailer)
+	for _, it := range items {
+		switch m.put(it) {
+		case mergeCreated:
+			result.Created++
+		case mergeUpdated:
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	}
+	result.Removed = m.removeUnlisted(listed)
+	err = m.commit()
+	c.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// The import stands even if its prices cannot be recorded
+	if c.history != nil {
+		if err := c.history.Record(ctx, m.observed); err != nil {
+			c.logger.WithError(err).WithField("feed", feed.ID).Warn("Failed to record price history")
+		}
+	}
+	// Products whose text changed are embedded in the background, see
+	// RunEmbeddings
+	c.queueEmbeddings()
+	if len(m.repriced) > 0 {
+		productIDs := make([]string, 0, len(m.repriced))
+		for productID := range m.repriced {
+			productIDs = append(productIDs, productID)
+		}
+		sort.Strings(productIDs)
+		for _, listener := range c.listeners {
+			listener.Imported(ctx, productIDs)
+		}
+	}
+
+	sort.SliceStable(rowErrors, func(i, j int) bool { return rowErrors[i].Row < rowErrors[j].Row })
+	result.Skipped = len(rowErrors)
+	result.Errors = rowErrors[:min(len(rowErrors), maxImportErrors)]
+	if result.Errors == nil {
+		result.Errors = []models.ImportError{}
+	}
+	return result, nil
+}
+
+func (c *Catalog) newItem(feed *Feed, rec record) (item, error) {
+	it := item{
+		row:         rec.row,
+		sku:         rec.fields[FieldSKU],
+		groupID:     rec.fields[FieldGroupID],
+		name:        rec.fields[FieldName],
+		variantName: rec.fields[FieldVariantName],
+		brand:       rec.fields[FieldBrand],
+		description: rec.fields[FieldDescription],
+		url:         rec.fields[FieldURL],
+		imageURL:    rec.fields[FieldImageURL],
+		inStock:     inStock(rec.fields[FieldAvailability]),
+		attributes:  map[string]string{},
+	}
+	if it.sku == "" {
+		return item{}, errors.New("sku is empty")
+	}
+	if it.name == "" {
+		return item{}, errors.New("name is empty")
+	}
+	if gtin := rec.fields[FieldGTIN]; gtin != "" {
+		normalized, ok := NormalizeGTIN(gtin)
+		if !ok {
+			return item{}, fmt.Errorf("gtin %q is not a valid GTIN", gtin)
+		}
+		it.gtin = normalized
+	}
+
+	amount, err := ParsePrice(rec.fields[FieldPrice])
+	if err != nil {
+		return item{}, err
+	}
+	currency := strings.ToUpper(rec.fields[FieldCurrency])
+	if currency == "" {
+		currency = feed.Currency
+	}
+	if _, ok := c.rates.Convert(models.Money{Currency: currency}); !ok {
+		return item{}, fmt.Errorf("there is no exchange rate for currency %q", currency)
+	}
+	it.price = models.Money{Amount: amount, Currency: currency}
+	if value := rec.fields[FieldShipping]; value != "" {
+		shipping, err := parseShipping(value)
+		if err != nil {
+			return item{}, err
+		}
+		it.shipping = &models.Money{Amount: shipping, Currency: currency}
+	}
+
+	for _, segment := range strings.Split(rec.fields[FieldCategory], feed.CategorySeparator) {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			it.category = append(it.category, segment)
+		}
+	}
+	for name, value := range rec.attributes {
+		if value != "" {
+			it.attributes[name] = value
+		}
+	}
+	return it, nil
+}
+
+// NormalizeGTIN checks a GTIN-8, UPC-A, EAN-13 or GTIN-14 and returns it
+// padded to 14 digits, so the same item matches in any of these forms
+func NormalizeGTIN(gtin string) (string, bool) {
+	gtin = strings.Join(strings.Fields(gtin), "")
+	switch len(gtin) {
+	case 8, 12, 13, 14:
+	default:
+		return "", false
+	}
+	gtin = strings.Repeat("0", 14-len(gtin)) + gtin
+	sum := 0
+	for i, r := range gtin {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+		digit := int(r - '0')
+		if i < 13 && i%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	if sum%10 != 0 {
+		return "", false
+	}
+	return gtin, true
+}
+
+type mergeOutcome int
+
+const (
+	mergeUnchanged mergeOutcome = iota
+	mergeUpdated
+	mergeCreated
+)
+
+// merge applies one import to a copy of the catalog's products. Products are
+// copied before they are changed, as readers may still hold the originals.
+type merge struct {
+	c          *Catalog
+	retailerID string
+	products   map[string]*models.Product
+	copied     map[string]bool
+	categories map[string]bool
+	byGTIN     map[string]location
+	bySKU      map[string]location
+	byGroup    map[string]string
+	observed   []Observation
+	// repriced holds the products whose offers were added, changed or removed
+	repriced map[string]bool
+}
+
+func (c *Catalog) newMerge(retailer models.Retailer) *merge {
+	m := &merge{
+		c:          c,
+		retailerID: retailer.ID,
+		products:   make(map[string]*models.Product, len(c.data.Products)),
+		copied:     map[string]bool{},
+		categories: map[string]bool{},
+		byGTIN:     map[string]location{},
+		bySKU:      map[string]location{},
+		byGroup:    map[string]string{},
+		repriced:   map[string]bool{},
+	}
+
+	retailers := c.data.Retailers[:0:0]
+	for _, existing := range c.data.Retailers {
+		if existing.ID != retailer.ID {
+			retailers = append(retailers, existing)
+		}
+	}
+	c.data.Retailers = append(retailers, retailer)
+
+	for _, category := range c.data.Categories {
+		m.categories[category.ID] = true
+	}
+	for _, product := range c.data.Products {
+		m.products[product.ID] = product
+		for _, variant := range product.Variants {
+			at := location{productID: product.ID, variantID: variant.ID}
+			if variant.GTIN != "" {
+				m.byGTIN[variant.GTIN] = at
+			}
+			for _, offer := range variant.Offers {
+				if offer.RetailerID == retailer.ID {
+					m.bySKU[offer.SKU] = at
+					if offer.GroupID != "" {
+						m.byGroup[offer.GroupID] = product.ID
+					}
+				}
+			}
+		}
+	}
+	return m
+}
+
+// edit returns the product for changing, copying it the first time
+func (m *merge) edit(id string) *models.Product {
+	product := m.products[id]
+	if m.copied[id] {
+		return product
+	}
+	copied := *product
+	copied.Variants = make([]models.ProductVariant, len(product.Variants))
+	for i, variant := range product.Variants {
+		variant.Attributes = maps.Clone(variant.Attributes)
+		variant.Offers = append([]models.Offer{}, variant.Offers...)
+		copied.Variants[i] = variant
+	}
+	m.products[id] = &copied
+	m.copied[id] = true
+	return &copied
+}
+
+func variantOf(product *models.Product, id string) *models.ProductVariant {
+	for i := range product.Variants {
+		if product.Variants[i].ID == id {
+			return &product.Variants[i]
+		}
+	}
+	return nil
+}
+
+// put merges one item and reports whether its offer was created, updated or unchanged
+func (m *merge) put(it item) mergeOutcome {
+	now := m.c.now().UTC()
+	previous, listed := m.bySKU[it.sku]
+	target, found := location{}, false
+	if it.gtin != "" {
+		target, found = m.byGTIN[it.gtin]
+	}
+	if !found && listed {
+		// The retailer's item, unless it now has a GTIN of another variant
+		variant := variantOf(m.products[previous.productID], previous.variantID)
+		if variant.GTIN == "" || variant.GTIN == it.gtin {
+			target, found = previous, true
+		}
+	}
+
+	changed := false
+	if !found {
+		productID, ok := m.byGroup[it.groupID]
+		if it.groupID == "" || !ok {
+			productID = storage.NewID()
+			m.products[productID] = &models.Product{ID: productID, Name: it.name, Variants: []models.ProductVariant{}, CreatedAt: now, UpdatedAt: now}
+			m.copied[productID] = true
+		}
+		product := m.edit(productID)
+		product.Variants = append(product.Variants, models.ProductVariant{ID: storage.NewID(), Offers: []models.Offer{}})
+		target = location{productID: productID, variantID: product.Variants[len(product.Variants)-1].ID}
+		changed = true
+	}
+
+	var old *models.Offer
+	if listed {
+		old = m.removeOffer(previous, it.sku)
+	}
+
+	product := m.edit(target.productID)
+	categoryID := m.ensureCategories(it.category)
+	// Products sold by other retailers too keep their details, so feeds do
+	// not overwrite each other; they only fill in what is missing
+	set := setIfSet
+	if m.soldByOthers(product) {
+		set = fillIfEmpty
+	}
+	changed = set(&product.Name, it.name) || changed
+	changed = set(&product.Brand, it.brand) || changed
+	changed = set(&product.Description, it.description) || changed
+	changed = set(&product.CategoryID, categoryID) || changed
+	changed = set(&product.ImageURL, it.imageURL) || changed
+
+	variant := variantOf(product, target.variantID)
+	changed = setIfSet(&variant.GTIN, it.gtin) || changed
+	changed = setIfSet(&variant.Name, it.variantName) || changed
+	if len(it.attributes) > 0 && !maps.Equal(variant.Attributes, it.attributes) {
+		variant.Attributes = it.attributes
+		changed = true
+	}
+
+	offer := models.Offer{
+		RetailerID: m.retailerID,
+		SKU:        it.sku,
+		GroupID:    it.groupID,
+		Price:      it.price,
+		Shipping:   it.shipping,
+		URL:        it.url,
+		InStock:    it.inStock,
+	}
+	outcome := mergeCreated
+	if old != nil {
+		offer.UpdatedAt = old.UpdatedAt
+		outcome = mergeUnchanged
+		if !sameOffer(*old, offer) || previous != target {
+			outcome = mergeUpdated
+		}
+	}
+	if outcome != mergeUnchanged {
+		offer.UpdatedAt = now
+		m.repriced[target.productID] = true
+		if listed {
+			m.repriced[previous.productID] = true
+		}
+	}
+	variant.Offers = append(variant.Offers, offer)
+	if changed {
+		product.UpdatedAt = now
+		if outcome == mergeUnchanged {
+			outcome = mergeUpdated
+		}
+	}
+
+	if converted, ok := m.c.rates.Convert(it.price); ok {
+		m.observed = append(m.observed, Observation{ProductID: target.productID, RetailerID: m.retailerID, Price: converted.Amount, At: now})
+	}
+	if variant.GTIN != "" {
+		m.byGTIN[variant.GTIN] = target
+	}
+	m.bySKU[it.sku] = target
+	if it.groupID != "" {
+		m.byGroup[it.groupID] = target.productID
+	}
+	return outcome
+}
+
+// sameOffer compares offers by value
+func sameOffer(a, b models.Offer) bool {
+	if (a.Shipping == nil) != (b.Shipping == nil) || a.Shipping != nil && *a.Shipping != *b.Shipping {
+		return false
+	}
+	a.Shipping, b.Shipping = nil, nil
+	return a == b
+}
+
+// soldByOthers reports whether another retailer has an offer for the product
+func (m *merge) soldByOthers(product *models.Product) bool {
+	for _, variant := range product.Variants {
+		for _, offer := range variant.Offers {
+			if offer.RetailerID != m.retailerID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// removeOffer removes the retailer's offer for sku and returns it
+func (m *merge) removeOffer(at location, sku string) *models.Offer {
+	variant := variantOf(m.edit(at.productID), at.variantID)
+	for i, offer := range variant.Offers {
+		if offer.RetailerID == m.retailerID && offer.SKU == sku {
+			variant.Offers = append(variant.Offers[:i], variant.Offers[i+1:]...)
+			return &offer
+		}
+	}
+	return nil
+}
+
+// removeUnlisted removes the retailer's offers whose SKU is not in listed
+func (m *merge) removeUnlisted(listed map[string]int) int {
+	removed := 0
+	for sku, at := range m.bySKU {
+		if _, ok := listed[sku]; ok {
+			continue
+		}
+		if m.removeOffer(at, sku) != nil {
+			m.products[at.productID].UpdatedAt = m.c.now().UTC()
+			m.repriced[at.productID] = true
+			removed++
+		}
+	}
+	return removed
+}
+
+// ensureCategories adds the categories of a path such as ["Audio",
+// "Headphones"] and returns the ID of the last one
+func (m *merge) ensureCategories(path []string) string {
+	parentID := ""
+	for _, name := range path {
+		slug := strings.Join(search.Terms(name), "-")
+		if slug == "" {
+			continue
+		}
+		id := slug
+		if parentID != "" {
+			id = parentID + "/" + slug
+		}
+		if !m.categories[id] {
+			m.categories[id] = true
+			m.c.data.Categories = append(m.c.data.Categories, models.Category{ID: id, Name: name, ParentID: parentID})
+		}
+		parentID = id
+	}
+	return parentID
+}
+
+// commit stores the merged products
+func (m *merge) commit() error {
+	products := make([]*models.Product, 0, len(m.products))
+	for _, product := range m.products {
+		products = append(products, product)
+	}
+	m.c.data.Products = products
+	return m.c.save()
+}
+
+// setIfSet sets *field to value unless value is empty, and reports whether it changed
+func setIfSet(field *string, value string) bool {
+	if value == "" || *field == value {
+		return false
+	}
+	*field = value
+	return true
+}
+
+// fillIfEmpty sets *field to value if it is empty, and reports whether it changed
+func fillIfEmpty(field *string, value string) bool {
+	if *field != "" {
+		return false
+	}
+	return setIfSet(field, value)
+}
@@ -0,0 +1,67 @@
+package catalog
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	errInvalidPrice    = errors.New("price must be a positive amount")
+	errInvalidShipping = errors.New("shipping must be an amount")
+)
+
+// ParsePrice parses a price such as "1299", "12.99", "12,99 €" or
+// "1.299,00" into minor units, assuming two decimals. A separator followed
+// by one or two digits is the decimal separator; others group thousands.
+func ParsePrice(value string) (int64, error) {
+	amount, err := parseAmount(value)
+	if err != nil || amount == 0 {
+		return 0, errInvalidPrice
+	}
+	return amount, nil
+}
+
+// parseShipping parses a shipping cost like a price, allowing zero and "free"
+func parseShipping(value string) (int64, error) {
+	if strings.EqualFold(strings.TrimSpace(value), "free") {
+		return 0, nil
+	}
+	amount, err := parseAmount(value)
+	if err != nil {
+		return 0, errInvalidShipping
+	}
+	return amount, nil
+}
+
+func parseAmount(value string) (int64, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' || r == '.' || r == ',' || r == '-' {
+			return r
+		}
+		return -1
+	}, value)
+	if digits == "" || strings.Contains(digits, "-") {
+		return 0, errInvalidPrice
+	}
+
+	whole, fraction := digits, ""
+	if i := strings.LastIndexAny(digits, ".,"); i >= 0 && len(digits)-i-1 <= 2 {
+		whole, fraction = digits[:i], digits[i+1:]
+	}
+	whole = strings.NewReplacer(".", "", ",", "").Replace(whole)
+	if whole == "" {
+		whole = "0"
+	}
+	fraction = (fraction + "00")[:2]
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > (1<<53)/100 {
+		return 0, errInvalidPrice
+	}
+	cents, err := strconv.ParseInt(fraction, 10, 64)
+	if err != nil {
+		return 0, errInvalidPrice
+	}
+	return units*100 + cents, nil
+}
@@ -0,0 +1,42 @@
+package catalog
+
+import (
+	"auth-service/internal/models"
+	"math"
+)
+
+// Rates converts money to the catalog currency
+type Rates struct {
+	currency string
+	rates    map[string]float64
+}
+
+// NewRates creates rates converting to currency; rates holds the value of
+// one unit of each other currency
+func NewRates(currency string, rates map[string]float64) Rates {
+	return Rates{currency: currency, rates: rates}
+}
+
+// Convert returns m in the catalog currency, rounded to the minor unit. It
+// reports false when there is no rate for m's currency.
+func (r Rates) Convert(m models.Money) (models.Money, bool) {
+	if m.Currency == r.currency {
+		return m, true
+	}
+	rate, ok := r.rates[m.Currency]
+	if !ok {
+		return models.Money{}, false
+	}
+	return models.Money{Amount: int64(math.Round(float64(m.Amount) * rate)), Currency: r.currency}, true
+}
+
+// total converts an offer's price plus shipping
+func (r Rates) total(offer models.Offer) (models.Money, bool) {
+	total, ok := r.Convert(offer.Price)
+	if !ok || offer.Shipping == nil {
+		return total, ok
+	}
+	shipping, ok := r.Convert(*offer.Shipping)
+	total.Amount += shipping.Amount
+	return total, ok
+}
@@ -0,0 +1,456 @@
+package catalog
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/search"
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Sort orders
+const (
+	SortRelevance = "relevance"
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+	SortName      = "name"
+	SortNewest    = "newest"
+)
+
+// Page sizes
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+const (
+	// maxQueryTerms bounds the words of a query
+	maxQueryTerms = 16
+	// maxBrandFacets bounds the brands listed in the brand facet
+	maxBrandFacets = 50
+)
+
+// Field weights: a word in the name counts more than one in the description
+const (
+	weightName        = 3.0
+	weightIdentifier  = 3.0
+	weightBrand       = 2.0
+	weightCategory    = 1.5
+	weightVariant     = 1.0
+	weightDescription = 0.5
+)
+
+// priceEdges are the lower bounds of the price range facets, in minor units
+var priceEdges = []int64{0, 2500, 5000, 10000, 25000, 50000, 100000}
+
+// SearchQuery selects and orders products. Empty fields do not filter.
+type SearchQuery struct {
+	// Text holds keywords, which must all match; the last may be the start of a word
+	Text string
+	// Category keeps the category and its descendants
+	Category string
+	// Brands keeps any of these brands, ignoring case
+	Brands []string
+	// MinPrice and MaxPrice bound the lowest offer price in the catalog
+	// currency, both inclusive
+	MinPrice *int64
+	MaxPrice *int64
+	Sort     string
+	// Mode is ModeKeyword or ModeHybrid, which also matches and ranks by
+	// meaning. It defaults to ModeHybrid when semantic search is enabled.
+	Mode string
+	// Page counts from 1
+	Page     int
+	PageSize int
+}
+
+// productIndex is the read side of the catalog, rebuilt after each import
+type productIndex struct {
+	products   map[string]*models.Product
+	retailers  map[string]models.Retailer
+	categories map[string]models.Category
+	entries    []*entry
+	// postings maps each term to the products containing it and the weight
+	// of the best field it is in
+	postings map[string]map[string]float64
+	// terms lists the keys of postings in order, for prefix matching
+	terms []string
+}
+
+// entry is a product with the values search filters and sorts on
+type entry struct {
+	product  *models.Product
+	brand    string
+	name     string
+	minPrice *models.Money
+	maxPrice *models.Money
+	offers   int
+	inStock  bool
+}
+
+func newProductIndex(data catalogData, rates Rates) *productIndex {
+	x := &productIndex{
+		products:   make(map[string]*models.Product, len(data.Products)),
+		retailers:  make(map[string]models.Retailer, len(data.Retailers)),
+		categories: make(map[string]models.Category, len(data.Categories)),
+		postings:   map[string]map[string]float64{},
+	}
+	for _, retailer := range data.Retailers {
+		x.retailers[retailer.ID] = retailer
+	}
+	for _, category := range data.Categories {
+		x.categories[category.ID] = category
+	}
+	for _, product := range data.Products {
+		x.products[product.ID] = product
+		x.entries = append(x.entries, newEntry(product, rates))
+		x.add(product)
+	}
+	sort.Slice(x.entries, func(i, j int) bool { return x.entries[i].product.ID < x.entries[j].product.ID })
+	for term := range x.postings {
+		x.terms = append(x.terms, term)
+	}
+	sort.Strings(x.terms)
+	return x
+}
+
+// newEntry prices a product by its offers, in the catalog currency
+func newEntry(product *models.Product, rates Rates) *entry {
+	e := &entry{product: product, brand: strings.ToLower(product.Brand), name: strings.ToLower(product.Name)}
+	for _, variant := range product.Variants {
+		for _, offer := range variant.Offers {
+			e.offers++
+			e.inStock = e.inStock || offer.InStock
+			price, ok := rates.Convert(offer.Price)
+			if !ok {
+				continue
+			}
+			if e.minPrice == nil || price.Amount < e.minPrice.Amount {
+				e.minPrice = &price
+			}
+			if e.maxPrice == nil || price.Amount > e.maxPrice.Amount {
+				e.maxPrice = &price
+			}
+		}
+	}
+	return e
+}
+
+func (x *productIndex) add(product *models.Product) {
+	addText := func(text string, weight float64) {
+		for _, term := range search.Terms(text) {
+			docs := x.postings[term]
+			if docs == nil {
+				docs = map[string]float64{}
+				x.postings[term] = docs
+			}
+			docs[product.ID] = math.Max(docs[product.ID], weight)
+		}
+	}
+	addText(product.Name, weightName)
+	addText(product.Brand, weightBrand)
+	addText(product.Description, weightDescription)
+	for _, category := range x.categoryPath(product.CategoryID) {
+		addText(category.Name, weightCategory)
+	}
+	for _, variant := range product.Variants {
+		addText(variant.Name, weightVariant)
+		addText(strings.TrimLeft(variant.GTIN, "0"), weightIdentifier)
+		for _, value := range variant.Attributes {
+			addText(value, weightVariant)
+		}
+		for _, offer := range variant.Offers {
+			addText(offer.SKU, weightIdentifier)
+		}
+	}
+}
+
+// categoryPath returns the categories from the root down to id
+func (x *productIndex) categoryPath(id string) []models.Category {
+	path := []models.Category{}
+	for id != "" {
+		category, ok := x.categories[id]
+		if !ok {
+			break
+		}
+		path = append([]models.Category{category}, path...)
+		id = category.ParentID
+	}
+	return path
+}
+
+// Search returns a page of the products matching q, with facets
+func (c *Catalog) Search(ctx context.Context, q SearchQuery) (*models.ProductSearchResponse, error) {
+	terms := search.Terms(q.Text)
+	if strings.TrimSpace(q.Text) != "" && len(terms) == 0 || len(terms) > maxQueryTerms {
+		return nil, ErrInvalidQuery
+	}
+	switch q.Mode {
+	case "":
+		q.Mode = ModeKeyword
+		if c.semantic != nil {
+			q.Mode = ModeHybrid
+		}
+	case ModeKeyword:
+	case ModeHybrid:
+		if c.semantic == nil {
+			return nil, ErrSemanticUnavailable
+		}
+	default:
+		return nil, ErrInvalidQuery
+	}
+	// The query is embedded before locking; a failure falls back to keywords
+	var similar map[string]int
+	if q.Mode == ModeHybrid && len(terms) > 0 {
+		var err error
+		if similar, err = c.semantic.similar(ctx, q.Text, semanticFilter(q)); err != nil {
+			c.logger.WithError(err).Warn("Semantic search failed, ranking by keywords only")
+			q.Mode = ModeKeyword
+		}
+	}
+	switch q.Sort {
+	case "":
+		q.Sort = SortName
+		if len(terms) > 0 {
+			q.Sort = SortRelevance
+		}
+	case SortRelevance, SortPriceAsc, SortPriceDesc, SortName, SortNewest:
+	default:
+		return nil, ErrInvalidQuery
+	}
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = DefaultPageSize
+	}
+	q.PageSize = min(q.PageSize, MaxPageSize)
+	brands := map[string]bool{}
+	for _, brand := range q.Brands {
+		brands[strings.ToLower(brand)] = true
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	x := c.index
+
+	scores := x.match(terms)
+	if similar != nil {
+		scores = fuseRanks(scores, similar)
+	}
+	inCategory := func(e *entry) bool {
+		id := e.product.CategoryID
+		return q.Category == "" || id == q.Category || strings.HasPrefix(id, q.Category+"/")
+	}
+	ofBrand := func(e *entry) bool {
+		return len(brands) == 0 || brands[e.brand]
+	}
+	inPriceRange := func(e *entry) bool {
+		if q.MinPrice == nil && q.MaxPrice == nil {
+			return true
+		}
+		return e.minPrice != nil &&
+			(q.MinPrice == nil || e.minPrice.Amount >= *q.MinPrice) &&
+			(q.MaxPrice == nil || e.minPrice.Amount <= *q.MaxPrice)
+	}
+
+	var results []*entry
+	categoryCounts := map[string]int{}
+	brandCounts := map[string]int{}
+	brandLabels := map[string]string{}
+	priceCounts := make([]int, len(priceEdges))
+	for _, e := range x.entries {
+		if scores != nil {
+			if _, ok := scores[e.product.ID]; !ok {
+				continue
+			}
+		}
+		category, brand, price := inCategory(e), ofBrand(e), inPriceRange(e)
+		if category && brand && price {
+			results = append(results, e)
+		}
+		// Each facet counts the products passing the other filters
+		if brand && price {
+			if id := x.facetCategory(e.product.CategoryID, q.Category); id != "" {
+				categoryCounts[id]++
+			}
+		}
+		if category && price && e.brand != "" {
+			brandCounts[e.brand]++
+			if _, ok := brandLabels[e.brand]; !ok {
+				brandLabels[e.brand] = e.product.Brand
+			}
+		}
+		if category && brand && e.minPrice != nil {
+			priceCounts[sort.Search(len(priceEdges), func(i int) bool { return priceEdges[i] > e.minPrice.Amount })-1]++
+		}
+	}
+
+	sortEntries(results, q.Sort, scores)
+	response := &models.ProductSearchResponse{
+		Products: []models.ProductSummary{},
+		Total:    len(results),
+		Mode:     q.Mode,
+		Page:     q.Page,
+		PageSize: q.PageSize,
+		Facets: models.ProductFacets{
+			Categories:  x.categoryFacets(categoryCounts),
+			Brands:      facetValues(brandCounts, brandLabels, maxBrandFacets),
+			PriceRanges: priceFacets(priceCounts),
+		},
+	}
+	start := (q.Page - 1) * q.PageSize
+	for i := start; i >= 0 && i < len(results) && i < start+q.PageSize; i++ {
+		response.Products = append(response.Products, results[i].summary())
+	}
+	return response, nil
+}
+
+// match returns the score of each product containing every term, the last
+// also as the start of a word; nil when there are no terms
+func (x *productIndex) match(terms []string) map[string]float64 {
+	if len(terms) == 0 {
+		return nil
+	}
+	n := float64(len(x.entries))
+	var scores map[string]float64
+	for i, term := range terms {
+		weights := map[string]float64{}
+		matched := []string{term}
+		if i == len(terms)-1 && utf8.RuneCountInString(term) >= 2 {
+			matched = x.withPrefix(term)
+		}
+		for _, t := range matched {
+			docs := x.postings[t]
+			idf := math.Log(1 + n/float64(len(docs)))
+			for id, weight := range docs {
+				weights[id] = math.Max(weights[id], weight*idf)
+			}
+		}
+
+		next := map[string]float64{}
+		for id, weight := range weights {
+			if scores == nil {
+				next[id] = weight
+			} else if score, ok := scores[id]; ok {
+				next[id] = score + weight
+			}
+		}
+		scores = next
+	}
+	return scores
+}
+
+// withPrefix returns the indexed terms starting with prefix
+func (x *productIndex) withPrefix(prefix string) []string {
+	var terms []string
+	for i := sort.SearchStrings(x.terms, prefix); i < len(x.terms) && strings.HasPrefix(x.terms[i], prefix); i++ {
+		terms = append(terms, x.terms[i])
+	}
+	return terms
+}
+
+// facetCategory returns the category a product counts toward: the top-level
+// category of its own, or the child of selected it is in
+func (x *productIndex) facetCategory(id, selected string) string {
+	if selected != "" {
+		rest, ok := strings.CutPrefix(id, selected+"/")
+		if !ok {
+			return ""
+		}
+		child, _, _ := strings.Cut(rest, "/")
+		return selected + "/" + child
+	}
+	top, _, _ := strings.Cut(id, "/")
+	return top
+}
+
+func (x *productIndex) categoryFacets(counts map[string]int) []models.FacetValue {
+	labels := make(map[string]string, len(counts))
+	for id := range counts {
+		labels[id] = x.categories[id].Name
+	}
+	return facetValues(counts, labels, len(counts))
+}
+
+// facetValues orders facet values by count, then label, keeping the first limit
+func facetValues(counts map[string]int, labels map[string]string, limit int) []models.FacetValue {
+	values := make([]models.FacetValue, 0, len(counts))
+	for value, count := range counts {
+		values = append(values, models.FacetValue{Value: value, Label: labels[value], Count: count})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Label < values[j].Label
+	})
+	return values[:min(len(values), limit)]
+}
+
+func priceFacets(counts []int) []models.PriceRangeFacet {
+	facets := []models.PriceRangeFacet{}
+	for i, count := range counts {
+		if count == 0 {
+			continue
+		}
+		facet := models.PriceRangeFacet{Min: priceEdges[i], Count: count}
+		if i+1 < len(priceEdges) {
+			max := priceEdges[i+1]
+			facet.Max = &max
+		}
+		facets = append(facets, facet)
+	}
+	return facets
+}
+
+func sortEntries(entries []*entry, order string, scores map[string]float64) {
+	// Products without offers go last when sorting by price
+	price := func(e *entry, missing int64) int64 {
+		if e.minPrice == nil {
+			return missing
+		}
+		return e.minPrice.Amount
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		switch order {
+		case SortRelevance:
+			if sa, sb := scores[a.product.ID], scores[b.product.ID]; sa != sb {
+				return sa > sb
+			}
+		case SortPriceAsc:
+			if pa, pb := price(a, math.MaxInt64), price(b, math.MaxInt64); pa != pb {
+				return pa < pb
+			}
+		case SortPriceDesc:
+			if pa, pb := price(a, -1), price(b, -1); pa != pb {
+				return pa > pb
+			}
+		case SortNewest:
+			if !a.product.CreatedAt.Equal(b.product.CreatedAt) {
+				return a.product.CreatedAt.After(b.product.CreatedAt)
+			}
+		}
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		return a.product.ID < b.product.ID
+	})
+}
+
+func (e *entry) summary() models.ProductSummary {
+	return models.ProductSummary{
+		ID:         e.product.ID,
+		Name:       e.product.Name,
+		Brand:      e.product.Brand,
+		CategoryID: e.product.CategoryID,
+		ImageURL:   e.product.ImageURL,
+		MinPrice:   e.minPrice,
+		MaxPrice:   e.maxPrice,
+		OfferCount: e.offers,
+		InStock:    e.inStock,
+	}
+}
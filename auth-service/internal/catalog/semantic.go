@@ -0,0 +1,269 @@
+package catalog
+
+import (
+	"auth-service/internal/embeddings"
+	"auth-service/internal/models"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Search modes
+const (
+	ModeKeyword = "keyword"
+	ModeHybrid  = "hybrid"
+)
+
+const (
+	// semanticCandidates bounds the products a query matches by meaning
+	semanticCandidates = 200
+	// embedBatch bounds the products embedded at once, so a failed batch
+	// loses no more than its own work
+	embedBatch = 64
+	// saveBatches is how many batches are embedded between saves of the
+	// index, which bounds the work a crash loses without writing the whole
+	// index after every batch
+	saveBatches = 16
+	// rrfK damps the ranks fused by reciprocal rank fusion, so the top few
+	// results of one ranking do not outweigh agreement between both
+	rrfK = 60
+)
+
+// ErrSemanticUnavailable is returned for hybrid searches when semantic
+// search is not enabled
+var ErrSemanticUnavailable = errors.New("semantic search is not enabled")
+
+// semantic ranks products by the meaning of their text, from a vector index
+// of their embeddings
+type semantic struct {
+	embedder      embeddings.Embedder
+	index         *embeddings.Index
+	minSimilarity float64
+	// syncing serializes SyncEmbeddings
+	syncing sync.Mutex
+	// wake is signalled when the index is due a sync
+	wake chan struct{}
+}
+
+// EnableSemanticSearch makes searches with text rank products by meaning as
+// well as by keywords. Products are embedded by embedder into index, whose
+// model must be the embedder's; a product matches by meaning alone when its
+// cosine similarity to the query reaches minSimilarity. It must be called
+// before the catalog is shared. A sync is queued right away, for the products
+// imported before semantic search was enabled; RunEmbeddings runs it.
+func (c *Catalog) EnableSemanticSearch(embedder embeddings.Embedder, index *embeddings.Index, minSimilarity float64) {
+	c.semantic = &semantic{embedder: embedder, index: index, minSimilarity: minSimilarity, wake: make(chan struct{}, 1)}
+	c.queueEmbeddings()
+}
+
+// queueEmbeddings asks RunEmbeddings for a sync, without waiting for it
+func (c *Catalog) queueEmbeddings() {
+	if c.semantic == nil {
+		return
+	}
+	select {
+	case c.semantic.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RunEmbeddings syncs the vector index whenever a sync is queued, until ctx
+// is done. Imports queue one, so they never wait for the embedder; imports
+// made while a sync runs are caught up by one more sync afterwards. It
+// returns right away when semantic search is not enabled.
+func (c *Catalog) RunEmbeddings(ctx context.Context) {
+	if c.semantic == nil {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.semantic.wake:
+		}
+
+		embedded, err := c.SyncEmbeddings(ctx)
+		if err != nil && ctx.Err() == nil {
+			// Products embedded so far are kept; the next sync does the rest
+			c.logger.WithError(err).Warnf("Failed to update product embeddings after %d products", embedded)
+		} else if embedded > 0 {
+			c.logger.Infof("Embedded %d products for semantic search", embedded)
+		}
+	}
+}
+
+// SyncEmbeddings brings the vector index up to date with the catalog: it
+// removes the products no longer in the catalog, and embeds the products
+// whose text changed since they were last embedded, embedBatch products at a
+// time. The index is stored every saveBatches batches and when the sync
+// ends. It returns how many products were embedded, which stay embedded when
+// a later batch fails.
+func (c *Catalog) SyncEmbeddings(ctx context.Context) (embedded int, err error) {
+	s := c.semantic
+	if s == nil {
+		return 0, nil
+	}
+	s.syncing.Lock()
+	defer s.syncing.Unlock()
+	defer func() {
+		if saveErr := s.index.Save(); err == nil {
+			err = saveErr
+		}
+	}()
+
+	// Texts are read under the lock; embedding happens without it
+	indexed := s.index.Digests()
+	var (
+		stale    []embeddings.Item
+		texts    []string
+		removed  []string
+		existing = map[string]bool{}
+	)
+	c.mutex.RLock()
+	for _, product := range c.data.Products {
+		existing[product.ID] = true
+		text := embeddingText(product, c.index.categoryPath(product.CategoryID))
+		digest := textDigest(text + "\x00" + product.CategoryID)
+		if indexed[product.ID] == digest {
+			continue
+		}
+		stale = append(stale, embeddings.Item{
+			ID:       product.ID,
+			Metadata: map[string]string{"brand": strings.ToLower(product.Brand), "category": product.CategoryID},
+			Digest:   digest,
+		})
+		texts = append(texts, text)
+	}
+	c.mutex.RUnlock()
+	for id := range indexed {
+		if !existing[id] {
+			removed = append(removed, id)
+		}
+	}
+	sort.Strings(removed)
+
+	if err := s.index.Put(nil, removed); err != nil {
+		return 0, err
+	}
+	for start := 0; start < len(stale); start += embedBatch {
+		end := min(start+embedBatch, len(stale))
+		vectors, err := s.embedder.Embed(ctx, texts[start:end])
+		if err != nil {
+			return embedded, err
+		}
+		if len(vectors) != end-start {
+			return embedded, embeddings.ErrEmbeddingFailed
+		}
+		batch := stale[start:end]
+		for i := range batch {
+			batch[i].Vector = vectors[i]
+		}
+		if err := s.index.Put(batch, nil); err != nil {
+			return embedded, err
+		}
+		embedded += len(batch)
+		if (start/embedBatch+1)%saveBatches == 0 {
+			if err := s.index.Save(); err != nil {
+				return embedded, err
+			}
+		}
+	}
+	return embedded, nil
+}
+
+// similar returns the products passing filter whose similarity to text
+// reaches the minimum, with their rank by similarity
+func (s *semantic) similar(ctx context.Context, text string, filter embeddings.Filter) (map[string]int, error) {
+	vectors, err := s.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != 1 {
+		return nil, embeddings.ErrEmbeddingFailed
+	}
+	hits, err := s.index.Search(vectors[0], semanticCandidates, filter)
+	if err != nil {
+		return nil, err
+	}
+	ranks := make(map[string]int, len(hits))
+	for i, hit := range hits {
+		if hit.Score < s.minSimilarity {
+			break
+		}
+		ranks[hit.ID] = i
+	}
+	return ranks, nil
+}
+
+// semanticFilter keeps the products of q's category and its descendants, and
+// of q's brands, as the keyword filters do. Filtering in the index keeps
+// products elsewhere from using up the semantic candidates.
+func semanticFilter(q SearchQuery) embeddings.Filter {
+	filter := embeddings.Filter{}
+	if q.Category != "" {
+		filter["category"] = []string{q.Category, q.Category + "/"}
+	}
+	for _, brand := range q.Brands {
+		filter["brand"] = append(filter["brand"], strings.ToLower(brand))
+	}
+	return filter
+}
+
+// fuseRanks combines keyword scores and semantic ranks by reciprocal rank
+// fusion: each product scores 1/(rrfK+rank) in each ranking it is in. It
+// needs no common scale between BM25-like and cosine scores, and favors
+// products both rankings agree on.
+func fuseRanks(keyword map[string]float64, similar map[string]int) map[string]float64 {
+	ids := make([]string, 0, len(keyword))
+	for id := range keyword {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if keyword[ids[i]] != keyword[ids[j]] {
+			return keyword[ids[i]] > keyword[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	fused := make(map[string]float64, len(keyword)+len(similar))
+	for rank, id := range ids {
+		fused[id] += 1 / float64(rrfK+rank+1)
+	}
+	for id, rank := range similar {
+		fused[id] += 1 / float64(rrfK+rank+1)
+	}
+	return fused
+}
+
+// embeddingText is the text a product is embedded from: what it is, who
+// makes it, where it is filed and how it is described
+func embeddingText(product *models.Product, path []models.Category) string {
+	parts := []string{product.Name}
+	if product.Brand != "" {
+		parts = append(parts, product.Brand)
+	}
+	if len(path) > 0 {
+		names := make([]string, len(path))
+		for i, category := range path {
+			names[i] = category.Name
+		}
+		parts = append(parts, strings.Join(names, " > "))
+	}
+	for _, variant := range product.Variants {
+		if variant.Name != "" {
+			parts = append(parts, variant.Name)
+		}
+	}
+	if product.Description != "" {
+		parts = append(parts, product.Description)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func textDigest(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
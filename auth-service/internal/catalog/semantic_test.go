@@ -0,0 +1,221 @@
+package catalog
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/embeddings"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const homeFeeds = `
+feeds:
+  - id: home
+    format: csv
+    fields: {sku: sku, name: name, description: description, category: category, price: price}
+`
+
+const homeFeed = "sku,name,description,category,price\n" +
+	"H-1,Whisper 900 Blender,\"Low-noise motor, crushes frozen fruit into smoothies\",Kitchen > Blenders,129.00\n" +
+	"H-2,Yoga Mat,Non-slip mat for home workouts,Sports > Yoga,24.50\n" +
+	"H-3,Steel Kettle,Boils water in two minutes,Kitchen > Kettles,39.00\n"
+
+// countingEmbedder is a HashEmbedder counting the texts it embeds, or failing,
+// from the start or once it has embedded failAfter texts
+type countingEmbedder struct {
+	*embeddings.HashEmbedder
+	texts     int
+	fail      bool
+	failAfter int
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if e.fail || e.failAfter > 0 && e.texts >= e.failAfter {
+		return nil, errors.New("embeddings API unavailable")
+	}
+	e.texts += len(texts)
+	return e.HashEmbedder.Embed(ctx, texts)
+}
+
+// newSemanticCatalog returns a catalog of home products ranked by meaning
+// with a hash embedder
+func newSemanticCatalog(t *testing.T, dir string) (*Catalog, *countingEmbedder, *embeddings.Index) {
+	c, err := NewCatalog(dir, config.CatalogConfig{Currency: "EUR"}, nil, testLogger)
+	require.NoError(t, err)
+	embedder := &countingEmbedder{HashEmbedder: embeddings.NewHashEmbedder(0)}
+	index, err := embeddings.OpenIndex(dir, "products", embedder.Model())
+	require.NoError(t, err)
+	c.EnableSemanticSearch(embedder, index, 0.3)
+	return c, embedder, index
+}
+
+// importAndEmbed imports a feed and embeds its products, as RunEmbeddings
+// does in the background
+func importAndEmbed(t *testing.T, c *Catalog, feeds *Feeds, id, content string) {
+	importFeed(t, c, feeds, id, content)
+	_, err := c.SyncEmbeddings(context.Background())
+	require.NoError(t, err)
+}
+
+func TestCatalog_HybridSearchMatchesByMeaning(t *testing.T) {
+	c, _, _ := newSemanticCatalog(t, t.TempDir())
+	feeds, err := ParseFeeds([]byte(homeFeeds), "EUR")
+	require.NoError(t, err)
+	importAndEmbed(t, c, feeds, "home", homeFeed)
+
+	keyword, err := c.Search(context.Background(), SearchQuery{Text: "quiet blender for smoothies", Mode: ModeKeyword})
+	require.NoError(t, err)
+	assert.Equal(t, 0, keyword.Total, "no product has every word")
+	assert.Equal(t, ModeKeyword, keyword.Mode)
+
+	hybrid, err := c.Search(context.Background(), SearchQuery{Text: "quiet blender for smoothies"})
+	require.NoError(t, err)
+	assert.Equal(t, ModeHybrid, hybrid.Mode, "the default with semantic search")
+	require.NotEmpty(t, hybrid.Products)
+	assert.Equal(t, "Whisper 900 Blender", hybrid.Products[0].Name)
+	assert.NotContains(t, names(hybrid.Products), "Yoga Mat")
+
+	// Keyword matches still count, and rank above meaning alone
+	hybrid, err = c.Search(context.Background(), SearchQuery{Text: "kettle", Category: "kitchen"})
+	require.NoError(t, err)
+	require.NotEmpty(t, hybrid.Products)
+	assert.Equal(t, "Steel Kettle", hybrid.Products[0].Name)
+	for _, product := range hybrid.Products {
+		assert.True(t, strings.HasPrefix(product.CategoryID, "kitchen"), "filters apply to matches by meaning")
+	}
+}
+
+func TestCatalog_HybridSearchFiltersCandidates(t *testing.T) {
+	c, _, _ := newSemanticCatalog(t, t.TempDir())
+	feeds, err := ParseFeeds([]byte(homeFeeds), "EUR")
+	require.NoError(t, err)
+	feed := "sku,name,description,category,price\n"
+	for i := 0; i < semanticCandidates+10; i++ {
+		feed += fmt.Sprintf("B-%d,Whisper %d Blender,\"Low-noise motor, crushes frozen fruit into smoothies\",Kitchen > Blenders,%d.00\n", i, i, i+1)
+	}
+	feed += "G-1,Quiet Leaf Blower,Low-noise motor for garden paths,Garden > Tools,89.00\n"
+	importAndEmbed(t, c, feeds, "home", feed)
+
+	// The blenders are closer to the query, but filtered out before the
+	// candidates are picked
+	page, err := c.Search(context.Background(), SearchQuery{Text: "quiet blender for smoothies", Category: "garden"})
+	require.NoError(t, err)
+	assert.Equal(t, ModeHybrid, page.Mode)
+	assert.Equal(t, []string{"Quiet Leaf Blower"}, names(page.Products))
+}
+
+func TestCatalog_SyncEmbeddingsIsIncremental(t *testing.T) {
+	dir := t.TempDir()
+	c, embedder, index := newSemanticCatalog(t, dir)
+	feeds, err := ParseFeeds([]byte(homeFeeds), "EUR")
+	require.NoError(t, err)
+
+	importAndEmbed(t, c, feeds, "home", homeFeed)
+	assert.Equal(t, 3, embedder.texts)
+	assert.Equal(t, 3, index.Len())
+
+	// Unchanged products are not embedded again; changed ones are
+	importAndEmbed(t, c, feeds, "home", homeFeed)
+	assert.Equal(t, 3, embedder.texts)
+	importAndEmbed(t, c, feeds, "home", homeFeed+"H-4,Travel Mug,Keeps coffee hot,Kitchen,12.00\n")
+	assert.Equal(t, 4, embedder.texts)
+
+	// Products gone from the catalog leave the index
+	stale := make([]float32, embeddings.DefaultHashDimensions)
+	stale[0] = 1
+	require.NoError(t, index.Apply([]embeddings.Item{{ID: "gone", Vector: stale}}, nil))
+	_, err = c.SyncEmbeddings(context.Background())
+	require.NoError(t, err)
+	assert.NotContains(t, index.Digests(), "gone")
+
+	// The index survives restarts
+	c, embedder, index = newSemanticCatalog(t, dir)
+	embedded, err := c.SyncEmbeddings(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, embedded)
+	assert.Equal(t, 0, embedder.texts)
+	assert.Equal(t, 4, index.Len())
+}
+
+func TestCatalog_SyncEmbeddingsKeepsBatchesDone(t *testing.T) {
+	dir := t.TempDir()
+	c, embedder, index := newSemanticCatalog(t, dir)
+	feeds, err := ParseFeeds([]byte(homeFeeds), "EUR")
+	require.NoError(t, err)
+	feed := "sku,name,description,category,price\n"
+	for i := 0; i < embedBatch+6; i++ {
+		feed += fmt.Sprintf("S-%d,Storage Box %d,Stackable box,Home,%d.00\n", i, i, i+1)
+	}
+	importFeed(t, c, feeds, "home", feed)
+
+	embedder.failAfter = embedBatch
+	embedded, err := c.SyncEmbeddings(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, embedBatch, embedded)
+	assert.Equal(t, embedBatch, index.Len(), "the first batch is kept")
+
+	// After a restart, the sync resumes with the batches not done
+	c, embedder, index = newSemanticCatalog(t, dir)
+	assert.Equal(t, embedBatch, index.Len(), "the first batch is stored")
+	embedded, err = c.SyncEmbeddings(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 6, embedded, "only the rest is embedded")
+	assert.Equal(t, 6, embedder.texts)
+	assert.Equal(t, embedBatch+6, index.Len())
+}
+
+func TestCatalog_RunEmbeddingsAfterImports(t *testing.T) {
+	c, embedder, index := newSemanticCatalog(t, t.TempDir())
+	feeds, err := ParseFeeds([]byte(homeFeeds), "EUR")
+	require.NoError(t, err)
+
+	// Imports do not wait for the embedder
+	importFeed(t, c, feeds, "home", homeFeed)
+	assert.Equal(t, 0, index.Len())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.RunEmbeddings(ctx)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return index.Len() == 3 }, time.Second, 5*time.Millisecond)
+	importFeed(t, c, feeds, "home", homeFeed+"H-4,Travel Mug,Keeps coffee hot,Kitchen,12.00\n")
+	assert.Eventually(t, func() bool { return index.Len() == 4 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+	assert.Equal(t, 4, embedder.texts)
+
+	// Without semantic search, there is nothing to run
+	plain, _, _ := newTestCatalog(t)
+	plain.RunEmbeddings(context.Background())
+}
+
+func TestCatalog_HybridSearchFallsBackToKeywords(t *testing.T) {
+	c, embedder, _ := newSemanticCatalog(t, t.TempDir())
+	feeds, err := ParseFeeds([]byte(homeFeeds), "EUR")
+	require.NoError(t, err)
+	importAndEmbed(t, c, feeds, "home", homeFeed)
+
+	embedder.fail = true
+	page, err := c.Search(context.Background(), SearchQuery{Text: "kettle"})
+	require.NoError(t, err)
+	assert.Equal(t, ModeKeyword, page.Mode)
+	assert.Equal(t, []string{"Steel Kettle"}, names(page.Products))
+
+	// Imports stand when products cannot be embedded
+	result := importFeed(t, c, feeds, "home", homeFeed+"H-4,Travel Mug,Keeps coffee hot,Kitchen,12.00\n")
+	assert.Equal(t, 1, result.Created)
+
+	plain, _, _ := newTestCatalog(t)
+	_, err = plain.Search(context.Background(), SearchQuery{Text: "kettle", Mode: ModeHybrid})
+	assert.ErrorIs(t, err, ErrSemanticUnavailable)
+	_, err = plain.Search(context.Background(), SearchQuery{Text: "kettle", Mode: "vibes"})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+}
@@ -1,7 +1,16 @@
 package config
 
 import (
+	"auth-service/internal/secrets"
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -11,6 +20,25 @@ type Config struct {
 	Server   ServerConfig   `mapstructure:"server"`
 	Keycloak KeycloakConfig `mapstructure:"keycloak"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
+	CORS     CORSConfig     `mapstructure:"cors"`
+	Secrets  SecretsConfig  `mapstructure:"secrets"`
+	Session  SessionConfig  `mapstructure:"session"`
+	Security SecurityConfig `mapstructure:"security"`
+	Password PasswordConfig `mapstructure:"password"`
+	Cache    CacheConfig    `mapstructure:"cache"`
+	Flags    FlagsConfig    `mapstructure:"flags"`
+	// LibreChat fills the startup and endpoints config read by the chat client
+	LibreChat LibreChatConfig `mapstructure:"librechat"`
+	Storage   StorageConfig   `mapstructure:"storage"`
+	Search    SearchConfig    `mapstructure:"search"`
+	LLM       LLMConfig       `mapstructure:"llm"`
+	Files     FilesConfig     `mapstructure:"files"`
+	Balance   BalanceConfig   `mapstructure:"balance"`
+	Catalog   CatalogConfig   `mapstructure:"catalog"`
+	// Embeddings enables semantic product search
+	Embeddings EmbeddingsConfig `mapstructure:"embeddings"`
+	Alerts     AlertsConfig     `mapstructure:"alerts"`
+	Lists      ListsConfig      `mapstructure:"lists"`
 }
 
 // ServerConfig holds server configuration
@@ -27,23 +55,317 @@ type KeycloakConfig struct {
 	Realm            string `mapstructure:"realm"`
 	AdminRealm       string `mapstructure:"admin_realm"`
 	ClientID         string `mapstructure:"client_id"`
-	ClientSecret     string `mapstructure:"client_secret"`
+	ClientSecret     Secret `mapstructure:"client_secret"`
 	AdminClientID    string `mapstructure:"admin_client_id"`
 	AdminUser        string `mapstructure:"admin_user"`
-	AdminPass        string `mapstructure:"admin_pass"`
+	AdminPass        Secret `mapstructure:"admin_pass"`
 	// External URLs for frontend
 	ExternalAuthURL   string `mapstructure:"external_auth_url"`
 	ExternalTokenURL  string `mapstructure:"external_token_url"`
 	ExternalLogoutURL string `mapstructure:"external_logout_url"`
+	// Timeouts, retries and circuit breaking for calls to Keycloak
+	Resilience ResilienceConfig `mapstructure:"resilience"`
+}
+
+// ResilienceConfig bounds how long identity provider calls may take and how
+// the service sheds load while the identity provider is failing
+type ResilienceConfig struct {
+	// Timeout applies to each call; LoginTimeout to password and token grants
+	Timeout      time.Duration `mapstructure:"timeout"`
+	LoginTimeout time.Duration `mapstructure:"login_timeout"`
+	// MaxRetries and RetryBackoff apply to idempotent calls only
+	MaxRetries   int           `mapstructure:"max_retries"`
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+	// The breaker opens after BreakerThreshold consecutive failures and
+	// probes again after BreakerCooldown
+	BreakerThreshold int           `mapstructure:"breaker_threshold"`
+	BreakerCooldown  time.Duration `mapstructure:"breaker_cooldown"`
+	BreakerProbes    int           `mapstructure:"breaker_probes"`
 }
 
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
-	SecretKey string `mapstructure:"secret_key"`
+	SecretKey Secret `mapstructure:"secret_key"`
 	Issuer    string `mapstructure:"issuer"`
 	Expiry    int    `mapstructure:"expiry"`
 }
 
+// CORSConfig holds the default CORS policy and per-route overrides
+type CORSConfig struct {
+	Default CORSPolicy `mapstructure:"default"`
+	// Routes maps a path prefix (e.g. "/api/config") to the policy used for it.
+	// The longest matching prefix wins; unmatched paths use Default.
+	Routes map[string]CORSPolicy `mapstructure:"routes"`
+}
+
+// CORSPolicy describes which cross-origin requests are allowed
+type CORSPolicy struct {
+	// AllowedOrigins holds exact origins ("https://app.example.com"),
+	// wildcard subdomain patterns ("https://*.example.com") or "*" for any origin.
+	AllowedOrigins   []string `mapstructure:"allowed_origins"`
+	AllowedMethods   []string `mapstructure:"allowed_methods"`
+	AllowedHeaders   []string `mapstructure:"allowed_headers"`
+	ExposedHeaders   []string `mapstructure:"exposed_headers"`
+	MaxAge           int      `mapstructure:"max_age"`
+	AllowCredentials bool     `mapstructure:"allow_credentials"`
+}
+
+// SessionConfig controls how refresh tokens reach the browser.
+// In cookie mode the refresh token is kept in an HttpOnly cookie scoped to the
+// auth routes and never appears in a response body; cookie-authenticated
+// requests must then carry a double-submit CSRF token.
+type SessionConfig struct {
+	CookieMode     bool   `mapstructure:"cookie_mode"`
+	CookieName     string `mapstructure:"cookie_name"`
+	CookiePath     string `mapstructure:"cookie_path"`
+	CookieDomain   string `mapstructure:"cookie_domain"`
+	CookieSecure   bool   `mapstructure:"cookie_secure"`
+	CookieSameSite string `mapstructure:"cookie_same_site"` // strict, lax or none
+	CSRFCookieName string `mapstructure:"csrf_cookie_name"`
+	CSRFHeaderName string `mapstructure:"csrf_header_name"`
+}
+
+// SecurityConfig holds the security response headers sent on every response.
+// An empty value disables the corresponding header.
+type SecurityConfig struct {
+	HeadersEnabled        bool   `mapstructure:"headers_enabled"`
+	HSTSMaxAge            int    `mapstructure:"hsts_max_age"`
+	HSTSIncludeSubdomains bool   `mapstructure:"hsts_include_subdomains"`
+	HSTSPreload           bool   `mapstructure:"hsts_preload"`
+	ContentTypeOptions    string `mapstructure:"content_type_options"`
+	FrameOptions          string `mapstructure:"frame_options"`
+	ReferrerPolicy        string `mapstructure:"referrer_policy"`
+	CSP                   string `mapstructure:"csp"`
+	// CSPReportOnly sends the policy as Content-Security-Policy-Report-Only
+	CSPReportOnly bool   `mapstructure:"csp_report_only"`
+	CSPReportURI  string `mapstructure:"csp_report_uri"`
+}
+
+// CacheConfig controls the short-lived cache of token validation results
+// and user profiles in front of Keycloak
+type CacheConfig struct {
+	// Backend is "memory", "redis" or "none"
+	Backend    string `mapstructure:"backend"`
+	MaxEntries int    `mapstructure:"max_entries"` // memory backend only
+	// TokenTTL bounds how long a validated token is trusted without asking
+	// Keycloak, and so how late a revoked token is noticed
+	TokenTTL   time.Duration `mapstructure:"token_ttl"`
+	ProfileTTL time.Duration `mapstructure:"profile_ttl"`
+	// Redis-protocol backend settings
+	RedisAddr     string        `mapstructure:"redis_addr"`
+	RedisPassword Secret        `mapstructure:"redis_password"`
+	RedisDB       int           `mapstructure:"redis_db"`
+	RedisPrefix   string        `mapstructure:"redis_prefix"`
+	RedisTimeout  time.Duration `mapstructure:"redis_timeout"`
+}
+
+// FlagsConfig holds the feature flags, keyed by lower-case flag name
+type FlagsConfig struct {
+	Flags map[string]FlagConfig `mapstructure:"flags"`
+}
+
+// FlagConfig targets one feature flag. A flag is on for everyone when
+// Enabled is set; otherwise it is on for the listed users and roles and for
+// Rollout percent of signed-in users.
+type FlagConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Rollout int      `mapstructure:"rollout"` // 0-100
+	Roles   []string `mapstructure:"roles"`
+	Users   []string `mapstructure:"users"`
+}
+
+// LibreChatConfig holds the settings served to the LibreChat client in
+// /api/startup and /api/endpoints. Variable names follow LibreChat's .env.
+type LibreChatConfig struct {
+	AppTitle     string `mapstructure:"app_title"`
+	ServerDomain string `mapstructure:"server_domain"` // public URL of the API, used for OAuth redirects
+	// InstanceProjectID is the project shared prompts and agents are attached to
+	InstanceProjectID string `mapstructure:"instance_project_id"`
+	HelpAndFAQURL     string `mapstructure:"help_and_faq_url"`
+	CustomFooter      string `mapstructure:"custom_footer"`
+	AnalyticsGtmID    string `mapstructure:"analytics_gtm_id"`
+	TurnstileSiteKey  string `mapstructure:"turnstile_site_key"`
+
+	EmailLoginEnabled        bool `mapstructure:"email_login_enabled"`
+	SharedLinksEnabled       bool `mapstructure:"shared_links_enabled"`
+	PublicSharedLinksEnabled bool `mapstructure:"public_shared_links_enabled"`
+
+	// OpenID login through the Keycloak realm
+	OpenIDEnabled      bool   `mapstructure:"openid_enabled"`
+	OpenIDLabel        string `mapstructure:"openid_label"`
+	OpenIDImageURL     string `mapstructure:"openid_image_url"`
+	OpenIDAutoRedirect bool   `mapstructure:"openid_auto_redirect"`
+	// LDAP login (through Keycloak user federation); LDAPUsername makes the
+	// login form ask for a username instead of an email
+	LDAPEnabled  bool `mapstructure:"ldap_enabled"`
+	LDAPUsername bool `mapstructure:"ldap_username"`
+
+	Interface InterfaceConfig `mapstructure:"interface"`
+	// ConfigPath is the librechat.yaml-style file defining the AI endpoints
+	ConfigPath string `mapstructure:"config_path"`
+	// Endpoints optionally restricts the defined endpoints and sets their menu order
+	Endpoints []string `mapstructure:"endpoints"`
+}
+
+// InterfaceConfig toggles parts of the chat UI
+type InterfaceConfig struct {
+	EndpointsMenu     bool   `mapstructure:"endpoints_menu"`
+	ModelSelect       bool   `mapstructure:"model_select"`
+	Parameters        bool   `mapstructure:"parameters"`
+	SidePanel         bool   `mapstructure:"side_panel"`
+	Presets           bool   `mapstructure:"presets"`
+	Prompts           bool   `mapstructure:"prompts"`
+	Bookmarks         bool   `mapstructure:"bookmarks"`
+	MultiConvo        bool   `mapstructure:"multi_convo"`
+	Agents            bool   `mapstructure:"agents"`
+	TemporaryChat     bool   `mapstructure:"temporary_chat"`
+	RunCode           bool   `mapstructure:"run_code"`
+	CustomWelcome     string `mapstructure:"custom_welcome"`
+	PrivacyPolicyURL  string `mapstructure:"privacy_policy_url"`
+	TermsOfServiceURL string `mapstructure:"terms_of_service_url"`
+}
+
+// StorageConfig locates the file-backed stores (conversations and other chat data)
+type StorageConfig struct {
+	Dir string `mapstructure:"dir"`
+}
+
+// SearchConfig configures conversation search
+type SearchConfig struct {
+	// CachedUsers bounds the users whose index is kept in memory; the least
+	// recently searched are loaded again from disk when needed
+	CachedUsers int `mapstructure:"cached_users"`
+}
+
+// LLMConfig selects how assistant replies are generated
+type LLMConfig struct {
+	// Provider is "openai" (each endpoint's OpenAI-compatible API), "echo"
+	// (a deterministic local reply, for development and tests) or
+	// "echo_tools" (echo that also calls tools on request)
+	Provider string        `mapstructure:"provider"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+	// Tools names the tools offered to the model
+	Tools []string `mapstructure:"tools"`
+	// MaxToolRounds bounds the rounds of tool calls in one reply; 0 offers
+	// no tools
+	MaxToolRounds int `mapstructure:"max_tool_rounds"`
+}
+
+// BalanceConfig controls charging AI replies to each user's credit ledger
+type BalanceConfig struct {
+	// Enabled makes replies reserve and settle credits, and shows the balance
+	// in the client
+	Enabled bool `mapstructure:"enabled"`
+	// StartBalance is the credits a new user starts with
+	StartBalance int64 `mapstructure:"start_balance"`
+	// ReserveTokens is held for the completion on top of the prompt until a
+	// reply is settled
+	ReserveTokens int64 `mapstructure:"reserve_tokens"`
+	// AdminRole is the Keycloak role allowed to grant credits
+	AdminRole string `mapstructure:"admin_role"`
+}
+
+// CatalogConfig locates the product feeds and limits their imports
+type CatalogConfig struct {
+	// FeedsPath is the YAML file defining the feeds and their field mappings
+	FeedsPath string `mapstructure:"feeds_path"`
+	// Currency is the currency prices are compared and searched in
+	Currency string `mapstructure:"currency"`
+	// ExchangeRates converts other currencies: the value of one unit in Currency
+	ExchangeRates map[string]float64 `mapstructure:"exchange_rates"`
+	// HistoryDays is how long daily price history is kept
+	HistoryDays int `mapstructure:"history_days"`
+	// MaxFeedSize caps an imported feed, in bytes
+	MaxFeedSize int64 `mapstructure:"max_feed_size"`
+	// AdminRole is the Keycloak role allowed to import feeds
+	AdminRole string `mapstructure:"admin_role"`
+}
+
+// EmbeddingsConfig selects how text is turned into vectors for semantic search
+type EmbeddingsConfig struct {
+	// Provider is "" (semantic search off), "hash" (local word hashing, for
+	// development and tests) or "openai" (an OpenAI-compatible /embeddings API)
+	Provider string `mapstructure:"provider"`
+	BaseURL  string `mapstructure:"base_url"`
+	APIKey   Secret `mapstructure:"api_key"`
+	Model    string `mapstructure:"model"`
+	// Dimensions sizes the vectors; 0 keeps the model's size
+	Dimensions int           `mapstructure:"dimensions"`
+	Timeout    time.Duration `mapstructure:"timeout"`
+	// MinSimilarity is the cosine similarity a product needs to match a
+	// query by meaning alone
+	MinSimilarity float64 `mapstructure:"min_similarity"`
+}
+
+// AlertsConfig selects how price-drop alerts are delivered
+type AlertsConfig struct {
+	// Notifiers lists the channels alerts go to: in_app, email and webhook
+	Notifiers []string `mapstructure:"notifiers"`
+	// EmailFrom is the sender of the alert emails written to the outbox
+	EmailFrom string `mapstructure:"email_from"`
+	// WebhookURL receives every alert as a JSON POST
+	WebhookURL string `mapstructure:"webhook_url"`
+	// WebhookSecret signs webhook bodies with HMAC-SHA256 when set
+	WebhookSecret  Secret        `mapstructure:"webhook_secret"`
+	WebhookTimeout time.Duration `mapstructure:"webhook_timeout"`
+}
+
+// ListsConfig configures shared shopping lists
+type ListsConfig struct {
+	// InviteSecret signs invitation tokens. It is required, as anyone knowing
+	// it can forge them.
+	InviteSecret Secret `mapstructure:"invite_secret"`
+	// InviteTTL is how long an invitation can be accepted
+	InviteTTL time.Duration `mapstructure:"invite_ttl"`
+}
+
+// FilesConfig limits file uploads. Sizes are in bytes.
+type FilesConfig struct {
+	MaxFileSize int64 `mapstructure:"max_file_size"`
+	// UserQuota caps the total size of a user's files
+	UserQuota int64 `mapstructure:"user_quota"`
+	// AllowedTypes lists the MIME types accepted, as sniffed from the content
+	AllowedTypes []string `mapstructure:"allowed_types"`
+	// ThumbnailSize is the longest side of image thumbnails, in pixels
+	ThumbnailSize int `mapstructure:"thumbnail_size"`
+}
+
+// PasswordConfig defines the password policy enforced on registration,
+// password change and (through the synced realm policy) Keycloak resets
+type PasswordConfig struct {
+	MinLength      int  `mapstructure:"min_length"`
+	MaxLength      int  `mapstructure:"max_length"`
+	RequireUpper   bool `mapstructure:"require_upper"`
+	RequireLower   bool `mapstructure:"require_lower"`
+	RequireDigit   bool `mapstructure:"require_digit"`
+	RequireSpecial bool `mapstructure:"require_special"`
+	// MaxRepeated is the longest allowed run of one character (0 disables)
+	MaxRepeated int `mapstructure:"max_repeated"`
+	// DisallowUserInfo rejects passwords containing the username or email
+	DisallowUserInfo bool `mapstructure:"disallow_user_info"`
+	// HistoryDepth is how many previous passwords may not be reused (0 disables)
+	HistoryDepth int `mapstructure:"history_depth"`
+	// BreachedDir holds k-anonymity range files: one file per 5-hex-digit
+	// SHA-1 prefix with "SUFFIX:COUNT" lines (empty disables the check)
+	BreachedDir      string `mapstructure:"breached_dir"`
+	BreachedMinCount int    `mapstructure:"breached_min_count"`
+	// SyncKeycloak pushes the policy to the realm at startup. It is forced on
+	// while password reset is enabled, since Keycloak sets reset passwords.
+	SyncKeycloak bool `mapstructure:"sync_keycloak"`
+}
+
+// SecretsConfig configures where secrets are resolved from
+type SecretsConfig struct {
+	// Dir is a secret mount directory (e.g. /run/secrets) holding one file per
+	// secret, named after the lower-cased variable
+	Dir            string `mapstructure:"dir"`
+	VaultAddr      string `mapstructure:"vault_addr"`
+	VaultToken     Secret `mapstructure:"vault_token"`
+	VaultMount     string `mapstructure:"vault_mount"`
+	VaultPath      string `mapstructure:"vault_path"`
+	VaultNamespace string `mapstructure:"vault_namespace"`
+}
+
 // LoadConfig loads configuration from environment variables and .env file
 func LoadConfig() (*Config, error) {
 	// Set config file
@@ -82,18 +404,12 @@ func LoadConfig() (*Config, error) {
 	if viper.GetString("KEYCLOAK_CLIENT_ID") != "" {
 		config.Keycloak.ClientID = viper.GetString("KEYCLOAK_CLIENT_ID")
 	}
-	if viper.GetString("KEYCLOAK_CLIENT_SECRET") != "" {
-		config.Keycloak.ClientSecret = viper.GetString("KEYCLOAK_CLIENT_SECRET")
-	}
 	if viper.GetString("KEYCLOAK_ADMIN_CLIENT_ID") != "" {
 		config.Keycloak.AdminClientID = viper.GetString("KEYCLOAK_ADMIN_CLIENT_ID")
 	}
 	if viper.GetString("KEYCLOAK_ADMIN_USER") != "" {
 		config.Keycloak.AdminUser = viper.GetString("KEYCLOAK_ADMIN_USER")
 	}
-	if viper.GetString("KEYCLOAK_ADMIN_PASS") != "" {
-		config.Keycloak.AdminPass = viper.GetString("KEYCLOAK_ADMIN_PASS")
-	}
 	if viper.GetString("KEYCLOAK_EXTERNAL_AUTH_URL") != "" {
 		config.Keycloak.ExternalAuthURL = viper.GetString("KEYCLOAK_EXTERNAL_AUTH_URL")
 	}
@@ -104,9 +420,390 @@ func LoadConfig() (*Config, error) {
 		config.Keycloak.ExternalLogoutURL = viper.GetString("KEYCLOAK_EXTERNAL_LOGOUT_URL")
 	}
 
+	config.Keycloak.Resilience = ResilienceConfig{
+		Timeout:          viper.GetDuration("KEYCLOAK_TIMEOUT"),
+		LoginTimeout:     viper.GetDuration("KEYCLOAK_LOGIN_TIMEOUT"),
+		MaxRetries:       viper.GetInt("KEYCLOAK_MAX_RETRIES"),
+		RetryBackoff:     viper.GetDuration("KEYCLOAK_RETRY_BACKOFF"),
+		BreakerThreshold: viper.GetInt("KEYCLOAK_BREAKER_THRESHOLD"),
+		BreakerCooldown:  viper.GetDuration("KEYCLOAK_BREAKER_COOLDOWN"),
+		BreakerProbes:    viper.GetInt("KEYCLOAK_BREAKER_PROBES"),
+	}
+
+	cors, err := loadCORSConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.CORS = cors
+	config.Session = SessionConfig{
+		CookieMode:     viper.GetBool("SESSION_COOKIE_MODE"),
+		CookieName:     viper.GetString("SESSION_COOKIE_NAME"),
+		CookiePath:     viper.GetString("SESSION_COOKIE_PATH"),
+		CookieDomain:   viper.GetString("SESSION_COOKIE_DOMAIN"),
+		CookieSecure:   viper.GetBool("SESSION_COOKIE_SECURE"),
+		CookieSameSite: viper.GetString("SESSION_COOKIE_SAME_SITE"),
+		CSRFCookieName: viper.GetString("SESSION_CSRF_COOKIE_NAME"),
+		CSRFHeaderName: viper.GetString("SESSION_CSRF_HEADER_NAME"),
+	}
+
+	config.Security = SecurityConfig{
+		HeadersEnabled:        viper.GetBool("SECURITY_HEADERS_ENABLED"),
+		HSTSMaxAge:            viper.GetInt("SECURITY_HSTS_MAX_AGE"),
+		HSTSIncludeSubdomains: viper.GetBool("SECURITY_HSTS_INCLUDE_SUBDOMAINS"),
+		HSTSPreload:           viper.GetBool("SECURITY_HSTS_PRELOAD"),
+		ContentTypeOptions:    viper.GetString("SECURITY_CONTENT_TYPE_OPTIONS"),
+		FrameOptions:          viper.GetString("SECURITY_FRAME_OPTIONS"),
+		ReferrerPolicy:        viper.GetString("SECURITY_REFERRER_POLICY"),
+		CSP:                   viper.GetString("SECURITY_CSP"),
+		CSPReportOnly:         viper.GetBool("SECURITY_CSP_REPORT_ONLY"),
+		CSPReportURI:          viper.GetString("SECURITY_CSP_REPORT_URI"),
+	}
+
+	config.Cache = CacheConfig{
+		Backend:      strings.ToLower(viper.GetString("CACHE_BACKEND")),
+		MaxEntries:   viper.GetInt("CACHE_MAX_ENTRIES"),
+		TokenTTL:     viper.GetDuration("CACHE_TOKEN_TTL"),
+		ProfileTTL:   viper.GetDuration("CACHE_PROFILE_TTL"),
+		RedisAddr:    viper.GetString("CACHE_REDIS_ADDR"),
+		RedisDB:      viper.GetInt("CACHE_REDIS_DB"),
+		RedisPrefix:  viper.GetString("CACHE_REDIS_PREFIX"),
+		RedisTimeout: viper.GetDuration("CACHE_REDIS_TIMEOUT"),
+	}
+
+	flags, err := loadFlagsConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.Flags = flags
+
+	config.LibreChat = LibreChatConfig{
+		AppTitle:                 viper.GetString("APP_TITLE"),
+		ServerDomain:             viper.GetString("DOMAIN_SERVER"),
+		InstanceProjectID:        viper.GetString("INSTANCE_PROJECT_ID"),
+		HelpAndFAQURL:            viper.GetString("HELP_AND_FAQ_URL"),
+		CustomFooter:             viper.GetString("CUSTOM_FOOTER"),
+		AnalyticsGtmID:           viper.GetString("ANALYTICS_GTM_ID"),
+		TurnstileSiteKey:         viper.GetString("TURNSTILE_SITE_KEY"),
+		EmailLoginEnabled:        viper.GetBool("ALLOW_EMAIL_LOGIN"),
+		SharedLinksEnabled:       viper.GetBool("ALLOW_SHARED_LINKS"),
+		PublicSharedLinksEnabled: viper.GetBool("ALLOW_SHARED_LINKS_PUBLIC"),
+		OpenIDEnabled:            viper.GetBool("OPENID_ENABLED"),
+		OpenIDLabel:              viper.GetString("OPENID_BUTTON_LABEL"),
+		OpenIDImageURL:           viper.GetString("OPENID_IMAGE_URL"),
+		OpenIDAutoRedirect:       viper.GetBool("OPENID_AUTO_REDIRECT"),
+		LDAPEnabled:              viper.GetBool("LDAP_ENABLED"),
+		LDAPUsername:             viper.GetBool("LDAP_LOGIN_USES_USERNAME"),
+		Interface: InterfaceConfig{
+			EndpointsMenu:     viper.GetBool("INTERFACE_ENDPOINTS_MENU"),
+			ModelSelect:       viper.GetBool("INTERFACE_MODEL_SELECT"),
+			Parameters:        viper.GetBool("INTERFACE_PARAMETERS"),
+			SidePanel:         viper.GetBool("INTERFACE_SIDE_PANEL"),
+			Presets:           viper.GetBool("INTERFACE_PRESETS"),
+			Prompts:           viper.GetBool("INTERFACE_PROMPTS"),
+			Bookmarks:         viper.GetBool("INTERFACE_BOOKMARKS"),
+			MultiConvo:        viper.GetBool("INTERFACE_MULTI_CONVO"),
+			Agents:            viper.GetBool("INTERFACE_AGENTS"),
+			TemporaryChat:     viper.GetBool("INTERFACE_TEMPORARY_CHAT"),
+			RunCode:           viper.GetBool("INTERFACE_RUN_CODE"),
+			CustomWelcome:     viper.GetString("INTERFACE_CUSTOM_WELCOME"),
+			PrivacyPolicyURL:  viper.GetString("INTERFACE_PRIVACY_POLICY_URL"),
+			TermsOfServiceURL: viper.GetString("INTERFACE_TERMS_OF_SERVICE_URL"),
+		},
+		ConfigPath: viper.GetString("CONFIG_PATH"),
+		Endpoints:  splitList(viper.GetString("ENDPOINTS")),
+	}
+
+	config.Storage = StorageConfig{
+		Dir: viper.GetString("STORAGE_DIR"),
+	}
+
+	config.Search = SearchConfig{
+		CachedUsers: viper.GetInt("SEARCH_CACHED_USERS"),
+	}
+
+	config.LLM = LLMConfig{
+		Provider:      viper.GetString("LLM_PROVIDER"),
+		Timeout:       viper.GetDuration("LLM_TIMEOUT"),
+		Tools:         splitList(viper.GetString("LLM_TOOLS")),
+		MaxToolRounds: viper.GetInt("LLM_MAX_TOOL_ROUNDS"),
+	}
+
+	config.Files = FilesConfig{
+		MaxFileSize:   viper.GetInt64("FILES_MAX_SIZE_MB") << 20,
+		UserQuota:     viper.GetInt64("FILES_USER_QUOTA_MB") << 20,
+		AllowedTypes:  splitList(viper.GetString("FILES_ALLOWED_TYPES")),
+		ThumbnailSize: viper.GetInt("FILES_THUMBNAIL_SIZE"),
+	}
+
+	config.Balance = BalanceConfig{
+		Enabled:       viper.GetBool("BALANCE_ENABLED"),
+		StartBalance:  viper.GetInt64("BALANCE_START"),
+		ReserveTokens: viper.GetInt64("BALANCE_RESERVE_TOKENS"),
+		AdminRole:     viper.GetString("BALANCE_ADMIN_ROLE"),
+	}
+
+	exchangeRates, err := parseRates(viper.GetString("CATALOG_EXCHANGE_RATES"))
+	if err != nil {
+		return nil, fmt.Errorf("CATALOG_EXCHANGE_RATES: %w", err)
+	}
+	config.Catalog = CatalogConfig{
+		FeedsPath:     viper.GetString("CATALOG_FEEDS_PATH"),
+		Currency:      strings.ToUpper(viper.GetString("CATALOG_CURRENCY")),
+		ExchangeRates: exchangeRates,
+		HistoryDays:   viper.GetInt("CATALOG_HISTORY_DAYS"),
+		MaxFeedSize:   viper.GetInt64("CATALOG_MAX_FEED_MB") << 20,
+		AdminRole:     viper.GetString("CATALOG_ADMIN_ROLE"),
+	}
+
+	config.Embeddings = EmbeddingsConfig{
+		Provider:      viper.GetString("EMBEDDINGS_PROVIDER"),
+		BaseURL:       viper.GetString("EMBEDDINGS_BASE_URL"),
+		Model:         viper.GetString("EMBEDDINGS_MODEL"),
+		Dimensions:    viper.GetInt("EMBEDDINGS_DIMENSIONS"),
+		Timeout:       viper.GetDuration("EMBEDDINGS_TIMEOUT"),
+		MinSimilarity: viper.GetFloat64("EMBEDDINGS_MIN_SIMILARITY"),
+	}
+
+	config.Alerts = AlertsConfig{
+		Notifiers:      splitList(viper.GetString("ALERTS_NOTIFIERS")),
+		EmailFrom:      viper.GetString("ALERTS_EMAIL_FROM"),
+		WebhookURL:     viper.GetString("ALERTS_WEBHOOK_URL"),
+		WebhookTimeout: viper.GetDuration("ALERTS_WEBHOOK_TIMEOUT"),
+	}
+
+	config.Lists = ListsConfig{
+		InviteTTL: viper.GetDuration("LISTS_INVITE_TTL"),
+	}
+
+	config.Password = PasswordConfig{
+		MinLength:        viper.GetInt("PASSWORD_MIN_LENGTH"),
+		MaxLength:        viper.GetInt("PASSWORD_MAX_LENGTH"),
+		RequireUpper:     viper.GetBool("PASSWORD_REQUIRE_UPPER"),
+		RequireLower:     viper.GetBool("PASSWORD_REQUIRE_LOWER"),
+		RequireDigit:     viper.GetBool("PASSWORD_REQUIRE_DIGIT"),
+		RequireSpecial:   viper.GetBool("PASSWORD_REQUIRE_SPECIAL"),
+		MaxRepeated:      viper.GetInt("PASSWORD_MAX_REPEATED"),
+		DisallowUserInfo: viper.GetBool("PASSWORD_DISALLOW_USER_INFO"),
+		HistoryDepth:     viper.GetInt("PASSWORD_HISTORY_DEPTH"),
+		BreachedDir:      viper.GetString("PASSWORD_BREACHED_DIR"),
+		BreachedMinCount: viper.GetInt("PASSWORD_BREACHED_MIN_COUNT"),
+		SyncKeycloak:     viper.GetBool("PASSWORD_SYNC_KEYCLOAK"),
+	}
+	// Reset passwords are set in Keycloak's own form, which only the realm
+	// policy checks; password_reset is evaluated for anonymous callers, so
+	// only Enabled turns it on
+	if config.Flags.Flags["password_reset"].Enabled {
+		config.Password.SyncKeycloak = true
+	}
+
+	if err := resolveSecrets(&config); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
 
+// resolveSecrets fills every secret through the configured SecretProvider chain:
+// *_FILE variables and the secret mount directory first, then Vault when
+// SECRETS_VAULT_ADDR is set, then plain environment / .env values.
+func resolveSecrets(config *Config) error {
+	ctx := context.Background()
+
+	config.Secrets = SecretsConfig{
+		Dir:            viper.GetString("SECRETS_DIR"),
+		VaultAddr:      viper.GetString("SECRETS_VAULT_ADDR"),
+		VaultMount:     viper.GetString("SECRETS_VAULT_MOUNT"),
+		VaultPath:      viper.GetString("SECRETS_VAULT_PATH"),
+		VaultNamespace: viper.GetString("SECRETS_VAULT_NAMESPACE"),
+	}
+
+	if config.Secrets.VaultAddr != "" {
+		local := secrets.NewChainProvider(
+			secrets.NewFileProvider(config.Secrets.Dir),
+			&secrets.EnvProvider{Lookup: viperLookup},
+		)
+		token, err := lookupSecret(ctx, local, "SECRETS_VAULT_TOKEN")
+		if err != nil {
+			return err
+		}
+		config.Secrets.VaultToken = token
+	}
+
+	return ResolveSecrets(ctx, config, config.SecretProvider())
+}
+
+// SecretProvider returns the chain the config's secrets were resolved from,
+// for secrets referenced elsewhere (e.g. endpoint API keys)
+func (c *Config) SecretProvider() secrets.SecretProvider {
+	providers := []secrets.SecretProvider{secrets.NewFileProvider(c.Secrets.Dir)}
+	if c.Secrets.VaultAddr != "" {
+		providers = append(providers, secrets.NewVaultProvider(secrets.VaultOptions{
+			Addr:      c.Secrets.VaultAddr,
+			Token:     c.Secrets.VaultToken.Value(),
+			Mount:     c.Secrets.VaultMount,
+			Path:      c.Secrets.VaultPath,
+			Namespace: c.Secrets.VaultNamespace,
+		}))
+	}
+	providers = append(providers, &secrets.EnvProvider{Lookup: viperLookup})
+	return secrets.NewChainProvider(providers...)
+}
+
+// ResolveSecrets fills the secret fields of config from provider. Secrets the
+// provider does not know keep their current value.
+func ResolveSecrets(ctx context.Context, config *Config, provider secrets.SecretProvider) error {
+	targets := map[string]*Secret{
+		"KEYCLOAK_CLIENT_SECRET": &config.Keycloak.ClientSecret,
+		"KEYCLOAK_ADMIN_PASS":    &config.Keycloak.AdminPass,
+		"JWT_SECRET_KEY":         &config.JWT.SecretKey,
+		"CACHE_REDIS_PASSWORD":   &config.Cache.RedisPassword,
+		"ALERTS_WEBHOOK_SECRET":  &config.Alerts.WebhookSecret,
+		"LISTS_INVITE_SECRET":    &config.Lists.InviteSecret,
+		"EMBEDDINGS_API_KEY":     &config.Embeddings.APIKey,
+	}
+	for name, target := range targets {
+		value, err := lookupSecret(ctx, provider, name)
+		if err != nil {
+			return err
+		}
+		if value != "" {
+			*target = value
+		}
+	}
+	return nil
+}
+
+func lookupSecret(ctx context.Context, provider secrets.SecretProvider, name string) (Secret, error) {
+	value, err := provider.GetSecret(ctx, name)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %s: %w", name, err)
+	}
+	return Secret(value), nil
+}
+
+// viperLookup exposes viper values (environment, .env and defaults) to secret providers
+func viperLookup(name string) (string, bool) {
+	value := viper.GetString(name)
+	return value, value != ""
+}
+
+// loadCORSConfig builds the CORS policies from CORS_* and CORS_PUBLIC_* variables.
+// The public policy applies to the unauthenticated config endpoints listed in
+// CORS_PUBLIC_PATHS; everything else uses the default policy.
+func loadCORSConfig() (CORSConfig, error) {
+	cors := CORSConfig{
+		Default: CORSPolicy{
+			AllowedOrigins:   splitList(viper.GetString("CORS_ALLOWED_ORIGINS")),
+			AllowedMethods:   splitList(viper.GetString("CORS_ALLOWED_METHODS")),
+			AllowedHeaders:   splitList(viper.GetString("CORS_ALLOWED_HEADERS")),
+			ExposedHeaders:   splitList(viper.GetString("CORS_EXPOSED_HEADERS")),
+			MaxAge:           viper.GetInt("CORS_MAX_AGE"),
+			AllowCredentials: viper.GetBool("CORS_ALLOW_CREDENTIALS"),
+		},
+		Routes: make(map[string]CORSPolicy),
+	}
+
+	public := CORSPolicy{
+		AllowedOrigins:   splitList(viper.GetString("CORS_PUBLIC_ALLOWED_ORIGINS")),
+		AllowedMethods:   splitList(viper.GetString("CORS_PUBLIC_ALLOWED_METHODS")),
+		AllowedHeaders:   splitList(viper.GetString("CORS_PUBLIC_ALLOWED_HEADERS")),
+		ExposedHeaders:   splitList(viper.GetString("CORS_PUBLIC_EXPOSED_HEADERS")),
+		MaxAge:           viper.GetInt("CORS_PUBLIC_MAX_AGE"),
+		AllowCredentials: viper.GetBool("CORS_PUBLIC_ALLOW_CREDENTIALS"),
+	}
+	for _, path := range splitList(viper.GetString("CORS_PUBLIC_PATHS")) {
+		cors.Routes[path] = public
+	}
+
+	// Browsers refuse "*" with credentials; reflecting every origin instead
+	// would let any site make credentialed requests
+	if err := checkCORSPolicy(cors.Default); err != nil {
+		return CORSConfig{}, fmt.Errorf("CORS_ALLOWED_ORIGINS: %w", err)
+	}
+	if err := checkCORSPolicy(public); err != nil {
+		return CORSConfig{}, fmt.Errorf("CORS_PUBLIC_ALLOWED_ORIGINS: %w", err)
+	}
+	return cors, nil
+}
+
+func checkCORSPolicy(policy CORSPolicy) error {
+	if !policy.AllowCredentials {
+		return nil
+	}
+	for _, origin := range policy.AllowedOrigins {
+		if origin == "*" {
+			return errors.New(`"*" cannot be combined with credentials`)
+		}
+	}
+	return nil
+}
+
+// loadFlagsConfig reads every FEATURE_<NAME> flag together with its optional
+// FEATURE_<NAME>__ROLLOUT, FEATURE_<NAME>__ROLES and FEATURE_<NAME>__USERS
+// targeting: the double underscore nests the targeting under the flag, as
+// flags.<name>.rollout, so flag names may contain single underscores. Flags
+// are discovered from the defaults, the .env file and the environment.
+func loadFlagsConfig() (FlagsConfig, error) {
+	keys := viper.AllKeys()
+	for _, env := range os.Environ() {
+		keys = append(keys, strings.ToLower(strings.SplitN(env, "=", 2)[0]))
+	}
+
+	flags := FlagsConfig{Flags: make(map[string]FlagConfig)}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, "feature_") {
+			continue
+		}
+		name, field, nested := strings.Cut(strings.TrimPrefix(key, "feature_"), "__")
+		if name == "" {
+			continue
+		}
+		if nested && field != "rollout" && field != "roles" && field != "users" {
+			return FlagsConfig{}, fmt.Errorf("%s: unknown flag setting %q", strings.ToUpper(key), field)
+		}
+
+		env := "FEATURE_" + strings.ToUpper(name)
+		flags.Flags[name] = FlagConfig{
+			Enabled: viper.GetBool(env),
+			Rollout: viper.GetInt(env + "__ROLLOUT"),
+			Roles:   splitList(viper.GetString(env + "__ROLES")),
+			Users:   splitList(viper.GetString(env + "__USERS")),
+		}
+	}
+	return flags, nil
+}
+
+// splitList splits a comma-separated value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseRates parses exchange rates such as "USD=0.92,GBP=1.17"
+func parseRates(value string) (map[string]float64, error) {
+	rates := map[string]float64{}
+	for _, item := range splitList(value) {
+		currency, rate, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not CURRENCY=RATE", item)
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+		if err != nil || n <= 0 || math.IsInf(n, 0) {
+			return nil, fmt.Errorf("%q is not a positive rate", rate)
+		}
+		rates[strings.ToUpper(strings.TrimSpace(currency))] = n
+	}
+	return rates, nil
+}
+
 // setDefaults sets default configuration values
 func setDefaults() {
 	// Server defaults
@@ -125,8 +822,171 @@ func setDefaults() {
 	viper.SetDefault("KEYCLOAK_ADMIN_USER", "admin")
 	viper.SetDefault("KEYCLOAK_ADMIN_PASS", "admin")
 
+	// Identity provider resilience defaults (fail well before the 10s write timeout)
+	viper.SetDefault("KEYCLOAK_TIMEOUT", "2s")
+	viper.SetDefault("KEYCLOAK_LOGIN_TIMEOUT", "5s")
+	viper.SetDefault("KEYCLOAK_MAX_RETRIES", 2)
+	viper.SetDefault("KEYCLOAK_RETRY_BACKOFF", "100ms")
+	viper.SetDefault("KEYCLOAK_BREAKER_THRESHOLD", 5)
+	viper.SetDefault("KEYCLOAK_BREAKER_COOLDOWN", "30s")
+	viper.SetDefault("KEYCLOAK_BREAKER_PROBES", 1)
+
+	// Session defaults (refresh token in JSON body unless cookie mode is enabled)
+	viper.SetDefault("SESSION_COOKIE_MODE", false)
+	viper.SetDefault("SESSION_COOKIE_NAME", "refresh_token")
+	viper.SetDefault("SESSION_COOKIE_PATH", "/api/v1/auth")
+	viper.SetDefault("SESSION_COOKIE_DOMAIN", "")
+	viper.SetDefault("SESSION_COOKIE_SECURE", true)
+	viper.SetDefault("SESSION_COOKIE_SAME_SITE", "strict")
+	viper.SetDefault("SESSION_CSRF_COOKIE_NAME", "csrf_token")
+	viper.SetDefault("SESSION_CSRF_HEADER_NAME", "X-CSRF-Token")
+
+	// Security header defaults (strict policy suited to a JSON API)
+	viper.SetDefault("SECURITY_HEADERS_ENABLED", true)
+	viper.SetDefault("SECURITY_HSTS_MAX_AGE", 31536000) // 1 year
+	viper.SetDefault("SECURITY_HSTS_INCLUDE_SUBDOMAINS", true)
+	viper.SetDefault("SECURITY_HSTS_PRELOAD", false)
+	viper.SetDefault("SECURITY_CONTENT_TYPE_OPTIONS", "nosniff")
+	viper.SetDefault("SECURITY_FRAME_OPTIONS", "DENY")
+	viper.SetDefault("SECURITY_REFERRER_POLICY", "no-referrer")
+	viper.SetDefault("SECURITY_CSP", "default-src 'none'; frame-ancestors 'none'; base-uri 'none'")
+	viper.SetDefault("SECURITY_CSP_REPORT_ONLY", false)
+	viper.SetDefault("SECURITY_CSP_REPORT_URI", "/api/csp-report")
+
+	// Cache defaults (in-process; switch to redis when running several replicas)
+	viper.SetDefault("CACHE_BACKEND", "memory")
+	viper.SetDefault("CACHE_MAX_ENTRIES", 10000)
+	viper.SetDefault("CACHE_TOKEN_TTL", "30s")
+	viper.SetDefault("CACHE_PROFILE_TTL", "60s")
+	viper.SetDefault("CACHE_REDIS_ADDR", "localhost:6379")
+	viper.SetDefault("CACHE_REDIS_DB", 0)
+	viper.SetDefault("CACHE_REDIS_PREFIX", "auth-service:")
+	viper.SetDefault("CACHE_REDIS_TIMEOUT", "500ms")
+
+	// Feature flag defaults (FEATURE_<NAME>=true|false; any other FEATURE_* variable adds a flag)
+	viper.SetDefault("FEATURE_AUTH", true)
+	viper.SetDefault("FEATURE_AI", true)
+	viper.SetDefault("FEATURE_SHOPPING", true)
+	viper.SetDefault("FEATURE_REGISTRATION", true)
+	viper.SetDefault("FEATURE_PASSWORD_RESET", true)
+	viper.SetDefault("FEATURE_EMAIL", false)
+	viper.SetDefault("FEATURE_SOCIAL_GOOGLE", false)
+	viper.SetDefault("FEATURE_SOCIAL_FACEBOOK", false)
+	viper.SetDefault("FEATURE_SOCIAL_DISCORD", false)
+	viper.SetDefault("FEATURE_SOCIAL_GITHUB", false)
+
+	// LibreChat client defaults (DOMAIN_SERVER is the address the client's dev proxy targets)
+	viper.SetDefault("APP_TITLE", "ShopMindAI")
+	viper.SetDefault("DOMAIN_SERVER", "http://localhost:3080")
+	viper.SetDefault("INSTANCE_PROJECT_ID", "instance")
+	viper.SetDefault("HELP_AND_FAQ_URL", "")
+	viper.SetDefault("ALLOW_EMAIL_LOGIN", true)
+	viper.SetDefault("ALLOW_SHARED_LINKS", false)
+	viper.SetDefault("ALLOW_SHARED_LINKS_PUBLIC", false)
+	viper.SetDefault("OPENID_ENABLED", false)
+	viper.SetDefault("OPENID_BUTTON_LABEL", "Continue with Keycloak")
+	viper.SetDefault("OPENID_AUTO_REDIRECT", false)
+	viper.SetDefault("LDAP_ENABLED", false)
+	viper.SetDefault("LDAP_LOGIN_USES_USERNAME", false)
+	viper.SetDefault("INTERFACE_ENDPOINTS_MENU", true)
+	viper.SetDefault("INTERFACE_MODEL_SELECT", true)
+	viper.SetDefault("INTERFACE_PARAMETERS", true)
+	viper.SetDefault("INTERFACE_SIDE_PANEL", true)
+	viper.SetDefault("INTERFACE_PRESETS", true)
+	viper.SetDefault("INTERFACE_PROMPTS", true)
+	viper.SetDefault("INTERFACE_BOOKMARKS", true)
+	viper.SetDefault("INTERFACE_MULTI_CONVO", true)
+	viper.SetDefault("INTERFACE_AGENTS", true)
+	viper.SetDefault("INTERFACE_TEMPORARY_CHAT", true)
+	viper.SetDefault("INTERFACE_RUN_CODE", true)
+	viper.SetDefault("CONFIG_PATH", "librechat.yaml")
+	viper.SetDefault("ENDPOINTS", "")
+
+	// Chat data is kept in files under STORAGE_DIR
+	viper.SetDefault("STORAGE_DIR", "data")
+	viper.SetDefault("SEARCH_CACHED_USERS", 1000)
+
+	// Assistant replies; LLM_TIMEOUT bounds a whole streamed reply
+	viper.SetDefault("LLM_PROVIDER", "openai")
+	viper.SetDefault("LLM_TIMEOUT", "2m")
+	viper.SetDefault("LLM_TOOLS", "search_products,get_offers,add_to_list")
+	viper.SetDefault("LLM_MAX_TOOL_ROUNDS", 4)
+
+	// File uploads
+	viper.SetDefault("FILES_MAX_SIZE_MB", 20)
+	viper.SetDefault("FILES_USER_QUOTA_MB", 200)
+	viper.SetDefault("FILES_ALLOWED_TYPES", "image/png,image/jpeg,image/gif,image/webp,application/pdf,text/plain")
+	viper.SetDefault("FILES_THUMBNAIL_SIZE", 256)
+
+	// Credit balance
+	viper.SetDefault("BALANCE_ENABLED", false)
+	viper.SetDefault("BALANCE_START", 20000)
+	viper.SetDefault("BALANCE_RESERVE_TOKENS", 1000)
+	viper.SetDefault("BALANCE_ADMIN_ROLE", "admin")
+
+	// Product catalog
+	viper.SetDefault("CATALOG_FEEDS_PATH", "feeds.yaml")
+	viper.SetDefault("CATALOG_CURRENCY", "EUR")
+	viper.SetDefault("CATALOG_EXCHANGE_RATES", "")
+	viper.SetDefault("CATALOG_HISTORY_DAYS", 365)
+	viper.SetDefault("CATALOG_MAX_FEED_MB", 50)
+	viper.SetDefault("CATALOG_ADMIN_ROLE", "admin")
+
+	// Semantic product search, off until a provider is set
+	viper.SetDefault("EMBEDDINGS_PROVIDER", "")
+	viper.SetDefault("EMBEDDINGS_BASE_URL", "https://api.openai.com/v1")
+	viper.SetDefault("EMBEDDINGS_MODEL", "text-embedding-3-small")
+	viper.SetDefault("EMBEDDINGS_DIMENSIONS", 0)
+	viper.SetDefault("EMBEDDINGS_TIMEOUT", "30s")
+	viper.SetDefault("EMBEDDINGS_MIN_SIMILARITY", 0.3)
+
+	// Price-drop alerts
+	viper.SetDefault("ALERTS_NOTIFIERS", "in_app")
+	viper.SetDefault("ALERTS_EMAIL_FROM", "alerts@shopmind.local")
+	viper.SetDefault("ALERTS_WEBHOOK_URL", "")
+	viper.SetDefault("ALERTS_WEBHOOK_TIMEOUT", "5s")
+
+	// Shared shopping lists
+	viper.SetDefault("LISTS_INVITE_TTL", "168h") // 7 days
+
+	// Password policy defaults
+	viper.SetDefault("PASSWORD_MIN_LENGTH", 8)
+	viper.SetDefault("PASSWORD_MAX_LENGTH", 128)
+	viper.SetDefault("PASSWORD_REQUIRE_UPPER", true)
+	viper.SetDefault("PASSWORD_REQUIRE_LOWER", true)
+	viper.SetDefault("PASSWORD_REQUIRE_DIGIT", true)
+	viper.SetDefault("PASSWORD_REQUIRE_SPECIAL", true)
+	viper.SetDefault("PASSWORD_MAX_REPEATED", 3)
+	viper.SetDefault("PASSWORD_DISALLOW_USER_INFO", true)
+	viper.SetDefault("PASSWORD_HISTORY_DEPTH", 5)
+	viper.SetDefault("PASSWORD_BREACHED_DIR", "")
+	viper.SetDefault("PASSWORD_BREACHED_MIN_COUNT", 1)
+	viper.SetDefault("PASSWORD_SYNC_KEYCLOAK", false)
+
+	// Secret sources (values themselves come from *_FILE, the mount dir, Vault or env)
+	viper.SetDefault("SECRETS_DIR", "/run/secrets")
+	viper.SetDefault("SECRETS_VAULT_MOUNT", "secret")
+	viper.SetDefault("SECRETS_VAULT_PATH", "auth-service")
+
 	// JWT defaults
 	viper.SetDefault("JWT_SECRET_KEY", "your-secret-key")
 	viper.SetDefault("JWT_ISSUER", "auth-service")
 	viper.SetDefault("JWT_EXPIRY", 3600) // 1 hour
+
+	// CORS defaults (credentialed API calls from the local frontends)
+	viper.SetDefault("CORS_ALLOWED_ORIGINS", "http://localhost:3000,http://localhost:3080,http://localhost:3090,http://localhost:8080")
+	viper.SetDefault("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS")
+	viper.SetDefault("CORS_ALLOWED_HEADERS", "Content-Type,Content-Length,Accept-Encoding,X-CSRF-Token,Authorization,Accept,Origin,Cache-Control,X-Requested-With,If-Match")
+	viper.SetDefault("CORS_EXPOSED_HEADERS", "ETag") // shopping list versions
+	viper.SetDefault("CORS_MAX_AGE", 600) // 10 minutes
+	viper.SetDefault("CORS_ALLOW_CREDENTIALS", true)
+
+	// Public CORS policy for read-only config endpoints
+	viper.SetDefault("CORS_PUBLIC_PATHS", "/api/config,/api/startup,/api/banner,/api/endpoints,/api/auth/config,/api/app/info,/api/validation/rules,/health")
+	viper.SetDefault("CORS_PUBLIC_ALLOWED_ORIGINS", "*")
+	viper.SetDefault("CORS_PUBLIC_ALLOWED_METHODS", "GET,OPTIONS")
+	viper.SetDefault("CORS_PUBLIC_ALLOWED_HEADERS", "Accept,Content-Type,Cache-Control")
+	viper.SetDefault("CORS_PUBLIC_EXPOSED_HEADERS", "")
+	viper.SetDefault("CORS_PUBLIC_MAX_AGE", 3600) // 1 hour
+	viper.SetDefault("CORS_PUBLIC_ALLOW_CREDENTIALS", false)
 }
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCORSConfig(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("CORS_ALLOWED_ORIGINS", "https://app.example.com")
+	viper.Set("CORS_ALLOW_CREDENTIALS", true)
+	viper.Set("CORS_PUBLIC_PATHS", "/api/config")
+	viper.Set("CORS_PUBLIC_ALLOWED_ORIGINS", "*")
+	cors, err := loadCORSConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*"}, cors.Routes["/api/config"].AllowedOrigins)
+
+	// Any origin with credentials would let every site act as the user
+	viper.Set("CORS_ALLOWED_ORIGINS", "*")
+	_, err = loadCORSConfig()
+	assert.ErrorContains(t, err, "CORS_ALLOWED_ORIGINS")
+
+	viper.Set("CORS_ALLOWED_ORIGINS", "https://app.example.com")
+	viper.Set("CORS_PUBLIC_ALLOW_CREDENTIALS", true)
+	_, err = loadCORSConfig()
+	assert.ErrorContains(t, err, "CORS_PUBLIC_ALLOWED_ORIGINS")
+}
+
+func TestLoadFlagsConfig(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("FEATURE_AI", false)
+	viper.Set("FEATURE_AI__ROLLOUT", 10)
+	viper.Set("FEATURE_AI__ROLES", "beta-tester")
+	viper.Set("FEATURE_POWER_USERS", true)
+	viper.Set("FEATURE_POWER_USERS__USERS", "u1,u2")
+	flags, err := loadFlagsConfig()
+	require.NoError(t, err)
+	assert.Equal(t, FlagConfig{Rollout: 10, Roles: []string{"beta-tester"}}, flags.Flags["ai"])
+	assert.Equal(t, FlagConfig{Enabled: true, Users: []string{"u1", "u2"}}, flags.Flags["power_users"],
+		"names keep their underscores")
+	assert.NotContains(t, flags.Flags, "power")
+
+	viper.Set("FEATURE_AI__ROLOUT", 10)
+	_, err = loadFlagsConfig()
+	assert.ErrorContains(t, err, "FEATURE_AI__ROLOUT")
+}
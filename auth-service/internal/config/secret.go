@@ -0,0 +1,37 @@
+package config
+
+import "encoding/json"
+
+const redacted = "[REDACTED]"
+
+// Secret is a string config value that never prints or serializes its content.
+// Use Value to get the actual secret when passing it to a client.
+type Secret string
+
+// Value returns the secret in clear text
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String implements fmt.Stringer
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString implements fmt.GoStringer so %#v is redacted too
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+// MarshalJSON implements json.Marshaler, used by the JSON log formatter
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
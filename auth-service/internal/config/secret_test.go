@@ -0,0 +1,50 @@
+package config
+
+import (
+	"auth-service/internal/secrets"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretRedaction(t *testing.T) {
+	cfg := Config{
+		Keycloak: KeycloakConfig{ClientID: "auth-service", ClientSecret: "s3cr3t", AdminPass: "admin-pass"},
+		JWT:      JWTConfig{SecretKey: "jwt-key"},
+	}
+
+	for _, out := range []string{
+		fmt.Sprintf("%v", cfg),
+		fmt.Sprintf("%+v", cfg),
+		fmt.Sprintf("%#v", cfg),
+	} {
+		assert.NotContains(t, out, "s3cr3t")
+		assert.NotContains(t, out, "admin-pass")
+		assert.NotContains(t, out, "jwt-key")
+	}
+
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "s3cr3t")
+	assert.Contains(t, string(data), `"ClientSecret":"[REDACTED]"`)
+
+	assert.Equal(t, "s3cr3t", cfg.Keycloak.ClientSecret.Value())
+}
+
+func TestResolveSecrets(t *testing.T) {
+	cfg := &Config{Keycloak: KeycloakConfig{ClientSecret: "default", AdminPass: "default"}}
+	provider := &secrets.EnvProvider{Lookup: func(name string) (string, bool) {
+		if name == "KEYCLOAK_CLIENT_SECRET" {
+			return "resolved", true
+		}
+		return "", false
+	}}
+
+	require.NoError(t, ResolveSecrets(context.Background(), cfg, provider))
+	assert.Equal(t, "resolved", cfg.Keycloak.ClientSecret.Value())
+	assert.Equal(t, "default", cfg.Keycloak.AdminPass.Value(), "unknown secrets keep their value")
+}
@@ -0,0 +1,179 @@
+package conversations
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/storage"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore keeps each user's conversations in one JSON file under dir
+type FileStore struct {
+	dir   string
+	mutex sync.Mutex
+	now   func() time.Time
+}
+
+// userConversations is the document stored per user
+type userConversations struct {
+	Conversations []models.Conversation `json:"conversations"`
+}
+
+// NewFileStore creates a store in dir/conversations
+func NewFileStore(dir string) (*FileStore, error) {
+	dir = filepath.Join(dir, "conversations")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir, now: time.Now}, nil
+}
+
+// List returns one page of the user's archived or active conversations
+func (s *FileStore) List(ctx context.Context, userID string, opts ListOptions) (*Page, error) {
+	s.mutex.Lock()
+	doc, err := s.load(userID)
+	s.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	convos := make([]models.Conversation, 0, len(doc.Conversations))
+	for _, convo := range doc.Conversations {
+		if convo.IsArchived == opts.IsArchived {
+			convos = append(convos, convo)
+		}
+	}
+	return paginate(convos, opts)
+}
+
+// Get returns one of the user's conversations
+func (s *FileStore) Get(ctx context.Context, userID, conversationID string) (*models.Conversation, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return nil, err
+	}
+	i := doc.find(conversationID)
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	convo := doc.Conversations[i]
+	return &convo, nil
+}
+
+// Create stores a new conversation
+func (s *FileStore) Create(ctx context.Context, userID string, convo *models.Conversation) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return err
+	}
+	if convo.ConversationID == "" {
+		convo.ConversationID = storage.NewID()
+	}
+	if doc.find(convo.ConversationID) >= 0 {
+		return ErrExists
+	}
+
+	now := s.now().UTC()
+	convo.User = userID
+	convo.CreatedAt = now
+	convo.UpdatedAt = now
+	if convo.Title == "" {
+		convo.Title = DefaultTitle
+	}
+	if convo.Tags == nil {
+		convo.Tags = []string{}
+	}
+
+	doc.Conversations = append(doc.Conversations, *convo)
+	return s.save(userID, doc)
+}
+
+// UpdateTitle renames one of the user's conversations
+func (s *FileStore) UpdateTitle(ctx context.Context, userID, conversationID, title string) (*models.Conversation, error) {
+	return s.update(userID, conversationID, func(convo *models.Conversation) {
+		convo.Title = title
+	})
+}
+
+// SetArchived archives or restores one of the user's conversations
+func (s *FileStore) SetArchived(ctx context.Context, userID, conversationID string, archived bool) (*models.Conversation, error) {
+	return s.update(userID, conversationID, func(convo *models.Conversation) {
+		convo.IsArchived = archived
+	})
+}
+
+// Touch bumps the UpdatedAt of one of the user's conversations
+func (s *FileStore) Touch(ctx context.Context, userID, conversationID string) (*models.Conversation, error) {
+	return s.update(userID, conversationID, func(*models.Conversation) {})
+}
+
+// Delete removes one of the user's conversations
+func (s *FileStore) Delete(ctx context.Context, userID, conversationID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return err
+	}
+	i := doc.find(conversationID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	doc.Conversations = append(doc.Conversations[:i], doc.Conversations[i+1:]...)
+	return s.save(userID, doc)
+}
+
+// update applies change to a conversation and bumps its UpdatedAt
+func (s *FileStore) update(userID, conversationID string, change func(*models.Conversation)) (*models.Conversation, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return nil, err
+	}
+	i := doc.find(conversationID)
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+
+	convo := &doc.Conversations[i]
+	change(convo)
+	convo.UpdatedAt = s.now().UTC()
+	if err := s.save(userID, doc); err != nil {
+		return nil, err
+	}
+	updated := *convo
+	return &updated, nil
+}
+
+func (s *FileStore) load(userID string) (*userConversations, error) {
+	doc := &userConversations{}
+	if _, err := storage.ReadJSON(storage.UserFile(s.dir, userID), doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (s *FileStore) save(userID string, doc *userConversations) error {
+	return storage.WriteJSON(storage.UserFile(s.dir, userID), doc)
+}
+
+func (doc *userConversations) find(conversationID string) int {
+	for i := range doc.Conversations {
+		if doc.Conversations[i].ConversationID == conversationID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,150 @@
+package conversations
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStore returns a store whose clock advances one second per call
+func newTestStore(t *testing.T) *FileStore {
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	return store
+}
+
+func create(t *testing.T, store *FileStore, userID, id, title string) {
+	require.NoError(t, store.Create(context.Background(), userID, &models.Conversation{ConversationID: id, Title: title}))
+}
+
+func ids(page *Page) []string {
+	var ids []string
+	for _, convo := range page.Conversations {
+		ids = append(ids, convo.ConversationID)
+	}
+	return ids
+}
+
+func TestFileStore_CreateAndGet(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	convo := &models.Conversation{Endpoint: "openAI"}
+	require.NoError(t, store.Create(ctx, "user-1", convo))
+	assert.Len(t, convo.ConversationID, 36)
+	assert.Equal(t, DefaultTitle, convo.Title)
+	assert.Equal(t, "user-1", convo.User)
+
+	got, err := store.Get(ctx, "user-1", convo.ConversationID)
+	require.NoError(t, err)
+	assert.Equal(t, convo, got)
+
+	assert.ErrorIs(t, store.Create(ctx, "user-1", &models.Conversation{ConversationID: convo.ConversationID}), ErrExists)
+}
+
+func TestFileStore_ScopedToUser(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	create(t, store, "user-1", "c1", "Mine")
+
+	_, err := store.Get(ctx, "user-2", "c1")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.UpdateTitle(ctx, "user-2", "c1", "Stolen")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.SetArchived(ctx, "user-2", "c1", true)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, store.Delete(ctx, "user-2", "c1"), ErrNotFound)
+
+	page, err := store.List(ctx, "user-2", ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, page.Conversations)
+
+	got, err := store.Get(ctx, "user-1", "c1")
+	require.NoError(t, err)
+	assert.Equal(t, "Mine", got.Title)
+}
+
+func TestFileStore_ListPagination(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	for i := 1; i <= 7; i++ {
+		create(t, store, "user-1", fmt.Sprintf("c%d", i), fmt.Sprintf("Chat %d", 8-i))
+	}
+
+	// Default order: most recently updated first
+	var seen []string
+	opts := ListOptions{Limit: 3}
+	for {
+		page, err := store.List(ctx, "user-1", opts)
+		require.NoError(t, err)
+		seen = append(seen, ids(page)...)
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []string{"c7", "c6", "c5", "c4", "c3", "c2", "c1"}, seen)
+
+	page, err := store.List(ctx, "user-1", ListOptions{SortBy: SortByTitle, SortDirection: SortAsc, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c7", "c6"}, ids(page))
+
+	page, err = store.List(ctx, "user-1", ListOptions{SortBy: SortByTitle, SortDirection: SortAsc, Limit: 2, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c5", "c4"}, ids(page))
+
+	// A cursor only continues the order it was issued for
+	_, err = store.List(ctx, "user-1", ListOptions{SortBy: SortByCreatedAt, Cursor: page.NextCursor})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	_, err = store.List(ctx, "user-1", ListOptions{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	_, err = store.List(ctx, "user-1", ListOptions{SortBy: "size"})
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+}
+
+func TestFileStore_UpdateArchiveDelete(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	create(t, store, "user-1", "c1", "First")
+	create(t, store, "user-1", "c2", "Second")
+
+	// Renaming bumps the conversation to the top
+	updated, err := store.UpdateTitle(ctx, "user-1", "c1", "Renamed")
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", updated.Title)
+	assert.True(t, updated.UpdatedAt.After(updated.CreatedAt))
+
+	page, err := store.List(ctx, "user-1", ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c1", "c2"}, ids(page))
+
+	_, err = store.SetArchived(ctx, "user-1", "c2", true)
+	require.NoError(t, err)
+	page, err = store.List(ctx, "user-1", ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c1"}, ids(page))
+	page, err = store.List(ctx, "user-1", ListOptions{IsArchived: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c2"}, ids(page))
+
+	require.NoError(t, store.Delete(ctx, "user-1", "c1"))
+	_, err = store.Get(ctx, "user-1", "c1")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Data survives reopening the store
+	reopened := &FileStore{dir: store.dir, now: time.Now}
+	got, err := reopened.Get(ctx, "user-1", "c2")
+	require.NoError(t, err)
+	assert.True(t, got.IsArchived)
+}
@@ -0,0 +1,196 @@
+// Package conversations stores chat conversations. Every operation is scoped
+// to a user: a conversation of another user behaves as if it did not exist.
+package conversations
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned for unknown conversations and for those of other users
+	ErrNotFound = errors.New("conversation not found")
+	// ErrExists is returned when creating a conversation with a taken ID
+	ErrExists = errors.New("conversation already exists")
+	// ErrInvalidCursor is returned for a cursor not issued for the same sort order
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidOptions is returned for unknown sort fields or directions
+	ErrInvalidOptions = errors.New("invalid list options")
+)
+
+// DefaultTitle is the title of conversations created without one
+const DefaultTitle = "New Chat"
+
+// Sort fields
+const (
+	SortByUpdatedAt = "updatedAt"
+	SortByCreatedAt = "createdAt"
+	SortByTitle     = "title"
+)
+
+// Sort directions
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// Page size limits
+const (
+	DefaultLimit = 25
+	MaxLimit     = 100
+)
+
+// ListOptions selects and orders one page of a user's conversations
+type ListOptions struct {
+	// Cursor is the NextCursor of the previous page; empty for the first page
+	Cursor        string
+	Limit         int
+	SortBy        string // SortByUpdatedAt (default), SortByCreatedAt or SortByTitle
+	SortDirection string // SortDesc (default) or SortAsc
+	// IsArchived lists archived conversations instead of active ones
+	IsArchived bool
+}
+
+// Page is one page of conversations. NextCursor is empty on the last page.
+type Page struct {
+	Conversations []models.Conversation
+	NextCursor    string
+}
+
+// ConversationStore persists conversations
+type ConversationStore interface {
+	// List returns one page of the user's conversations
+	List(ctx context.Context, userID string, opts ListOptions) (*Page, error)
+	// Get returns one of the user's conversations
+	Get(ctx context.Context, userID, conversationID string) (*models.Conversation, error)
+	// Create stores a new conversation for the user, filling in its ID,
+	// owner and timestamps
+	Create(ctx context.Context, userID string, convo *models.Conversation) error
+	// UpdateTitle renames one of the user's conversations
+	UpdateTitle(ctx context.Context, userID, conversationID, title string) (*models.Conversation, error)
+	// SetArchived archives or restores one of the user's conversations
+	SetArchived(ctx context.Context, userID, conversationID string, archived bool) (*models.Conversation, error)
+	// Touch bumps the UpdatedAt of one of the user's conversations, e.g. when
+	// a message is added
+	Touch(ctx context.Context, userID, conversationID string) (*models.Conversation, error)
+	// Delete removes one of the user's conversations
+	Delete(ctx context.Context, userID, conversationID string) error
+}
+
+// cursor marks the last conversation of a page by its sort key and ID
+type cursor struct {
+	SortBy string `json:"s"`
+	Key    string `json:"k"`
+	ID     string `json:"i"`
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value, sortBy string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.SortBy != sortBy {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// sortKey returns the value a conversation is ordered by. Timestamps are
+// formatted with a fixed width so they compare as strings.
+func sortKey(convo *models.Conversation, sortBy string) string {
+	switch sortBy {
+	case SortByTitle:
+		return strings.ToLower(convo.Title)
+	case SortByCreatedAt:
+		return convo.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000Z")
+	default:
+		return convo.UpdatedAt.UTC().Format("2006-01-02T15:04:05.000000000Z")
+	}
+}
+
+// normalize fills in the defaults of opts and rejects unknown values
+func (opts *ListOptions) normalize() error {
+	switch opts.SortBy {
+	case "":
+		opts.SortBy = SortByUpdatedAt
+	case SortByUpdatedAt, SortByCreatedAt, SortByTitle:
+	default:
+		return fmt.Errorf("%w: unknown sort field %q", ErrInvalidOptions, opts.SortBy)
+	}
+	switch opts.SortDirection {
+	case "":
+		opts.SortDirection = SortDesc
+	case SortAsc, SortDesc:
+	default:
+		return fmt.Errorf("%w: unknown sort direction %q", ErrInvalidOptions, opts.SortDirection)
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultLimit
+	}
+	if opts.Limit > MaxLimit {
+		opts.Limit = MaxLimit
+	}
+	return nil
+}
+
+// paginate sorts convos by opts and returns the page after opts.Cursor.
+// Ties on the sort key are broken by conversation ID so the order is total
+// and a cursor never skips or repeats a conversation.
+func paginate(convos []models.Conversation, opts ListOptions) (*Page, error) {
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]string, len(convos))
+	for i := range convos {
+		keys[convos[i].ConversationID] = sortKey(&convos[i], opts.SortBy)
+	}
+	before := func(keyA, idA, keyB, idB string) bool {
+		if keyA != keyB {
+			return (keyA < keyB) == (opts.SortDirection == SortAsc)
+		}
+		if idA == idB {
+			return false
+		}
+		return (idA < idB) == (opts.SortDirection == SortAsc)
+	}
+	sort.Slice(convos, func(i, j int) bool {
+		a, b := convos[i].ConversationID, convos[j].ConversationID
+		return before(keys[a], a, keys[b], b)
+	})
+
+	start := 0
+	if opts.Cursor != "" {
+		after, err := decodeCursor(opts.Cursor, opts.SortBy)
+		if err != nil {
+			return nil, err
+		}
+		start = sort.Search(len(convos), func(i int) bool {
+			id := convos[i].ConversationID
+			return before(after.Key, after.ID, keys[id], id)
+		})
+	}
+
+	end := start + opts.Limit
+	if end > len(convos) {
+		end = len(convos)
+	}
+	page := &Page{Conversations: convos[start:end]}
+	if end < len(convos) {
+		last := &convos[end-1]
+		page.NextCursor = encodeCursor(cursor{SortBy: opts.SortBy, Key: keys[last.ConversationID], ID: last.ConversationID})
+	}
+	return page, nil
+}
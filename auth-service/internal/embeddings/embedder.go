@@ -0,0 +1,228 @@
+// Package embeddings turns text into vectors that are close when the texts
+// mean similar things, and keeps them in an embedded vector index searched
+// by cosine similarity. It lets the catalog match "quiet blender for
+// smoothies" to products that never use those exact words.
+package embeddings
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/search"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Provider names accepted in EMBEDDINGS_PROVIDER
+const (
+	ProviderHash   = "hash"
+	ProviderOpenAI = "openai"
+)
+
+// DefaultHashDimensions sizes the vectors of a HashEmbedder configured
+// without dimensions
+const DefaultHashDimensions = 256
+
+// maxBatch bounds the texts sent in one embeddings request
+const maxBatch = 64
+
+// ErrEmbeddingFailed is returned when a provider does not return one vector
+// per text
+var ErrEmbeddingFailed = errors.New("embedding failed")
+
+// Embedder turns texts into vectors
+type Embedder interface {
+	// Model names the vectors. Vectors of different models cannot be
+	// compared, so an index is rebuilt when the model changes.
+	Model() string
+	// Embed returns one vector per text, in order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewEmbedder returns the embedder selected by cfg.Provider, or nil when
+// semantic search is off
+func NewEmbedder(cfg config.EmbeddingsConfig) (Embedder, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case ProviderHash:
+		return NewHashEmbedder(cfg.Dimensions), nil
+	case ProviderOpenAI:
+		if cfg.Model == "" {
+			return nil, errors.New("EMBEDDINGS_MODEL is required")
+		}
+		client := &http.Client{Timeout: cfg.Timeout}
+		return NewHTTPEmbedder(cfg.BaseURL, cfg.APIKey.Value(), cfg.Model, cfg.Dimensions, client), nil
+	default:
+		return nil, fmt.Errorf("unknown embeddings provider %q", cfg.Provider)
+	}
+}
+
+// HashEmbedder embeds text locally by hashing its words and their
+// character trigrams into a fixed number of dimensions. It understands no
+// meaning, but matches texts sharing words or word stems, needs no network
+// and always gives the same vectors, for development and tests.
+type HashEmbedder struct {
+	dims int
+}
+
+// NewHashEmbedder creates a HashEmbedder of dims dimensions, or
+// DefaultHashDimensions when dims is not positive
+func NewHashEmbedder(dims int) *HashEmbedder {
+	if dims <= 0 {
+		dims = DefaultHashDimensions
+	}
+	return &HashEmbedder{dims: dims}
+}
+
+// Model names the embedder and its size
+func (e *HashEmbedder) Model() string {
+	return fmt.Sprintf("hash-%d", e.dims)
+}
+
+// Embed hashes each text into a vector
+func (e *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, e.dims)
+		for _, term := range search.Terms(text) {
+			e.add(vector, "w:"+term, 1)
+			padded := []rune(" " + term + " ")
+			for j := 0; j+3 <= len(padded); j++ {
+				e.add(vector, "t:"+string(padded[j:j+3]), 0.5)
+			}
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+// add adds weight to the dimension feature hashes to, with the sign the hash
+// picks so unrelated features cancel out rather than pile up
+func (e *HashEmbedder) add(vector []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(feature))
+	sum := h.Sum64()
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	vector[sum%uint64(e.dims)] += weight
+}
+
+// HTTPEmbedder calls an OpenAI-compatible /embeddings API
+type HTTPEmbedder struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	dimensions int
+	client     *http.Client
+}
+
+// NewHTTPEmbedder creates an embedder for the API at baseURL, such as
+// "https://api.openai.com/v1". Dimensions shortens the vectors of models
+// that support it; 0 keeps the model's size.
+func NewHTTPEmbedder(baseURL, apiKey, model string, dimensions int, client *http.Client) *HTTPEmbedder {
+	return &HTTPEmbedder{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		dimensions: dimensions,
+		client:     client,
+	}
+}
+
+// Model names the API's model and the vector size asked for
+func (e *HTTPEmbedder) Model() string {
+	if e.dimensions > 0 {
+		return fmt.Sprintf("%s-%d", e.model, e.dimensions)
+	}
+	return e.model
+}
+
+type embeddingsRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type embeddingsResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+type embeddingsError struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Embed posts the texts in batches of at most maxBatch
+func (e *HTTPEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxBatch {
+		batch, err := e.embedBatch(ctx, texts[start:min(start+maxBatch, len(texts))])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+func (e *HTTPEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(embeddingsRequest{Model: e.model, Input: texts, Dimensions: e.dimensions})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("embeddings request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var apiErr embeddingsError
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, fmt.Errorf("%w with status %d: %s", ErrEmbeddingFailed, resp.StatusCode, apiErr.Error.Message)
+		}
+		return nil, fmt.Errorf("%w with status %d", ErrEmbeddingFailed, resp.StatusCode)
+	}
+
+	var result embeddingsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("%w: invalid response: %v", ErrEmbeddingFailed, err)
+	}
+	vectors := make([][]float32, len(texts))
+	for _, item := range result.Data {
+		if item.Index < 0 || item.Index >= len(texts) || vectors[item.Index] != nil {
+			return nil, fmt.Errorf("%w: unexpected index %d", ErrEmbeddingFailed, item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	for i, vector := range vectors {
+		if len(vector) == 0 {
+			return nil, fmt.Errorf("%w: no vector for input %d", ErrEmbeddingFailed, i)
+		}
+	}
+	return vectors, nil
+}
@@ -0,0 +1,188 @@
+package embeddings
+
+import (
+	"auth-service/internal/config"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cosine(a, b []float32) float64 {
+	a, b = normalize(a), normalize(b)
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
+
+func TestHashEmbedder(t *testing.T) {
+	e := NewHashEmbedder(0)
+	assert.Equal(t, "hash-256", e.Model())
+
+	texts := []string{"quiet blender for smoothies", "Whisper Blender: crushes fruit into smoothies", "Non-slip yoga mat"}
+	vectors, err := e.Embed(context.Background(), texts)
+	require.NoError(t, err)
+	require.Len(t, vectors, 3)
+	assert.Len(t, vectors[0], DefaultHashDimensions)
+
+	again, err := e.Embed(context.Background(), texts[:1])
+	require.NoError(t, err)
+	assert.Equal(t, vectors[0], again[0], "vectors are deterministic")
+	assert.Greater(t, cosine(vectors[0], vectors[1]), cosine(vectors[0], vectors[2]))
+}
+
+func TestHTTPEmbedder(t *testing.T) {
+	var batches []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+		var req embeddingsRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Model == "broken" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": {"message": "model not found"}}`))
+			return
+		}
+		assert.Equal(t, 8, req.Dimensions)
+		batches = append(batches, len(req.Input))
+
+		// Answer in reverse order; the index places each vector
+		var resp embeddingsResponse
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			vector := make([]float32, req.Dimensions)
+			vector[0] = float32(len(req.Input[i]))
+			resp.Data = append(resp.Data, struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			}{i, vector})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	e := NewHTTPEmbedder(server.URL+"/v1/", "sk-test", "small", 8, server.Client())
+	assert.Equal(t, "small-8", e.Model())
+	texts := make([]string, maxBatch+2)
+	for i := range texts {
+		texts[i] = string(make([]byte, i))
+	}
+	vectors, err := e.Embed(context.Background(), texts)
+	require.NoError(t, err)
+	assert.Equal(t, []int{maxBatch, 2}, batches)
+	require.Len(t, vectors, len(texts))
+	for i, vector := range vectors {
+		assert.Equal(t, float32(i), vector[0])
+	}
+
+	_, err = NewHTTPEmbedder(server.URL+"/v1", "sk-test", "broken", 0, server.Client()).Embed(context.Background(), []string{"x"})
+	assert.ErrorIs(t, err, ErrEmbeddingFailed)
+	assert.Contains(t, err.Error(), "model not found")
+}
+
+func TestNewEmbedder(t *testing.T) {
+	e, err := NewEmbedder(config.EmbeddingsConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, e, "semantic search is off")
+
+	e, err = NewEmbedder(config.EmbeddingsConfig{Provider: ProviderHash, Dimensions: 64})
+	require.NoError(t, err)
+	assert.Equal(t, "hash-64", e.Model())
+
+	e, err = NewEmbedder(config.EmbeddingsConfig{Provider: ProviderOpenAI, Model: "text-embedding-3-small"})
+	require.NoError(t, err)
+	assert.IsType(t, &HTTPEmbedder{}, e)
+
+	_, err = NewEmbedder(config.EmbeddingsConfig{Provider: ProviderOpenAI})
+	assert.Error(t, err)
+	_, err = NewEmbedder(config.EmbeddingsConfig{Provider: "magic"})
+	assert.Error(t, err)
+}
+
+func TestIndex(t *testing.T) {
+	dir := t.TempDir()
+	x, err := OpenIndex(dir, "products", "test")
+	require.NoError(t, err)
+	hits, err := x.Search([]float32{1, 0}, 5, nil)
+	require.NoError(t, err)
+	assert.Empty(t, hits)
+
+	require.NoError(t, x.Apply([]Item{
+		{ID: "east", Vector: []float32{2, 0}, Metadata: map[string]string{"brand": "acme"}, Digest: "d1"},
+		{ID: "north", Vector: []float32{0, 3}, Metadata: map[string]string{"brand": "acme"}},
+		{ID: "northeast", Vector: []float32{1, 1}, Metadata: map[string]string{"brand": "other"}},
+	}, nil))
+	assert.Equal(t, 3, x.Len())
+
+	hits, err = x.Search([]float32{1, 0.1}, 2, nil)
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	assert.Equal(t, "east", hits[0].ID)
+	assert.Equal(t, "northeast", hits[1].ID)
+	assert.InDelta(t, 0.995, hits[0].Score, 0.001, "scores are cosine similarities")
+
+	hits, err = x.Search([]float32{1, 1}, 5, Filter{"brand": {"acme"}})
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	assert.Equal(t, "east", hits[0].ID, "ties are ordered by ID")
+
+	assert.ErrorIs(t, x.Apply([]Item{{ID: "up", Vector: []float32{0, 0, 1}}}, nil), ErrDimensions)
+	_, err = x.Search([]float32{1}, 5, nil)
+	assert.ErrorIs(t, err, ErrDimensions)
+
+	// Changes are stored, for the same model only
+	require.NoError(t, x.Apply(nil, []string{"north"}))
+	x, err = OpenIndex(dir, "products", "test")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"east": "d1", "northeast": ""}, x.Digests())
+	hits, err = x.Search([]float32{1, 0}, 1, nil)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.InDelta(t, 1, hits[0].Score, 1e-6)
+
+	x, err = OpenIndex(dir, "products", "other-model")
+	require.NoError(t, err)
+	assert.Equal(t, 0, x.Len())
+}
+
+func TestFilter(t *testing.T) {
+	metadata := map[string]string{"brand": "acme", "category": "kitchen/blenders"}
+	assert.True(t, Filter(nil).matches(metadata))
+	assert.True(t, Filter{"brand": {"other", "acme"}}.matches(metadata), "any of the values")
+	assert.True(t, Filter{"category": {"kitchen", "kitchen/"}}.matches(metadata), "values below a prefix")
+	assert.True(t, Filter{"category": {"kitchen/blenders", "kitchen/blenders/"}}.matches(metadata))
+	assert.False(t, Filter{"category": {"kitchen"}}.matches(metadata), "other values match exactly")
+	assert.False(t, Filter{"category": {"kitch/"}}.matches(metadata))
+	assert.False(t, Filter{"brand": {"acme"}, "category": {"garden", "garden/"}}.matches(metadata), "every key")
+}
+
+func TestIndex_PutIsStoredBySave(t *testing.T) {
+	dir := t.TempDir()
+	x, err := OpenIndex(dir, "products", "test")
+	require.NoError(t, err)
+	require.NoError(t, x.Put([]Item{{ID: "east", Vector: []float32{1, 0}}}, nil))
+	require.NoError(t, x.Put([]Item{{ID: "north", Vector: []float32{0, 1}}}, nil))
+	assert.Equal(t, 2, x.Len())
+	stored, err := OpenIndex(dir, "products", "test")
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.Len(), "puts stay in memory")
+
+	require.NoError(t, x.Save())
+	stored, err = OpenIndex(dir, "products", "test")
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Len())
+
+	// A put with a vector of the wrong size changes nothing
+	err = x.Put([]Item{{ID: "west", Vector: []float32{-1, 0}}, {ID: "up", Vector: []float32{0, 0, 1}}}, []string{"east"})
+	assert.ErrorIs(t, err, ErrDimensions)
+	assert.Equal(t, map[string]string{"east": "", "north": ""}, x.Digests())
+
+	// Removing every item lets the next put set the dimensions
+	require.NoError(t, x.Put([]Item{{ID: "up", Vector: []float32{0, 0, 1}}}, []string{"east", "north"}))
+	assert.Equal(t, map[string]string{"up": ""}, x.Digests())
+}
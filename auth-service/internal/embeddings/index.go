@@ -0,0 +1,286 @@
+package embeddings
+
+import (
+	"auth-service/internal/storage"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrDimensions is returned for vectors whose size differs from the index's
+var ErrDimensions = errors.New("vector size does not match the index")
+
+// Item is a vector with the metadata searches filter on
+type Item struct {
+	ID       string
+	Vector   []float32
+	Metadata map[string]string
+	// Digest identifies the text the vector was made from, so texts that did
+	// not change are not embedded again
+	Digest string
+}
+
+// Hit is an item found by Search, with its cosine similarity to the query
+type Hit struct {
+	ID       string
+	Score    float64
+	Metadata map[string]string
+}
+
+// Filter keeps the items whose metadata has, for each key, one of its
+// values. A value ending in "/" also matches the values it is a prefix of,
+// so "kitchen/" keeps the items filed anywhere below kitchen.
+type Filter map[string][]string
+
+// Index is an embedded vector index. It is kept in memory and stored in one
+// JSON file by Save, so a run of changes is written once; searches compare
+// the query with every item, which is fast enough for a catalog of tens of
+// thousands of products.
+type Index struct {
+	path  string
+	model string
+	mutex sync.RWMutex
+	items map[string]*Item
+	dims  int
+	// changes counts the changes put, and saved those stored
+	changes int64
+	saved   int64
+	// saving serializes Save
+	saving sync.Mutex
+}
+
+// indexFile is the document stored on disk
+type indexFile struct {
+	Model string       `json:"model"`
+	Items []storedItem `json:"items"`
+}
+
+// storedItem is an Item with its vector packed as little-endian float32s,
+// which JSON encodes in base64
+type storedItem struct {
+	ID       string            `json:"id"`
+	Vector   []byte            `json:"vector"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Digest   string            `json:"digest,omitempty"`
+}
+
+// OpenIndex opens the index name in dir/embeddings for vectors of model. An
+// index stored for another model is discarded, and fills again as items are
+// put.
+func OpenIndex(dir, name, model string) (*Index, error) {
+	dir = filepath.Join(dir, "embeddings")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	x := &Index{path: filepath.Join(dir, name+".json"), model: model, items: map[string]*Item{}}
+
+	var stored indexFile
+	if _, err := storage.ReadJSON(x.path, &stored); err != nil {
+		return nil, err
+	}
+	if stored.Model != model {
+		return x, nil
+	}
+	for _, s := range stored.Items {
+		if len(s.Vector)%4 != 0 {
+			return nil, fmt.Errorf("embeddings index %s: invalid vector for %q", name, s.ID)
+		}
+		vector := make([]float32, len(s.Vector)/4)
+		for i := range vector {
+			vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(s.Vector[i*4:]))
+		}
+		x.items[s.ID] = &Item{ID: s.ID, Vector: vector, Metadata: s.Metadata, Digest: s.Digest}
+		x.dims = len(vector)
+	}
+	return x, nil
+}
+
+// Model names the vectors the index holds
+func (x *Index) Model() string {
+	return x.model
+}
+
+// Len returns the number of items
+func (x *Index) Len() int {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+	return len(x.items)
+}
+
+// Digests returns the digest of every item by ID
+func (x *Index) Digests() map[string]string {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+	digests := make(map[string]string, len(x.items))
+	for id, item := range x.items {
+		digests[id] = item.Digest
+	}
+	return digests
+}
+
+// Apply puts items and removes the items with the given IDs, as Put does,
+// then stores the index
+func (x *Index) Apply(put []Item, remove []string) error {
+	if err := x.Put(put, remove); err != nil {
+		return err
+	}
+	return x.Save()
+}
+
+// Put puts items, replacing those with the same ID, and removes the items
+// with the given IDs, in memory; Save stores them. Vectors are normalized to
+// unit length. If a vector's size does not match, the index is left
+// unchanged.
+func (x *Index) Put(put []Item, remove []string) error {
+	if len(put) == 0 && len(remove) == 0 {
+		return nil
+	}
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	// The dimensions are those of the items left, or of the first put when
+	// none are
+	gone := map[string]bool{}
+	for _, id := range remove {
+		if x.items[id] != nil {
+			gone[id] = true
+		}
+	}
+	dims := x.dims
+	if len(gone) == len(x.items) {
+		dims = 0
+	}
+	for _, item := range put {
+		if dims == 0 {
+			dims = len(item.Vector)
+		}
+		if len(item.Vector) != dims || dims == 0 {
+			return fmt.Errorf("%w: %q has %d dimensions, not %d", ErrDimensions, item.ID, len(item.Vector), dims)
+		}
+	}
+
+	for _, id := range remove {
+		delete(x.items, id)
+	}
+	for _, item := range put {
+		item := item
+		item.Vector = normalize(item.Vector)
+		x.items[item.ID] = &item
+	}
+	x.dims = dims
+	x.changes++
+	return nil
+}
+
+// Save stores the changes put since the last save, if any. Searches go on
+// while the file is written.
+func (x *Index) Save() error {
+	x.saving.Lock()
+	defer x.saving.Unlock()
+
+	// Items are replaced rather than changed, so the snapshot can be packed
+	// without the lock
+	x.mutex.RLock()
+	changes := x.changes
+	if changes == x.saved {
+		x.mutex.RUnlock()
+		return nil
+	}
+	items := make([]*Item, 0, len(x.items))
+	for _, item := range x.items {
+		items = append(items, item)
+	}
+	x.mutex.RUnlock()
+
+	stored := indexFile{Model: x.model, Items: make([]storedItem, 0, len(items))}
+	for _, item := range items {
+		packed := make([]byte, len(item.Vector)*4)
+		for i, value := range item.Vector {
+			binary.LittleEndian.PutUint32(packed[i*4:], math.Float32bits(value))
+		}
+		stored.Items = append(stored.Items, storedItem{ID: item.ID, Vector: packed, Metadata: item.Metadata, Digest: item.Digest})
+	}
+	sort.Slice(stored.Items, func(i, j int) bool { return stored.Items[i].ID < stored.Items[j].ID })
+	if err := storage.WriteJSON(x.path, stored); err != nil {
+		return err
+	}
+	x.mutex.Lock()
+	x.saved = changes
+	x.mutex.Unlock()
+	return nil
+}
+
+// Search returns the k items most similar to query that pass filter, most
+// similar first
+func (x *Index) Search(query []float32, k int, filter Filter) ([]Hit, error) {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+
+	if len(x.items) == 0 || k <= 0 {
+		return []Hit{}, nil
+	}
+	if len(query) != x.dims {
+		return nil, fmt.Errorf("%w: the query has %d dimensions, not %d", ErrDimensions, len(query), x.dims)
+	}
+	query = normalize(query)
+	hits := make([]Hit, 0, len(x.items))
+	for _, item := range x.items {
+		if !filter.matches(item.Metadata) {
+			continue
+		}
+		var dot float64
+		for i, value := range item.Vector {
+			dot += float64(value) * float64(query[i])
+		}
+		hits = append(hits, Hit{ID: item.ID, Score: dot, Metadata: item.Metadata})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	return hits[:min(k, len(hits))], nil
+}
+
+func (f Filter) matches(metadata map[string]string) bool {
+	for key, values := range f {
+		if !matchesAny(metadata[key], values) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAny(value string, values []string) bool {
+	for _, want := range values {
+		if value == want || strings.HasSuffix(want, "/") && strings.HasPrefix(value, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize returns vector scaled to unit length, so the dot product of two
+// vectors is their cosine similarity. A zero vector stays zero.
+func normalize(vector []float32) []float32 {
+	var sum float64
+	for _, value := range vector {
+		sum += float64(value) * float64(value)
+	}
+	normalized := make([]float32, len(vector))
+	if sum == 0 {
+		return normalized
+	}
+	norm := math.Sqrt(sum)
+	for i, value := range vector {
+		normalized[i] = float32(float64(value) / norm)
+	}
+	return normalized
+}
@@ -0,0 +1,104 @@
+package files
+
+import (
+	"auth-service/internal/storage"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidKey is returned for blob keys that could escape the store
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// BlobStore holds file contents by key. Keys are slash-separated paths
+// generated by the Service, never taken from requests.
+type BlobStore interface {
+	// Put stores everything read from r under key and returns its size
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Get opens the content stored under key; ErrNotFound if there is none
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the content stored under key; a missing key is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// LocalBlobStore keeps blobs as files under dir
+type LocalBlobStore struct {
+	dir string
+}
+
+// NewLocalBlobStore creates a store in dir
+func NewLocalBlobStore(dir string) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &LocalBlobStore{dir: dir}, nil
+}
+
+// Put writes r to a temporary file and renames it over the blob, so readers
+// never see a partial upload
+func (s *LocalBlobStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return n, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return n, err
+	}
+	if err := tmp.Close(); err != nil {
+		return n, err
+	}
+	return n, os.Rename(tmp.Name(), path)
+}
+
+// Get opens the blob file
+func (s *LocalBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete removes the blob file
+func (s *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	return storage.Remove(path)
+}
+
+func (s *LocalBlobStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,132 @@
+package files
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/storage"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore keeps each user's file metadata in one JSON file under dir
+type FileStore struct {
+	dir   string
+	mutex sync.Mutex
+	now   func() time.Time
+}
+
+// userFiles is the document stored per user
+type userFiles struct {
+	Files []models.File `json:"files"`
+}
+
+// NewFileStore creates a store in dir/files
+func NewFileStore(dir string) (*FileStore, error) {
+	dir = filepath.Join(dir, "files")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir, now: time.Now}, nil
+}
+
+// List returns the user's files, newest first
+func (s *FileStore) List(ctx context.Context, userID string) ([]models.File, error) {
+	s.mutex.Lock()
+	doc, err := s.load(userID)
+	s.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	sortFiles(doc.Files)
+	return doc.Files, nil
+}
+
+// Get returns one of the user's files
+func (s *FileStore) Get(ctx context.Context, userID, fileID string) (*models.File, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return nil, err
+	}
+	i := doc.find(fileID)
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	file := doc.Files[i]
+	return &file, nil
+}
+
+// Create records a file within the user's quota
+func (s *FileStore) Create(ctx context.Context, userID string, file *models.File, quota int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return err
+	}
+	if usage(doc.Files)+file.Bytes > quota {
+		return ErrQuotaExceeded
+	}
+
+	now := s.now().UTC()
+	file.User = userID
+	file.CreatedAt = now
+	file.UpdatedAt = now
+	doc.Files = append(doc.Files, *file)
+	return s.save(userID, doc)
+}
+
+// Delete removes one of the user's files
+func (s *FileStore) Delete(ctx context.Context, userID, fileID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return err
+	}
+	i := doc.find(fileID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	doc.Files = append(doc.Files[:i], doc.Files[i+1:]...)
+	return s.save(userID, doc)
+}
+
+// Usage returns the total size of the user's files
+func (s *FileStore) Usage(ctx context.Context, userID string) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return 0, err
+	}
+	return usage(doc.Files), nil
+}
+
+func (s *FileStore) load(userID string) (*userFiles, error) {
+	doc := &userFiles{Files: []models.File{}}
+	if _, err := storage.ReadJSON(storage.UserFile(s.dir, userID), doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (s *FileStore) save(userID string, doc *userFiles) error {
+	return storage.WriteJSON(storage.UserFile(s.dir, userID), doc)
+}
+
+func (doc *userFiles) find(fileID string) int {
+	for i := range doc.Files {
+		if doc.Files[i].FileID == fileID {
+			return i
+		}
+	}
+	return -1
+}
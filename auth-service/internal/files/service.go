@@ -0,0 +1,228 @@
+package files
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/storage"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// SourceLocal is the source of files kept by this service
+const SourceLocal = "local"
+
+// sniffLen is how much content http.DetectContentType looks at
+const sniffLen = 512
+
+// Service accepts uploads within the configured limits and serves them back
+// to their owner
+type Service struct {
+	cfg   config.FilesConfig
+	blobs BlobStore
+	store MetadataStore
+}
+
+// NewService creates a file service
+func NewService(cfg config.FilesConfig, blobs BlobStore, store MetadataStore) *Service {
+	return &Service{cfg: cfg, blobs: blobs, store: store}
+}
+
+// Put streams content to the blob store under a new file ID and returns the
+// file, with its sniffed type, size and (for images) thumbnail. The file is
+// not recorded yet: pass it to Commit, or to Discard to drop it.
+func (s *Service) Put(ctx context.Context, userID, filename string, content io.Reader) (*models.File, error) {
+	used, err := s.store.Usage(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if used >= s.cfg.UserQuota {
+		return nil, ErrQuotaExceeded
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	head = head[:n]
+	mimeType := sniff(head)
+	if !s.Allowed(mimeType) {
+		return nil, ErrUnsupportedType
+	}
+
+	file := &models.File{
+		FileID:   storage.NewID(),
+		User:     userID,
+		Filename: cleanFilename(filename),
+		Type:     mimeType,
+		Source:   SourceLocal,
+		Object:   "file",
+	}
+	file.Filepath = DownloadURL(userID, file.FileID)
+
+	// Read one byte past the limit to tell a file of exactly the limit from a larger one
+	limited := io.LimitReader(io.MultiReader(bytes.NewReader(head), content), s.cfg.MaxFileSize+1)
+	size, err := s.blobs.Put(ctx, blobKey(userID, file.FileID), limited)
+	if err != nil {
+		s.Discard(ctx, file)
+		return nil, err
+	}
+	file.Bytes = size
+	if size > s.cfg.MaxFileSize {
+		s.Discard(ctx, file)
+		return nil, ErrTooLarge
+	}
+	if used+size > s.cfg.UserQuota {
+		s.Discard(ctx, file)
+		return nil, ErrQuotaExceeded
+	}
+
+	if strings.HasPrefix(mimeType, "image/") {
+		s.thumbnail(ctx, file)
+	}
+	return file, nil
+}
+
+// Commit records a file returned by Put. Its content is discarded when the
+// user's quota was used up by concurrent uploads in the meantime.
+func (s *Service) Commit(ctx context.Context, file *models.File) error {
+	if err := s.store.Create(ctx, file.User, file, s.cfg.UserQuota); err != nil {
+		s.Discard(context.WithoutCancel(ctx), file)
+		return err
+	}
+	return nil
+}
+
+// Discard removes the content of a file that will not be committed
+func (s *Service) Discard(ctx context.Context, file *models.File) {
+	_ = s.blobs.Delete(ctx, blobKey(file.User, file.FileID))
+	_ = s.blobs.Delete(ctx, thumbnailKey(file.User, file.FileID))
+}
+
+// List returns the user's files, newest first. A non-empty conversationID
+// keeps only the files uploaded to that conversation.
+func (s *Service) List(ctx context.Context, userID, conversationID string) ([]models.File, error) {
+	list, err := s.store.List(ctx, userID)
+	if err != nil || conversationID == "" {
+		return list, err
+	}
+	filtered := []models.File{}
+	for _, file := range list {
+		if file.ConversationID == conversationID {
+			filtered = append(filtered, file)
+		}
+	}
+	return filtered, nil
+}
+
+// Open returns one of the user's files and its content
+func (s *Service) Open(ctx context.Context, userID, fileID string) (*models.File, io.ReadCloser, error) {
+	file, err := s.store.Get(ctx, userID, fileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := s.blobs.Get(ctx, blobKey(userID, fileID))
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, content, nil
+}
+
+// OpenThumbnail returns the thumbnail of one of the user's images;
+// ErrNotFound when it has none
+func (s *Service) OpenThumbnail(ctx context.Context, userID, fileID string) (io.ReadCloser, error) {
+	file, err := s.store.Get(ctx, userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if file.Thumbnail == "" {
+		return nil, ErrNotFound
+	}
+	return s.blobs.Get(ctx, thumbnailKey(userID, fileID))
+}
+
+// Delete removes the user's files and returns the IDs that were deleted.
+// Unknown IDs and files of other users are skipped.
+func (s *Service) Delete(ctx context.Context, userID string, fileIDs []string) ([]string, error) {
+	deleted := []string{}
+	for _, fileID := range fileIDs {
+		err := s.store.Delete(ctx, userID, fileID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return deleted, err
+		}
+		// The metadata is gone, so leftover content is unreachable either way
+		s.Discard(ctx, &models.File{User: userID, FileID: fileID})
+		deleted = append(deleted, fileID)
+	}
+	return deleted, nil
+}
+
+// Allowed reports whether the MIME type matches the allowlist. Entries may
+// end in "/*" to allow a whole family, e.g. "image/*".
+func (s *Service) Allowed(mimeType string) bool {
+	for _, allowed := range s.cfg.AllowedTypes {
+		if allowed == mimeType || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(allowed, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// Limits returns the configured limits
+func (s *Service) Limits() config.FilesConfig {
+	return s.cfg
+}
+
+// DownloadURL is the owner-checked URL a file is served from
+func DownloadURL(userID, fileID string) string {
+	return "/api/files/download/" + userID + "/" + fileID
+}
+
+// sniff returns the MIME type of content without parameters such as charset
+func sniff(head []byte) string {
+	mimeType := http.DetectContentType(head)
+	if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return strings.TrimSpace(mimeType)
+}
+
+// blobKey places a user's files under a directory named by a hash of their ID
+func blobKey(userID, fileID string) string {
+	sum := sha256.Sum256([]byte(userID))
+	return hex.EncodeToString(sum[:]) + "/" + fileID
+}
+
+func thumbnailKey(userID, fileID string) string {
+	return blobKey(userID, fileID) + ".thumb"
+}
+
+// cleanFilename keeps the base name of an uploaded file, without control
+// characters and at most 255 characters long
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[:255])
+	}
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	return name
+}
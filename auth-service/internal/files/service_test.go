@@ -0,0 +1,186 @@
+package files
+
+import (
+	"auth-service/internal/config"
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T, cfg config.FilesConfig) *Service {
+	blobs, err := NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	return NewService(cfg, blobs, store)
+}
+
+func testLimits() config.FilesConfig {
+	return config.FilesConfig{
+		MaxFileSize:   1 << 20,
+		UserQuota:     2 << 20,
+		AllowedTypes:  []string{"image/*", "text/plain"},
+		ThumbnailSize: 16,
+	}
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// upload puts and commits a file
+func upload(s *Service, userID, filename string, content []byte) error {
+	ctx := context.Background()
+	file, err := s.Put(ctx, userID, filename, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	return s.Commit(ctx, file)
+}
+
+func TestService_PutSniffsTypeAndMakesThumbnail(t *testing.T) {
+	s := newTestService(t, testLimits())
+	ctx := context.Background()
+
+	file, err := s.Put(ctx, "user-1", "../../photo.png", bytes.NewReader(testPNG(t, 64, 32)))
+	require.NoError(t, err)
+	require.NoError(t, s.Commit(ctx, file))
+
+	assert.Equal(t, "image/png", file.Type)
+	assert.Equal(t, "photo.png", file.Filename, "directories are stripped from the name")
+	assert.Equal(t, 64, file.Width)
+	assert.Equal(t, 32, file.Height)
+	assert.Equal(t, "/api/files/download/user-1/"+file.FileID, file.Filepath)
+	assert.Equal(t, file.Filepath+"/thumbnail", file.Thumbnail)
+
+	thumb, err := s.OpenThumbnail(ctx, "user-1", file.FileID)
+	require.NoError(t, err)
+	defer thumb.Close()
+	cfg, format, err := image.DecodeConfig(thumb)
+	require.NoError(t, err)
+	assert.Equal(t, "png", format)
+	assert.Equal(t, 16, cfg.Width)
+	assert.Equal(t, 8, cfg.Height)
+
+	_, content, err := s.Open(ctx, "user-1", file.FileID)
+	require.NoError(t, err)
+	defer content.Close()
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	assert.Equal(t, testPNG(t, 64, 32), data)
+}
+
+func TestService_PutRejectsContent(t *testing.T) {
+	limits := testLimits()
+	limits.MaxFileSize = 1024
+	s := newTestService(t, limits)
+
+	// The type comes from the content, not the name
+	err := upload(s, "user-1", "notes.txt", []byte("%PDF-1.7\n..."))
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	err = upload(s, "user-1", "big.txt", bytes.Repeat([]byte("a"), 1025))
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	err = upload(s, "user-1", "exact.txt", bytes.Repeat([]byte("a"), 1024))
+	assert.NoError(t, err, "a file of exactly the limit is accepted")
+
+	list, err := s.List(context.Background(), "user-1", "")
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestService_Quota(t *testing.T) {
+	limits := testLimits()
+	limits.MaxFileSize = 600
+	limits.UserQuota = 1000
+	s := newTestService(t, limits)
+	text := []byte(strings.Repeat("a", 600))
+
+	err := upload(s, "user-1", "one.txt", text)
+	require.NoError(t, err)
+	err = upload(s, "user-1", "two.txt", text)
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+	// Quotas are per user
+	err = upload(s, "user-2", "one.txt", text)
+	assert.NoError(t, err)
+}
+
+func TestService_ListByConversationAndDelete(t *testing.T) {
+	s := newTestService(t, testLimits())
+	ctx := context.Background()
+
+	var ids []string
+	for _, convo := range []string{"convo-1", "convo-2", "convo-1"} {
+		file, err := s.Put(ctx, "user-1", "notes.txt", strings.NewReader("shopping notes"))
+		require.NoError(t, err)
+		file.ConversationID = convo
+		require.NoError(t, s.Commit(ctx, file))
+		ids = append(ids, file.FileID)
+	}
+
+	list, err := s.List(ctx, "user-1", "convo-1")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, ids[2], list[0].FileID, "newest first")
+
+	deleted, err := s.Delete(ctx, "user-2", ids)
+	require.NoError(t, err)
+	assert.Empty(t, deleted, "other users cannot delete the files")
+
+	deleted, err = s.Delete(ctx, "user-1", []string{ids[0], "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{ids[0]}, deleted)
+	_, _, err = s.Open(ctx, "user-1", ids[0])
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, _, err = s.Open(ctx, "user-2", ids[1])
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestService_Allowed(t *testing.T) {
+	s := newTestService(t, testLimits())
+
+	assert.True(t, s.Allowed("image/webp"))
+	assert.True(t, s.Allowed("text/plain"))
+	assert.False(t, s.Allowed("text/html"))
+	assert.False(t, s.Allowed("imagex/png"))
+}
+
+func TestLocalBlobStore_RejectsEscapingKeys(t *testing.T) {
+	blobs, err := NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "a/../../b", "a//b", `a\b`} {
+		_, err := blobs.Put(ctx, key, strings.NewReader("x"))
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+
+	_, err = blobs.Get(ctx, "missing/blob")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, blobs.Delete(ctx, "missing/blob"))
+}
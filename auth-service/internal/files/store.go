@@ -0,0 +1,52 @@
+// Package files stores uploaded files: their content in a BlobStore and their
+// metadata in a MetadataStore. Every operation is scoped to a user: a file of
+// another user behaves as if it did not exist.
+package files
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"sort"
+)
+
+var (
+	// ErrNotFound is returned for unknown files and for those of other users
+	ErrNotFound = errors.New("file not found")
+	// ErrTooLarge is returned for files over the size limit
+	ErrTooLarge = errors.New("file too large")
+	// ErrQuotaExceeded is returned when a file would take a user over their quota
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+	// ErrUnsupportedType is returned for content whose MIME type is not allowed
+	ErrUnsupportedType = errors.New("unsupported file type")
+)
+
+// MetadataStore persists file metadata
+type MetadataStore interface {
+	// List returns the user's files, newest first
+	List(ctx context.Context, userID string) ([]models.File, error)
+	// Get returns one of the user's files
+	Get(ctx context.Context, userID, fileID string) (*models.File, error)
+	// Create records a file, filling in its timestamps, unless the user's
+	// files would then take more than quota bytes
+	Create(ctx context.Context, userID string, file *models.File, quota int64) error
+	// Delete removes one of the user's files
+	Delete(ctx context.Context, userID, fileID string) error
+	// Usage returns the total size of the user's files in bytes
+	Usage(ctx context.Context, userID string) (int64, error)
+}
+
+// sortFiles orders files as List returns them
+func sortFiles(list []models.File) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+}
+
+func usage(list []models.File) int64 {
+	var total int64
+	for _, file := range list {
+		total += file.Bytes
+	}
+	return total
+}
@@ -0,0 +1,94 @@
+package files
+
+import (
+	"auth-service/internal/models"
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+
+	// Decoders for the image formats thumbnails are made from
+	_ "image/gif"
+	_ "image/jpeg"
+)
+
+// maxThumbnailPixels bounds the images decoded for a thumbnail, so a small
+// file declaring huge dimensions cannot exhaust memory
+const maxThumbnailPixels = 40_000_000
+
+// thumbnail records the dimensions of an image file and stores a PNG
+// thumbnail whose longest side is ThumbnailSize. Formats the standard library
+// cannot decode (e.g. WebP) are kept without one.
+func (s *Service) thumbnail(ctx context.Context, file *models.File) {
+	key := blobKey(file.User, file.FileID)
+
+	content, err := s.blobs.Get(ctx, key)
+	if err != nil {
+		return
+	}
+	cfg, _, err := image.DecodeConfig(content)
+	content.Close()
+	if err != nil {
+		return
+	}
+	file.Width, file.Height = cfg.Width, cfg.Height
+	if s.cfg.ThumbnailSize <= 0 || cfg.Width*cfg.Height > maxThumbnailPixels {
+		return
+	}
+
+	content, err = s.blobs.Get(ctx, key)
+	if err != nil {
+		return
+	}
+	img, _, err := image.Decode(content)
+	content.Close()
+	if err != nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scale(img, s.cfg.ThumbnailSize)); err != nil {
+		return
+	}
+	if _, err := s.blobs.Put(ctx, thumbnailKey(file.User, file.FileID), &buf); err != nil {
+		return
+	}
+	file.Thumbnail = file.Filepath + "/thumbnail"
+}
+
+// scale shrinks src to fit in a maxSide square, averaging the source pixels
+// covered by each thumbnail pixel. Smaller images keep their size.
+func scale(src image.Image, maxSide int) *image.RGBA64 {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	tw, th := w, h
+	if w > maxSide || h > maxSide {
+		if w >= h {
+			tw, th = maxSide, max(1, h*maxSide/w)
+		} else {
+			tw, th = max(1, w*maxSide/h), maxSide
+		}
+	}
+
+	dst := image.NewRGBA64(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0 := bounds.Min.Y + y*h/th
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*h/th)
+		for x := 0; x < tw; x++ {
+			x0 := bounds.Min.X + x*w/tw
+			x1 := max(x0+1, bounds.Min.X+(x+1)*w/tw)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,166 @@
+package flags
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/problem"
+	"context"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Flag names used by the service
+const (
+	Auth          = "auth"
+	AI            = "ai"
+	Shopping      = "shopping"
+	Registration  = "registration"
+	PasswordReset = "password_reset"
+	Email         = "email"
+	// SocialPrefix prefixes the per-provider social login flags, e.g. "social_google"
+	SocialPrefix = "social_"
+)
+
+// Subject is who a flag is evaluated for. The zero Subject is an anonymous caller.
+type Subject struct {
+	UserID string
+	Roles  []string
+}
+
+type subjectKey struct{}
+
+// WithSubject returns a context carrying the subject flags are evaluated for
+func WithSubject(ctx context.Context, subject Subject) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// SubjectFrom returns the subject stored in ctx, or an anonymous one
+func SubjectFrom(ctx context.Context) Subject {
+	subject, _ := ctx.Value(subjectKey{}).(Subject)
+	return subject
+}
+
+// Service evaluates feature flags
+type Service struct {
+	flags map[string]config.FlagConfig
+}
+
+// NewService creates a service for the configured flags.
+// Flags that are not configured are off.
+func NewService(cfg config.FlagsConfig) *Service {
+	flags := make(map[string]config.FlagConfig, len(cfg.Flags))
+	for name, flag := range cfg.Flags {
+		flags[strings.ToLower(name)] = flag
+	}
+	return &Service{flags: flags}
+}
+
+// Enabled reports whether the flag is on for the subject in ctx
+func (s *Service) Enabled(ctx context.Context, name string) bool {
+	flag, ok := s.flags[strings.ToLower(name)]
+	if !ok {
+		return false
+	}
+	if flag.Enabled {
+		return true
+	}
+
+	subject := SubjectFrom(ctx)
+	if subject.UserID == "" {
+		return false
+	}
+	for _, user := range flag.Users {
+		if user == subject.UserID {
+			return true
+		}
+	}
+	for _, role := range flag.Roles {
+		for _, subjectRole := range subject.Roles {
+			if role == subjectRole {
+				return true
+			}
+		}
+	}
+	return flag.Rollout > 0 && bucket(name, subject.UserID) < flag.Rollout
+}
+
+// Evaluate returns every flag's value for the subject in ctx
+func (s *Service) Evaluate(ctx context.Context) map[string]bool {
+	values := make(map[string]bool, len(s.flags))
+	for name := range s.flags {
+		values[name] = s.Enabled(ctx, name)
+	}
+	return values
+}
+
+// Names returns the configured flag names in order
+func (s *Service) Names() []string {
+	names := make([]string, 0, len(s.flags))
+	for name := range s.flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// bucket places a user in [0, 100) for a flag. The same user always lands
+// in the same bucket, and buckets differ between flags, so rollouts of
+// different flags reach different users.
+func bucket(name, userID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(name)))
+	h.Write([]byte{0})
+	h.Write([]byte(userID))
+	return int(h.Sum32() % 100)
+}
+
+var (
+	mutex          sync.RWMutex
+	defaultService = NewService(config.FlagsConfig{})
+)
+
+// SetDefault sets the service used by the package-level functions
+func SetDefault(s *Service) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	defaultService = s
+}
+
+// Default returns the service used by the package-level functions
+func Default() *Service {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return defaultService
+}
+
+// Enabled reports whether the flag is on for the subject in ctx
+func Enabled(ctx context.Context, name string) bool {
+	return Default().Enabled(ctx, name)
+}
+
+// Evaluate returns every flag's value for the subject in ctx
+func Evaluate(ctx context.Context) map[string]bool {
+	return Default().Evaluate(ctx)
+}
+
+// Require rejects requests with 403 feature_disabled while the flag is off
+// for the caller. It goes after AuthMiddleware, which sets the subject.
+func Require(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Enabled(c.Request.Context(), name) {
+			problem.Write(c, models.ErrorResponse{
+				Error:   "feature_disabled",
+				Message: "This feature is currently disabled",
+				Code:    http.StatusForbidden,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,120 @@
+package flags
+
+import (
+	"auth-service/internal/config"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestService() *Service {
+	return NewService(config.FlagsConfig{Flags: map[string]config.FlagConfig{
+		"on":      {Enabled: true},
+		"off":     {},
+		"beta":    {Users: []string{"user-1"}, Roles: []string{"beta-tester"}},
+		"Rollout": {Rollout: 30},
+	}})
+}
+
+func TestService_Boolean(t *testing.T) {
+	s := newTestService()
+	ctx := context.Background()
+
+	assert.True(t, s.Enabled(ctx, "on"))
+	assert.True(t, s.Enabled(ctx, "ON"))
+	assert.False(t, s.Enabled(ctx, "off"))
+	assert.False(t, s.Enabled(ctx, "unknown"))
+}
+
+func TestService_Targeting(t *testing.T) {
+	s := newTestService()
+
+	tests := []struct {
+		name    string
+		subject Subject
+		want    bool
+	}{
+		{"anonymous", Subject{}, false},
+		{"listed user", Subject{UserID: "user-1"}, true},
+		{"listed role", Subject{UserID: "user-2", Roles: []string{"user", "beta-tester"}}, true},
+		{"other user", Subject{UserID: "user-2", Roles: []string{"user"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithSubject(context.Background(), tt.subject)
+			assert.Equal(t, tt.want, s.Enabled(ctx, "beta"))
+		})
+	}
+}
+
+func TestService_Rollout(t *testing.T) {
+	s := newTestService()
+
+	assert.False(t, s.Enabled(context.Background(), "rollout"), "anonymous callers are outside rollouts")
+
+	enabled := 0
+	for i := 0; i < 2000; i++ {
+		ctx := WithSubject(context.Background(), Subject{UserID: fmt.Sprintf("user-%d", i)})
+		value := s.Enabled(ctx, "rollout")
+		// A user keeps the same value across evaluations
+		assert.Equal(t, value, s.Enabled(ctx, "rollout"))
+		if value {
+			enabled++
+		}
+	}
+	assert.InDelta(t, 600, enabled, 100)
+}
+
+func TestService_Evaluate(t *testing.T) {
+	s := newTestService()
+	ctx := WithSubject(context.Background(), Subject{UserID: "user-1"})
+
+	values := s.Evaluate(ctx)
+	assert.Equal(t, []string{"beta", "off", "on", "rollout"}, s.Names())
+	assert.True(t, values["on"])
+	assert.True(t, values["beta"])
+	assert.False(t, values["off"])
+}
+
+func TestRequire(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	previous := Default()
+	SetDefault(newTestService())
+	t.Cleanup(func() { SetDefault(previous) })
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			c.Request = c.Request.WithContext(WithSubject(c.Request.Context(), Subject{UserID: user}))
+		}
+	})
+	for _, name := range []string{"on", "off", "beta"} {
+		router.GET("/"+name, Require(name), func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+
+	tests := []struct {
+		path, user string
+		want       int
+	}{
+		{"/on", "", http.StatusOK},
+		{"/off", "user-1", http.StatusForbidden},
+		{"/beta", "user-1", http.StatusOK},
+		{"/beta", "user-2", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Header.Set("X-User", tt.user)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tt.want, w.Code, tt.path+" "+tt.user)
+		if tt.want == http.StatusForbidden {
+			assert.Contains(t, w.Body.String(), "feature_disabled")
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"auth-service/internal/alerts"
+	"auth-service/internal/catalog"
+	"auth-service/internal/config"
+	"auth-service/internal/lists"
+	"auth-service/internal/models"
+	"auth-service/internal/problem"
+	"auth-service/internal/validation"
+	"auth-service/pkg/logger"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Alert rule bounds: target prices in minor units, drops in percent
+const (
+	maxTargetPrice = 1_000_000_000
+	minPercentDrop = 1
+	maxPercentDrop = 99
+)
+
+// AlertHandler serves the watchlist (/api/v1/watchlist) and the in-app
+// notifications (/api/v1/notifications). Every request is scoped to the
+// user_id set by AuthMiddleware.
+type AlertHandler struct {
+	watches alerts.WatchStore
+	inbox   alerts.NotificationStore
+	catalog *catalog.Catalog
+	logger  *logger.Logger
+}
+
+// NewAlertHandler creates a new alert handler watching the products of catalog
+func NewAlertHandler(cfg *config.Config, logger *logger.Logger, watches alerts.WatchStore, inbox alerts.NotificationStore, catalog *catalog.Catalog) *AlertHandler {
+	return &AlertHandler{
+		watches: watches,
+		inbox:   inbox,
+		catalog: catalog,
+		logger:  logger,
+	}
+}
+
+// ListWatches returns the user's watches, the most recently created first
+func (h *AlertHandler) ListWatches(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	watches, err := h.watches.List(c.Request.Context(), userID)
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to list watches")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"watches": watches})
+}
+
+// CreateWatch adds a product, or one of its variants, to the watchlist with
+// a target price or a percent drop. Alerts are sent for drops after this.
+func (h *AlertHandler) CreateWatch(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	var req models.SaveWatchRequest
+	var product *models.ProductDetail
+	if !bindAndValidate(c, h.logger, validation.SaveWatch, &req, func() []validation.Error {
+		errs := checkRule(req)
+		var err error
+		if product, err = h.catalog.Product(ctx, req.ProductID); err != nil {
+			return append(errs, validation.Error{Field: "product_id", Code: "unknown_product"})
+		}
+		if req.VariantID != "" && !lists.HasVariant(product, req.VariantID) {
+			errs = append(errs, validation.Error{Field: "variant_id", Code: "unknown_variant"})
+		}
+		return errs
+	}) {
+		return
+	}
+
+	watch := &models.Watch{
+		ProductID:   product.ID,
+		VariantID:   req.VariantID,
+		ProductName: product.Name,
+		TargetPrice: req.TargetPrice,
+		PercentDrop: req.PercentDrop,
+		Email:       c.GetString("email"),
+	}
+	if !h.prime(ctx, c, watch) {
+		return
+	}
+	if _, err := h.watches.Save(ctx, userID, watch); err != nil {
+		h.writeStoreError(c, err, "Failed to save watch")
+		return
+	}
+	c.JSON(http.StatusCreated, watch)
+}
+
+// UpdateWatch replaces the rule of the watch named by :watchId. Drops are
+// measured from the current price again.
+func (h *AlertHandler) UpdateWatch(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.SaveWatchRequest
+	if !bindAndValidate(c, h.logger, validation.UpdateWatch, &req, func() []validation.Error {
+		return checkRule(req)
+	}) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	watch, err := h.watches.Get(ctx, userID, c.Param("watchId"))
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to get watch")
+		return
+	}
+	watch.TargetPrice = req.TargetPrice
+	watch.PercentDrop = req.PercentDrop
+	if !h.prime(ctx, c, watch) {
+		return
+	}
+	if _, err := h.watches.Save(ctx, userID, watch); err != nil {
+		h.writeStoreError(c, err, "Failed to save watch")
+		return
+	}
+	c.JSON(http.StatusOK, watch)
+}
+
+// DeleteWatch removes the watch named by :watchId
+func (h *AlertHandler) DeleteWatch(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.watches.Delete(c.Request.Context(), userID, c.Param("watchId")); err != nil {
+		h.writeStoreError(c, err, "Failed to delete watch")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListNotifications returns the user's notifications, newest first, and the
+// number unread. unread=true leaves out those already read.
+func (h *AlertHandler) ListNotifications(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	unreadOnly := false
+	switch c.Query("unread") {
+	case "", "false":
+	case "true":
+		unreadOnly = true
+	default:
+		problem.Write(c, models.ErrorResponse{
+			Error:   "invalid_query",
+			Message: "unread must be true or false",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	list, err := h.inbox.List(c.Request.Context(), userID, unreadOnly)
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to list notifications")
+		return
+	}
+	response := models.NotificationsResponse{Notifications: list}
+	for _, notification := range list {
+		if notification.ReadAt == nil {
+			response.Unread++
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// MarkRead marks the listed notifications as read, or all of them when the
+// body has no IDs, and returns how many were unread
+func (h *AlertHandler) MarkRead(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.MarkReadRequest
+	if c.Request.ContentLength != 0 && !bindAndValidate(c, h.logger, validation.MarkRead, &req) {
+		return
+	}
+
+	marked, err := h.inbox.MarkRead(c.Request.Context(), userID, req.IDs)
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to mark notifications as read")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"marked": marked})
+}
+
+// prime sets the state of watch from the product's current offers, writing
+// an error response and returning false on failure
+func (h *AlertHandler) prime(ctx context.Context, c *gin.Context, watch *models.Watch) bool {
+	offers, err := h.catalog.Offers(ctx, watch.ProductID, watch.VariantID)
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to get offers")
+		return false
+	}
+	alerts.Prime(watch, offers)
+	return true
+}
+
+// checkRule requires exactly one of a target price and a percent drop
+func checkRule(req models.SaveWatchRequest) []validation.Error {
+	if (req.TargetPrice == nil) == (req.PercentDrop == nil) {
+		return []validation.Error{{Field: "target_price", Code: "one_of", Params: map[string]interface{}{"other": "percent_drop"}}}
+	}
+	errs := validation.CheckRange("target_price", req.TargetPrice, 1, maxTargetPrice)
+	return append(errs, validation.CheckRange("percent_drop", req.PercentDrop, minPercentDrop, maxPercentDrop)...)
+}
+
+func (h *AlertHandler) writeStoreError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, alerts.ErrNotFound):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "watch_not_found",
+			Message: "Watch not found",
+			Code:    http.StatusNotFound,
+		})
+	case errors.Is(err, catalog.ErrNotFound):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "product_not_found",
+			Message: "Product not found",
+			Code:    http.StatusNotFound,
+		})
+	case errors.Is(err, alerts.ErrExists):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "watch_exists",
+			Message: "The product is already on the watchlist",
+			Code:    http.StatusConflict,
+		})
+	case errors.Is(err, alerts.ErrLimit):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "watch_limit_reached",
+			Message: "Remove a product from the watchlist before adding another",
+			Code:    http.StatusConflict,
+		})
+	default:
+		h.logger.WithError(err).Error(msg)
+		problem.Write(c, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: msg,
+			Code:    http.StatusInternalServerError,
+		})
+	}
+}
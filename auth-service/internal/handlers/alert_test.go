@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"auth-service/internal/alerts"
+	"auth-service/internal/catalog"
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/pkg/logger"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type alertTest struct {
+	router    *gin.Engine
+	catalog   *catalog.Catalog
+	feed      *catalog.Feed
+	evaluator *alerts.Evaluator
+	productID string
+}
+
+// newAlertTest mounts the watchlist and notification routes behind
+// testUser, with one product in the catalog and in-app notifications
+func newAlertTest(t *testing.T) *alertTest {
+	gin.SetMode(gin.TestMode)
+	log := &logger.Logger{Logger: logrus.New()}
+
+	dir := t.TempDir()
+	products, err := catalog.NewCatalog(dir, config.CatalogConfig{Currency: "EUR"}, nil, log)
+	require.NoError(t, err)
+	feeds, err := catalog.ParseFeeds([]byte(catalogTestFeeds), "EUR")
+	require.NoError(t, err)
+	feed, _ := feeds.Get("acme")
+	watches, err := alerts.NewFileStore(dir)
+	require.NoError(t, err)
+	inbox, err := alerts.NewFileInbox(dir)
+	require.NoError(t, err)
+	handler := NewAlertHandler(&config.Config{}, log, watches, inbox, products)
+
+	r := gin.New()
+	group := r.Group("/api/v1", testUser())
+	group.GET("/watchlist", handler.ListWatches)
+	group.POST("/watchlist", handler.CreateWatch)
+	group.PUT("/watchlist/:watchId", handler.UpdateWatch)
+	group.DELETE("/watchlist/:watchId", handler.DeleteWatch)
+	group.GET("/notifications", handler.ListNotifications)
+	group.POST("/notifications/read", handler.MarkRead)
+
+	a := &alertTest{
+		router:    r,
+		catalog:   products,
+		feed:      feed,
+		evaluator: alerts.NewEvaluator(watches, products, alerts.NewInAppNotifier(inbox), log),
+	}
+	a.reprice(t, "49.99")
+	page, err := products.Search(context.Background(), catalog.SearchQuery{})
+	require.NoError(t, err)
+	a.productID = page.Products[0].ID
+	return a
+}
+
+// reprice imports a new price for the product and evaluates the watches
+func (a *alertTest) reprice(t *testing.T, price string) {
+	content := "sku,gtin,name,brand,category,price\nA-1,,Trail Runner,,," + price + "\n"
+	_, err := a.catalog.Import(context.Background(), a.feed, strings.NewReader(content))
+	require.NoError(t, err)
+	if a.productID != "" {
+		require.NoError(t, a.evaluator.Evaluate(context.Background(), []string{a.productID}))
+	}
+}
+
+func TestAlertHandler_WatchAndNotify(t *testing.T) {
+	a := newAlertTest(t)
+	target := int64(4500)
+
+	w := doJSON(a.router, "POST", "/api/v1/watchlist", "user-1", models.SaveWatchRequest{ProductID: a.productID, TargetPrice: &target})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var watch models.Watch
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &watch))
+	assert.Equal(t, "Trail Runner", watch.ProductName)
+	assert.Equal(t, int64(4999), watch.State.Price.Amount)
+
+	w = doJSON(a.router, "POST", "/api/v1/watchlist", "user-1", models.SaveWatchRequest{ProductID: a.productID, TargetPrice: &target})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "watch_exists")
+
+	a.reprice(t, "44.99")
+	a.reprice(t, "42.00")
+
+	w = doJSON(a.router, "GET", "/api/v1/notifications", "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var inbox models.NotificationsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &inbox))
+	require.Len(t, inbox.Notifications, 1, "one drop alerts once")
+	assert.Equal(t, 1, inbox.Unread)
+	assert.Equal(t, "Trail Runner is down to 44.99 EUR", inbox.Notifications[0].Title)
+	assert.Equal(t, watch.ID, inbox.Notifications[0].WatchID)
+
+	w = doJSON(a.router, "POST", "/api/v1/notifications/read", "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"marked": 1}`, w.Body.String())
+	w = doJSON(a.router, "GET", "/api/v1/notifications?unread=true", "user-1", nil)
+	assert.JSONEq(t, `{"notifications": [], "unread": 0}`, w.Body.String())
+
+	// Changing the rule measures drops from the current price
+	drop := 10.0
+	w = doJSON(a.router, "PUT", "/api/v1/watchlist/"+watch.ID, "user-1", models.SaveWatchRequest{PercentDrop: &drop})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated models.Watch
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Nil(t, updated.TargetPrice)
+	assert.Equal(t, int64(4200), updated.State.Reference.Amount)
+
+	w = doJSON(a.router, "GET", "/api/v1/watchlist", "user-2", nil)
+	assert.JSONEq(t, `{"watches": []}`, w.Body.String())
+	w = doJSON(a.router, "DELETE", "/api/v1/watchlist/"+watch.ID, "user-2", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, "watches of other users do not exist")
+	w = doJSON(a.router, "DELETE", "/api/v1/watchlist/"+watch.ID, "user-1", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestAlertHandler_InvalidRequests(t *testing.T) {
+	a := newAlertTest(t)
+	target := int64(4500)
+	drop := 150.0
+
+	for name, req := range map[string]models.SaveWatchRequest{
+		"one_of":          {ProductID: a.productID},
+		"range":           {ProductID: a.productID, PercentDrop: &drop},
+		"unknown_product": {ProductID: "missing", TargetPrice: &target},
+		"unknown_variant": {ProductID: a.productID, VariantID: "missing", TargetPrice: &target},
+		"required":        {TargetPrice: &target},
+	} {
+		w := doJSON(a.router, "POST", "/api/v1/watchlist", "user-1", req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		assert.Contains(t, w.Body.String(), `"code":"`+name+`"`, name)
+	}
+
+	w := doJSON(a.router, "PUT", "/api/v1/watchlist/missing", "user-1", models.SaveWatchRequest{TargetPrice: &target})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doJSON(a.router, "GET", "/api/v1/notifications?unread=maybe", "user-1", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON(a.router, "GET", "/api/v1/watchlist", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -2,51 +2,74 @@ package handlers
 
 import (
 	"auth-service/internal/config"
+	"auth-service/internal/flags"
 	"auth-service/internal/models"
+	"auth-service/internal/problem"
+	"auth-service/internal/password"
 	"auth-service/internal/services"
+	"auth-service/internal/validation"
 	"auth-service/pkg/logger"
+	"errors"
 	"net/http"
-	"strings"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 )
 
+// AuthService is the identity provider API used by the handlers
+type AuthService interface {
+	Login(username, password string) (*models.AuthResponse, error)
+	Register(req *models.RegisterRequest) error
+	RefreshToken(refreshToken string) (*models.AuthResponse, error)
+	Logout(refreshToken string) error
+	GetUserProfile(accessToken string) (*models.User, error)
+	UpdateUserProfile(accessToken string, req *models.UpdateProfileRequest) error
+	ChangePassword(accessToken, currentPassword, newPassword string) error
+	SendPasswordReset(email string) error
+}
+
 // AuthHandler handles authentication requests
 type AuthHandler struct {
-	keycloakService *services.KeycloakService
+	keycloakService AuthService
+	passwordPolicy  *password.Policy
 	logger          *logger.Logger
+	session         sessionCookies
+	// resetNeedsSync holds password resets until the realm policy is synced
+	resetNeedsSync bool
+	policySynced   atomic.Bool
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(cfg *config.Config, logger *logger.Logger) *AuthHandler {
+func NewAuthHandler(cfg *config.Config, logger *logger.Logger, keycloakService AuthService, passwordPolicy *password.Policy) *AuthHandler {
 	return &AuthHandler{
-		keycloakService: services.NewKeycloakService(cfg.Keycloak, logger),
+		keycloakService: keycloakService,
+		passwordPolicy:  passwordPolicy,
 		logger:          logger,
+		session:         sessionCookies{cfg: cfg.Session},
+		resetNeedsSync:  cfg.Password.SyncKeycloak,
 	}
 }
 
+// PasswordPolicySynced records that the realm password policy matches
+// password.Policy, so password resets may start
+func (h *AuthHandler) PasswordPolicySynced() {
+	h.policySynced.Store(true)
+}
+
 // Login handles user login - IMPROVED with better validation
 func (h *AuthHandler) Login(c *gin.Context) {
+	noStore(c)
+
 	var req models.LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("Invalid login request")
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_error",
-			Message: "Invalid request format",
-			Code:    http.StatusBadRequest,
-			Details: err.Error(),
-		})
+	if !bindAndValidate(c, h.logger, validation.Login, &req) {
 		return
 	}
 
-	// Basic input sanitization
-	req.Username = sanitizeInput(req.Username)
-
 	// Authenticate with Keycloak
 	authResponse, err := h.keycloakService.Login(req.Username, req.Password)
 	if err != nil {
 		h.logger.WithError(err).WithField("username", req.Username).Error("Login failed")
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+		writeServiceError(c, err, models.ErrorResponse{
 			Error:   "authentication_failed",
 			Message: "Invalid username or password",
 			Code:    http.StatusUnauthorized,
@@ -54,6 +77,10 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	if !h.writeSession(c, authResponse) {
+		return
+	}
+
 	h.logger.WithField("username", req.Username).Info("User logged in successfully")
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Message: "Login successful",
@@ -63,52 +90,30 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 // Register handles user registration - IMPROVED with validation
 func (h *AuthHandler) Register(c *gin.Context) {
-	var req models.RegisterRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("Invalid registration request")
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_error",
-			Message: "Invalid request format",
-			Code:    http.StatusBadRequest,
-			Details: err.Error(),
+	if !flags.Enabled(c.Request.Context(), flags.Registration) {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "registration_disabled",
+			Message: "Registration is currently disabled",
+			Code:    http.StatusForbidden,
 		})
 		return
 	}
 
-	// Additional validation
-	if validationErrors := req.Validate(); len(validationErrors) > 0 {
-		h.logger.WithField("errors", validationErrors).Error("Registration validation failed")
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_error",
-			Message: "Invalid input data",
-			Code:    http.StatusBadRequest,
-			Details: validationErrors,
+	var req models.RegisterRequest
+	if !bindAndValidate(c, h.logger, validation.Register, &req, func() []validation.Error {
+		return h.passwordPolicy.Check(c.Request.Context(), "password", req.Password, password.UserInfo{
+			Username: req.Username,
+			Email:    req.Email,
 		})
+	}) {
 		return
 	}
 
-	// Sanitize inputs
-	req.Username = sanitizeInput(req.Username)
-	req.Email = sanitizeInput(req.Email)
-	req.FirstName = sanitizeInput(req.FirstName)
-	req.LastName = sanitizeInput(req.LastName)
-
 	// Register user in Keycloak
 	err := h.keycloakService.Register(&req)
 	if err != nil {
 		h.logger.WithError(err).WithField("username", req.Username).Error("Registration failed")
-		
-		// Check if user already exists
-		if isUserExistsError(err) {
-			c.JSON(http.StatusConflict, models.ErrorResponse{
-				Error:   "user_exists",
-				Message: "Username or email already exists",
-				Code:    http.StatusConflict,
-			})
-			return
-		}
-
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		writeServiceError(c, err, models.ErrorResponse{
 			Error:   "registration_failed",
 			Message: "Failed to create user account",
 			Code:    http.StatusBadRequest,
@@ -116,6 +121,10 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	if err := h.passwordPolicy.Remember(c.Request.Context(), password.UserInfo{Username: req.Username}, req.Password); err != nil {
+		h.logger.WithError(err).Warn("Failed to record password history")
+	}
+
 	h.logger.WithField("username", req.Username).Info("User registered successfully")
 	c.JSON(http.StatusCreated, models.SuccessResponse{
 		Message: "Registration successful",
@@ -124,22 +133,21 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 // Refresh handles token refresh - IMPROVED with better error handling
 func (h *AuthHandler) Refresh(c *gin.Context) {
-	var req models.RefreshRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("Invalid refresh request")
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_error",
-			Message: "Refresh token is required",
-			Code:    http.StatusBadRequest,
-		})
+	noStore(c)
+
+	refreshToken, ok := h.refreshToken(c)
+	if !ok {
 		return
 	}
 
 	// Refresh token with Keycloak
-	authResponse, err := h.keycloakService.RefreshToken(req.RefreshToken)
+	authResponse, err := h.keycloakService.RefreshToken(refreshToken)
 	if err != nil {
 		h.logger.WithError(err).Error("Token refresh failed")
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+		if h.session.enabled() {
+			h.session.clear(c)
+		}
+		writeServiceError(c, err, models.ErrorResponse{
 			Error:   "refresh_failed",
 			Message: "Invalid or expired refresh token",
 			Code:    http.StatusUnauthorized,
@@ -147,6 +155,11 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		return
 	}
 
+	// Rotate the cookies along with the tokens
+	if !h.writeSession(c, authResponse) {
+		return
+	}
+
 	h.logger.Info("Token refreshed successfully")
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Message: "Token refreshed successfully",
@@ -156,19 +169,17 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 
 // Logout handles user logout - IMPROVED with better error handling
 func (h *AuthHandler) Logout(c *gin.Context) {
-	var req models.RefreshRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("Invalid logout request")
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_error",
-			Message: "Refresh token is required for logout",
-			Code:    http.StatusBadRequest,
-		})
+	refreshToken, ok := h.refreshToken(c)
+	if !ok {
 		return
 	}
 
+	if h.session.enabled() {
+		h.session.clear(c)
+	}
+
 	// Logout from Keycloak
-	err := h.keycloakService.Logout(req.RefreshToken)
+	err := h.keycloakService.Logout(refreshToken)
 	if err != nil {
 		h.logger.WithError(err).Error("Logout failed")
 		// Don't return error to client - logout should always appear successful
@@ -181,21 +192,121 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	})
 }
 
-// Helper functions
-func sanitizeInput(input string) string {
-	// Basic XSS prevention - remove dangerous characters
-	input = strings.ReplaceAll(input, "<", "")
-	input = strings.ReplaceAll(input, ">", "")
-	input = strings.ReplaceAll(input, "\"", "")
-	input = strings.ReplaceAll(input, "'", "")
-	input = strings.ReplaceAll(input, "&", "")
-	return strings.TrimSpace(input)
+// ForgotPassword starts a password reset. Keycloak emails the user a reset
+// link and enforces the realm password policy, which is kept in sync with
+// password.Policy; until it is synced, resets are refused with 503. The
+// response does not reveal whether the account exists.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	if !flags.Enabled(c.Request.Context(), flags.PasswordReset) {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "password_reset_disabled",
+			Message: "Password reset is currently disabled",
+			Code:    http.StatusForbidden,
+		})
+		return
+	}
+
+	if h.resetNeedsSync && !h.policySynced.Load() {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "password_reset_unavailable",
+			Message: "Password reset is temporarily unavailable",
+			Code:    http.StatusServiceUnavailable,
+		})
+		return
+	}
+
+	var req models.ForgotPasswordRequest
+	if !bindAndValidate(c, h.logger, validation.ForgotPassword, &req) {
+		return
+	}
+
+	if err := h.keycloakService.SendPasswordReset(req.Email); err != nil {
+		h.logger.WithError(err).Error("Password reset failed")
+		// Outages are safe to report; they say nothing about the account
+		if errors.Is(err, services.ErrIdPUnavailable) || errors.Is(err, services.ErrRateLimited) {
+			writeServiceError(c, err, models.ErrorResponse{})
+			return
+		}
+	}
+
+	c.JSON(http.StatusAccepted, models.SuccessResponse{
+		Message: "If an account with that email exists, a password reset link has been sent",
+	})
 }
 
-func isUserExistsError(err error) bool {
-	// Check if error indicates user already exists
-	// This is specific to Keycloak error messages
-	return strings.Contains(strings.ToLower(err.Error()), "user exists") ||
-		   strings.Contains(strings.ToLower(err.Error()), "already exists") ||
-		   strings.Contains(strings.ToLower(err.Error()), "conflict")
+// CSRFToken issues a fresh double-submit CSRF token in cookie session mode
+func (h *AuthHandler) CSRFToken(c *gin.Context) {
+	noStore(c)
+
+	if !h.session.enabled() {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Cookie sessions are not enabled",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	token, err := h.session.issueCSRFToken(c, 0)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to generate CSRF token")
+		problem.Write(c, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to generate CSRF token",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "CSRF token issued",
+		Data:    gin.H{"csrf_token": token},
+	})
+}
+
+// refreshToken reads the refresh token from the session cookie in cookie mode,
+// or from the JSON body otherwise. It writes the error response itself.
+func (h *AuthHandler) refreshToken(c *gin.Context) (string, bool) {
+	if h.session.enabled() {
+		token := h.session.refreshToken(c)
+		if token == "" {
+			problem.Write(c, models.ErrorResponse{
+				Error:   "unauthorized",
+				Message: "Session cookie is missing",
+				Code:    http.StatusUnauthorized,
+			})
+			return "", false
+		}
+		return token, true
+	}
+
+	var req models.RefreshRequest
+	if !bindAndValidate(c, h.logger, validation.Refresh, &req) {
+		return "", false
+	}
+	return req.RefreshToken, true
+}
+
+// writeSession moves the refresh token into the session cookies in cookie mode.
+// It writes the error response itself and returns false on failure.
+func (h *AuthHandler) writeSession(c *gin.Context, authResponse *models.AuthResponse) bool {
+	if !h.session.enabled() {
+		return true
+	}
+
+	csrfToken, err := h.session.issueCSRFToken(c, authResponse.RefreshExpiresIn)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to generate CSRF token")
+		problem.Write(c, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to create session",
+			Code:    http.StatusInternalServerError,
+		})
+		return false
+	}
+	h.session.setRefreshToken(c, authResponse.RefreshToken, authResponse.RefreshExpiresIn)
+
+	authResponse.RefreshToken = ""
+	authResponse.CSRFToken = csrfToken
+	return true
 }
@@ -2,12 +2,20 @@ package handlers
 
 import (
 	"auth-service/internal/config"
+	"auth-service/internal/flags"
 	"auth-service/internal/models"
+	"auth-service/internal/password"
+	"auth-service/internal/problem"
+	"auth-service/internal/resilience"
+	"auth-service/internal/services"
+	"auth-service/pkg/logger"
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -55,12 +63,42 @@ func (m *MockKeycloakService) ChangePassword(accessToken, currentPassword, newPa
 	return args.Error(0)
 }
 
+func (m *MockKeycloakService) SendPasswordReset(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+// newTestPasswordPolicy returns the default password policy without history
+func newTestPasswordPolicy() *password.Policy {
+	return password.NewPolicy(config.PasswordConfig{
+		MinLength:        8,
+		MaxLength:        128,
+		RequireUpper:     true,
+		RequireLower:     true,
+		RequireDigit:     true,
+		RequireSpecial:   true,
+		MaxRepeated:      3,
+		DisallowUserInfo: true,
+	}, nil, &logger.Logger{Logger: logrus.New()})
+}
+
+// enableFlags turns the named feature flags on for the test
+func enableFlags(t *testing.T, names ...string) {
+	previous := flags.Default()
+	cfg := config.FlagsConfig{Flags: map[string]config.FlagConfig{}}
+	for _, name := range names {
+		cfg.Flags[name] = config.FlagConfig{Enabled: true}
+	}
+	flags.SetDefault(flags.NewService(cfg))
+	t.Cleanup(func() { flags.SetDefault(previous) })
+}
+
 func TestAuthHandler_Login(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	
 	// Create mock service
 	mockService := new(MockKeycloakService)
-	logger := logrus.New()
+	logger := &logger.Logger{Logger: logrus.New()}
 	
 	// Create handler with mock service
 	handler := &AuthHandler{
@@ -115,6 +153,36 @@ func TestAuthHandler_Login(t *testing.T) {
 			expectedStatus: http.StatusUnauthorized,
 			expectedError:  "authentication_failed",
 		},
+		{
+			name: "account disabled",
+			requestBody: models.LoginRequest{
+				Username: "disabled",
+				Password: "password123",
+			},
+			mockSetup: func() {
+				mockService.On("Login", "disabled", "password123").Return(
+					(*models.AuthResponse)(nil),
+					fmt.Errorf("login: %w", services.ErrAccountDisabled),
+				)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "account_disabled",
+		},
+		{
+			name: "identity provider unavailable",
+			requestBody: models.LoginRequest{
+				Username: "testuser",
+				Password: "password456",
+			},
+			mockSetup: func() {
+				mockService.On("Login", "testuser", "password456").Return(
+					(*models.AuthResponse)(nil),
+					fmt.Errorf("login: %w", services.ErrIdPUnavailable),
+				)
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedError:  "idp_unavailable",
+		},
 	}
 	
 	for _, tt := range tests {
@@ -155,14 +223,16 @@ func TestAuthHandler_Login(t *testing.T) {
 
 func TestAuthHandler_Register(t *testing.T) {
 	gin.SetMode(gin.TestMode)
+	enableFlags(t, flags.Registration)
 	
 	// Create mock service
 	mockService := new(MockKeycloakService)
-	logger := logrus.New()
+	logger := &logger.Logger{Logger: logrus.New()}
 	
 	// Create handler with mock service
 	handler := &AuthHandler{
 		keycloakService: mockService,
+		passwordPolicy:  newTestPasswordPolicy(),
 		logger:          logger,
 	}
 	
@@ -170,7 +240,7 @@ func TestAuthHandler_Register(t *testing.T) {
 	requestBody := models.RegisterRequest{
 		Username:  "newuser",
 		Email:     "newuser@example.com",
-		Password:  "password123",
+		Password:  "Password123!",
 		FirstName: "John",
 		LastName:  "Doe",
 	}
@@ -204,3 +274,424 @@ func TestAuthHandler_Register(t *testing.T) {
 	// Verify mock expectations
 	mockService.AssertExpectations(t)
 }
+
+func newCookieModeHandler(mockService *MockKeycloakService) *AuthHandler {
+	return &AuthHandler{
+		keycloakService: mockService,
+		logger:          &logger.Logger{Logger: logrus.New()},
+		session: sessionCookies{cfg: config.SessionConfig{
+			CookieMode:     true,
+			CookieName:     "refresh_token",
+			CookiePath:     "/api/v1/auth",
+			CookieSecure:   true,
+			CookieSameSite: "strict",
+			CSRFCookieName: "csrf_token",
+			CSRFHeaderName: "X-CSRF-Token",
+		}},
+	}
+}
+
+func findCookie(w *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestAuthHandler_LoginCookieMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockKeycloakService)
+	handler := newCookieModeHandler(mockService)
+
+	mockService.On("Login", "testuser", "Password123!").Return(
+		&models.AuthResponse{
+			AccessToken:      "access-token",
+			RefreshToken:     "refresh-token",
+			TokenType:        "Bearer",
+			ExpiresIn:        300,
+			RefreshExpiresIn: 1800,
+		},
+		nil,
+	)
+
+	jsonBody, _ := json.Marshal(models.LoginRequest{Username: "testuser", Password: "Password123!"})
+	req, _ := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.Login(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "refresh-token", "refresh token must not be in the body")
+
+	refresh := findCookie(w, "refresh_token")
+	if assert.NotNil(t, refresh) {
+		assert.Equal(t, "refresh-token", refresh.Value)
+		assert.True(t, refresh.HttpOnly)
+		assert.True(t, refresh.Secure)
+		assert.Equal(t, http.SameSiteStrictMode, refresh.SameSite)
+		assert.Equal(t, "/api/v1/auth", refresh.Path)
+		assert.Equal(t, 1800, refresh.MaxAge)
+	}
+
+	csrf := findCookie(w, "csrf_token")
+	if assert.NotNil(t, csrf) {
+		assert.False(t, csrf.HttpOnly, "the client must be able to read the CSRF cookie")
+		var response struct {
+			Data models.AuthResponse `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, csrf.Value, response.Data.CSRFToken)
+	}
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthHandler_RefreshCookieMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockKeycloakService)
+	handler := newCookieModeHandler(mockService)
+
+	mockService.On("RefreshToken", "old-refresh-token").Return(
+		&models.AuthResponse{AccessToken: "new-access-token", RefreshToken: "new-refresh-token"},
+		nil,
+	)
+
+	// Refresh reads the cookie and rotates it
+	req, _ := http.NewRequest("POST", "/api/v1/auth/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "old-refresh-token"})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.Refresh(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "new-refresh-token")
+	if refresh := findCookie(w, "refresh_token"); assert.NotNil(t, refresh) {
+		assert.Equal(t, "new-refresh-token", refresh.Value)
+	}
+
+	// Without the cookie there is nothing to refresh
+	req, _ = http.NewRequest("POST", "/api/v1/auth/refresh", nil)
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.Refresh(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthHandler_LogoutCookieMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockKeycloakService)
+	handler := newCookieModeHandler(mockService)
+
+	mockService.On("Logout", "refresh-token").Return(nil)
+
+	req, _ := http.NewRequest("POST", "/api/v1/auth/logout", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh-token"})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.Logout(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	for _, name := range []string{"refresh_token", "csrf_token"} {
+		if cookie := findCookie(w, name); assert.NotNil(t, cookie) {
+			assert.True(t, cookie.MaxAge < 0, "%s cookie is expired", name)
+		}
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthHandler_TokenResponsesAreNotCacheable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockKeycloakService)
+	handler := &AuthHandler{
+		keycloakService: mockService,
+		logger:          &logger.Logger{Logger: logrus.New()},
+	}
+
+	mockService.On("Login", "testuser", "Password123!").Return(&models.AuthResponse{AccessToken: "access-token"}, nil)
+	mockService.On("RefreshToken", "refresh-token").Return(&models.AuthResponse{AccessToken: "access-token"}, nil)
+
+	loginBody, _ := json.Marshal(models.LoginRequest{Username: "testuser", Password: "Password123!"})
+	refreshBody, _ := json.Marshal(models.RefreshRequest{RefreshToken: "refresh-token"})
+
+	for _, tc := range []struct {
+		body    []byte
+		handler gin.HandlerFunc
+	}{
+		{loginBody, handler.Login},
+		{refreshBody, handler.Refresh},
+	} {
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		tc.handler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	}
+}
+
+func TestAuthHandler_RegisterValidationDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	enableFlags(t, flags.Registration)
+
+	mockService := new(MockKeycloakService)
+	handler := &AuthHandler{
+		keycloakService: mockService,
+		passwordPolicy:  newTestPasswordPolicy(),
+		logger:          &logger.Logger{Logger: logrus.New()},
+	}
+
+	jsonBody, _ := json.Marshal(models.RegisterRequest{
+		Username:  "new user",
+		Email:     "newuser@example.com",
+		Password:  "Password123!",
+		FirstName: "John",
+		LastName:  "Doe",
+	})
+	req, _ := http.NewRequest("POST", "/auth/register", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "ro-RO,ro;q=0.9")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.Register(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "ro", w.Header().Get("Content-Language"))
+
+	var response models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "validation_error", response.Error)
+	if assert.Len(t, response.Details, 1) {
+		assert.Equal(t, "username", response.Details[0].Field)
+		assert.Equal(t, "username_format", response.Details[0].Code)
+		assert.Contains(t, response.Details[0].Message, "Numele de utilizator")
+	}
+
+	mockService.AssertNotCalled(t, "Register", mock.Anything)
+}
+
+func TestAuthHandler_RegisterPasswordPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	enableFlags(t, flags.Registration)
+
+	mockService := new(MockKeycloakService)
+	handler := &AuthHandler{
+		keycloakService: mockService,
+		passwordPolicy:  newTestPasswordPolicy(),
+		logger:          &logger.Logger{Logger: logrus.New()},
+	}
+
+	jsonBody, _ := json.Marshal(models.RegisterRequest{
+		Username:  "johndoe",
+		Email:     "john@example.com",
+		Password:  "Johndoe1111!",
+		FirstName: "John",
+		LastName:  "Doe",
+	})
+	req, _ := http.NewRequest("POST", "/auth/register", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.Register(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	var codes []string
+	for _, detail := range response.Details {
+		assert.Equal(t, "password", detail.Field)
+		codes = append(codes, detail.Code)
+	}
+	assert.ElementsMatch(t, []string{"password_repeated", "password_user_info"}, codes)
+
+	mockService.AssertNotCalled(t, "Register", mock.Anything)
+}
+
+func TestAuthHandler_ForgotPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	enableFlags(t, flags.PasswordReset)
+
+	mockService := new(MockKeycloakService)
+	handler := &AuthHandler{
+		keycloakService: mockService,
+		logger:          &logger.Logger{Logger: logrus.New()},
+	}
+
+	// Failures are not revealed to the caller
+	mockService.On("SendPasswordReset", "unknown@example.com").Return(assert.AnError)
+
+	jsonBody, _ := json.Marshal(models.ForgotPasswordRequest{Email: "unknown@example.com"})
+	req, _ := http.NewRequest("POST", "/auth/password/forgot", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.ForgotPassword(c)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthHandler_ForgotPasswordWaitsForPolicySync(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	enableFlags(t, flags.PasswordReset)
+
+	mockService := new(MockKeycloakService)
+	cfg := &config.Config{Password: config.PasswordConfig{SyncKeycloak: true}}
+	handler := NewAuthHandler(cfg, &logger.Logger{Logger: logrus.New()}, mockService, newTestPasswordPolicy())
+	mockService.On("SendPasswordReset", "user@example.com").Return(nil)
+
+	forgot := func() int {
+		jsonBody, _ := json.Marshal(models.ForgotPasswordRequest{Email: "user@example.com"})
+		req, _ := http.NewRequest("POST", "/auth/password/forgot", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		handler.ForgotPassword(c)
+		return w.Code
+	}
+
+	// Keycloak would accept passwords the policy rejects
+	assert.Equal(t, http.StatusServiceUnavailable, forgot())
+	mockService.AssertNotCalled(t, "SendPasswordReset", mock.Anything)
+
+	handler.PasswordPolicySynced()
+	assert.Equal(t, http.StatusAccepted, forgot())
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthHandler_RegisterUserExists(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	enableFlags(t, flags.Registration)
+
+	mockService := new(MockKeycloakService)
+	handler := &AuthHandler{
+		keycloakService: mockService,
+		passwordPolicy:  newTestPasswordPolicy(),
+		logger:          &logger.Logger{Logger: logrus.New()},
+	}
+
+	body := models.RegisterRequest{
+		Username:  "newuser",
+		Email:     "new@example.com",
+		Password:  "Str0ng!Passphrase",
+		FirstName: "New",
+		LastName:  "User",
+	}
+	mockService.On("Register", &body).Return(fmt.Errorf("create user: %w", services.ErrUserExists))
+
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", "/auth/register", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/problem+json")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.Register(c)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+	var response models.ProblemDetails
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, problem.TypePrefix+"user_exists", response.Type)
+	assert.Equal(t, "Conflict", response.Title)
+	assert.Equal(t, http.StatusConflict, response.Status)
+	assert.Equal(t, "user_exists", response.Error)
+	assert.Equal(t, "/auth/register", response.Instance)
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthHandler_RegisterDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	enableFlags(t)
+
+	mockService := new(MockKeycloakService)
+	handler := &AuthHandler{
+		keycloakService: mockService,
+		passwordPolicy:  newTestPasswordPolicy(),
+		logger:          &logger.Logger{Logger: logrus.New()},
+	}
+
+	jsonBody, _ := json.Marshal(models.RegisterRequest{
+		Username:  "newuser",
+		Email:     "newuser@example.com",
+		Password:  "Password123!",
+		FirstName: "John",
+		LastName:  "Doe",
+	})
+	req, _ := http.NewRequest("POST", "/auth/register", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.Register(c)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var response models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "registration_disabled", response.Error)
+	mockService.AssertNotCalled(t, "Register", mock.Anything)
+}
+
+func TestAuthHandler_LoginBreakerOpen(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockKeycloakService)
+	handler := &AuthHandler{
+		keycloakService: mockService,
+		logger:          &logger.Logger{Logger: logrus.New()},
+	}
+
+	openErr := &resilience.OpenError{Name: services.KeycloakBreaker, RetryAfter: 29500 * time.Millisecond}
+	mockService.On("Login", "testuser", "Password123!").Return(
+		(*models.AuthResponse)(nil),
+		fmt.Errorf("login: %w: %w", services.ErrIdPUnavailable, openErr),
+	)
+
+	jsonBody, _ := json.Marshal(models.LoginRequest{Username: "testuser", Password: "Password123!"})
+	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.Login(c)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+	var response models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "idp_unavailable", response.Error)
+}
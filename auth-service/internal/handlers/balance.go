@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"auth-service/internal/balance"
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/problem"
+	"auth-service/internal/validation"
+	"auth-service/pkg/logger"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxGrant bounds the credits granted at once, to catch typos
+const maxGrant = 1_000_000_000
+
+// BalanceHandler serves the user's credit balance (/api/balance) and the
+// admin endpoints managing the balances of others (/api/admin/balance)
+type BalanceHandler struct {
+	ledger *balance.Ledger
+	logger *logger.Logger
+}
+
+// NewBalanceHandler creates a new balance handler
+func NewBalanceHandler(cfg *config.Config, logger *logger.Logger, ledger *balance.Ledger) *BalanceHandler {
+	return &BalanceHandler{
+		ledger: ledger,
+		logger: logger,
+	}
+}
+
+// Get returns the caller's balance in the client's TBalanceResponse shape
+func (h *BalanceHandler) Get(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	credits, err := h.ledger.Balance(c.Request.Context(), userID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get balance")
+		return
+	}
+	c.JSON(http.StatusOK, models.BalanceResponse{TokenCredits: credits})
+}
+
+// GetAccount returns the balance and ledger of the user named by :userId
+func (h *BalanceHandler) GetAccount(c *gin.Context) {
+	userID := c.Param("userId")
+	ctx := c.Request.Context()
+
+	entries, err := h.ledger.Entries(ctx, userID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get ledger")
+		return
+	}
+	credits, err := h.ledger.Balance(ctx, userID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get balance")
+		return
+	}
+	c.JSON(http.StatusOK, models.AccountBalance{UserID: userID, TokenCredits: credits, Entries: entries})
+}
+
+// Grant credits the user named by :userId and returns the ledger entry
+func (h *BalanceHandler) Grant(c *gin.Context) {
+	adminID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	userID := c.Param("userId")
+
+	var req models.GrantCreditsRequest
+	if !bindAndValidate(c, h.logger, validation.GrantCredits, &req, func() []validation.Error {
+		if req.Amount == nil {
+			return []validation.Error{{Field: "amount", Code: "required"}}
+		}
+		return validation.CheckRange("amount", req.Amount, 1, maxGrant)
+	}) {
+		return
+	}
+
+	entry, err := h.ledger.Grant(c.Request.Context(), userID, *req.Amount, adminID, req.Note)
+	if err != nil {
+		h.writeError(c, err, "Failed to grant credits")
+		return
+	}
+
+	h.logger.WithField("user_id", userID).WithField("admin_id", adminID).WithField("amount", entry.Amount).Info("Credits granted")
+	c.JSON(http.StatusCreated, entry)
+}
+
+// writeError maps ledger errors to responses
+func (h *BalanceHandler) writeError(c *gin.Context, err error, msg string) {
+	if errors.Is(err, balance.ErrConflict) {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "balance_conflict",
+			Message: "The balance changed concurrently, please try again",
+			Code:    http.StatusConflict,
+		})
+		return
+	}
+	h.logger.WithError(err).Error(msg)
+	problem.Write(c, models.ErrorResponse{
+		Error:   "internal_error",
+		Message: msg,
+		Code:    http.StatusInternalServerError,
+	})
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"auth-service/internal/balance"
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/pkg/logger"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBalanceRouter mounts the balance routes behind testUser; RequireRole
+// is tested with the middleware
+func newBalanceRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	store, err := balance.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	handler := NewBalanceHandler(&config.Config{}, &logger.Logger{Logger: logrus.New()}, balance.NewLedger(store, 1000))
+
+	r := gin.New()
+	api := r.Group("/api", testUser())
+	api.GET("/balance", handler.Get)
+	api.GET("/admin/balance/:userId", handler.GetAccount)
+	api.POST("/admin/balance/:userId/credits", handler.Grant)
+	return r
+}
+
+func TestBalanceHandler_GrantAndGet(t *testing.T) {
+	r := newBalanceRouter(t)
+
+	w := doJSON(r, "GET", "/api/balance", "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"tokenCredits": 1000, "autoRefillEnabled": false}`, w.Body.String())
+
+	amount := int64(500)
+	w = doJSON(r, "POST", "/api/admin/balance/user-1/credits", "admin-1", models.GrantCreditsRequest{Amount: &amount, Note: "Support refund"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var entry models.LedgerEntry
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entry))
+	assert.Equal(t, balance.TypeCredit, entry.Type)
+	assert.Equal(t, int64(1500), entry.Balance)
+	assert.Equal(t, "admin-1", entry.Actor)
+
+	w = doJSON(r, "GET", "/api/balance", "user-1", nil)
+	assert.JSONEq(t, `{"tokenCredits": 1500, "autoRefillEnabled": false}`, w.Body.String())
+
+	w = doJSON(r, "GET", "/api/admin/balance/user-1", "admin-1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var account models.AccountBalance
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &account))
+	assert.Equal(t, int64(1500), account.TokenCredits)
+	assert.Len(t, account.Entries, 2)
+}
+
+func TestBalanceHandler_GrantValidation(t *testing.T) {
+	r := newBalanceRouter(t)
+
+	w := doJSON(r, "POST", "/api/admin/balance/user-1/credits", "admin-1", models.GrantCreditsRequest{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"amount"`)
+
+	amount := int64(-5)
+	w = doJSON(r, "POST", "/api/admin/balance/user-1/credits", "admin-1", models.GrantCreditsRequest{Amount: &amount})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "range")
+
+	w = doJSON(r, "GET", "/api/admin/balance/user-1", "admin-1", nil)
+	assert.Contains(t, w.Body.String(), `"entries":[]`, "rejected grants write nothing")
+}
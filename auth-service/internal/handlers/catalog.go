@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"auth-service/internal/catalog"
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/problem"
+	"auth-service/pkg/logger"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// importTimeout bounds reading an imported feed and writing its result
+const importTimeout = 10 * time.Minute
+
+// Price history ranges, in days
+const (
+	defaultHistoryDays = 90
+	maxHistoryDays     = 366
+)
+
+// CatalogHandler serves product search (/api/v1/products) and the admin
+// endpoints importing retailer feeds (/api/admin/catalog)
+type CatalogHandler struct {
+	catalog     *catalog.Catalog
+	feeds       *catalog.Feeds
+	maxFeedSize int64
+	logger      *logger.Logger
+}
+
+// NewCatalogHandler creates a new catalog handler
+func NewCatalogHandler(cfg *config.Config, logger *logger.Logger, catalog *catalog.Catalog, feeds *catalog.Feeds) *CatalogHandler {
+	return &CatalogHandler{
+		catalog:     catalog,
+		feeds:       feeds,
+		maxFeedSize: cfg.Catalog.MaxFeedSize,
+		logger:      logger,
+	}
+}
+
+// Search returns a page of products with their facets. Query parameters: q,
+// category, brand (repeatable), min_price and max_price (in minor units),
+// sort (relevance, price_asc, price_desc, name or newest), mode (keyword or
+// hybrid), page and page_size.
+func (h *CatalogHandler) Search(c *gin.Context) {
+	q := catalog.SearchQuery{
+		Text:     c.Query("q"),
+		Category: c.Query("category"),
+		Brands:   c.QueryArray("brand"),
+		Sort:     c.Query("sort"),
+		Mode:     c.Query("mode"),
+	}
+	if len(q.Text) > maxSearchQuery {
+		h.invalidQuery(c, "q must be at most 500 characters")
+		return
+	}
+	for _, param := range []struct {
+		name  string
+		value **int64
+	}{{"min_price", &q.MinPrice}, {"max_price", &q.MaxPrice}} {
+		if value := c.Query(param.name); value != "" {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 0 {
+				h.invalidQuery(c, param.name+" must be a whole number of minor units, such as 1999 for 19.99")
+				return
+			}
+			*param.value = &n
+		}
+	}
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"page", &q.Page}, {"page_size", &q.PageSize}} {
+		if value := c.Query(param.name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				h.invalidQuery(c, param.name+" must be a positive number")
+				return
+			}
+			*param.value = n
+		}
+	}
+
+	response, err := h.catalog.Search(c.Request.Context(), q)
+	if errors.Is(err, catalog.ErrInvalidQuery) {
+		h.invalidQuery(c, "The query has no words to search for or too many, or the sort order or mode is unknown")
+		return
+	}
+	if errors.Is(err, catalog.ErrSemanticUnavailable) {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "semantic_search_unavailable",
+			Message: "Semantic search is not enabled; use mode=keyword",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Product search failed")
+		problem.Write(c, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Product search failed",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Get returns a product with its variants, offers, retailers and category path
+func (h *CatalogHandler) Get(c *gin.Context) {
+	product, err := h.catalog.Product(c.Request.Context(), c.Param("productId"))
+	if err != nil {
+		h.writeProductError(c, err, "Failed to get product")
+		return
+	}
+	c.JSON(http.StatusOK, product)
+}
+
+// Offers returns a product's offers, best first: in stock, then by price
+// plus shipping in the catalog currency. The variant query parameter keeps
+// the offers of one variant.
+func (h *CatalogHandler) Offers(c *gin.Context) {
+	offers, err := h.catalog.Offers(c.Request.Context(), c.Param("productId"), c.Query("variant"))
+	if err != nil {
+		h.writeProductError(c, err, "Failed to get offers")
+		return
+	}
+	c.JSON(http.StatusOK, offers)
+}
+
+// PriceHistory returns a product's daily minimum, maximum and average
+// prices. Query parameters: from and to (dates, both inclusive; the last
+// 90 days by default) and retailer.
+func (h *CatalogHandler) PriceHistory(c *gin.Context) {
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if value := c.Query("to"); value != "" {
+		day, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			h.invalidQuery(c, "to must be a date such as 2024-03-01")
+			return
+		}
+		to = day
+	}
+	from := to.AddDate(0, 0, 1-defaultHistoryDays)
+	if value := c.Query("from"); value != "" {
+		day, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			h.invalidQuery(c, "from must be a date such as 2024-03-01")
+			return
+		}
+		from = day
+	}
+	if from.After(to) || to.Sub(from) >= maxHistoryDays*24*time.Hour {
+		h.invalidQuery(c, "from must not be after to, and the range must be at most 366 days")
+		return
+	}
+
+	history, err := h.catalog.PriceHistory(c.Request.Context(), c.Param("productId"), c.Query("retailer"), from, to)
+	if err != nil {
+		h.writeProductError(c, err, "Failed to get price history")
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// Categories returns the category tree as a flat list, parents first
+func (h *CatalogHandler) Categories(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"categories": h.catalog.Categories(c.Request.Context())})
+}
+
+// Feeds lists the feeds that can be imported
+func (h *CatalogHandler) Feeds(c *gin.Context) {
+	feeds := []models.CatalogFeed{}
+	for _, feed := range h.feeds.List() {
+		feeds = append(feeds, models.CatalogFeed{ID: feed.ID, Retailer: feed.Retailer, Format: feed.Format, Currency: feed.Currency})
+	}
+	c.JSON(http.StatusOK, gin.H{"feeds": feeds})
+}
+
+// Import merges the feed in the request body into the catalog using the
+// field mapping of the feed named by :feedId
+func (h *CatalogHandler) Import(c *gin.Context) {
+	feed, ok := h.feeds.Get(c.Param("feedId"))
+	if !ok {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "feed_not_found",
+			Message: "Feed not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	extendDeadlines(c, importTimeout)
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, h.maxFeedSize)
+	result, err := h.catalog.Import(c.Request.Context(), feed, body)
+	var maxBytes *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytes):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "feed_too_large",
+			Message: "The feed exceeds the maximum size",
+			Code:    http.StatusRequestEntityTooLarge,
+		})
+	case errors.Is(err, catalog.ErrInvalidFeed):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "invalid_feed",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+	case err != nil:
+		h.logger.WithError(err).WithField("feed", feed.ID).Error("Feed import failed")
+		problem.Write(c, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Feed import failed",
+			Code:    http.StatusInternalServerError,
+		})
+	default:
+		h.logger.WithField("feed", feed.ID).WithField("created", result.Created).WithField("updated", result.Updated).
+			WithField("removed", result.Removed).WithField("skipped", result.Skipped).Info("Feed imported")
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// writeProductError maps catalog errors for a product to responses
+func (h *CatalogHandler) writeProductError(c *gin.Context, err error, msg string) {
+	if errors.Is(err, catalog.ErrNotFound) {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "product_not_found",
+			Message: "Product not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	h.logger.WithError(err).Error(msg)
+	problem.Write(c, models.ErrorResponse{
+		Error:   "internal_error",
+		Message: msg,
+		Code:    http.StatusInternalServerError,
+	})
+}
+
+func (h *CatalogHandler) invalidQuery(c *gin.Context, msg string) {
+	problem.Write(c, models.ErrorResponse{
+		Error:   "invalid_query",
+		Message: msg,
+		Code:    http.StatusBadRequest,
+	})
+}
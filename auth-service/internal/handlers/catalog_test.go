@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"auth-service/internal/catalog"
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/pkg/logger"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const catalogTestFeeds = `
+feeds:
+  - id: acme
+    format: csv
+    fields: {sku: sku, gtin: gtin, name: name, brand: brand, category: category, price: price}
+  - id: shoply
+    format: csv
+    fields: {sku: sku, gtin: gtin, name: name, price: price, shipping: shipping}
+`
+
+// newCatalogRouter mounts the catalog routes without authentication, which
+// is tested with the middleware
+func newCatalogRouter(t *testing.T, maxFeedSize int64) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	history, err := catalog.NewFileHistory(dir, 365)
+	require.NoError(t, err)
+	catalogConfig := config.CatalogConfig{Currency: "EUR", MaxFeedSize: maxFeedSize}
+	products, err := catalog.NewCatalog(dir, catalogConfig, history, &logger.Logger{Logger: logrus.New()})
+	require.NoError(t, err)
+	feeds, err := catalog.ParseFeeds([]byte(catalogTestFeeds), "EUR")
+	require.NoError(t, err)
+	cfg := &config.Config{Catalog: catalogConfig}
+	handler := NewCatalogHandler(cfg, &logger.Logger{Logger: logrus.New()}, products, feeds)
+
+	r := gin.New()
+	r.GET("/api/v1/products/search", handler.Search)
+	r.GET("/api/v1/products/categories", handler.Categories)
+	r.GET("/api/v1/products/:productId", handler.Get)
+	r.GET("/api/v1/products/:productId/offers", handler.Offers)
+	r.GET("/api/v1/products/:productId/price-history", handler.PriceHistory)
+	r.GET("/api/admin/catalog/feeds", handler.Feeds)
+	r.POST("/api/admin/catalog/feeds/:feedId/import", handler.Import)
+	return r
+}
+
+func postFeed(r *gin.Engine, feedID, content string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/admin/catalog/feeds/"+feedID+"/import", strings.NewReader(content))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCatalogHandler_ImportAndSearch(t *testing.T) {
+	r := newCatalogRouter(t, 1<<20)
+
+	w := postFeed(r, "acme", "sku,gtin,name,brand,category,price\n"+
+		"A-1,4006381333931,Trail Runner,Stridex,Sports > Running,89.99\n"+
+		"A-2,,Yoga Mat,Zenly,Sports > Yoga,24.50\n"+
+		"A-3,,Mystery,,,\n")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var result models.ImportResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 2, result.Created)
+	assert.Equal(t, 1, result.Skipped)
+
+	w = doJSON(r, "GET", "/api/v1/products/search?q=runner&category=sports&brand=stridex&max_price=9000", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var page models.ProductSearchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	require.Len(t, page.Products, 1)
+	assert.Equal(t, "Trail Runner", page.Products[0].Name)
+	assert.Equal(t, &models.Money{Amount: 8999, Currency: "EUR"}, page.Products[0].MinPrice)
+	assert.Equal(t, 1, page.Page)
+	assert.Equal(t, catalog.DefaultPageSize, page.PageSize)
+	assert.Equal(t, catalog.ModeKeyword, page.Mode)
+
+	w = doJSON(r, "GET", "/api/v1/products/"+page.Products[0].ID, "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"category_path":[{"id":"sports","name":"Sports"},{"id":"sports/running","name":"Running","parent_id":"sports"}]`)
+
+	w = doJSON(r, "GET", "/api/v1/products/missing", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "product_not_found")
+
+	w = doJSON(r, "GET", "/api/v1/products/categories", "", nil)
+	assert.Contains(t, w.Body.String(), `"sports/yoga"`)
+
+	w = doJSON(r, "GET", "/api/admin/catalog/feeds", "", nil)
+	assert.JSONEq(t, `{"feeds": [
+		{"id": "acme", "retailer": {"id": "acme", "name": "acme"}, "format": "csv", "currency": "EUR"},
+		{"id": "shoply", "retailer": {"id": "shoply", "name": "shoply"}, "format": "csv", "currency": "EUR"}
+	]}`, w.Body.String())
+}
+
+func TestCatalogHandler_SlowImportOutlastsServerTimeouts(t *testing.T) {
+	server := httptest.NewUnstartedServer(newCatalogRouter(t, 1<<20))
+	server.Config.ReadTimeout = 100 * time.Millisecond
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	content := "sku,gtin,name,brand,category,price\nA-1,,Trail Runner,Stridex,Sports > Running,89.99\n"
+	req, _ := http.NewRequest("POST", server.URL+"/api/admin/catalog/feeds/acme/import", &slowReader{data: []byte(content), delay: 3 * time.Millisecond})
+	req.ContentLength = int64(len(content))
+	req.Header.Set("Content-Type", "text/csv")
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err, "the result arrives after the server's WriteTimeout")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var result models.ImportResult
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, 1, result.Created)
+}
+
+func TestCatalogHandler_OffersAndPriceHistory(t *testing.T) {
+	r := newCatalogRouter(t, 1<<20)
+	require.Equal(t, http.StatusOK, postFeed(r, "acme", "sku,gtin,name,brand,category,price\nA-1,4006381333931,Trail Runner,,,89.99\n").Code)
+	require.Equal(t, http.StatusOK, postFeed(r, "shoply", "sku,gtin,name,price,shipping\nS-1,4006381333931,Trail Runner,84.99,5.95\n").Code)
+
+	w := doJSON(r, "GET", "/api/v1/products/search?q=runner", "", nil)
+	var page models.ProductSearchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	require.Len(t, page.Products, 1)
+	productID := page.Products[0].ID
+
+	w = doJSON(r, "GET", "/api/v1/products/"+productID+"/offers", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var offers models.ProductOffers
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &offers))
+	require.Len(t, offers.Offers, 2)
+	require.NotNil(t, offers.Best)
+	assert.Equal(t, "acme", offers.Best.Retailer.ID, "shipping makes the cheaper price dearer")
+	assert.Equal(t, &models.Money{Amount: 9094, Currency: "EUR"}, offers.Offers[1].Total)
+
+	w = doJSON(r, "GET", "/api/v1/products/"+productID+"/offers?variant=missing", "", nil)
+	assert.JSONEq(t, `{"product_id": "`+productID+`", "best": null, "offers": []}`, w.Body.String())
+
+	w = doJSON(r, "GET", "/api/v1/products/"+productID+"/price-history?retailer=shoply", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var history models.PriceHistoryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Equal(t, "EUR", history.Currency)
+	require.Len(t, history.Buckets, 1)
+	assert.Equal(t, time.Now().UTC().Format(time.DateOnly), history.Buckets[0].Date)
+	assert.Equal(t, int64(8499), history.Buckets[0].Avg)
+
+	w = doJSON(r, "GET", "/api/v1/products/missing/offers", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doJSON(r, "GET", "/api/v1/products/missing/price-history", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	for _, query := range []string{"from=yesterday", "to=2024-13-01", "from=2024-03-02&to=2024-03-01", "from=2023-01-01&to=2024-03-01"} {
+		w := doJSON(r, "GET", "/api/v1/products/"+productID+"/price-history?"+query, "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		assert.Contains(t, w.Body.String(), "invalid_query", query)
+	}
+}
+
+func TestCatalogHandler_InvalidRequests(t *testing.T) {
+	r := newCatalogRouter(t, 64)
+
+	for _, query := range []string{"q=%3F%21", "sort=cheapest", "min_price=9.99", "max_price=-1", "page=0", "page_size=x", "mode=vibes"} {
+		w := doJSON(r, "GET", "/api/v1/products/search?"+query, "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		assert.Contains(t, w.Body.String(), "invalid_query", query)
+	}
+
+	w := doJSON(r, "GET", "/api/v1/products/search?q=shoe&mode=hybrid", "", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "semantic_search_unavailable")
+
+	w = postFeed(r, "missing", "sku,name,price\n")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = postFeed(r, "acme", "sku,name\nA-1,Shoe\n")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_feed")
+
+	w = postFeed(r, "acme", "sku,gtin,name,brand,category,price\n"+strings.Repeat("A-1,,Shoe,,,10\n", 10))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/conversations"
+	"auth-service/internal/messages"
+	"auth-service/internal/models"
+	"auth-service/internal/problem"
+	"auth-service/internal/validation"
+	"auth-service/pkg/logger"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConversationHandler serves the LibreChat conversations API (/api/convos).
+// Every request is scoped to the user_id set by AuthMiddleware.
+type ConversationHandler struct {
+	store    conversations.ConversationStore
+	messages messages.MessageStore
+	logger   *logger.Logger
+}
+
+// NewConversationHandler creates a new conversation handler. Deleting a
+// conversation also deletes its messages from messageStore.
+func NewConversationHandler(cfg *config.Config, logger *logger.Logger, store conversations.ConversationStore, messageStore messages.MessageStore) *ConversationHandler {
+	return &ConversationHandler{
+		store:    store,
+		messages: messageStore,
+		logger:   logger,
+	}
+}
+
+// List returns one page of the user's conversations. Query parameters:
+// cursor, limit, sortBy (updatedAt, createdAt, title), sortDirection (asc,
+// desc) and isArchived.
+func (h *ConversationHandler) List(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	opts := conversations.ListOptions{
+		Cursor:        c.Query("cursor"),
+		SortBy:        c.Query("sortBy"),
+		SortDirection: c.Query("sortDirection"),
+		IsArchived:    c.Query("isArchived") == "true",
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			problem.Write(c, models.ErrorResponse{
+				Error:   "invalid_query",
+				Message: "limit must be a positive number",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		opts.Limit = n
+	}
+
+	page, err := h.store.List(c.Request.Context(), userID, opts)
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to list conversations")
+		return
+	}
+
+	response := models.ConversationListResponse{Conversations: page.Conversations}
+	if response.Conversations == nil {
+		response.Conversations = []models.Conversation{}
+	}
+	if page.NextCursor != "" {
+		response.NextCursor = &page.NextCursor
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Get returns one of the user's conversations
+func (h *ConversationHandler) Get(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	convo, err := h.store.Get(c.Request.Context(), userID, c.Param("conversationId"))
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to get conversation")
+		return
+	}
+	c.JSON(http.StatusOK, convo)
+}
+
+// Create starts a new conversation
+func (h *ConversationHandler) Create(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateConversationRequest
+	if !bindAndValidate(c, h.logger, validation.CreateConversation, &req) {
+		return
+	}
+
+	convo := &models.Conversation{
+		ConversationID: req.ConversationID,
+		Title:          req.Title,
+		Endpoint:       req.Endpoint,
+		EndpointType:   req.EndpointType,
+		Model:          req.Model,
+	}
+	if err := h.store.Create(c.Request.Context(), userID, convo); err != nil {
+		h.writeStoreError(c, err, "Failed to create conversation")
+		return
+	}
+
+	h.logger.WithField("user_id", userID).WithField("conversation_id", convo.ConversationID).Info("Conversation created")
+	c.JSON(http.StatusCreated, convo)
+}
+
+// Update renames a conversation
+func (h *ConversationHandler) Update(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateConversationRequest
+	if !bindArgAndValidate(c, h.logger, validation.UpdateConversation, &req) {
+		return
+	}
+
+	convo, err := h.store.UpdateTitle(c.Request.Context(), userID, req.ConversationID, req.Title)
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to update conversation")
+		return
+	}
+	c.JSON(http.StatusOK, convo)
+}
+
+// Archive archives or restores a conversation
+func (h *ConversationHandler) Archive(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.ArchiveConversationRequest
+	if !bindArgAndValidate(c, h.logger, validation.ConversationRef, &req) {
+		return
+	}
+
+	convo, err := h.store.SetArchived(c.Request.Context(), userID, req.ConversationID, req.IsArchived)
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to archive conversation")
+		return
+	}
+	c.JSON(http.StatusOK, convo)
+}
+
+// Delete removes a conversation and its messages
+func (h *ConversationHandler) Delete(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.DeleteConversationRequest
+	if !bindArgAndValidate(c, h.logger, validation.ConversationRef, &req) {
+		return
+	}
+
+	if err := h.store.Delete(c.Request.Context(), userID, req.ConversationID); err != nil {
+		h.writeStoreError(c, err, "Failed to delete conversation")
+		return
+	}
+	if err := h.messages.DeleteConversation(c.Request.Context(), userID, req.ConversationID); err != nil {
+		// The conversation is gone; leftover messages are unreachable
+		h.logger.WithError(err).WithField("conversation_id", req.ConversationID).Warn("Failed to delete conversation messages")
+	}
+
+	h.logger.WithField("user_id", userID).WithField("conversation_id", req.ConversationID).Info("Conversation deleted")
+	c.JSON(http.StatusOK, models.DeleteResponse{Acknowledged: true, DeletedCount: 1})
+}
+
+// writeStoreError maps conversation store errors to responses
+func (h *ConversationHandler) writeStoreError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, conversations.ErrNotFound):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "conversation_not_found",
+			Message: "Conversation not found",
+			Code:    http.StatusNotFound,
+		})
+	case errors.Is(err, conversations.ErrExists):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "conversation_exists",
+			Message: "A conversation with this ID already exists",
+			Code:    http.StatusConflict,
+		})
+	case errors.Is(err, conversations.ErrInvalidCursor), errors.Is(err, conversations.ErrInvalidOptions):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "invalid_query",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+	default:
+		h.logger.WithError(err).Error(msg)
+		problem.Write(c, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: msg,
+			Code:    http.StatusInternalServerError,
+		})
+	}
+}
+
+// requireUser returns the user_id set by AuthMiddleware, writing a 401 when
+// the route was mounted without it
+func requireUser(c *gin.Context) (string, bool) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not found in context",
+			Code:    http.StatusUnauthorized,
+		})
+		return "", false
+	}
+	return userID, true
+}
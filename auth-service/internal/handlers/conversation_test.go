@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"auth-service/internal/conversations"
+	"auth-service/internal/messages"
+	"auth-service/internal/models"
+	"auth-service/pkg/logger"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newConversationRouter mounts the conversation routes behind testUser
+func newConversationRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	store, err := conversations.NewFileStore(dir)
+	require.NoError(t, err)
+	messageStore, err := messages.NewFileStore(dir)
+	require.NoError(t, err)
+	handler := NewConversationHandler(nil, &logger.Logger{Logger: logrus.New()}, store, messageStore)
+
+	r := gin.New()
+	convos := r.Group("/api/convos", testUser())
+	convos.GET("", handler.List)
+	convos.POST("", handler.Create)
+	convos.DELETE("", handler.Delete)
+	convos.GET("/:conversationId", handler.Get)
+	convos.POST("/update", handler.Update)
+	convos.POST("/archive", handler.Archive)
+	return r
+}
+
+func doJSON(r *gin.Engine, method, path, user string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, _ := http.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestConversationHandler_Lifecycle(t *testing.T) {
+	r := newConversationRouter(t)
+
+	w := doJSON(r, "POST", "/api/convos", "user-1", models.CreateConversationRequest{Title: "Groceries", Endpoint: "openAI"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created models.Conversation
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	id := created.ConversationID
+	assert.Equal(t, "user-1", created.User)
+
+	w = doJSON(r, "POST", "/api/convos/update", "user-1", gin.H{"arg": gin.H{"conversationId": id, "title": "Weekly groceries"}})
+	require.Equal(t, http.StatusOK, w.Code)
+	var updated models.Conversation
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, "Weekly groceries", updated.Title)
+
+	w = doJSON(r, "GET", "/api/convos?limit=10", "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"conversations":[`+mustJSON(t, updated)+`],"nextCursor":null}`, w.Body.String())
+
+	w = doJSON(r, "POST", "/api/convos/archive", "user-1", gin.H{"arg": gin.H{"conversationId": id, "isArchived": true}})
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(r, "GET", "/api/convos?isArchived=true", "user-1", nil)
+	assert.Contains(t, w.Body.String(), id)
+
+	w = doJSON(r, "DELETE", "/api/convos", "user-1", gin.H{"arg": gin.H{"conversationId": id}})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"acknowledged":true,"deletedCount":1}`, w.Body.String())
+
+	w = doJSON(r, "GET", "/api/convos/"+id, "user-1", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestConversationHandler_ScopedToUser(t *testing.T) {
+	r := newConversationRouter(t)
+
+	w := doJSON(r, "POST", "/api/convos", "user-1", models.CreateConversationRequest{ConversationID: "c1"})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	tests := []struct {
+		method string
+		path   string
+		body   interface{}
+	}{
+		{"GET", "/api/convos/c1", nil},
+		{"POST", "/api/convos/update", gin.H{"arg": gin.H{"conversationId": "c1", "title": "Mine now"}}},
+		{"POST", "/api/convos/archive", gin.H{"arg": gin.H{"conversationId": "c1", "isArchived": true}}},
+		{"DELETE", "/api/convos", gin.H{"arg": gin.H{"conversationId": "c1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := doJSON(r, tt.method, tt.path, "user-2", tt.body)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+
+			var response models.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, "conversation_not_found", response.Error)
+		})
+	}
+
+	w = doJSON(r, "GET", "/api/convos", "user-2", nil)
+	assert.JSONEq(t, `{"conversations":[],"nextCursor":null}`, w.Body.String())
+
+	w = doJSON(r, "GET", "/api/convos", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestConversationHandler_Validation(t *testing.T) {
+	r := newConversationRouter(t)
+
+	w := doJSON(r, "POST", "/api/convos/update", "user-1", gin.H{"arg": gin.H{"conversationId": "c1"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response models.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.Len(t, response.Details, 1) {
+		assert.Equal(t, "title", response.Details[0].Field)
+		assert.Equal(t, "required", response.Details[0].Code)
+	}
+
+	w = doJSON(r, "POST", "/api/convos", "user-1", models.CreateConversationRequest{ConversationID: "../etc"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(r, "GET", "/api/convos?sortBy=size", "user-1", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON(r, "GET", "/api/convos?cursor=bogus", "user-1", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
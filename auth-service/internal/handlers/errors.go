@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/problem"
+	"auth-service/internal/resilience"
+	"auth-service/internal/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serviceErrors maps the AuthService domain errors to responses, so every
+// endpoint reports them with the same status and error code
+var serviceErrors = []struct {
+	err  error
+	resp models.ErrorResponse
+}{
+	{services.ErrInvalidCredentials, models.ErrorResponse{
+		Error:   "authentication_failed",
+		Message: "Invalid username or password",
+		Code:    http.StatusUnauthorized,
+	}},
+	{services.ErrAccountDisabled, models.ErrorResponse{
+		Error:   "account_disabled",
+		Message: "This account is disabled",
+		Code:    http.StatusForbidden,
+	}},
+	{services.ErrUserExists, models.ErrorResponse{
+		Error:   "user_exists",
+		Message: "Username or email already exists",
+		Code:    http.StatusConflict,
+	}},
+	{services.ErrTokenExpired, models.ErrorResponse{
+		Error:   "token_expired",
+		Message: "Token is expired or has been revoked",
+		Code:    http.StatusUnauthorized,
+	}},
+	{services.ErrIdPUnavailable, models.ErrorResponse{
+		Error:   "idp_unavailable",
+		Message: "Identity provider is unavailable. Please try again later.",
+		Code:    http.StatusServiceUnavailable,
+	}},
+	{services.ErrRateLimited, models.ErrorResponse{
+		Error:   "rate_limit_exceeded",
+		Message: "Too many requests. Please try again later.",
+		Code:    http.StatusTooManyRequests,
+	}},
+}
+
+// serviceErrorResponse returns the response for a domain error, or fallback
+// for errors the service could not classify
+func serviceErrorResponse(err error, fallback models.ErrorResponse) models.ErrorResponse {
+	for _, e := range serviceErrors {
+		if errors.Is(err, e.err) {
+			return e.resp
+		}
+	}
+	return fallback
+}
+
+// writeServiceError writes the response for an AuthService error. Calls
+// rejected by the open identity provider breaker also get a Retry-After.
+func writeServiceError(c *gin.Context, err error, fallback models.ErrorResponse) {
+	if seconds, ok := resilience.RetryAfterSeconds(err); ok {
+		c.Header("Retry-After", strconv.Itoa(seconds))
+	}
+	problem.Write(c, serviceErrorResponse(err, fallback))
+}
@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/files"
+	"auth-service/internal/models"
+	"auth-service/internal/problem"
+	"auth-service/internal/validation"
+	"auth-service/pkg/logger"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxFormOverhead is how much an upload body may exceed the file size limit
+// for the multipart framing and the other form fields
+const maxFormOverhead = 1 << 20
+
+// maxFormField is the size limit of a form field other than the file
+const maxFormField = 4 << 10
+
+// minTransferRate is the slowest connection, in bytes per second, that file
+// transfers are given time for
+const minTransferRate = 32 << 10
+
+// transferTimeout bounds moving size bytes at minTransferRate, plus a minute
+// for the rest of the request. Transfers of large files outlast the
+// server's ReadTimeout and WriteTimeout on slow connections.
+func transferTimeout(size int64) time.Duration {
+	return time.Minute + time.Duration(size/minTransferRate)*time.Second
+}
+
+// extendDeadlines gives the request timeout to read its body and write its
+// response, in place of the server's ReadTimeout and WriteTimeout
+func extendDeadlines(c *gin.Context, timeout time.Duration) {
+	controller := http.NewResponseController(c.Writer)
+	deadline := time.Now().Add(timeout)
+	_ = controller.SetReadDeadline(deadline)
+	_ = controller.SetWriteDeadline(deadline)
+}
+
+// FileHandler serves the LibreChat files API (/api/files). Every request is
+// scoped to the user_id set by AuthMiddleware.
+type FileHandler struct {
+	service *files.Service
+	logger  *logger.Logger
+}
+
+// NewFileHandler creates a new file handler
+func NewFileHandler(cfg *config.Config, logger *logger.Logger, service *files.Service) *FileHandler {
+	return &FileHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Upload stores the file of a multipart form. The file is streamed to the
+// blob store as it arrives; its form fields are file_id (the client's
+// temporary ID) and conversationId.
+func (h *FileHandler) Upload(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	maxBody := h.service.Limits().MaxFileSize + maxFormOverhead
+	extendDeadlines(c, transferTimeout(maxBody))
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBody)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "invalid_upload",
+			Message: "Expected a multipart form",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req models.UploadFileRequest
+	var file *models.File
+	discard := func() {
+		if file != nil {
+			h.service.Discard(ctx, file)
+		}
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			discard()
+			h.writeFileError(c, err)
+			return
+		}
+
+		switch part.FormName() {
+		case "file":
+			if file != nil {
+				part.Close()
+				discard()
+				problem.Write(c, models.ErrorResponse{
+					Error:   "invalid_upload",
+					Message: "Upload one file at a time",
+					Code:    http.StatusBadRequest,
+				})
+				return
+			}
+			filename := part.FileName()
+			if unescaped, err := url.PathUnescape(filename); err == nil {
+				filename = unescaped
+			}
+			file, err = h.service.Put(ctx, userID, filename, part)
+			if err != nil {
+				part.Close()
+				h.writeFileError(c, err)
+				return
+			}
+		case "file_id", "conversationId":
+			value, err := io.ReadAll(io.LimitReader(part, maxFormField))
+			if err != nil {
+				part.Close()
+				discard()
+				h.writeFileError(c, err)
+				return
+			}
+			if part.FormName() == "file_id" {
+				req.FileID = string(value)
+			} else {
+				req.ConversationID = string(value)
+			}
+		}
+		part.Close()
+	}
+
+	if file == nil {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "file_required",
+			Message: "The form has no file",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if !validateRequest(c, h.logger, validation.UploadFile, &req) {
+		discard()
+		return
+	}
+
+	file.TempFileID = req.FileID
+	file.ConversationID = req.ConversationID
+	if err := h.service.Commit(ctx, file); err != nil {
+		h.writeFileError(c, err)
+		return
+	}
+
+	h.logger.WithField("user_id", userID).WithField("file_id", file.FileID).WithField("bytes", file.Bytes).Info("File uploaded")
+	c.JSON(http.StatusCreated, file)
+}
+
+// List returns the user's files, newest first, optionally only those of the
+// conversation named by ?conversationId=
+func (h *FileHandler) List(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.service.List(c.Request.Context(), userID, c.Query("conversationId"))
+	if err != nil {
+		h.writeFileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// Delete removes the listed files. Files the user does not own are skipped
+// and left out of the deleted IDs.
+func (h *FileHandler) Delete(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.DeleteFilesRequest
+	if !bindAndValidate(c, h.logger, validation.DeleteFiles, &req, func() []validation.Error {
+		if len(req.Files) == 0 {
+			return []validation.Error{{Field: "files", Code: "required"}}
+		}
+		return nil
+	}) {
+		return
+	}
+
+	ids := make([]string, 0, len(req.Files))
+	for _, ref := range req.Files {
+		ids = append(ids, ref.FileID)
+	}
+	deleted, err := h.service.Delete(c.Request.Context(), userID, ids)
+	if err != nil {
+		h.writeFileError(c, err)
+		return
+	}
+
+	h.logger.WithField("user_id", userID).WithField("count", len(deleted)).Info("Files deleted")
+	c.JSON(http.StatusOK, models.DeleteFilesResponse{Message: "Files deleted successfully", Deleted: deleted})
+}
+
+// Download streams one of the user's files as an attachment. The URL names
+// the owner, and any other caller gets the same 404 as for a missing file.
+func (h *FileHandler) Download(c *gin.Context) {
+	userID, ok := h.requireOwner(c)
+	if !ok {
+		return
+	}
+
+	file, content, err := h.service.Open(c.Request.Context(), userID, c.Param("fileId"))
+	if err != nil {
+		h.writeFileError(c, err)
+		return
+	}
+	defer content.Close()
+
+	extendDeadlines(c, transferTimeout(file.Bytes))
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": file.Filename})
+	c.DataFromReader(http.StatusOK, file.Bytes, file.Type, content, map[string]string{
+		"Content-Disposition": disposition,
+	})
+}
+
+// Thumbnail streams the PNG thumbnail of one of the user's images
+func (h *FileHandler) Thumbnail(c *gin.Context) {
+	userID, ok := h.requireOwner(c)
+	if !ok {
+		return
+	}
+
+	content, err := h.service.OpenThumbnail(c.Request.Context(), userID, c.Param("fileId"))
+	if err != nil {
+		h.writeFileError(c, err)
+		return
+	}
+	defer content.Close()
+
+	// Thumbnails are small, but their size is not stored
+	extendDeadlines(c, transferTimeout(h.service.Limits().MaxFileSize))
+	c.DataFromReader(http.StatusOK, -1, "image/png", content, map[string]string{
+		"Content-Disposition": "inline",
+	})
+}
+
+// Config returns the upload limits for the client to check files against
+// before sending them
+func (h *FileHandler) Config(c *gin.Context) {
+	limits := h.service.Limits()
+
+	types := make([]string, 0, len(limits.AllowedTypes))
+	for _, allowed := range limits.AllowedTypes {
+		if family, ok := strings.CutSuffix(allowed, "/*"); ok {
+			types = append(types, "^"+regexp.QuoteMeta(family)+"/.+$")
+		} else {
+			types = append(types, "^"+regexp.QuoteMeta(allowed)+"$")
+		}
+	}
+
+	c.JSON(http.StatusOK, models.FileConfig{
+		Endpoints: map[string]models.EndpointFileConfig{
+			"default": {
+				FileSizeLimit:      limits.MaxFileSize,
+				TotalSizeLimit:     limits.UserQuota,
+				SupportedMimeTypes: types,
+			},
+		},
+		ServerFileSizeLimit: limits.MaxFileSize,
+	})
+}
+
+// requireOwner returns the caller's ID when it matches the :userId of the URL
+func (h *FileHandler) requireOwner(c *gin.Context) (string, bool) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return "", false
+	}
+	if c.Param("userId") != userID {
+		h.writeFileError(c, files.ErrNotFound)
+		return "", false
+	}
+	return userID, true
+}
+
+// writeFileError maps file service errors to responses
+func (h *FileHandler) writeFileError(c *gin.Context, err error) {
+	var maxBytes *http.MaxBytesError
+
+	switch {
+	case errors.Is(err, files.ErrNotFound):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "file_not_found",
+			Message: "File not found",
+			Code:    http.StatusNotFound,
+		})
+	case errors.Is(err, files.ErrTooLarge), errors.As(err, &maxBytes):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "file_too_large",
+			Message: "The file is larger than the size limit",
+			Code:    http.StatusRequestEntityTooLarge,
+		})
+	case errors.Is(err, files.ErrQuotaExceeded):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "quota_exceeded",
+			Message: "Delete some files before uploading more",
+			Code:    http.StatusRequestEntityTooLarge,
+		})
+	case errors.Is(err, files.ErrUnsupportedType):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "unsupported_file_type",
+			Message: "This type of file is not allowed",
+			Code:    http.StatusUnsupportedMediaType,
+		})
+	default:
+		h.logger.WithError(err).Error("File operation failed")
+		problem.Write(c, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "File operation failed",
+			Code:    http.StatusInternalServerError,
+		})
+	}
+}
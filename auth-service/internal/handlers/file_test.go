@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/files"
+	"auth-service/internal/models"
+	"auth-service/pkg/logger"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFileRouter mounts the file routes behind testUser, with a 1KB size
+// limit
+func newFileRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	blobs, err := files.NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+	store, err := files.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	service := files.NewService(config.FilesConfig{
+		MaxFileSize:   1024,
+		UserQuota:     4096,
+		AllowedTypes:  []string{"text/plain", "image/*"},
+		ThumbnailSize: 64,
+	}, blobs, store)
+	handler := NewFileHandler(&config.Config{}, &logger.Logger{Logger: logrus.New()}, service)
+
+	r := gin.New()
+	group := r.Group("/api/files", testUser())
+	group.GET("", handler.List)
+	group.POST("", handler.Upload)
+	group.DELETE("", handler.Delete)
+	group.GET("/config", handler.Config)
+	group.GET("/download/:userId/:fileId", handler.Download)
+	group.GET("/download/:userId/:fileId/thumbnail", handler.Thumbnail)
+	return r
+}
+
+// doUpload posts a multipart form with the given fields and, unless content
+// is nil, a file part after them
+func doUpload(t *testing.T, r *gin.Engine, user string, fields map[string]string, filename string, content []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		require.NoError(t, form.WriteField(name, value))
+	}
+	if content != nil {
+		part, err := form.CreateFormFile("file", filename)
+		require.NoError(t, err)
+		_, err = part.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, form.Close())
+
+	req, _ := http.NewRequest("POST", "/api/files", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("X-Test-User", user)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestFileHandler_Lifecycle(t *testing.T) {
+	r := newFileRouter(t)
+
+	w := doUpload(t, r, "user-1", map[string]string{
+		"file_id":        "temp-1",
+		"conversationId": "convo-1",
+		"endpoint":       "openAI",
+	}, "shopping%20list.txt", []byte("milk, eggs, bread"))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var file models.File
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &file))
+	assert.Equal(t, "temp-1", file.TempFileID)
+	assert.Equal(t, "convo-1", file.ConversationID)
+	assert.Equal(t, "shopping list.txt", file.Filename)
+	assert.Equal(t, "text/plain", file.Type)
+	assert.Equal(t, int64(17), file.Bytes)
+
+	w = doJSON(r, "GET", "/api/files?conversationId=convo-1", "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), file.FileID)
+	w = doJSON(r, "GET", "/api/files?conversationId=convo-2", "user-1", nil)
+	assert.JSONEq(t, "[]", w.Body.String())
+
+	w = doJSON(r, "GET", file.Filepath, "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "milk, eggs, bread", w.Body.String())
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="shopping list.txt"`, w.Header().Get("Content-Disposition"))
+
+	// Only the owner can download, even with the owner's URL
+	w = doJSON(r, "GET", file.Filepath, "user-2", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doJSON(r, "GET", "/api/files/download/user-2/"+file.FileID, "user-2", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doJSON(r, "DELETE", "/api/files", "user-2", models.DeleteFilesRequest{Files: []models.FileRef{{FileID: file.FileID}}})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"deleted":[]`)
+
+	w = doJSON(r, "DELETE", "/api/files", "user-1", models.DeleteFilesRequest{Files: []models.FileRef{{FileID: file.FileID}}})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), file.FileID)
+	w = doJSON(r, "GET", file.Filepath, "user-1", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestFileHandler_UploadRejections(t *testing.T) {
+	r := newFileRouter(t)
+
+	w := doUpload(t, r, "user-1", map[string]string{"file_id": "temp-1"}, "", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "file_required")
+
+	w = doUpload(t, r, "user-1", nil, "page.html", []byte("<!DOCTYPE html><script>alert(1)</script>"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	w = doUpload(t, r, "user-1", nil, "big.txt", []byte(strings.Repeat("a", 1025)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "file_too_large")
+
+	w = doUpload(t, r, "user-1", map[string]string{"file_id": "../temp"}, "notes.txt", []byte("notes"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "file_id")
+
+	w = doJSON(r, "DELETE", "/api/files", "user-1", models.DeleteFilesRequest{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Rejected uploads leave nothing behind
+	w = doJSON(r, "GET", "/api/files", "user-1", nil)
+	assert.JSONEq(t, "[]", w.Body.String())
+}
+
+// slowReader returns one byte per read, after a delay
+type slowReader struct {
+	data  []byte
+	delay time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(r.delay)
+	p[0], r.data = r.data[0], r.data[1:]
+	return 1, nil
+}
+
+func TestFileHandler_SlowTransfersOutlastServerTimeouts(t *testing.T) {
+	server := httptest.NewUnstartedServer(newFileRouter(t))
+	server.Config.ReadTimeout = 100 * time.Millisecond
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "notes.txt")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("slow notes"))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	// The body takes several times the server's timeouts to arrive
+	req, _ := http.NewRequest("POST", server.URL+"/api/files", &slowReader{data: body.Bytes(), delay: time.Millisecond})
+	req.ContentLength = int64(body.Len())
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("X-Test-User", "user-1")
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err, "the response arrives after the server's WriteTimeout")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var file models.File
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&file))
+	assert.Equal(t, int64(len("slow notes")), file.Bytes)
+}
+
+func TestFileHandler_Config(t *testing.T) {
+	r := newFileRouter(t)
+
+	w := doJSON(r, "GET", "/api/files/config", "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var cfg models.FileConfig
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &cfg))
+	assert.Equal(t, int64(1024), cfg.ServerFileSizeLimit)
+	assert.Equal(t, []string{`^text/plain$`, `^image/.+$`}, cfg.Endpoints["default"].SupportedMimeTypes)
+}
@@ -2,28 +2,38 @@ package handlers
 
 import (
 	"auth-service/internal/config"
+	"auth-service/internal/flags"
+	"auth-service/internal/password"
+	"auth-service/internal/resilience"
+	"auth-service/internal/services"
+	"auth-service/internal/validation"
 	"auth-service/pkg/logger"
+	"context"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 // FrontendHandler provides endpoints for frontend integration
 type FrontendHandler struct {
-	cfg    *config.Config
-	logger *logger.Logger
+	cfg            *config.Config
+	passwordPolicy *password.Policy
+	logger         *logger.Logger
 }
 
 // NewFrontendHandler creates a new frontend handler
-func NewFrontendHandler(cfg *config.Config, logger *logger.Logger) *FrontendHandler {
+func NewFrontendHandler(cfg *config.Config, logger *logger.Logger, passwordPolicy *password.Policy) *FrontendHandler {
 	return &FrontendHandler{
-		cfg:    cfg,
-		logger: logger,
+		cfg:            cfg,
+		passwordPolicy: passwordPolicy,
+		logger:         logger,
 	}
 }
 
 // GetAuthConfig returns authentication configuration for frontend
 func (h *FrontendHandler) GetAuthConfig(c *gin.Context) {
+	ctx := c.Request.Context()
 	config := gin.H{
 		"keycloak": gin.H{
 			"url":       h.cfg.Keycloak.ExternalURL,
@@ -41,33 +51,18 @@ func (h *FrontendHandler) GetAuthConfig(c *gin.Context) {
 			"profile":       "/api/v1/user/profile",
 			"updateProfile": "/api/v1/user/profile",
 			"changePassword": "/api/v1/user/change-password",
+			"forgotPassword": "/api/v1/auth/password/forgot",
 		},
 		"features": gin.H{
-			"registration":     true,
-			"passwordReset":    true,
-			"emailVerification": false,
-			"socialLogin":      false,
+			"registration":      flags.Enabled(ctx, flags.Registration),
+			"passwordReset":     flags.Enabled(ctx, flags.PasswordReset),
+			"emailVerification": flags.Enabled(ctx, flags.Email),
+			"socialLogin":       anyEnabled(socialLogins(ctx)),
 		},
 		"validation": gin.H{
-			"username": gin.H{
-				"minLength": 3,
-				"maxLength": 30,
-				"pattern":   "^[a-zA-Z0-9_]+$",
-			},
-			"password": gin.H{
-				"minLength": 8,
-				"maxLength": 128,
-				"requirements": []string{
-					"At least one uppercase letter",
-					"At least one lowercase letter", 
-					"At least one number",
-					"At least one special character",
-				},
-			},
-			"email": gin.H{
-				"required": true,
-				"pattern":  "^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\\.[a-zA-Z]{2,}$",
-			},
+			"username": fieldConstraints(validation.Register, "username"),
+			"password": h.passwordConstraints(),
+			"email":    fieldConstraints(validation.Register, "email"),
 		},
 	}
 
@@ -77,6 +72,66 @@ func (h *FrontendHandler) GetAuthConfig(c *gin.Context) {
 	})
 }
 
+// GetClientConfig serves /api/config: the app settings and feature flags,
+// evaluated for the calling user when a bearer token is sent
+func (h *FrontendHandler) GetClientConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+	config := gin.H{
+		"app_name":            "ShopMindAI",
+		"version":             "1.0.0-mvp",
+		"emailEnabled":        flags.Enabled(ctx, flags.Email),
+		"registrationEnabled": flags.Enabled(ctx, flags.Registration),
+		"socialLogins":        socialLogins(ctx),
+		"features":            enabledFeatures(ctx),
+		"turnstile": gin.H{
+			"siteKey": h.cfg.LibreChat.TurnstileSiteKey,
+		},
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Config endpoint - placeholder for ShopMindAI",
+		"data":    config,
+	})
+}
+
+// socialLogins maps each social login provider to whether it is enabled,
+// from the social_<provider> flags
+func socialLogins(ctx context.Context) map[string]bool {
+	logins := make(map[string]bool)
+	for _, name := range flags.Default().Names() {
+		if provider := strings.TrimPrefix(name, flags.SocialPrefix); provider != name {
+			logins[provider] = flags.Enabled(ctx, name)
+		}
+	}
+	return logins
+}
+
+// enabledFeatures lists the enabled product feature flags, leaving out the
+// flags reported through their own config fields
+func enabledFeatures(ctx context.Context) []string {
+	features := []string{}
+	for _, name := range flags.Default().Names() {
+		switch {
+		case name == flags.Registration, name == flags.PasswordReset, name == flags.Email,
+			strings.HasPrefix(name, flags.SocialPrefix):
+			continue
+		}
+		if flags.Enabled(ctx, name) {
+			features = append(features, name)
+		}
+	}
+	return features
+}
+
+func anyEnabled(values map[string]bool) bool {
+	for _, enabled := range values {
+		if enabled {
+			return true
+		}
+	}
+	return false
+}
+
 // GetAppInfo returns application information
 func (h *FrontendHandler) GetAppInfo(c *gin.Context) {
 	info := gin.H{
@@ -104,8 +159,25 @@ func (h *FrontendHandler) GetAppInfo(c *gin.Context) {
 
 // GetHealthStatus returns detailed health status
 func (h *FrontendHandler) GetHealthStatus(c *gin.Context) {
+	healthStatus := "healthy"
+	identityProvider := gin.H{
+		"status": "connected",
+		"type":   "keycloak",
+	}
+	if breaker, ok := resilience.Breakers.Get(services.KeycloakBreaker); ok {
+		identityProvider["circuit_breaker"] = breaker.Stats()
+		switch breaker.State() {
+		case resilience.StateOpen:
+			identityProvider["status"] = "unavailable"
+			healthStatus = "degraded"
+		case resilience.StateHalfOpen:
+			identityProvider["status"] = "recovering"
+			healthStatus = "degraded"
+		}
+	}
+
 	status := gin.H{
-		"status":     "healthy",
+		"status":     healthStatus,
 		"service":    "auth-service",
 		"version":    "1.0.0-mvp",
 		"timestamp":  gin.H{},
@@ -118,10 +190,7 @@ func (h *FrontendHandler) GetHealthStatus(c *gin.Context) {
 				"status": "connected", 
 				"type":   "redis",
 			},
-			"identity_provider": gin.H{
-				"status": "connected",
-				"type":   "keycloak",
-			},
+			"identity_provider": identityProvider,
 		},
 		"metrics": gin.H{
 			"uptime":         "running",
@@ -133,3 +202,53 @@ func (h *FrontendHandler) GetHealthStatus(c *gin.Context) {
 
 	c.JSON(http.StatusOK, status)
 }
+
+// GetValidationRules returns every request schema together with the message
+// catalog for the caller's language, so the frontend validates with the same rules
+func (h *FrontendHandler) GetValidationRules(c *gin.Context) {
+	lang := validation.NegotiateLanguage(c.GetHeader("Accept-Language"))
+
+	c.Header("Content-Language", lang)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"language":  lang,
+			"languages": validation.Languages(),
+			"schemas":   validation.Schemas(),
+			"password":  h.passwordPolicy.Rules(),
+			"messages":  validation.CatalogFor(lang),
+		},
+	})
+}
+
+// fieldConstraints summarizes a schema field in the auth config format
+func fieldConstraints(schema *validation.Schema, name string) gin.H {
+	field := schema.Field(name)
+	constraints := gin.H{}
+	if field.Rule("required") != nil {
+		constraints["required"] = true
+	}
+	if rule := field.Rule("min_length"); rule != nil {
+		constraints["minLength"] = rule.Params["min"]
+	}
+	if rule := field.Rule("max_length"); rule != nil {
+		constraints["maxLength"] = rule.Params["max"]
+	}
+	for _, rule := range field.Rules {
+		if pattern, ok := rule.Params["pattern"]; ok {
+			constraints["pattern"] = pattern
+		}
+	}
+	return constraints
+}
+
+// passwordConstraints describes the password policy in the auth config format
+func (h *FrontendHandler) passwordConstraints() gin.H {
+	cfg := h.passwordPolicy.Config()
+	return gin.H{
+		"minLength":    cfg.MinLength,
+		"maxLength":    cfg.MaxLength,
+		"requirements": h.passwordPolicy.Requirements(),
+		"rules":        h.passwordPolicy.Rules(),
+	}
+}
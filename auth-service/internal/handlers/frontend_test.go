@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/flags"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrontendHandler_GetClientConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	previous := flags.Default()
+	flags.SetDefault(flags.NewService(config.FlagsConfig{Flags: map[string]config.FlagConfig{
+		flags.Registration:            {Enabled: true},
+		flags.Email:                   {},
+		flags.Shopping:                {Enabled: true},
+		flags.AI:                      {Roles: []string{"beta-tester"}},
+		flags.SocialPrefix + "google": {Enabled: true},
+		flags.SocialPrefix + "github": {},
+	}}))
+	t.Cleanup(func() { flags.SetDefault(previous) })
+
+	handler := &FrontendHandler{cfg: &config.Config{}}
+
+	tests := []struct {
+		name     string
+		subject  flags.Subject
+		features []string
+	}{
+		{"anonymous", flags.Subject{}, []string{flags.Shopping}},
+		{"targeted role", flags.Subject{UserID: "user-1", Roles: []string{"beta-tester"}}, []string{flags.AI, flags.Shopping}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/config", nil)
+			req = req.WithContext(flags.WithSubject(req.Context(), tt.subject))
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			handler.GetClientConfig(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var response struct {
+				Data struct {
+					RegistrationEnabled bool            `json:"registrationEnabled"`
+					EmailEnabled        bool            `json:"emailEnabled"`
+					SocialLogins        map[string]bool `json:"socialLogins"`
+					Features            []string        `json:"features"`
+				} `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.True(t, response.Data.RegistrationEnabled)
+			assert.False(t, response.Data.EmailEnabled)
+			assert.Equal(t, map[string]bool{"google": true, "github": false}, response.Data.SocialLogins)
+			assert.Equal(t, tt.features, response.Data.Features)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package handlers
+
+import "github.com/gin-gonic/gin"
+
+// testUser stands in for AuthMiddleware in the handler tests, taking the
+// user from the X-Test-User header that doJSON sets
+func testUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			c.Set("user_id", user)
+			c.Set("username", user)
+			c.Set("email", user+"@example.com")
+		}
+	}
+}
@@ -0,0 +1,431 @@
+package handlers
+
+import (
+	"auth-service/internal/catalog"
+	"auth-service/internal/config"
+	"auth-service/internal/lists"
+	"auth-service/internal/models"
+	"auth-service/internal/problem"
+	"auth-service/internal/storage"
+	"auth-service/internal/validation"
+	"auth-service/pkg/logger"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Item quantity bounds
+const (
+	minQuantity = 1
+	maxQuantity = 999
+)
+
+// errVersionRequired is returned by listVersion for requests without If-Match
+var errVersionRequired = errors.New("if-match required")
+
+// ListHandler serves the shopping lists under /api/v1/lists. Every list
+// carries a version, sent as its ETag; changes to a list must send it back
+// in If-Match and fail with 412 once another device changed the list.
+// Members see and follow the lists shared with them; what they may change
+// depends on their role.
+type ListHandler struct {
+	store     lists.ListStore
+	hub       *lists.Hub
+	signer    *lists.InviteSigner
+	inviteTTL time.Duration
+	catalog   *catalog.Catalog
+	logger    *logger.Logger
+}
+
+// NewListHandler creates a new list handler pricing carts with catalog and
+// streaming changes from hub
+func NewListHandler(cfg *config.Config, logger *logger.Logger, store lists.ListStore, hub *lists.Hub, catalog *catalog.Catalog) *ListHandler {
+	return &ListHandler{
+		store:     store,
+		hub:       hub,
+		signer:    lists.NewInviteSigner(cfg.Lists.InviteSecret.Value()),
+		inviteTTL: cfg.Lists.InviteTTL,
+		catalog:   catalog,
+		logger:    logger,
+	}
+}
+
+// ListLists returns summaries of the lists the user owns or is a member of,
+// the most recently updated first
+func (h *ListHandler) ListLists(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	all, err := h.store.Lists(c.Request.Context(), userID)
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to list shopping lists")
+		return
+	}
+	summaries := make([]models.ListSummary, 0, len(all))
+	for _, list := range all {
+		summary := models.ListSummary{
+			ID:          list.ID,
+			Name:        list.Name,
+			Role:        lists.RoleOf(&list, userID),
+			MemberCount: len(list.Members),
+			ItemCount:   len(list.Items),
+			Version:     list.Version,
+			CreatedAt:   list.CreatedAt,
+			UpdatedAt:   list.UpdatedAt,
+		}
+		for _, item := range list.Items {
+			if item.Checked {
+				summary.CheckedCount++
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	c.JSON(http.StatusOK, gin.H{"lists": summaries})
+}
+
+// CreateList creates an empty list
+func (h *ListHandler) CreateList(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.SaveListRequest
+	if !bindAndValidate(c, h.logger, validation.SaveList, &req) {
+		return
+	}
+
+	list := &models.ShoppingList{Name: strings.TrimSpace(req.Name)}
+	if err := h.store.Create(c.Request.Context(), userID, list); err != nil {
+		h.writeStoreError(c, err, "Failed to create shopping list")
+		return
+	}
+	writeList(c, http.StatusCreated, list, userID)
+}
+
+// GetList returns the list named by :listId with its items
+func (h *ListHandler) GetList(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.store.Get(c.Request.Context(), userID, c.Param("listId"))
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to get shopping list")
+		return
+	}
+	writeList(c, http.StatusOK, list, userID)
+}
+
+// RenameList renames the list named by :listId
+func (h *ListHandler) RenameList(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.SaveListRequest
+	if !bindAndValidate(c, h.logger, validation.SaveList, &req) {
+		return
+	}
+	h.update(c, userID, http.StatusOK, func(list *models.ShoppingList) error {
+		list.Name = strings.TrimSpace(req.Name)
+		return nil
+	})
+}
+
+// DeleteList removes the list named by :listId. Only its owner may.
+func (h *ListHandler) DeleteList(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	version, err := listVersion(c)
+	if err != nil {
+		h.writeStoreError(c, err, "")
+		return
+	}
+	if err := h.store.Delete(c.Request.Context(), userID, c.Param("listId"), version); err != nil {
+		h.writeStoreError(c, err, "Failed to delete shopping list")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AddItem adds a catalog product, or free text, to the list named by
+// :listId and returns the list. Product items are named after the product
+// unless the request names them.
+func (h *ListHandler) AddItem(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.AddListItemRequest
+	var product *models.ProductDetail
+	if !bindAndValidate(c, h.logger, validation.AddListItem, &req, func() []validation.Error {
+		errs := validation.CheckRange("quantity", req.Quantity, minQuantity, maxQuantity)
+		if req.ProductID == "" {
+			if strings.TrimSpace(req.Name) == "" {
+				errs = append(errs, validation.Error{Field: "name", Code: "required"})
+			}
+			return errs
+		}
+		var err error
+		if product, err = h.catalog.Product(c.Request.Context(), req.ProductID); err != nil {
+			return append(errs, validation.Error{Field: "product_id", Code: "unknown_product"})
+		}
+		if req.VariantID != "" && !lists.HasVariant(product, req.VariantID) {
+			errs = append(errs, validation.Error{Field: "variant_id", Code: "unknown_variant"})
+		}
+		return errs
+	}) {
+		return
+	}
+
+	now := time.Now().UTC()
+	item := models.ListItem{
+		ID:        storage.NewID(),
+		Name:      strings.TrimSpace(req.Name),
+		Quantity:  minQuantity,
+		Note:      req.Note,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.Quantity != nil {
+		item.Quantity = *req.Quantity
+	}
+	if product != nil {
+		item.ProductID = product.ID
+		item.VariantID = req.VariantID
+		if item.Name == "" {
+			item.Name = product.Name
+		}
+	}
+	h.update(c, userID, http.StatusCreated, func(list *models.ShoppingList) error {
+		list.Items = append(list.Items, item)
+		return nil
+	})
+}
+
+// UpdateItem replaces the name, quantity, note and checked state of the
+// item named by :itemId and returns the list
+func (h *ListHandler) UpdateItem(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateListItemRequest
+	if !bindAndValidate(c, h.logger, validation.UpdateListItem, &req, func() []validation.Error {
+		return validation.CheckRange("quantity", req.Quantity, minQuantity, maxQuantity)
+	}) {
+		return
+	}
+	h.update(c, userID, http.StatusOK, func(list *models.ShoppingList) error {
+		i := lists.FindItem(list, c.Param("itemId"))
+		if i < 0 {
+			return lists.ErrItemNotFound
+		}
+		item := &list.Items[i]
+		item.Name = strings.TrimSpace(req.Name)
+		item.Note = req.Note
+		item.Checked = req.Checked
+		if req.Quantity != nil {
+			item.Quantity = *req.Quantity
+		}
+		item.UpdatedAt = time.Now().UTC()
+		return nil
+	})
+}
+
+// DeleteItem removes the item named by :itemId and returns the list
+func (h *ListHandler) DeleteItem(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	h.update(c, userID, http.StatusOK, func(list *models.ShoppingList) error {
+		i := lists.FindItem(list, c.Param("itemId"))
+		if i < 0 {
+			return lists.ErrItemNotFound
+		}
+		list.Items = append(list.Items[:i], list.Items[i+1:]...)
+		return nil
+	})
+}
+
+// Cart prices the unchecked product items of the list named by :listId at
+// their best offers and totals them by retailer. Free-text items are left
+// out.
+func (h *ListHandler) Cart(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	list, err := h.store.Get(ctx, userID, c.Param("listId"))
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to get shopping list")
+		return
+	}
+	var lines []catalog.CartLine
+	for _, item := range list.Items {
+		if item.Checked || item.ProductID == "" {
+			continue
+		}
+		lines = append(lines, catalog.CartLine{
+			ItemID:    item.ID,
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+		})
+	}
+	cart, err := h.catalog.Cart(ctx, list.ID, lines)
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to price cart")
+		return
+	}
+	c.Header("ETag", formatVersion(list.Version))
+	c.JSON(http.StatusOK, cart)
+}
+
+// update applies change to the list named by :listId at the version sent in
+// If-Match and writes the changed list with status
+func (h *ListHandler) update(c *gin.Context, userID string, status int, change func(list *models.ShoppingList) error) {
+	version, err := listVersion(c)
+	if err != nil {
+		h.writeStoreError(c, err, "")
+		return
+	}
+	list, err := h.store.Update(c.Request.Context(), userID, c.Param("listId"), version, change)
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to update shopping list")
+		return
+	}
+	writeList(c, status, list, userID)
+}
+
+// writeList writes list as the user sees it, with its version as the ETag
+func writeList(c *gin.Context, status int, list *models.ShoppingList, userID string) {
+	c.Header("ETag", formatVersion(list.Version))
+	c.JSON(status, lists.VisibleTo(list, userID))
+}
+
+func formatVersion(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// listVersion parses the version in If-Match, an ETag of the list, or "*"
+// for lists.AnyVersion
+func listVersion(c *gin.Context) (int64, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return 0, errVersionRequired
+	}
+	if header == "*" {
+		return lists.AnyVersion, nil
+	}
+	header = strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	version, err := strconv.ParseInt(header, 10, 64)
+	if err != nil || version == lists.AnyVersion {
+		// An ETag that is no version never matches
+		return 0, lists.ErrConflict
+	}
+	return version, nil
+}
+
+func (h *ListHandler) writeStoreError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, lists.ErrNotFound):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "list_not_found",
+			Message: "Shopping list not found",
+			Code:    http.StatusNotFound,
+		})
+	case errors.Is(err, lists.ErrItemNotFound):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "item_not_found",
+			Message: "Item not found on the shopping list",
+			Code:    http.StatusNotFound,
+		})
+	case errors.Is(err, lists.ErrMemberNotFound):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "member_not_found",
+			Message: "The user is not a member of the shopping list",
+			Code:    http.StatusNotFound,
+		})
+	case errors.Is(err, lists.ErrInviteNotFound):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "invite_not_found",
+			Message: "The invitation was revoked or already accepted",
+			Code:    http.StatusNotFound,
+		})
+	case errors.Is(err, lists.ErrInvalidInvite):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "invite_invalid",
+			Message: "Invalid invitation token",
+			Code:    http.StatusBadRequest,
+		})
+	case errors.Is(err, lists.ErrInviteExpired):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "invite_expired",
+			Message: "The invitation has expired",
+			Code:    http.StatusGone,
+		})
+	case errors.Is(err, errInviteMismatch):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "invite_mismatch",
+			Message: "The invitation is for another user",
+			Code:    http.StatusForbidden,
+		})
+	case errors.Is(err, errAlreadyMember):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "already_member",
+			Message: "The user already shares the shopping list",
+			Code:    http.StatusConflict,
+		})
+	case errors.Is(err, lists.ErrForbidden):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "list_forbidden",
+			Message: "Your role on the shopping list does not allow this",
+			Code:    http.StatusForbidden,
+		})
+	case errors.Is(err, errVersionRequired):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "version_required",
+			Message: "Send the list's ETag in If-Match",
+			Code:    http.StatusPreconditionRequired,
+		})
+	case errors.Is(err, lists.ErrConflict):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "version_conflict",
+			Message: "The shopping list changed; reload it and try again",
+			Code:    http.StatusPreconditionFailed,
+		})
+	case errors.Is(err, lists.ErrLimit):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "list_limit_reached",
+			Message: "Remove lists, items or members before adding more",
+			Code:    http.StatusConflict,
+		})
+	default:
+		h.logger.WithError(err).Error(msg)
+		problem.Write(c, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: msg,
+			Code:    http.StatusInternalServerError,
+		})
+	}
+}
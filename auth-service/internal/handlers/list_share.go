@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"auth-service/internal/lists"
+	"auth-service/internal/models"
+	"auth-service/internal/storage"
+	"auth-service/internal/validation"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listKeepAlive is how often an idle list event stream sends a comment, so
+// proxies do not close it
+const listKeepAlive = 25 * time.Second
+
+var (
+	// errInviteMismatch is returned when accepting an invitation for another
+	// email or username
+	errInviteMismatch = errors.New("invitation is for another user")
+	// errAlreadyMember is returned when inviting or accepting for a user who
+	// already shares the list
+	errAlreadyMember = errors.New("already a member")
+)
+
+// Invite invites a user, by email or username, to the list named by :listId
+// with the editor or viewer role. The response holds the token accepting the
+// invitation, which the owner passes on. Inviting the same user again
+// replaces the pending invitation, and expired invitations are dropped.
+func (h *ListHandler) Invite(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.InviteListMemberRequest
+	if !bindAndValidate(c, h.logger, validation.InviteListMember, &req, func() []validation.Error {
+		if (req.Email == "") == (req.Username == "") {
+			return []validation.Error{{Field: "email", Code: "one_of", Params: map[string]interface{}{"other": "username"}}}
+		}
+		return nil
+	}) {
+		return
+	}
+
+	now := time.Now().UTC()
+	invite := models.ListInvite{
+		ID:        storage.NewID(),
+		Email:     strings.ToLower(req.Email),
+		Username:  strings.ToLower(req.Username),
+		Role:      req.Role,
+		InvitedBy: userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(h.inviteTTL),
+	}
+	list, err := h.store.Share(c.Request.Context(), c.Param("listId"), func(list *models.ShoppingList) error {
+		if err := requireOwner(list, userID); err != nil {
+			return err
+		}
+		lists.PruneInvites(list, now)
+		if invitesUser(invite, c.GetString("email"), c.GetString("username")) {
+			return errAlreadyMember
+		}
+		for _, member := range list.Members {
+			if invitesUser(invite, member.Email, member.Username) {
+				return errAlreadyMember
+			}
+		}
+		for i, pending := range list.Invites {
+			if pending.Email == invite.Email && pending.Username == invite.Username {
+				list.Invites = append(list.Invites[:i], list.Invites[i+1:]...)
+				break
+			}
+		}
+		list.Invites = append(list.Invites, invite)
+		return nil
+	})
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to invite to shopping list")
+		return
+	}
+	token, err := h.signer.Sign(list.ID, invite)
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to sign invitation")
+		return
+	}
+	c.Header("ETag", formatVersion(list.Version))
+	c.JSON(http.StatusCreated, models.ListInviteResponse{Invite: invite, Token: token})
+}
+
+// RevokeInvite withdraws the invitation named by :inviteId, voiding its token
+func (h *ListHandler) RevokeInvite(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	_, err := h.store.Share(c.Request.Context(), c.Param("listId"), func(list *models.ShoppingList) error {
+		if err := requireOwner(list, userID); err != nil {
+			return err
+		}
+		i := lists.FindInvite(list, c.Param("inviteId"))
+		if i < 0 {
+			return lists.ErrInviteNotFound
+		}
+		list.Invites = append(list.Invites[:i], list.Invites[i+1:]...)
+		return nil
+	})
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to revoke invitation")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AcceptInvite joins the list of the invitation whose token is posted. The
+// invitation must be for the user's email or username and still pending
+// until its stored expiry, which may be earlier than the token's; it is used
+// up, and expired invitations are dropped.
+func (h *ListHandler) AcceptInvite(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.AcceptListInviteRequest
+	if !bindAndValidate(c, h.logger, validation.AcceptListInvite, &req) {
+		return
+	}
+	listID, inviteID, err := h.signer.Verify(req.Token)
+	if err != nil {
+		h.writeStoreError(c, err, "")
+		return
+	}
+
+	email, username := c.GetString("email"), c.GetString("username")
+	list, err := h.store.Share(c.Request.Context(), listID, func(list *models.ShoppingList) error {
+		i := lists.FindInvite(list, inviteID)
+		if i < 0 {
+			return lists.ErrInviteNotFound
+		}
+		invite := list.Invites[i]
+		now := time.Now().UTC()
+		if !now.Before(invite.ExpiresAt) {
+			return lists.ErrInviteExpired
+		}
+		if !invitesUser(invite, email, username) {
+			return errInviteMismatch
+		}
+		if lists.RoleOf(list, userID) != "" {
+			return errAlreadyMember
+		}
+		list.Invites = append(list.Invites[:i], list.Invites[i+1:]...)
+		lists.PruneInvites(list, now)
+		list.Members = append(list.Members, models.ListMember{
+			UserID:   userID,
+			Username: username,
+			Email:    email,
+			Role:     invite.Role,
+			JoinedAt: now,
+		})
+		return nil
+	})
+	if errors.Is(err, lists.ErrNotFound) {
+		// The list was deleted after inviting
+		err = lists.ErrInviteNotFound
+	}
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to accept invitation")
+		return
+	}
+	writeList(c, http.StatusOK, list, userID)
+}
+
+// UpdateMember changes the role of the member named by :userId
+func (h *ListHandler) UpdateMember(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateListMemberRequest
+	if !bindAndValidate(c, h.logger, validation.UpdateListMember, &req) {
+		return
+	}
+	list, err := h.store.Share(c.Request.Context(), c.Param("listId"), func(list *models.ShoppingList) error {
+		if err := requireOwner(list, userID); err != nil {
+			return err
+		}
+		i := lists.FindMember(list, c.Param("userId"))
+		if i < 0 {
+			return lists.ErrMemberNotFound
+		}
+		list.Members[i].Role = req.Role
+		return nil
+	})
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to update member")
+		return
+	}
+	writeList(c, http.StatusOK, list, userID)
+}
+
+// RemoveMember stops sharing the list with the member named by :userId. The
+// owner may remove anyone; other members may only remove themselves, which
+// leaves the list. The owner cannot leave.
+func (h *ListHandler) RemoveMember(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	memberID := c.Param("userId")
+	_, err := h.store.Share(c.Request.Context(), c.Param("listId"), func(list *models.ShoppingList) error {
+		if memberID != userID {
+			if err := requireOwner(list, userID); err != nil {
+				return err
+			}
+		} else if lists.RoleOf(list, userID) == "" {
+			return lists.ErrNotFound
+		}
+		i := lists.FindMember(list, memberID)
+		if i < 0 {
+			if memberID == list.UserID {
+				return lists.ErrForbidden
+			}
+			return lists.ErrMemberNotFound
+		}
+		list.Members = append(list.Members[:i], list.Members[i+1:]...)
+		return nil
+	})
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to remove member")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Events streams the list named by :listId as server-sent events: the list
+// right away, then after every change by any member. The stream ends with a
+// "deleted" or "removed" event once the user can no longer see the list.
+func (h *ListHandler) Events(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	// Subscribe first, so no change between reading and following is missed
+	listID := c.Param("listId")
+	events, cancel := h.hub.Subscribe(listID, userID)
+	defer cancel()
+	ctx := c.Request.Context()
+	list, err := h.store.Get(ctx, userID, listID)
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to get shopping list")
+		return
+	}
+
+	// Streams outlive the server's WriteTimeout; the client ends them
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	writeEvent(c, "list", models.ListEvent{Type: "list", ListID: list.ID, Version: list.Version, List: lists.VisibleTo(list, userID)})
+
+	keepAlive := time.NewTicker(listKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			_, _ = c.Writer.WriteString(": keep-alive\n\n")
+			c.Writer.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			writeEvent(c, event.Type, event)
+		}
+	}
+}
+
+// requireOwner fails with ErrNotFound for users who cannot see the list and
+// ErrForbidden for members other than the owner
+func requireOwner(list *models.ShoppingList, userID string) error {
+	switch lists.RoleOf(list, userID) {
+	case "":
+		return lists.ErrNotFound
+	case models.ListOwner:
+		return nil
+	default:
+		return lists.ErrForbidden
+	}
+}
+
+// invitesUser reports whether the invitation is for the user with email or
+// username. Both are compared case-insensitively.
+func invitesUser(invite models.ListInvite, email, username string) bool {
+	if invite.Email != "" {
+		return strings.EqualFold(invite.Email, email)
+	}
+	return invite.Username != "" && strings.EqualFold(invite.Username, username)
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"auth-service/internal/lists"
+	"auth-service/internal/models"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSharedList creates a list of user-1 and returns its ID
+func newSharedList(t *testing.T, r *gin.Engine) string {
+	w := doJSON(r, "POST", "/api/v1/lists", "user-1", models.SaveListRequest{Name: "Household"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	return decodeList(t, w).ID
+}
+
+// inviteTo has user-1 invite someone to the list and returns the token
+func inviteTo(t *testing.T, r *gin.Engine, listID string, req models.InviteListMemberRequest) string {
+	w := doJSON(r, "POST", "/api/v1/lists/"+listID+"/invites", "user-1", req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var response models.ListInviteResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response.Token
+}
+
+func accept(r *gin.Engine, user, token string) *httptest.ResponseRecorder {
+	return doJSON(r, "POST", "/api/v1/lists/invites/accept", user, models.AcceptListInviteRequest{Token: token})
+}
+
+func TestListHandler_SharingRoles(t *testing.T) {
+	r, _ := newListRouter(t)
+	listID := newSharedList(t, r)
+	base := "/api/v1/lists/" + listID
+
+	editorToken := inviteTo(t, r, listID, models.InviteListMemberRequest{Email: "User-2@example.com", Role: models.ListEditor})
+	viewerToken := inviteTo(t, r, listID, models.InviteListMemberRequest{Username: "user-3", Role: models.ListViewer})
+
+	w := accept(r, "user-4", editorToken)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "invite_mismatch")
+	w = accept(r, "user-2", editorToken[:len(editorToken)-2]+"xx")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invite_invalid")
+
+	w = accept(r, "user-2", editorToken)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	list := decodeList(t, w)
+	require.Len(t, list.Members, 1)
+	assert.Equal(t, models.ListEditor, list.Members[0].Role)
+	assert.Len(t, list.Invites, 1, "the viewer's invitation is still pending")
+	w = accept(r, "user-2", editorToken)
+	assert.Equal(t, http.StatusNotFound, w.Code, "invitations are used up")
+	w = accept(r, "user-3", viewerToken)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Editors change items; viewers only read
+	w = doJSON(r, "GET", "/api/v1/lists", "user-2", nil)
+	assert.Contains(t, w.Body.String(), `"role":"editor"`)
+	w = doIfMatchAs(r, "POST", base+"/items", "user-2", `"5"`, models.AddListItemRequest{Name: "Bread"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = doIfMatchAs(r, "POST", base+"/items", "user-3", `"6"`, models.AddListItemRequest{Name: "Milk"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "list_forbidden")
+	w = doJSON(r, "GET", base+"/cart", "user-3", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Only the owner shares and deletes
+	w = doJSON(r, "POST", base+"/invites", "user-2", models.InviteListMemberRequest{Username: "user-5", Role: models.ListViewer})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doIfMatchAs(r, "DELETE", base, "user-2", `"6"`, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON(r, "PUT", base+"/members/user-3", "user-1", models.UpdateListMemberRequest{Role: models.ListEditor})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doIfMatchAs(r, "POST", base+"/items", "user-3", `"7"`, models.AddListItemRequest{Name: "Milk"})
+	assert.Equal(t, http.StatusCreated, w.Code, "promoted to editor")
+
+	// Revoked invitations void their token
+	w = doJSON(r, "POST", base+"/invites", "user-1", models.InviteListMemberRequest{Username: "user-5", Role: models.ListViewer})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var pending models.ListInviteResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pending))
+	w = doJSON(r, "DELETE", base+"/invites/"+pending.Invite.ID, "user-1", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = accept(r, "user-5", pending.Token)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "invite_not_found")
+
+	// Members leave or are removed; the owner stays
+	w = doJSON(r, "DELETE", base+"/members/user-2", "user-2", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = doJSON(r, "DELETE", base+"/members/user-3", "user-1", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = doJSON(r, "GET", base, "user-3", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doJSON(r, "GET", "/api/v1/lists", "user-2", nil)
+	assert.JSONEq(t, `{"lists": []}`, w.Body.String())
+	w = doJSON(r, "DELETE", base+"/members/user-1", "user-1", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestListHandler_ViewersDoNotSeeInvitees(t *testing.T) {
+	r, _ := newListRouter(t)
+	listID := newSharedList(t, r)
+	base := "/api/v1/lists/" + listID
+	token := inviteTo(t, r, listID, models.InviteListMemberRequest{Username: "user-2", Role: models.ListViewer})
+	require.Equal(t, http.StatusOK, accept(r, "user-2", token).Code)
+	inviteTo(t, r, listID, models.InviteListMemberRequest{Email: "user-3@example.com", Role: models.ListEditor})
+
+	w := doJSON(r, "GET", base, "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	invites := decodeList(t, w).Invites
+	require.Len(t, invites, 1)
+	assert.Equal(t, "user-3@example.com", invites[0].Email)
+
+	w = doJSON(r, "GET", base, "user-2", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "user-3")
+	invites = decodeList(t, w).Invites
+	require.Len(t, invites, 1, "viewers see that an invitation is pending")
+	assert.Empty(t, invites[0].Email)
+	assert.Equal(t, models.ListEditor, invites[0].Role)
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+	req, err := http.NewRequest("GET", server.URL+base+"/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Test-User", "user-2")
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	reader := bufio.NewReader(resp.Body)
+
+	event := readListEvent(t, reader)
+	require.NotNil(t, event.data.List)
+	require.Len(t, event.data.List.Invites, 1)
+	assert.Empty(t, event.data.List.Invites[0].Email)
+
+	inviteTo(t, r, listID, models.InviteListMemberRequest{Username: "user-4", Role: models.ListViewer})
+	event = readListEvent(t, reader)
+	require.NotNil(t, event.data.List)
+	require.Len(t, event.data.List.Invites, 2)
+	for _, invite := range event.data.List.Invites {
+		assert.Empty(t, invite.Email)
+		assert.Empty(t, invite.Username)
+	}
+
+	w = doJSON(r, "GET", base, "user-1", nil)
+	assert.Contains(t, w.Body.String(), "user-3@example.com", "redacting copies the list")
+}
+
+func TestListHandler_InvalidInvites(t *testing.T) {
+	r, _ := newListRouter(t)
+	listID := newSharedList(t, r)
+
+	for name, req := range map[string]models.InviteListMemberRequest{
+		"one_of":    {Role: models.ListEditor},
+		"list_role": {Username: "user-2", Role: models.ListOwner},
+		"email":     {Email: "not-an-email", Role: models.ListViewer},
+	} {
+		w := doJSON(r, "POST", "/api/v1/lists/"+listID+"/invites", "user-1", req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		assert.Contains(t, w.Body.String(), `"code":"`+name+`"`, name)
+	}
+
+	w := doJSON(r, "POST", "/api/v1/lists/"+listID+"/invites", "user-1", models.InviteListMemberRequest{Username: "USER-1", Role: models.ListViewer})
+	assert.Equal(t, http.StatusConflict, w.Code, "the owner is a member already")
+	w = doJSON(r, "POST", "/api/v1/lists/"+listID+"/invites", "user-2", models.InviteListMemberRequest{Username: "user-3", Role: models.ListViewer})
+	assert.Equal(t, http.StatusNotFound, w.Code, "lists of other users do not exist")
+}
+
+func TestListHandler_StoredInviteExpiry(t *testing.T) {
+	r, _, store := newListRouterWithStore(t)
+	listID := newSharedList(t, r)
+	base := "/api/v1/lists/" + listID
+	expireInvites := func() {
+		_, err := store.Share(context.Background(), listID, func(list *models.ShoppingList) error {
+			for i := range list.Invites {
+				list.Invites[i].ExpiresAt = time.Now().Add(-time.Minute)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	// Shortening an invitation on the server voids its token
+	token := inviteTo(t, r, listID, models.InviteListMemberRequest{Username: "user-2", Role: models.ListEditor})
+	expireInvites()
+	w := accept(r, "user-2", token)
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Contains(t, w.Body.String(), "invite_expired")
+
+	// Expired invitations do not hold places on the list
+	for i := 0; i < lists.MaxMembers; i++ {
+		inviteTo(t, r, listID, models.InviteListMemberRequest{Username: fmt.Sprintf("guest-%d", i), Role: models.ListViewer})
+	}
+	w = doJSON(r, "POST", base+"/invites", "user-1", models.InviteListMemberRequest{Username: "user-3", Role: models.ListViewer})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "list_limit_reached")
+	expireInvites()
+	token = inviteTo(t, r, listID, models.InviteListMemberRequest{Username: "user-3", Role: models.ListViewer})
+	w = doJSON(r, "GET", base, "user-1", nil)
+	assert.Len(t, decodeList(t, w).Invites, 1, "expired invitations are dropped")
+
+	w = accept(r, "user-3", token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(t, decodeList(t, w).Invites)
+}
+
+// streamedListEvent is one server-sent event of a list stream
+type streamedListEvent struct {
+	name string
+	data models.ListEvent
+}
+
+// readListEvent reads the next event of a stream, skipping keep-alive comments
+func readListEvent(t *testing.T, reader *bufio.Reader) streamedListEvent {
+	var event streamedListEvent
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && event.name != "":
+			return event
+		case strings.HasPrefix(line, "event:"):
+			event.name = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &event.data))
+		}
+	}
+}
+
+func TestListHandler_EventsFollowChanges(t *testing.T) {
+	r, _ := newListRouter(t)
+	listID := newSharedList(t, r)
+	base := "/api/v1/lists/" + listID
+	token := inviteTo(t, r, listID, models.InviteListMemberRequest{Username: "user-2", Role: models.ListViewer})
+	require.Equal(t, http.StatusOK, accept(r, "user-2", token).Code)
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+	req, err := http.NewRequest("GET", server.URL+base+"/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Test-User", "user-2")
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	reader := bufio.NewReader(resp.Body)
+
+	event := readListEvent(t, reader)
+	assert.Equal(t, "list", event.name)
+	assert.Equal(t, int64(3), event.data.Version)
+
+	w := doIfMatch(r, "POST", base+"/items", `"3"`, models.AddListItemRequest{Name: "Eggs"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	event = readListEvent(t, reader)
+	assert.Equal(t, "list", event.name)
+	assert.Equal(t, int64(4), event.data.Version)
+	require.NotNil(t, event.data.List)
+	require.Len(t, event.data.List.Items, 1)
+	assert.Equal(t, "Eggs", event.data.List.Items[0].Name)
+
+	w = doJSON(r, "DELETE", base+"/members/user-2", "user-1", nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	event = readListEvent(t, reader)
+	assert.Equal(t, "removed", event.name)
+	assert.Nil(t, event.data.List, "removed members no longer see the list")
+	_, err = reader.ReadString('\n')
+	assert.Error(t, err, "the stream ends")
+
+	w = doJSON(r, "GET", base+"/events", "user-2", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"auth-service/internal/catalog"
+	"auth-service/internal/config"
+	"auth-service/internal/lists"
+	"auth-service/internal/models"
+	"auth-service/pkg/logger"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newListRouter mounts the shopping list routes behind testUser, with a
+// product sold by two retailers. It returns the product's ID.
+func newListRouter(t *testing.T) (*gin.Engine, string) {
+	r, productID, _ := newListRouterWithStore(t)
+	return r, productID
+}
+
+// newListRouterWithStore is newListRouter also returning the list store
+func newListRouterWithStore(t *testing.T) (*gin.Engine, string, lists.ListStore) {
+	gin.SetMode(gin.TestMode)
+	log := &logger.Logger{Logger: logrus.New()}
+
+	dir := t.TempDir()
+	products, err := catalog.NewCatalog(dir, config.CatalogConfig{Currency: "EUR"}, nil, log)
+	require.NoError(t, err)
+	feeds, err := catalog.ParseFeeds([]byte(catalogTestFeeds), "EUR")
+	require.NoError(t, err)
+	for id, content := range map[string]string{
+		"acme":   "sku,gtin,name,brand,category,price\nA-1,4006381333931,Trail Runner,,,49.99\n",
+		"shoply": "sku,gtin,name,price,shipping\nS-1,4006381333931,Trail Runner,45.00,4.95\n",
+	} {
+		feed, _ := feeds.Get(id)
+		_, err := products.Import(context.Background(), feed, strings.NewReader(content))
+		require.NoError(t, err)
+	}
+	page, err := products.Search(context.Background(), catalog.SearchQuery{})
+	require.NoError(t, err)
+	store, err := lists.NewFileStore(dir)
+	require.NoError(t, err)
+	hub := lists.NewHub()
+	cfg := &config.Config{Lists: config.ListsConfig{InviteSecret: "test-secret", InviteTTL: time.Hour}}
+	handler := NewListHandler(cfg, log, lists.NewBroadcast(store, hub), hub, products)
+
+	r := gin.New()
+	group := r.Group("/api/v1/lists", testUser())
+	group.GET("", handler.ListLists)
+	group.POST("", handler.CreateList)
+	group.GET("/:listId", handler.GetList)
+	group.PUT("/:listId", handler.RenameList)
+	group.DELETE("/:listId", handler.DeleteList)
+	group.POST("/:listId/items", handler.AddItem)
+	group.PUT("/:listId/items/:itemId", handler.UpdateItem)
+	group.DELETE("/:listId/items/:itemId", handler.DeleteItem)
+	group.GET("/:listId/cart", handler.Cart)
+	group.GET("/:listId/events", handler.Events)
+	group.POST("/:listId/invites", handler.Invite)
+	group.DELETE("/:listId/invites/:inviteId", handler.RevokeInvite)
+	group.PUT("/:listId/members/:userId", handler.UpdateMember)
+	group.DELETE("/:listId/members/:userId", handler.RemoveMember)
+	group.POST("/invites/accept", handler.AcceptInvite)
+	return r, page.Products[0].ID, store
+}
+
+// doIfMatch is doJSON for user-1 with an If-Match header
+func doIfMatch(r *gin.Engine, method, path, etag string, body interface{}) *httptest.ResponseRecorder {
+	return doIfMatchAs(r, method, path, "user-1", etag, body)
+}
+
+// doIfMatchAs is doJSON with an If-Match header
+func doIfMatchAs(r *gin.Engine, method, path, user, etag string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", user)
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decodeList(t *testing.T, w *httptest.ResponseRecorder) models.ShoppingList {
+	var list models.ShoppingList
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list), w.Body.String())
+	return list
+}
+
+func TestListHandler_ItemsAndCart(t *testing.T) {
+	r, productID := newListRouter(t)
+
+	w := doJSON(r, "POST", "/api/v1/lists", "user-1", models.SaveListRequest{Name: " Running "})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	list := decodeList(t, w)
+	assert.Equal(t, "Running", list.Name)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	base := "/api/v1/lists/" + list.ID
+
+	quantity := 2
+	w = doIfMatch(r, "POST", base+"/items", `"1"`, models.AddListItemRequest{ProductID: productID, Quantity: &quantity})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	list = decodeList(t, w)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "Trail Runner", list.Items[0].Name, "product items are named after the product")
+	shoes := list.Items[0].ID
+
+	w = doIfMatch(r, "POST", base+"/items", w.Header().Get("ETag"), models.AddListItemRequest{Name: "Socks", Note: "wool"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	list = decodeList(t, w)
+	require.Len(t, list.Items, 2)
+	assert.Equal(t, 1, list.Items[1].Quantity)
+	assert.Equal(t, int64(3), list.Version)
+
+	w = doJSON(r, "GET", base+"/cart", "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var cart models.Cart
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &cart))
+	require.Len(t, cart.Retailers, 1, "free-text items are not priced")
+	assert.Equal(t, "shoply", cart.Retailers[0].Retailer.ID)
+	assert.Equal(t, models.Money{Amount: 9000 + 495, Currency: "EUR"}, cart.Total)
+
+	w = doIfMatch(r, "PUT", base+"/items/"+shoes, `"3"`, models.UpdateListItemRequest{Name: "Trail Runner", Quantity: &quantity, Checked: true})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.True(t, decodeList(t, w).Items[0].Checked)
+	w = doJSON(r, "GET", base+"/cart", "user-1", nil)
+	assert.JSONEq(t, `{"list_id": "`+list.ID+`", "currency": "EUR", "retailers": [], "unavailable": [], "total": {"amount": 0, "currency": "EUR"}}`, w.Body.String(), "checked items are bought")
+
+	w = doIfMatch(r, "DELETE", base+"/items/"+shoes, `"4"`, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Len(t, decodeList(t, w).Items, 1)
+
+	w = doJSON(r, "GET", "/api/v1/lists", "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var summaries struct {
+		Lists []models.ListSummary `json:"lists"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summaries))
+	require.Len(t, summaries.Lists, 1)
+	assert.Equal(t, 1, summaries.Lists[0].ItemCount)
+	assert.Equal(t, int64(5), summaries.Lists[0].Version)
+
+	w = doJSON(r, "GET", "/api/v1/lists", "user-2", nil)
+	assert.JSONEq(t, `{"lists": []}`, w.Body.String())
+	w = doJSON(r, "GET", base, "user-2", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, "lists of other users do not exist")
+	w = doIfMatch(r, "DELETE", base, `"5"`, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestListHandler_Versions(t *testing.T) {
+	r, _ := newListRouter(t)
+
+	w := doJSON(r, "POST", "/api/v1/lists", "user-1", models.SaveListRequest{Name: "Groceries"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	base := "/api/v1/lists/" + decodeList(t, w).ID
+
+	w = doIfMatch(r, "PUT", base, "", models.SaveListRequest{Name: "Food"})
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+	assert.Contains(t, w.Body.String(), "version_required")
+
+	// Two devices rename the list from the same version; the second loses
+	w = doIfMatch(r, "PUT", base, `"1"`, models.SaveListRequest{Name: "Food"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	w = doIfMatch(r, "PUT", base, `"1"`, models.SaveListRequest{Name: "Weekly shop"})
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Contains(t, w.Body.String(), "version_conflict")
+	w = doIfMatch(r, "DELETE", base, `"1"`, nil)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	w = doIfMatch(r, "PUT", base, `W/"banana"`, models.SaveListRequest{Name: "Weekly shop"})
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = doJSON(r, "GET", base, "user-1", nil)
+	assert.Equal(t, "Food", decodeList(t, w).Name)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	w = doIfMatch(r, "PUT", base+"/items/missing", `"2"`, models.UpdateListItemRequest{Name: "Bread"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "item_not_found")
+
+	// "*" matches any version of an existing list
+	w = doIfMatch(r, "PUT", base, `"0"`, models.SaveListRequest{Name: "Weekly shop"})
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	w = doIfMatch(r, "PUT", base, "*", models.SaveListRequest{Name: "Weekly shop"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	w = doIfMatch(r, "DELETE", base, "*", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = doIfMatch(r, "DELETE", base, "*", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestListHandler_InvalidItems(t *testing.T) {
+	r, productID := newListRouter(t)
+
+	w := doJSON(r, "POST", "/api/v1/lists", "user-1", models.SaveListRequest{Name: "Groceries"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	base := "/api/v1/lists/" + decodeList(t, w).ID
+	zero := 0
+
+	for name, req := range map[string]models.AddListItemRequest{
+		"required":        {Note: "no name"},
+		"range":           {Name: "Bread", Quantity: &zero},
+		"unknown_product": {ProductID: "missing"},
+		"unknown_variant": {ProductID: productID, VariantID: "missing"},
+		"id_format":       {ProductID: "not an id"},
+	} {
+		w := doIfMatch(r, "POST", base+"/items", `"1"`, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		assert.Contains(t, w.Body.String(), `"code":"`+name+`"`, name)
+	}
+
+	w = doJSON(r, "POST", "/api/v1/lists", "user-1", models.SaveListRequest{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON(r, "GET", "/api/v1/lists/missing/cart", "user-1", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doJSON(r, "GET", "/api/v1/lists", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,470 @@
+package handlers
+
+import (
+	"auth-service/internal/balance"
+	"auth-service/internal/config"
+	"auth-service/internal/conversations"
+	"auth-service/internal/flags"
+	"auth-service/internal/librechat"
+	"auth-service/internal/messages"
+	"auth-service/internal/models"
+	"auth-service/internal/problem"
+	"auth-service/internal/storage"
+	"auth-service/internal/tools"
+	"auth-service/internal/validation"
+	"auth-service/pkg/logger"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// generationFailedText replaces the text of a reply whose generation failed
+const generationFailedText = "An error occurred while generating the response. Please try again."
+
+// MessageHandler serves the messages API (/api/messages): the message trees
+// of conversations and assistant replies streamed as Server-Sent Events.
+// Every request is scoped to the user_id set by AuthMiddleware.
+type MessageHandler struct {
+	registry      *librechat.Registry
+	conversations conversations.ConversationStore
+	messages      messages.MessageStore
+	providers     messages.ProviderFactory
+	meter         messages.Meter
+	tools         *tools.Registry
+	maxToolRounds int
+	generations   *messages.Generations
+	timeout       time.Duration
+	logger        *logger.Logger
+}
+
+// NewMessageHandler creates a new message handler. Replies are charged to
+// meter unless it is nil, and may call the tools of toolbox unless it is nil.
+func NewMessageHandler(cfg *config.Config, logger *logger.Logger, registry *librechat.Registry, convos conversations.ConversationStore, store messages.MessageStore, providers messages.ProviderFactory, meter messages.Meter, toolbox *tools.Registry) *MessageHandler {
+	return &MessageHandler{
+		registry:      registry.Restrict(cfg.LibreChat.Endpoints),
+		conversations: convos,
+		messages:      store,
+		providers:     providers,
+		meter:         meter,
+		tools:         toolbox,
+		maxToolRounds: cfg.LLM.MaxToolRounds,
+		generations:   messages.NewGenerations(),
+		timeout:       cfg.LLM.Timeout,
+		logger:        logger,
+	}
+}
+
+// List returns every message of one of the user's conversations, oldest
+// first; the client rebuilds the tree from parentMessageId
+func (h *MessageHandler) List(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	conversationID := c.Param("conversationId")
+	if _, err := h.conversations.Get(ctx, userID, conversationID); err != nil {
+		h.writeError(c, err, "Failed to get conversation")
+		return
+	}
+	list, err := h.messages.List(ctx, userID, conversationID)
+	if err != nil {
+		h.writeError(c, err, "Failed to list messages")
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// Send stores the user's message and streams the assistant reply as
+// Server-Sent Events: a "message" event with {created: true} and the stored
+// user message, "message" events with the reply text so far, then a
+// "message" event with {final: true} and both stored messages. A failed
+// generation ends with an "error" event carrying the stored error reply.
+// Each tool the model calls is sent as a "message" event with a toolCall
+// before it runs and again with its output, and is stored on the reply.
+func (h *MessageHandler) Send(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.SendMessageRequest
+	if !bindAndValidate(c, h.logger, validation.SendMessage, &req) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	endpoint, ok := h.registry.Get(req.Endpoint)
+	if !ok || !endpoint.VisibleTo(flags.SubjectFrom(ctx)) {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "endpoint_not_found",
+			Message: "Endpoint not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	model, ok := pickModel(endpoint, req.Model)
+	if !ok {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "invalid_model",
+			Message: "The endpoint does not offer this model",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	provider, err := h.providers(endpoint)
+	if err != nil {
+		h.logger.WithError(err).WithField("endpoint", endpoint.Name).Warn("No provider for endpoint")
+		problem.Write(c, models.ErrorResponse{
+			Error:   "unsupported_endpoint",
+			Message: "The endpoint cannot be used for chat",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	convo, err := h.conversation(ctx, userID, &req, endpoint, model)
+	if err != nil {
+		h.writeError(c, err, "Failed to get conversation")
+		return
+	}
+	history, err := h.messages.List(ctx, userID, convo.ConversationID)
+	if err != nil {
+		h.writeError(c, err, "Failed to list messages")
+		return
+	}
+
+	parentID := req.ParentMessageID
+	if parentID == "" {
+		parentID = messages.NoParentID
+	}
+	var thread []models.Message
+	if parentID != messages.NoParentID {
+		if thread, err = messages.Thread(history, parentID); err != nil {
+			problem.Write(c, models.ErrorResponse{
+				Error:   "parent_message_not_found",
+				Message: "Parent message not found",
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+	}
+	if req.MessageID == "" {
+		req.MessageID = storage.NewID()
+	}
+	for _, msg := range history {
+		if msg.MessageID == req.MessageID {
+			problem.Write(c, models.ErrorResponse{
+				Error:   "message_exists",
+				Message: "A message with this ID already exists",
+				Code:    http.StatusConflict,
+			})
+			return
+		}
+	}
+
+	genCtx, finish, ok := h.generations.Start(ctx, userID, convo.ConversationID)
+	if !ok {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "generation_in_progress",
+			Message: "A response is already being generated in this conversation",
+			Code:    http.StatusConflict,
+		})
+		return
+	}
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		genCtx, cancel = context.WithTimeout(genCtx, h.timeout)
+		defer cancel()
+	}
+
+	userMessage := &models.Message{
+		MessageID:       req.MessageID,
+		ConversationID:  convo.ConversationID,
+		ParentMessageID: parentID,
+		Sender:          "User",
+		Text:            req.Text,
+		IsCreatedByUser: true,
+		Endpoint:        endpoint.Name,
+		Model:           model,
+	}
+	thread = append(thread, *userMessage)
+	completion := messages.CompletionRequest{Model: model, Messages: chatMessages(thread)}
+
+	settle := func(context.Context, messages.Usage) error { return nil }
+	if h.meter != nil {
+		if settle, err = h.meter.Reserve(ctx, userID, completion); err != nil {
+			finish()
+			h.writeBalanceError(c, err)
+			return
+		}
+	}
+	if err := h.messages.Save(ctx, userID, userMessage); err != nil {
+		finish()
+		if settleErr := settle(context.WithoutCancel(ctx), messages.Usage{}); settleErr != nil {
+			h.logger.WithError(settleErr).WithField("user_id", userID).Error("Failed to release credits")
+		}
+		h.writeError(c, err, "Failed to save message")
+		return
+	}
+
+	// Replies outlive the server's WriteTimeout; genCtx bounds them instead
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	writeEvent(c, "message", models.MessageEvent{Created: true, Message: userMessage})
+
+	responseMessage := &models.Message{
+		MessageID:       storage.NewID(),
+		ConversationID:  convo.ConversationID,
+		ParentMessageID: userMessage.MessageID,
+		Sender:          senderLabel(endpoint, model),
+		Endpoint:        endpoint.Name,
+		Model:           model,
+	}
+	var text strings.Builder
+	toolbox := h.tools.For(tools.Caller{UserID: userID, Username: c.GetString("username"), Email: c.GetString("email")})
+	reply, err := messages.Generate(genCtx, provider, completion, toolbox, h.maxToolRounds, func(delta string) {
+		text.WriteString(delta)
+		writeEvent(c, "message", models.MessageEvent{
+			Message:         true,
+			Text:            text.String(),
+			MessageID:       responseMessage.MessageID,
+			ParentMessageID: userMessage.MessageID,
+			ConversationID:  convo.ConversationID,
+		})
+	}, func(call models.ToolCall) {
+		writeEvent(c, "message", models.MessageEvent{
+			ToolCall:        &call,
+			MessageID:       responseMessage.MessageID,
+			ParentMessageID: userMessage.MessageID,
+			ConversationID:  convo.ConversationID,
+		})
+	})
+	aborted := finish()
+	disconnected := ctx.Err() != nil
+
+	log := h.logger.WithField("user_id", userID).WithField("conversation_id", convo.ConversationID)
+	for _, call := range reply.ToolCalls {
+		if err, ok := reply.ToolErrors[call.ID]; ok {
+			log.WithError(err).WithField("tool", call.Name).Warn("Tool call failed")
+		}
+	}
+	responseMessage.Text = reply.Text
+	responseMessage.ToolCalls = reply.ToolCalls
+	switch {
+	case err == nil:
+	case aborted || disconnected:
+		// Keep what was generated so far, as LibreChat does
+		responseMessage.Unfinished = true
+		log.Info("Generation aborted")
+	default:
+		log.WithError(err).WithField("endpoint", endpoint.Name).Error("Generation failed")
+		responseMessage.Error = true
+		responseMessage.Text = generationFailedText
+	}
+
+	// Store the reply even when the client went away
+	saveCtx := context.WithoutCancel(ctx)
+	usage := messages.Usage{}
+	if !responseMessage.Error {
+		usage = reply.Usage
+	}
+	if err := settle(saveCtx, usage); err != nil {
+		log.WithError(err).Error("Failed to settle credits")
+	}
+	if err := h.messages.Save(saveCtx, userID, responseMessage); err != nil {
+		log.WithError(err).Error("Failed to save response message")
+	}
+	if touched, err := h.conversations.Touch(saveCtx, userID, convo.ConversationID); err != nil {
+		log.WithError(err).Error("Failed to update conversation")
+	} else {
+		convo = touched
+	}
+	if disconnected {
+		return
+	}
+
+	final := models.MessageEvent{
+		Final:           !responseMessage.Error,
+		Conversation:    convo,
+		RequestMessage:  userMessage,
+		ResponseMessage: responseMessage,
+	}
+	if responseMessage.Error {
+		writeEvent(c, "error", final)
+		return
+	}
+	writeEvent(c, "message", final)
+}
+
+// Abort stops the user's running generation in a conversation. The reply
+// stream then ends with the partial reply, stored as unfinished.
+func (h *MessageHandler) Abort(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.AbortMessageRequest
+	if !bindAndValidate(c, h.logger, validation.AbortMessage, &req, func() []validation.Error {
+		if req.ConversationID == "" && req.AbortKey == "" {
+			return []validation.Error{{Field: "conversationId", Code: "required"}}
+		}
+		return nil
+	}) {
+		return
+	}
+
+	conversationID := req.ConversationID
+	if conversationID == "" {
+		// The client's abort key is "conversationId:messageId"
+		conversationID, _, _ = strings.Cut(req.AbortKey, ":")
+	}
+	if !h.generations.Abort(userID, conversationID) {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "generation_not_found",
+			Message: "No response is being generated in this conversation",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Generation aborted",
+		Data:    gin.H{"conversationId": conversationID},
+	})
+}
+
+// conversation returns the conversation a message is sent to, creating it
+// when the request starts a new one
+func (h *MessageHandler) conversation(ctx context.Context, userID string, req *models.SendMessageRequest, endpoint *librechat.Endpoint, model string) (*models.Conversation, error) {
+	if req.ConversationID != "" && req.ConversationID != "new" {
+		return h.conversations.Get(ctx, userID, req.ConversationID)
+	}
+	convo := &models.Conversation{
+		Endpoint:     endpoint.Name,
+		EndpointType: endpoint.Type,
+		Model:        model,
+	}
+	if err := h.conversations.Create(ctx, userID, convo); err != nil {
+		return nil, err
+	}
+	return convo, nil
+}
+
+// writeError maps conversation and message store errors to responses
+func (h *MessageHandler) writeError(c *gin.Context, err error, msg string) {
+	if errors.Is(err, conversations.ErrNotFound) {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "conversation_not_found",
+			Message: "Conversation not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	h.logger.WithError(err).Error(msg)
+	problem.Write(c, models.ErrorResponse{
+		Error:   "internal_error",
+		Message: msg,
+		Code:    http.StatusInternalServerError,
+	})
+}
+
+// writeBalanceError maps meter errors to responses
+func (h *MessageHandler) writeBalanceError(c *gin.Context, err error) {
+	if errors.Is(err, balance.ErrInsufficientFunds) {
+		problem.Write(c, models.ErrorResponse{
+			Error:   "insufficient_balance",
+			Message: "Not enough credits to generate a response",
+			Code:    http.StatusPaymentRequired,
+		})
+		return
+	}
+	h.logger.WithError(err).Error("Failed to reserve credits")
+	problem.Write(c, models.ErrorResponse{
+		Error:   "internal_error",
+		Message: "Failed to reserve credits",
+		Code:    http.StatusInternalServerError,
+	})
+}
+
+// pickModel returns the requested model, or the endpoint's first model when
+// none was requested. Endpoints listing their models accept only those.
+func pickModel(endpoint *librechat.Endpoint, requested string) (string, bool) {
+	offered := endpoint.Models.Default
+	if requested == "" {
+		if len(offered) == 0 {
+			return "", true
+		}
+		return offered[0], true
+	}
+	if len(offered) == 0 {
+		return requested, true
+	}
+	for _, model := range offered {
+		if model == requested {
+			return requested, true
+		}
+	}
+	return "", false
+}
+
+// senderLabel names the assistant in its replies
+func senderLabel(endpoint *librechat.Endpoint, model string) string {
+	switch {
+	case endpoint.ModelDisplayLabel != "":
+		return endpoint.ModelDisplayLabel
+	case model != "":
+		return model
+	default:
+		return endpoint.Name
+	}
+}
+
+// chatMessages converts a thread to model input, leaving out failed replies.
+// The tool calls of a reply come before its text, each followed by its
+// output, as the model made them.
+func chatMessages(thread []models.Message) []messages.ChatMessage {
+	chat := make([]messages.ChatMessage, 0, len(thread))
+	for _, msg := range thread {
+		if msg.Error {
+			continue
+		}
+		if len(msg.ToolCalls) > 0 {
+			calls := make([]messages.ToolCall, 0, len(msg.ToolCalls))
+			for _, call := range msg.ToolCalls {
+				calls = append(calls, messages.ToolCall{
+					ID:       call.ID,
+					Type:     messages.ToolTypeFunction,
+					Function: messages.FunctionCall{Name: call.Name, Arguments: call.Args},
+				})
+			}
+			chat = append(chat, messages.ChatMessage{Role: messages.RoleAssistant, ToolCalls: calls})
+			for _, call := range msg.ToolCalls {
+				chat = append(chat, messages.ChatMessage{Role: messages.RoleTool, Content: call.Output, ToolCallID: call.ID})
+			}
+		}
+		if msg.Text == "" {
+			continue
+		}
+		role := messages.RoleAssistant
+		if msg.IsCreatedByUser {
+			role = messages.RoleUser
+		}
+		chat = append(chat, messages.ChatMessage{Role: role, Content: msg.Text})
+	}
+	return chat
+}
+
+// writeEvent sends one Server-Sent Event with JSON data and flushes it
+func writeEvent(c *gin.Context, event string, data interface{}) {
+	c.SSEvent(event, data)
+	c.Writer.Flush()
+}
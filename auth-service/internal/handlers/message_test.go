@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"auth-service/internal/balance"
+	"auth-service/internal/config"
+	"auth-service/internal/conversations"
+	"auth-service/internal/librechat"
+	"auth-service/internal/messages"
+	"auth-service/internal/models"
+	"auth-service/internal/tools"
+	"auth-service/pkg/logger"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRegistry = `
+endpoints:
+  openAI:
+    models:
+      default: ["gpt-4o-mini", "gpt-4o"]
+  custom:
+    - name: "Ollama"
+      baseURL: "http://localhost:11434/v1"
+      modelDisplayLabel: "Llama"
+      roles: ["premium"]
+`
+
+// failingProvider streams a partial reply and fails
+type failingProvider struct{}
+
+func (failingProvider) Stream(ctx context.Context, req messages.CompletionRequest, onDelta func(string)) ([]messages.ToolCall, error) {
+	onDelta("Partial")
+	return nil, errors.New("upstream closed the connection")
+}
+
+// newMessageRouter mounts the message and conversation routes behind
+// testUser, replying through provider
+func newMessageRouter(t *testing.T, provider messages.LLMProvider) *gin.Engine {
+	return newMeteredMessageRouter(t, provider, nil)
+}
+
+// newMeteredMessageRouter is newMessageRouter charging replies to meter
+func newMeteredMessageRouter(t *testing.T, provider messages.LLMProvider, meter messages.Meter) *gin.Engine {
+	return newToolMessageRouter(t, provider, meter, nil)
+}
+
+// newToolMessageRouter is newMeteredMessageRouter offering the tools of toolbox
+func newToolMessageRouter(t *testing.T, provider messages.LLMProvider, meter messages.Meter, toolbox *tools.Registry) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	registry, err := librechat.ParseRegistry(context.Background(), []byte(testRegistry), nil)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	convoStore, err := conversations.NewFileStore(dir)
+	require.NoError(t, err)
+	messageStore, err := messages.NewFileStore(dir)
+	require.NoError(t, err)
+
+	log := &logger.Logger{Logger: logrus.New()}
+	providers := func(*librechat.Endpoint) (messages.LLMProvider, error) { return provider, nil }
+	handler := NewMessageHandler(&config.Config{LLM: config.LLMConfig{MaxToolRounds: 2}}, log, registry, convoStore, messageStore, providers, meter, toolbox)
+	convoHandler := NewConversationHandler(nil, log, convoStore, messageStore)
+
+	r := gin.New()
+	api := r.Group("/api", testUser())
+	api.POST("/messages", handler.Send)
+	api.POST("/messages/abort", handler.Abort)
+	api.GET("/messages/:conversationId", handler.List)
+	api.DELETE("/convos", convoHandler.Delete)
+	return r
+}
+
+type sseEvent struct {
+	Name string
+	Data models.MessageEvent
+}
+
+// parseEvents splits a Server-Sent Events body into events
+func parseEvents(t *testing.T, body string) []sseEvent {
+	var events []sseEvent
+	var current sseEvent
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			current.Name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &current.Data))
+		case line == "" && current.Name != "":
+			events = append(events, current)
+			current = sseEvent{}
+		}
+	}
+	return events
+}
+
+func TestMessageHandler_StreamsReply(t *testing.T) {
+	r := newMessageRouter(t, &messages.EchoProvider{})
+
+	w := doJSON(r, "POST", "/api/messages", "user-1", models.SendMessageRequest{
+		Text:     "Find running shoes",
+		Endpoint: "openAI",
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+
+	events := parseEvents(t, w.Body.String())
+	require.Len(t, events, 6) // created, four deltas, final
+
+	created := events[0].Data
+	assert.True(t, created.Created)
+	userMessage, ok := created.Message.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "Find running shoes", userMessage["text"])
+	assert.Equal(t, messages.NoParentID, userMessage["parentMessageId"])
+
+	assert.Equal(t, "Echo: ", events[1].Data.Text)
+	assert.Equal(t, "Echo: Find running ", events[3].Data.Text)
+	assert.Equal(t, true, events[4].Data.Message)
+
+	final := events[5].Data
+	assert.Equal(t, "message", events[5].Name)
+	require.True(t, final.Final)
+	assert.Equal(t, "Echo: Find running shoes", final.ResponseMessage.Text)
+	assert.Equal(t, "gpt-4o-mini", final.ResponseMessage.Model, "defaults to the endpoint's first model")
+	assert.Equal(t, final.RequestMessage.MessageID, final.ResponseMessage.ParentMessageID)
+	assert.Equal(t, events[4].Data.MessageID, final.ResponseMessage.MessageID)
+	conversationID := final.Conversation.ConversationID
+	assert.Equal(t, "openAI", final.Conversation.Endpoint)
+
+	// Continue the thread from the reply
+	w = doJSON(r, "POST", "/api/messages", "user-1", models.SendMessageRequest{
+		Text:            "Find trail shoes",
+		ConversationID:  conversationID,
+		ParentMessageID: final.ResponseMessage.MessageID,
+		Endpoint:        "openAI",
+		Model:           "gpt-4o",
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	events = parseEvents(t, w.Body.String())
+	second := events[len(events)-1].Data
+	assert.Equal(t, "Echo: Find trail shoes", second.ResponseMessage.Text)
+
+	w = doJSON(r, "GET", "/api/messages/"+conversationID, "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list []models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list, 4)
+	thread, err := messages.Thread(list, second.ResponseMessage.MessageID)
+	require.NoError(t, err)
+	assert.Len(t, thread, 4)
+
+	// Other users see nothing, and deleting the conversation deletes its messages
+	w = doJSON(r, "GET", "/api/messages/"+conversationID, "user-2", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doJSON(r, "DELETE", "/api/convos", "user-1", gin.H{"arg": gin.H{"conversationId": conversationID}})
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(r, "GET", "/api/messages/"+conversationID, "user-1", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// recordingProvider echoes like EchoProvider, keeping the last request
+type recordingProvider struct {
+	messages.EchoProvider
+	last messages.CompletionRequest
+}
+
+func (p *recordingProvider) Stream(ctx context.Context, req messages.CompletionRequest, onDelta func(string)) ([]messages.ToolCall, error) {
+	p.last = req
+	return p.EchoProvider.Stream(ctx, req, onDelta)
+}
+
+func TestMessageHandler_CallsTools(t *testing.T) {
+	whoami := tools.Tool{
+		Name:       "whoami",
+		Parameters: json.RawMessage(`{"type": "object"}`),
+		Run: func(ctx context.Context, caller tools.Caller, args json.RawMessage) (interface{}, error) {
+			return gin.H{"user": caller.UserID}, nil
+		},
+	}
+	provider := &recordingProvider{EchoProvider: messages.EchoProvider{Tools: true}}
+	r := newToolMessageRouter(t, provider, nil, tools.NewRegistry(whoami))
+
+	w := doJSON(r, "POST", "/api/messages", "user-1", models.SendMessageRequest{Text: "/whoami", Endpoint: "openAI"})
+	require.Equal(t, http.StatusOK, w.Code)
+	events := parseEvents(t, w.Body.String())
+	require.Len(t, events, 6) // created, tool call, its output, two deltas, final
+
+	final := events[5].Data
+	require.True(t, final.Final)
+	started, done := events[1].Data.ToolCall, events[2].Data.ToolCall
+	require.NotNil(t, started)
+	require.NotNil(t, done)
+	assert.Equal(t, "whoami", started.Name)
+	assert.Empty(t, started.Output)
+	assert.JSONEq(t, `{"user": "user-1"}`, done.Output, "tools run as the sender")
+	assert.Equal(t, final.ResponseMessage.MessageID, events[1].Data.MessageID)
+	assert.Equal(t, `Echo: {"user":"user-1"}`, final.ResponseMessage.Text)
+	assert.Equal(t, []models.ToolCall{*done}, final.ResponseMessage.ToolCalls)
+
+	// The stored calls are sent back to the model with the thread
+	w = doJSON(r, "POST", "/api/messages", "user-1", models.SendMessageRequest{
+		Text:            "Thanks",
+		ConversationID:  final.Conversation.ConversationID,
+		ParentMessageID: final.ResponseMessage.MessageID,
+		Endpoint:        "openAI",
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	roles := make([]string, 0, len(provider.last.Messages))
+	for _, msg := range provider.last.Messages {
+		roles = append(roles, msg.Role)
+	}
+	assert.Equal(t, []string{"user", "assistant", "tool", "assistant", "user"}, roles)
+	assert.Equal(t, done.ID, provider.last.Messages[1].ToolCalls[0].ID)
+	assert.Equal(t, done.ID, provider.last.Messages[2].ToolCallID)
+	require.Len(t, provider.last.Tools, 1)
+
+	w = doJSON(r, "GET", "/api/messages/"+final.Conversation.ConversationID, "user-1", nil)
+	var list []models.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list, 4)
+	assert.Len(t, list[1].ToolCalls, 1)
+}
+
+func TestMessageHandler_Rejects(t *testing.T) {
+	r := newMessageRouter(t, &messages.EchoProvider{})
+
+	tests := []struct {
+		name string
+		user string
+		req  models.SendMessageRequest
+		code int
+	}{
+		{"unauthenticated", "", models.SendMessageRequest{Text: "Hi", Endpoint: "openAI"}, http.StatusUnauthorized},
+		{"missing text", "user-1", models.SendMessageRequest{Endpoint: "openAI"}, http.StatusBadRequest},
+		{"unknown endpoint", "user-1", models.SendMessageRequest{Text: "Hi", Endpoint: "bing"}, http.StatusNotFound},
+		{"endpoint limited to a role", "user-1", models.SendMessageRequest{Text: "Hi", Endpoint: "Ollama"}, http.StatusNotFound},
+		{"model not offered", "user-1", models.SendMessageRequest{Text: "Hi", Endpoint: "openAI", Model: "o1"}, http.StatusBadRequest},
+		{"unknown conversation", "user-1", models.SendMessageRequest{Text: "Hi", Endpoint: "openAI", ConversationID: "missing"}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(r, "POST", "/api/messages", tt.user, tt.req)
+			assert.Equal(t, tt.code, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestMessageHandler_ProviderError(t *testing.T) {
+	r := newMessageRouter(t, failingProvider{})
+
+	w := doJSON(r, "POST", "/api/messages", "user-1", models.SendMessageRequest{Text: "Hi", Endpoint: "openAI"})
+	require.Equal(t, http.StatusOK, w.Code)
+	events := parseEvents(t, w.Body.String())
+	last := events[len(events)-1]
+	assert.Equal(t, "error", last.Name)
+	assert.True(t, last.Data.ResponseMessage.Error)
+	assert.Equal(t, generationFailedText, last.Data.ResponseMessage.Text)
+	assert.NotContains(t, w.Body.String(), "upstream closed", "provider errors are not leaked")
+}
+
+func TestMessageHandler_Abort(t *testing.T) {
+	r := newMessageRouter(t, &messages.EchoProvider{Delay: 20 * time.Millisecond})
+
+	const conversationID = "abort-test"
+	w := doJSON(r, "POST", "/api/messages/abort", "user-1", gin.H{"conversationId": conversationID})
+	assert.Equal(t, http.StatusNotFound, w.Code, "nothing to abort yet")
+
+	// Start a conversation, then abort the next reply while it streams
+	w = doJSON(r, "POST", "/api/messages", "user-1", models.SendMessageRequest{Text: "Hi", Endpoint: "openAI"})
+	require.Equal(t, http.StatusOK, w.Code)
+	events := parseEvents(t, w.Body.String())
+	id := events[len(events)-1].Data.Conversation.ConversationID
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- doJSON(r, "POST", "/api/messages", "user-1", models.SendMessageRequest{
+			Text:           "one two three four five six seven eight nine ten",
+			ConversationID: id,
+			Endpoint:       "openAI",
+		})
+	}()
+
+	require.Eventually(t, func() bool {
+		w := doJSON(r, "POST", "/api/messages/abort", "user-1", gin.H{"abortKey": id + ":response"})
+		return w.Code == http.StatusOK
+	}, time.Second, 5*time.Millisecond)
+
+	w = <-done
+	events = parseEvents(t, w.Body.String())
+	final := events[len(events)-1].Data
+	require.True(t, final.Final)
+	assert.True(t, final.ResponseMessage.Unfinished)
+	assert.NotContains(t, final.ResponseMessage.Text, "ten")
+}
+
+func TestMessageHandler_ChargesReplies(t *testing.T) {
+	store, err := balance.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	ledger := balance.NewLedger(store, 120)
+	r := newMeteredMessageRouter(t, &messages.EchoProvider{}, balance.NewMeter(ledger, 100))
+	ctx := context.Background()
+
+	w := doJSON(r, "POST", "/api/messages", "user-1", models.SendMessageRequest{
+		Text:     "Find running shoes",
+		Endpoint: "openAI",
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	credits, err := ledger.Balance(ctx, "user-1")
+	require.NoError(t, err)
+	// 5 prompt tokens and 6 for "Echo: Find running shoes"; the rest of the reservation is refunded
+	assert.Equal(t, int64(120-5-6), credits)
+
+	// 109 credits cannot cover the prompt plus the 100 reserved for the reply
+	w = doJSON(r, "POST", "/api/messages", "user-1", models.SendMessageRequest{
+		Text:     "Find running shoes for trail running in the mountains",
+		Endpoint: "openAI",
+	})
+	assert.Equal(t, http.StatusPaymentRequired, w.Code)
+	assert.Contains(t, w.Body.String(), "insufficient_balance")
+	credits, _ = ledger.Balance(ctx, "user-1")
+	assert.Equal(t, int64(109), credits)
+}
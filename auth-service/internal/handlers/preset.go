@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/flags"
+	"auth-service/internal/librechat"
+	"auth-service/internal/models"
+	"auth-service/internal/presets"
+	"auth-service/internal/problem"
+	"auth-service/internal/validation"
+	"auth-service/pkg/logger"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PresetHandler serves the LibreChat presets API (/api/presets). Every
+// request is scoped to the user_id set by AuthMiddleware.
+type PresetHandler struct {
+	registry *librechat.Registry
+	store    presets.PresetStore
+	logger   *logger.Logger
+}
+
+// NewPresetHandler creates a new preset handler. Presets are checked against
+// the endpoints of registry, as restricted by ENDPOINTS.
+func NewPresetHandler(cfg *config.Config, logger *logger.Logger, registry *librechat.Registry, store presets.PresetStore) *PresetHandler {
+	return &PresetHandler{
+		registry: registry.Restrict(cfg.LibreChat.Endpoints),
+		store:    store,
+		logger:   logger,
+	}
+}
+
+// List returns the user's presets, the default first
+func (h *PresetHandler) List(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.store.List(c.Request.Context(), userID)
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to list presets")
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// Save creates a preset, or replaces the one with the same presetId. The
+// endpoint must be one the user can see and the model one it offers.
+func (h *PresetHandler) Save(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.SavePresetRequest
+	var endpoint *librechat.Endpoint
+	model := ""
+	if !bindAndValidate(c, h.logger, validation.SavePreset, &req, func() []validation.Error {
+		var errs []validation.Error
+		var found bool
+		endpoint, found = h.registry.Get(req.Endpoint)
+		if !found || !endpoint.VisibleTo(flags.SubjectFrom(c.Request.Context())) {
+			errs = append(errs, validation.Error{Field: "endpoint", Code: "unknown_endpoint"})
+		} else if model, found = pickModel(endpoint, req.Model); !found {
+			errs = append(errs, validation.Error{Field: "model", Code: "unknown_model"})
+		}
+		errs = append(errs, validation.CheckRange("temperature", req.Temperature, 0, 2)...)
+		errs = append(errs, validation.CheckRange("top_p", req.TopP, 0, 1)...)
+		errs = append(errs, validation.CheckRange("frequency_penalty", req.FrequencyPenalty, -2, 2)...)
+		errs = append(errs, validation.CheckRange("presence_penalty", req.PresencePenalty, -2, 2)...)
+		errs = append(errs, validation.CheckRange("maxContextTokens", req.MaxContextTokens, 1, 2000000)...)
+		errs = append(errs, validation.CheckRange("max_tokens", req.MaxTokens, 1, 200000)...)
+		return errs
+	}) {
+		return
+	}
+
+	preset := &models.Preset{
+		PresetID:         req.PresetID,
+		Title:            req.Title,
+		Endpoint:         endpoint.Name,
+		EndpointType:     endpoint.Type,
+		Model:            model,
+		ModelLabel:       req.ModelLabel,
+		PromptPrefix:     req.PromptPrefix,
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		FrequencyPenalty: req.FrequencyPenalty,
+		PresencePenalty:  req.PresencePenalty,
+		MaxContextTokens: req.MaxContextTokens,
+		MaxTokens:        req.MaxTokens,
+		DefaultPreset:    req.DefaultPreset,
+	}
+	created, err := h.store.Save(c.Request.Context(), userID, preset)
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to save preset")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		h.logger.WithField("user_id", userID).WithField("preset_id", preset.PresetID).Info("Preset created")
+	}
+	c.JSON(status, preset)
+}
+
+// Delete removes the preset named by presetId, or all of the user's presets
+// when the body is empty or has no presetId (the client's "clear all")
+func (h *PresetHandler) Delete(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.PresetRefRequest
+	if c.Request.ContentLength != 0 && !bindAndValidate(c, h.logger, validation.PresetRef, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if req.PresetID == "" {
+		count, err := h.store.DeleteAll(ctx, userID)
+		if err != nil {
+			h.writeStoreError(c, err, "Failed to delete presets")
+			return
+		}
+		h.logger.WithField("user_id", userID).WithField("count", count).Info("Presets deleted")
+		c.JSON(http.StatusOK, models.DeleteResponse{Acknowledged: true, DeletedCount: count})
+		return
+	}
+
+	if err := h.store.Delete(ctx, userID, req.PresetID); err != nil {
+		h.writeStoreError(c, err, "Failed to delete preset")
+		return
+	}
+	c.JSON(http.StatusOK, models.DeleteResponse{Acknowledged: true, DeletedCount: 1})
+}
+
+// SetDefault makes the preset named by presetId the user's default. An
+// empty presetId clears the default and returns 204.
+func (h *PresetHandler) SetDefault(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.PresetRefRequest
+	if !bindAndValidate(c, h.logger, validation.PresetRef, &req) {
+		return
+	}
+
+	preset, err := h.store.SetDefault(c.Request.Context(), userID, req.PresetID)
+	if err != nil {
+		h.writeStoreError(c, err, "Failed to set default preset")
+		return
+	}
+	if preset == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, preset)
+}
+
+// writeStoreError maps preset store errors to responses
+func (h *PresetHandler) writeStoreError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, presets.ErrNotFound):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "preset_not_found",
+			Message: "Preset not found",
+			Code:    http.StatusNotFound,
+		})
+	case errors.Is(err, presets.ErrLimit):
+		problem.Write(c, models.ErrorResponse{
+			Error:   "preset_limit_reached",
+			Message: "Delete a preset before saving a new one",
+			Code:    http.StatusConflict,
+		})
+	default:
+		h.logger.WithError(err).Error(msg)
+		problem.Write(c, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: msg,
+			Code:    http.StatusInternalServerError,
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/librechat"
+	"auth-service/internal/models"
+	"auth-service/internal/presets"
+	"auth-service/pkg/logger"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPresetRouter mounts the preset routes behind testUser, with the
+// endpoints of testRegistry
+func newPresetRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	registry, err := librechat.ParseRegistry(context.Background(), []byte(testRegistry), nil)
+	require.NoError(t, err)
+	store, err := presets.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	handler := NewPresetHandler(&config.Config{}, &logger.Logger{Logger: logrus.New()}, registry, store)
+
+	r := gin.New()
+	group := r.Group("/api/presets", testUser())
+	group.GET("", handler.List)
+	group.POST("", handler.Save)
+	group.POST("/delete", handler.Delete)
+	group.POST("/default", handler.SetDefault)
+	return r
+}
+
+func TestPresetHandler_Lifecycle(t *testing.T) {
+	r := newPresetRouter(t)
+	temperature := 0.2
+
+	w := doJSON(r, "POST", "/api/presets", "user-1", models.SavePresetRequest{
+		Title:        "Bargain hunter",
+		Endpoint:     "openAI",
+		PromptPrefix: "Always look for the lowest price.",
+		Temperature:  &temperature,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created models.Preset
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.PresetID)
+	assert.Equal(t, "gpt-4o-mini", created.Model, "defaults to the endpoint's first model")
+	assert.Equal(t, 0.2, *created.Temperature)
+
+	// The client updates by posting the whole preset again
+	w = doJSON(r, "POST", "/api/presets", "user-1", models.SavePresetRequest{
+		PresetID:      created.PresetID,
+		Title:         "Bargain hunter",
+		Endpoint:      "openAI",
+		Model:         "gpt-4o",
+		DefaultPreset: true,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doJSON(r, "POST", "/api/presets", "user-1", models.SavePresetRequest{Title: "Gift ideas", Endpoint: "openAI"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var gifts models.Preset
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &gifts))
+
+	w = doJSON(r, "GET", "/api/presets", "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list []models.Preset
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list, 2)
+	assert.Equal(t, created.PresetID, list[0].PresetID, "the default comes first")
+	assert.Equal(t, "gpt-4o", list[0].Model)
+	assert.Nil(t, list[0].Temperature, "an update replaces every parameter")
+
+	w = doJSON(r, "POST", "/api/presets/default", "user-1", gin.H{"presetId": gifts.PresetID})
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(r, "POST", "/api/presets/default", "user-1", gin.H{"presetId": ""})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = doJSON(r, "GET", "/api/presets", "user-2", nil)
+	assert.JSONEq(t, `[]`, w.Body.String())
+	w = doJSON(r, "POST", "/api/presets/delete", "user-2", gin.H{"presetId": gifts.PresetID})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doJSON(r, "POST", "/api/presets/delete", "user-1", gin.H{"presetId": gifts.PresetID})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"acknowledged":true,"deletedCount":1}`, w.Body.String())
+
+	// An empty body clears every preset
+	w = doJSON(r, "POST", "/api/presets/delete", "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"acknowledged":true,"deletedCount":1}`, w.Body.String())
+}
+
+func TestPresetHandler_Validation(t *testing.T) {
+	r := newPresetRouter(t)
+	hot := 2.5
+	zero := 0
+
+	tests := []struct {
+		name  string
+		req   models.SavePresetRequest
+		field string
+		code  string
+	}{
+		{"missing title", models.SavePresetRequest{Endpoint: "openAI"}, "title", "required"},
+		{"unknown endpoint", models.SavePresetRequest{Title: "T", Endpoint: "bing"}, "endpoint", "unknown_endpoint"},
+		{"endpoint limited to a role", models.SavePresetRequest{Title: "T", Endpoint: "Ollama"}, "endpoint", "unknown_endpoint"},
+		{"model not offered", models.SavePresetRequest{Title: "T", Endpoint: "openAI", Model: "o1"}, "model", "unknown_model"},
+		{"temperature out of range", models.SavePresetRequest{Title: "T", Endpoint: "openAI", Temperature: &hot}, "temperature", "range"},
+		{"token limit out of range", models.SavePresetRequest{Title: "T", Endpoint: "openAI", MaxTokens: &zero}, "max_tokens", "range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(r, "POST", "/api/presets", "user-1", tt.req)
+			require.Equal(t, http.StatusBadRequest, w.Code)
+			var resp models.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			require.Len(t, resp.Details, 1, w.Body.String())
+			assert.Equal(t, tt.field, resp.Details[0].Field)
+			assert.Equal(t, tt.code, resp.Details[0].Code)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/problem"
+	"auth-service/internal/search"
+	"auth-service/pkg/logger"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSearchQuery is the longest search query accepted, in bytes
+const maxSearchQuery = 500
+
+// SearchHandler serves full-text search over the user's conversations and
+// messages (/api/search). Every request is scoped to the user_id set by
+// AuthMiddleware.
+type SearchHandler struct {
+	index  search.Index
+	logger *logger.Logger
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(cfg *config.Config, logger *logger.Logger, index search.Index) *SearchHandler {
+	return &SearchHandler{
+		index:  index,
+		logger: logger,
+	}
+}
+
+// Enabled serves /api/search/enable, which the client checks before showing
+// the search bar
+func (h *SearchHandler) Enabled(c *gin.Context) {
+	c.JSON(http.StatusOK, true)
+}
+
+// Search returns the user's conversation titles and messages matching q,
+// best first. Query parameters: q, type (conversation or message),
+// endpoint, from and to (RFC 3339 times or dates, both inclusive) and limit.
+func (h *SearchHandler) Search(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	q := search.Query{
+		Text:     c.Query("q"),
+		Kind:     c.Query("type"),
+		Endpoint: c.Query("endpoint"),
+	}
+	if q.Text == "" || len(q.Text) > maxSearchQuery {
+		h.invalidQuery(c, "q must be between 1 and 500 characters")
+		return
+	}
+	if q.Kind != "" && q.Kind != search.KindConversation && q.Kind != search.KindMessage {
+		h.invalidQuery(c, "type must be conversation or message")
+		return
+	}
+	var err error
+	if q.From, err = parseSearchTime(c.Query("from"), false); err != nil {
+		h.invalidQuery(c, "from must be a date or an RFC 3339 time")
+		return
+	}
+	if q.To, err = parseSearchTime(c.Query("to"), true); err != nil {
+		h.invalidQuery(c, "to must be a date or an RFC 3339 time")
+		return
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			h.invalidQuery(c, "limit must be a positive number")
+			return
+		}
+		q.Limit = n
+	}
+
+	hits, err := h.index.Search(c.Request.Context(), userID, q)
+	if errors.Is(err, search.ErrInvalidQuery) {
+		h.invalidQuery(c, "The query has no words to search for, too many, or a prefix shorter than two characters")
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Search failed")
+		problem.Write(c, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Search failed",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	results := make([]models.SearchResult, len(hits))
+	for i, hit := range hits {
+		doc := hit.Document
+		results[i] = models.SearchResult{
+			Type:           doc.Kind,
+			ConversationID: doc.ConversationID,
+			MessageID:      doc.MessageID,
+			Sender:         doc.Sender,
+			Endpoint:       doc.Endpoint,
+			Snippet:        hit.Snippet,
+			Score:          hit.Score,
+			CreatedAt:      doc.CreatedAt,
+		}
+	}
+	c.JSON(http.StatusOK, models.SearchResponse{Results: results})
+}
+
+func (h *SearchHandler) invalidQuery(c *gin.Context, msg string) {
+	problem.Write(c, models.ErrorResponse{
+		Error:   "invalid_query",
+		Message: msg,
+		Code:    http.StatusBadRequest,
+	})
+}
+
+// parseSearchTime parses an RFC 3339 time or a date. A date as the end of a
+// range stands for the whole day.
+func parseSearchTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		return day.Add(24*time.Hour - time.Nanosecond), nil
+	}
+	return day, nil
+}
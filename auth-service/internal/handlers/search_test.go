@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/search"
+	"auth-service/pkg/logger"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSearchRouter mounts the search routes behind testUser, over an index
+// holding a few documents
+func newSearchRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	index, err := search.NewFileIndex(t.TempDir(), 0)
+	require.NoError(t, err)
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	docs := []search.Document{
+		search.ConversationDocument(&models.Conversation{User: "user-1", ConversationID: "convo-1", Title: "Running shoes", Endpoint: "openAI", CreatedAt: day}),
+		search.MessageDocument(&models.Message{User: "user-1", ConversationID: "convo-1", MessageID: "m1", Sender: "User", Text: "Cheap running shoes?", Endpoint: "openAI", CreatedAt: day}),
+		search.MessageDocument(&models.Message{User: "user-1", ConversationID: "convo-1", MessageID: "m2", Sender: "Assistant", Text: "Try these shoes", Endpoint: "openAI", CreatedAt: day.AddDate(0, 0, 1)}),
+	}
+	for _, doc := range docs {
+		require.NoError(t, index.Put(context.Background(), doc))
+	}
+	handler := NewSearchHandler(&config.Config{}, &logger.Logger{Logger: logrus.New()}, index)
+
+	r := gin.New()
+	api := r.Group("/api", testUser())
+	api.GET("/search", handler.Search)
+	api.GET("/search/enable", handler.Enabled)
+	return r
+}
+
+func searchResults(t *testing.T, r *gin.Engine, user string, params url.Values) []models.SearchResult {
+	w := doJSON(r, "GET", "/api/search?"+params.Encode(), user, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp models.SearchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Results
+}
+
+func TestSearchHandler_Search(t *testing.T) {
+	r := newSearchRouter(t)
+
+	w := doJSON(r, "GET", "/api/search/enable", "user-1", nil)
+	assert.Equal(t, "true", w.Body.String())
+
+	results := searchResults(t, r, "user-1", url.Values{"q": {"shoes"}})
+	assert.Len(t, results, 3)
+
+	results = searchResults(t, r, "user-1", url.Values{"q": {`"running shoes"`}, "type": {"message"}})
+	require.Len(t, results, 1)
+	assert.Equal(t, "m1", results[0].MessageID)
+	assert.Equal(t, "User", results[0].Sender)
+	assert.Equal(t, "Cheap <mark>running</mark> <mark>shoes</mark>?", results[0].Snippet)
+
+	results = searchResults(t, r, "user-1", url.Values{"q": {"shoes"}, "type": {"conversation"}})
+	require.Len(t, results, 1)
+	assert.Equal(t, search.KindConversation, results[0].Type)
+
+	// A date as the end of the range covers the whole day
+	results = searchResults(t, r, "user-1", url.Values{"q": {"shoes"}, "type": {"message"}, "to": {"2024-03-01"}})
+	require.Len(t, results, 1)
+	assert.Equal(t, "m1", results[0].MessageID)
+
+	results = searchResults(t, r, "user-1", url.Values{"q": {"shoes"}, "from": {"2024-03-02T00:00:00Z"}})
+	require.Len(t, results, 1)
+	assert.Equal(t, "m2", results[0].MessageID)
+
+	results = searchResults(t, r, "user-1", url.Values{"q": {"shoes"}, "limit": {"2"}})
+	assert.Len(t, results, 2)
+
+	results = searchResults(t, r, "user-2", url.Values{"q": {"shoes"}})
+	assert.Empty(t, results, "other users' documents are never returned")
+}
+
+func TestSearchHandler_InvalidQuery(t *testing.T) {
+	r := newSearchRouter(t)
+
+	for _, params := range []url.Values{
+		{},
+		{"q": {"?!"}},
+		{"q": {"s*"}},
+		{"q": {"shoes"}, "type": {"file"}},
+		{"q": {"shoes"}, "from": {"yesterday"}},
+		{"q": {"shoes"}, "limit": {"0"}},
+	} {
+		w := doJSON(r, "GET", "/api/search?"+params.Encode(), "user-1", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, params.Encode())
+		assert.Contains(t, w.Body.String(), "invalid_query", params.Encode())
+	}
+
+	w := doJSON(r, "GET", "/api/search?q=shoes", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"auth-service/internal/config"
+	"auth-service/pkg/logger"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// maxCSPReportSize caps the size of a violation report body
+const maxCSPReportSize = 64 << 10
+
+// SecurityHandler handles security-related endpoints
+type SecurityHandler struct {
+	cfg    *config.Config
+	logger *logger.Logger
+}
+
+// NewSecurityHandler creates a new security handler
+func NewSecurityHandler(cfg *config.Config, logger *logger.Logger) *SecurityHandler {
+	return &SecurityHandler{
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// cspViolation is a CSP violation in either the legacy report-uri format
+// ({"csp-report": {...}}) or a Reporting API body
+type cspViolation struct {
+	DocumentURI        string `json:"document-uri"`
+	Referrer           string `json:"referrer"`
+	BlockedURI         string `json:"blocked-uri"`
+	ViolatedDirective  string `json:"violated-directive"`
+	EffectiveDirective string `json:"effective-directive"`
+	OriginalPolicy     string `json:"original-policy"`
+	Disposition        string `json:"disposition"`
+	SourceFile         string `json:"source-file"`
+	LineNumber         int    `json:"line-number"`
+
+	// Reporting API (application/reports+json) field names
+	DocumentURL           string `json:"documentURL"`
+	BlockedURL            string `json:"blockedURL"`
+	EffectiveDirectiveAPI string `json:"effectiveDirective"`
+}
+
+// CSPReport collects Content-Security-Policy violation reports and logs them
+func (h *SecurityHandler) CSPReport(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxCSPReportSize))
+	if err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	violations := parseCSPReports(body)
+	if len(violations) == 0 {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	for _, v := range violations {
+		h.logger.WithFields(logrus.Fields{
+			"document_uri":        firstNonEmpty(v.DocumentURI, v.DocumentURL),
+			"blocked_uri":         firstNonEmpty(v.BlockedURI, v.BlockedURL),
+			"violated_directive":  v.ViolatedDirective,
+			"effective_directive": firstNonEmpty(v.EffectiveDirective, v.EffectiveDirectiveAPI),
+			"disposition":         v.Disposition,
+			"source_file":         v.SourceFile,
+			"line_number":         v.LineNumber,
+			"ip":                  c.ClientIP(),
+			"user_agent":          c.Request.UserAgent(),
+		}).Warn("CSP violation reported")
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// parseCSPReports accepts both the application/csp-report and the
+// application/reports+json formats
+func parseCSPReports(body []byte) []cspViolation {
+	var legacy struct {
+		Report *cspViolation `json:"csp-report"`
+	}
+	if err := json.Unmarshal(body, &legacy); err == nil && legacy.Report != nil {
+		return []cspViolation{*legacy.Report}
+	}
+
+	var reports []struct {
+		Type string       `json:"type"`
+		Body cspViolation `json:"body"`
+	}
+	if err := json.Unmarshal(body, &reports); err != nil {
+		return nil
+	}
+
+	var violations []cspViolation
+	for _, report := range reports {
+		if report.Type == "csp-violation" {
+			violations = append(violations, report.Body)
+		}
+	}
+	return violations
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
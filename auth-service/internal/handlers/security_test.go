@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"auth-service/pkg/logger"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHandler_CSPReport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	base, hook := test.NewNullLogger()
+	handler := &SecurityHandler{logger: &logger.Logger{Logger: base}}
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedLogs   int
+	}{
+		{
+			name:           "legacy report-uri format",
+			contentType:    "application/csp-report",
+			body:           `{"csp-report":{"document-uri":"https://app.example.com/","blocked-uri":"https://evil.example.com/x.js","violated-directive":"script-src"}}`,
+			expectedStatus: http.StatusNoContent,
+			expectedLogs:   1,
+		},
+		{
+			name:           "reporting API format",
+			contentType:    "application/reports+json",
+			body:           `[{"type":"csp-violation","body":{"documentURL":"https://app.example.com/","blockedURL":"inline","effectiveDirective":"style-src"}},{"type":"deprecation","body":{}}]`,
+			expectedStatus: http.StatusNoContent,
+			expectedLogs:   1,
+		},
+		{
+			name:           "malformed report",
+			contentType:    "application/json",
+			body:           `not json`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook.Reset()
+
+			req, _ := http.NewRequest("POST", "/api/csp-report", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			handler.CSPReport(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Len(t, hook.AllEntries(), tt.expectedLogs)
+			for _, entry := range hook.AllEntries() {
+				assert.Equal(t, logrus.WarnLevel, entry.Level)
+				assert.NotEmpty(t, entry.Data["blocked_uri"])
+			}
+		})
+	}
+}
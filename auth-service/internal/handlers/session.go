@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"auth-service/internal/config"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultSessionMaxAge is used when the identity provider does not report a refresh token lifetime
+const defaultSessionMaxAge = 30 * 60
+
+// noStore marks a token-bearing response as non-cacheable
+func noStore(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+}
+
+// sessionCookies reads and writes the refresh token and CSRF cookies used in cookie session mode
+type sessionCookies struct {
+	cfg config.SessionConfig
+}
+
+func (s sessionCookies) enabled() bool {
+	return s.cfg.CookieMode
+}
+
+func (s sessionCookies) sameSite() http.SameSite {
+	switch strings.ToLower(s.cfg.CookieSameSite) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteStrictMode
+	}
+}
+
+// setRefreshToken stores the refresh token in an HttpOnly cookie scoped to the auth routes
+func (s sessionCookies) setRefreshToken(c *gin.Context, token string, maxAge int) {
+	if maxAge <= 0 {
+		maxAge = defaultSessionMaxAge
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     s.cfg.CookieName,
+		Value:    token,
+		Path:     s.cfg.CookiePath,
+		Domain:   s.cfg.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   s.cfg.CookieSecure,
+		HttpOnly: true,
+		SameSite: s.sameSite(),
+	})
+}
+
+// refreshToken returns the refresh token cookie, or "" if it is missing
+func (s sessionCookies) refreshToken(c *gin.Context) string {
+	token, err := c.Cookie(s.cfg.CookieName)
+	if err != nil {
+		return ""
+	}
+	return token
+}
+
+// issueCSRFToken sets a new double-submit CSRF cookie readable by the client
+// and returns its value
+func (s sessionCookies) issueCSRFToken(c *gin.Context, maxAge int) (string, error) {
+	if maxAge <= 0 {
+		maxAge = defaultSessionMaxAge
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     s.cfg.CSRFCookieName,
+		Value:    token,
+		Path:     "/",
+		Domain:   s.cfg.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   s.cfg.CookieSecure,
+		HttpOnly: false,
+		SameSite: s.sameSite(),
+	})
+	return token, nil
+}
+
+// clear expires both session cookies
+func (s sessionCookies) clear(c *gin.Context) {
+	for _, cookie := range []*http.Cookie{
+		{Name: s.cfg.CookieName, Path: s.cfg.CookiePath, HttpOnly: true},
+		{Name: s.cfg.CSRFCookieName, Path: "/"},
+	} {
+		cookie.Domain = s.cfg.CookieDomain
+		cookie.MaxAge = -1
+		cookie.Secure = s.cfg.CookieSecure
+		cookie.SameSite = s.sameSite()
+		http.SetCookie(c.Writer, cookie)
+	}
+}
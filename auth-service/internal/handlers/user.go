@@ -3,7 +3,9 @@ package handlers
 import (
 	"auth-service/internal/config"
 	"auth-service/internal/models"
-	"auth-service/internal/services"
+	"auth-service/internal/problem"
+	"auth-service/internal/password"
+	"auth-service/internal/validation"
 	"auth-service/pkg/logger"
 	"net/http"
 
@@ -12,14 +14,16 @@ import (
 
 // UserHandler handles user-related requests
 type UserHandler struct {
-	keycloakService *services.KeycloakService
+	keycloakService AuthService
+	passwordPolicy  *password.Policy
 	logger          *logger.Logger
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(cfg *config.Config, logger *logger.Logger) *UserHandler {
+func NewUserHandler(cfg *config.Config, logger *logger.Logger, keycloakService AuthService, passwordPolicy *password.Policy) *UserHandler {
 	return &UserHandler{
-		keycloakService: services.NewKeycloakService(cfg.Keycloak, logger),
+		keycloakService: keycloakService,
+		passwordPolicy:  passwordPolicy,
 		logger:          logger,
 	}
 }
@@ -30,7 +34,7 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	accessToken, exists := c.Get("access_token")
 	if !exists {
 		h.logger.Error("Access token not found in context")
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+		problem.Write(c, models.ErrorResponse{
 			Error:   "unauthorized",
 			Message: "Access token not found",
 			Code:    http.StatusUnauthorized,
@@ -42,7 +46,7 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	user, err := h.keycloakService.GetUserProfile(accessToken.(string))
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get user profile")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		writeServiceError(c, err, models.ErrorResponse{
 			Error:   "internal_error",
 			Message: "Failed to retrieve user profile",
 			Code:    http.StatusInternalServerError,
@@ -63,7 +67,7 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	accessToken, exists := c.Get("access_token")
 	if !exists {
 		h.logger.Error("Access token not found in context")
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+		problem.Write(c, models.ErrorResponse{
 			Error:   "unauthorized",
 			Message: "Access token not found",
 			Code:    http.StatusUnauthorized,
@@ -72,13 +76,7 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	}
 
 	var req models.UpdateProfileRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("Invalid profile update request")
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_error",
-			Message: err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+	if !bindAndValidate(c, h.logger, validation.UpdateProfile, &req) {
 		return
 	}
 
@@ -86,7 +84,7 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	err := h.keycloakService.UpdateUserProfile(accessToken.(string), &req)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to update user profile")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		writeServiceError(c, err, models.ErrorResponse{
 			Error:   "update_failed",
 			Message: "Failed to update user profile",
 			Code:    http.StatusInternalServerError,
@@ -107,7 +105,7 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	accessToken, exists := c.Get("access_token")
 	if !exists {
 		h.logger.Error("Access token not found in context")
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+		problem.Write(c, models.ErrorResponse{
 			Error:   "unauthorized",
 			Message: "Access token not found",
 			Code:    http.StatusUnauthorized,
@@ -115,14 +113,12 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
+	user := password.UserInfo{Username: c.GetString("username"), Email: c.GetString("email")}
+
 	var req models.ChangePasswordRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("Invalid password change request")
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_error",
-			Message: err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+	if !bindAndValidate(c, h.logger, validation.ChangePassword, &req, func() []validation.Error {
+		return h.passwordPolicy.Check(c.Request.Context(), "new_password", req.NewPassword, user)
+	}) {
 		return
 	}
 
@@ -130,7 +126,7 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	err := h.keycloakService.ChangePassword(accessToken.(string), req.CurrentPassword, req.NewPassword)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to change password")
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		writeServiceError(c, err, models.ErrorResponse{
 			Error:   "password_change_failed",
 			Message: "Failed to change password",
 			Code:    http.StatusBadRequest,
@@ -138,6 +134,10 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
+	if err := h.passwordPolicy.Remember(c.Request.Context(), user, req.NewPassword); err != nil {
+		h.logger.WithError(err).Warn("Failed to record password history")
+	}
+
 	userID, _ := c.Get("user_id")
 	h.logger.WithField("user_id", userID).Info("Password changed successfully")
 	c.JSON(http.StatusOK, models.SuccessResponse{
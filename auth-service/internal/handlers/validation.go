@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/problem"
+	"auth-service/internal/validation"
+	"auth-service/pkg/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindAndValidate decodes the JSON body into req and checks it against schema,
+// then runs any extra checks (such as the password policy) on the decoded
+// request. On failure it writes a 400 response with field-level details
+// localized from Accept-Language and returns false.
+func bindAndValidate(c *gin.Context, log *logger.Logger, schema *validation.Schema, req interface{}, checks ...func() []validation.Error) bool {
+	lang := validation.NegotiateLanguage(c.GetHeader("Accept-Language"))
+
+	if err := c.ShouldBindJSON(req); err != nil {
+		log.WithError(err).WithField("request", schema.Name).Error("Invalid request body")
+		c.Header("Content-Language", lang)
+		problem.Write(c, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: validation.Message(lang, "invalid_request"),
+			Code:    http.StatusBadRequest,
+		})
+		return false
+	}
+
+	return validateRequest(c, log, schema, req, checks...)
+}
+
+// bindArgAndValidate is bindAndValidate for LibreChat-style bodies that wrap
+// the request in {"arg": {...}}
+func bindArgAndValidate(c *gin.Context, log *logger.Logger, schema *validation.Schema, req interface{}) bool {
+	lang := validation.NegotiateLanguage(c.GetHeader("Accept-Language"))
+
+	body := struct {
+		Arg interface{} `json:"arg"`
+	}{Arg: req}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		log.WithError(err).WithField("request", schema.Name).Error("Invalid request body")
+		c.Header("Content-Language", lang)
+		problem.Write(c, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: validation.Message(lang, "invalid_request"),
+			Code:    http.StatusBadRequest,
+		})
+		return false
+	}
+
+	return validateRequest(c, log, schema, req)
+}
+
+// validateRequest checks a decoded request against schema and checks, writing
+// a localized 400 response and returning false on failure
+func validateRequest(c *gin.Context, log *logger.Logger, schema *validation.Schema, req interface{}, checks ...func() []validation.Error) bool {
+	lang := validation.NegotiateLanguage(c.GetHeader("Accept-Language"))
+
+	errs := validation.Validate(schema, req)
+	failed := make(map[string]bool, len(errs))
+	for _, e := range errs {
+		failed[e.Field] = true
+	}
+	for _, check := range checks {
+		// Report only the schema error for fields that already failed one
+		for _, e := range check() {
+			if !failed[e.Field] {
+				errs = append(errs, e)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		details := validation.Localize(errs, lang)
+		log.WithField("request", schema.Name).WithField("errors", details).Warn("Request validation failed")
+		c.Header("Content-Language", lang)
+		problem.Write(c, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: validation.Message(lang, "validation_failed"),
+			Code:    http.StatusBadRequest,
+			Details: details,
+		})
+		return false
+	}
+
+	return true
+}
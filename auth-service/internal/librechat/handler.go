@@ -0,0 +1,172 @@
+package librechat
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/flags"
+	"auth-service/pkg/logger"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the config endpoints read by librechat-data-provider.
+// Responses are the bare TStartupConfig / TEndpointsConfig / TModelsConfig
+// objects, not wrapped in {message, data}.
+type Handler struct {
+	cfg      *config.Config
+	registry *Registry
+	logger   *logger.Logger
+}
+
+// NewHandler creates a new LibreChat config handler serving the endpoints
+// in registry, restricted to those listed in ENDPOINTS when it is set
+func NewHandler(cfg *config.Config, logger *logger.Logger, registry *Registry) *Handler {
+	return &Handler{
+		cfg:      cfg,
+		registry: registry.Restrict(cfg.LibreChat.Endpoints),
+		logger:   logger,
+	}
+}
+
+// GetStartupConfig serves /api/startup. Flags are evaluated for the calling
+// user when the request went through OptionalAuthMiddleware with a token.
+func (h *Handler) GetStartupConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, h.StartupConfig(c.Request.Context()))
+}
+
+// GetEndpoints serves /api/endpoints with the endpoints the caller may use
+func (h *Handler) GetEndpoints(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Endpoints(c.Request.Context()))
+}
+
+// GetModels serves /api/models: the models of each endpoint the caller may use
+func (h *Handler) GetModels(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Models(c.Request.Context()))
+}
+
+// StartupConfig builds the startup config for the subject in ctx
+func (h *Handler) StartupConfig(ctx context.Context) StartupConfig {
+	lc := h.cfg.LibreChat
+
+	social := make(map[string]bool, len(socialProviders))
+	socialLogins := []string{}
+	for _, provider := range socialProviders {
+		social[provider] = flags.Enabled(ctx, flags.SocialPrefix+provider)
+		if social[provider] {
+			socialLogins = append(socialLogins, provider)
+		}
+	}
+	if lc.OpenIDEnabled {
+		socialLogins = append(socialLogins, "openid")
+	}
+
+	startup := StartupConfig{
+		AppTitle:                 lc.AppTitle,
+		SocialLogins:             socialLogins,
+		Interface:                interfaceConfig(lc.Interface),
+		DiscordLoginEnabled:      social["discord"],
+		FacebookLoginEnabled:     social["facebook"],
+		GithubLoginEnabled:       social["github"],
+		GoogleLoginEnabled:       social["google"],
+		AppleLoginEnabled:        social["apple"],
+		OpenIDLoginEnabled:       lc.OpenIDEnabled,
+		OpenIDLabel:              lc.OpenIDLabel,
+		OpenIDImageURL:           lc.OpenIDImageURL,
+		OpenIDAutoRedirect:       lc.OpenIDAutoRedirect,
+		LDAP:                     &LDAPConfig{Enabled: lc.LDAPEnabled, Username: lc.LDAPUsername},
+		ServerDomain:             lc.ServerDomain,
+		EmailLoginEnabled:        lc.EmailLoginEnabled,
+		RegistrationEnabled:      flags.Enabled(ctx, flags.Registration),
+		SocialLoginEnabled:       len(socialLogins) > 0,
+		PasswordResetEnabled:     flags.Enabled(ctx, flags.PasswordReset),
+		EmailEnabled:             flags.Enabled(ctx, flags.Email),
+		HelpAndFAQURL:            lc.HelpAndFAQURL,
+		CustomFooter:             lc.CustomFooter,
+		SharedLinksEnabled:       lc.SharedLinksEnabled,
+		PublicSharedLinksEnabled: lc.SharedLinksEnabled && lc.PublicSharedLinksEnabled,
+		AnalyticsGtmID:           lc.AnalyticsGtmID,
+		InstanceProjectID:        lc.InstanceProjectID,
+	}
+	if lc.TurnstileSiteKey != "" {
+		startup.Turnstile = &TurnstileConfig{SiteKey: lc.TurnstileSiteKey}
+	}
+	if h.cfg.Balance.Enabled {
+		startup.Balance = &BalanceConfig{Enabled: true, StartBalance: h.cfg.Balance.StartBalance}
+	}
+	return startup
+}
+
+// Endpoints builds the endpoints config for the subject in ctx. Known
+// endpoints the subject may not use, or that are not defined, are null.
+func (h *Handler) Endpoints(ctx context.Context) EndpointsConfig {
+	subject := flags.SubjectFrom(ctx)
+
+	endpoints := make(EndpointsConfig, len(KnownEndpoints))
+	for _, name := range KnownEndpoints {
+		endpoints[name] = nil
+	}
+	for i, endpoint := range h.registry.Endpoints() {
+		if !endpoint.VisibleTo(subject) {
+			continue
+		}
+		endpoints[endpoint.Name] = &EndpointConfig{
+			Order:             i,
+			Type:              endpoint.Type,
+			Name:              customName(endpoint),
+			IconURL:           endpoint.IconURL,
+			ModelDisplayLabel: endpoint.ModelDisplayLabel,
+			UserProvide:       endpoint.UserProvidesKey(),
+			UserProvideURL:    endpoint.UserProvidesURL(),
+			Capabilities:      endpoint.Capabilities,
+			DisableBuilder:    endpoint.DisableBuilder,
+		}
+	}
+	return endpoints
+}
+
+// Models lists the models of each endpoint the subject in ctx may use
+func (h *Handler) Models(ctx context.Context) ModelsConfig {
+	models := make(ModelsConfig)
+	for _, endpoint := range h.registry.Visible(flags.SubjectFrom(ctx)) {
+		list := endpoint.Models.Default
+		if list == nil {
+			list = []string{}
+		}
+		models[endpoint.Name] = list
+	}
+	return models
+}
+
+// customName is the display name of a custom endpoint; the client names
+// known endpoints itself
+func customName(endpoint *Endpoint) string {
+	if endpoint.Type == EndpointCustom {
+		return endpoint.Name
+	}
+	return ""
+}
+
+func interfaceConfig(cfg config.InterfaceConfig) *InterfaceConfig {
+	ui := &InterfaceConfig{
+		EndpointsMenu: cfg.EndpointsMenu,
+		ModelSelect:   cfg.ModelSelect,
+		Parameters:    cfg.Parameters,
+		SidePanel:     cfg.SidePanel,
+		Presets:       cfg.Presets,
+		Prompts:       cfg.Prompts,
+		Bookmarks:     cfg.Bookmarks,
+		MultiConvo:    cfg.MultiConvo,
+		Agents:        cfg.Agents,
+		TemporaryChat: cfg.TemporaryChat,
+		RunCode:       cfg.RunCode,
+		CustomWelcome: cfg.CustomWelcome,
+	}
+	if cfg.PrivacyPolicyURL != "" {
+		ui.PrivacyPolicy = &PrivacyPolicy{ExternalURL: cfg.PrivacyPolicyURL, OpenNewTab: true}
+	}
+	if cfg.TermsOfServiceURL != "" {
+		ui.TermsOfService = &TermsOfService{ExternalURL: cfg.TermsOfServiceURL, OpenNewTab: true}
+	}
+	return ui
+}
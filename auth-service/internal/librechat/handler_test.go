@@ -0,0 +1,172 @@
+package librechat
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/flags"
+	"auth-service/internal/secrets"
+	"auth-service/pkg/logger"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run with -update to rewrite the golden files after an intended change to
+// the wire format. The client depends on these shapes, so review the diff.
+var update = flag.Bool("update", false, "update golden files")
+
+// testSecrets resolves the API key references in testdata/librechat.yaml
+var testSecrets = &secrets.EnvProvider{Lookup: func(name string) (string, bool) {
+	value, ok := map[string]string{
+		"OPENAI_API_KEY":  "sk-openai",
+		"MISTRAL_API_KEY": "sk-mistral",
+	}[name]
+	return value, ok
+}}
+
+func newTestHandler(t *testing.T) *Handler {
+	registry, err := LoadRegistry(context.Background(), filepath.Join("testdata", "librechat.yaml"), testSecrets)
+	require.NoError(t, err)
+
+	return NewHandler(&config.Config{
+		LibreChat: config.LibreChatConfig{
+			AppTitle:           "ShopMindAI",
+			ServerDomain:       "https://api.shopmind.example",
+			InstanceProjectID:  "instance",
+			HelpAndFAQURL:      "https://shopmind.example/help",
+			EmailLoginEnabled:  true,
+			SharedLinksEnabled: true,
+			OpenIDEnabled:      true,
+			OpenIDLabel:        "Continue with Keycloak",
+			LDAPEnabled:        true,
+			LDAPUsername:       true,
+			TurnstileSiteKey:   "site-key",
+			Interface: config.InterfaceConfig{
+				EndpointsMenu:     true,
+				ModelSelect:       true,
+				Parameters:        true,
+				SidePanel:         true,
+				Presets:           true,
+				Bookmarks:         true,
+				TermsOfServiceURL: "https://shopmind.example/terms",
+			},
+		},
+		Balance: config.BalanceConfig{Enabled: true, StartBalance: 20000},
+	}, &logger.Logger{Logger: logrus.New()}, registry)
+}
+
+func setTestFlags(t *testing.T) {
+	previous := flags.Default()
+	flags.SetDefault(flags.NewService(config.FlagsConfig{Flags: map[string]config.FlagConfig{
+		flags.Registration:            {Enabled: true},
+		flags.PasswordReset:           {Roles: []string{"beta-tester"}},
+		flags.SocialPrefix + "google": {Enabled: true},
+		flags.SocialPrefix + "github": {Users: []string{"user-1"}},
+	}}))
+	t.Cleanup(func() { flags.SetDefault(previous) })
+}
+
+func TestHandler_GetStartupConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setTestFlags(t)
+	handler := newTestHandler(t)
+
+	tests := []struct {
+		golden  string
+		subject flags.Subject
+	}{
+		{"startup_anonymous.json", flags.Subject{}},
+		{"startup_user.json", flags.Subject{UserID: "user-1", Roles: []string{"beta-tester"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/startup", nil)
+			req = req.WithContext(flags.WithSubject(req.Context(), tt.subject))
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			handler.GetStartupConfig(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assertGolden(t, tt.golden, w.Body.Bytes())
+		})
+	}
+}
+
+func TestHandler_GetEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := newTestHandler(t)
+
+	tests := []struct {
+		path    string
+		golden  string
+		subject flags.Subject
+	}{
+		{"/api/endpoints", "endpoints_anonymous.json", flags.Subject{}},
+		{"/api/endpoints", "endpoints_premium.json", flags.Subject{UserID: "user-1", Roles: []string{"premium"}}},
+		{"/api/models", "models_anonymous.json", flags.Subject{}},
+		{"/api/models", "models_premium.json", flags.Subject{UserID: "user-1", Roles: []string{"premium"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(flags.WithSubject(req.Context(), tt.subject))
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			if tt.path == "/api/models" {
+				handler.GetModels(c)
+			} else {
+				handler.GetEndpoints(c)
+			}
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assertGolden(t, tt.golden, w.Body.Bytes())
+		})
+	}
+}
+
+func TestHandler_RestrictEndpoints(t *testing.T) {
+	registry, err := LoadRegistry(context.Background(), filepath.Join("testdata", "librechat.yaml"), testSecrets)
+	require.NoError(t, err)
+
+	cfg := &config.Config{LibreChat: config.LibreChatConfig{Endpoints: []string{"Mistral", EndpointOpenAI, "unknown"}}}
+	handler := NewHandler(cfg, &logger.Logger{Logger: logrus.New()}, registry)
+
+	endpoints := handler.Endpoints(context.Background())
+	assert.Equal(t, 0, endpoints["Mistral"].Order)
+	assert.Equal(t, 1, endpoints[EndpointOpenAI].Order)
+	assert.Nil(t, endpoints[EndpointAnthropic])
+	assert.NotContains(t, endpoints, "unknown")
+}
+
+// assertGolden compares body, indented, with testdata/name
+func assertGolden(t *testing.T, name string, body []byte) {
+	t.Helper()
+
+	var indented bytes.Buffer
+	require.NoError(t, json.Indent(&indented, body, "", "  "))
+	indented.WriteByte('\n')
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, indented.Bytes(), 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), indented.String())
+}
@@ -0,0 +1,230 @@
+package librechat
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/flags"
+	"auth-service/internal/secrets"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UserProvided as an API key or base URL means each user enters their own
+const UserProvided = "user_provided"
+
+// Endpoint is one AI endpoint defined in the registry file
+type Endpoint struct {
+	// Name is the endpoint key: a known endpoint such as "openAI", or the
+	// name of a custom endpoint
+	Name string `yaml:"name"`
+	// Type is "custom" for endpoints listed under endpoints.custom
+	Type string `yaml:"-"`
+	// APIKey and BaseURL may reference secrets as "${NAME}", resolved at load
+	APIKey            config.Secret  `yaml:"apiKey"`
+	BaseURL           string         `yaml:"baseURL"`
+	Models            EndpointModels `yaml:"models"`
+	TitleConvo        bool           `yaml:"titleConvo"`
+	TitleModel        string         `yaml:"titleModel"`
+	IconURL           string         `yaml:"iconURL"`
+	ModelDisplayLabel string         `yaml:"modelDisplayLabel"`
+	Capabilities      []string       `yaml:"capabilities"`
+	DisableBuilder    bool           `yaml:"disableBuilder"`
+	// Roles limits the endpoint to users with one of these realm or client
+	// roles; empty means everyone
+	Roles []string `yaml:"roles"`
+}
+
+// EndpointModels lists the models offered by an endpoint
+type EndpointModels struct {
+	Default []string `yaml:"default"`
+}
+
+// UserProvidesKey reports whether users enter their own API key
+func (e *Endpoint) UserProvidesKey() bool {
+	return e.APIKey.Value() == UserProvided
+}
+
+// UserProvidesURL reports whether users enter their own base URL
+func (e *Endpoint) UserProvidesURL() bool {
+	return e.BaseURL == UserProvided
+}
+
+// VisibleTo reports whether the subject may use the endpoint
+func (e *Endpoint) VisibleTo(subject flags.Subject) bool {
+	if len(e.Roles) == 0 {
+		return true
+	}
+	for _, role := range e.Roles {
+		for _, subjectRole := range subject.Roles {
+			if role == subjectRole {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Registry holds the AI endpoints in menu order. The zero Registry has no endpoints.
+type Registry struct {
+	endpoints []*Endpoint
+}
+
+// registryFile is the subset of librechat.yaml read by the registry.
+// Other top-level keys are ignored so a LibreChat file can be reused.
+type registryFile struct {
+	Version   string    `yaml:"version"`
+	Endpoints yaml.Node `yaml:"endpoints"`
+}
+
+// LoadRegistry reads the registry file at path, resolving secret references
+// through provider. A missing file returns an error wrapping os.ErrNotExist.
+func LoadRegistry(ctx context.Context, path string, provider secrets.SecretProvider) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := ParseRegistry(ctx, data, provider)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return registry, nil
+}
+
+// ParseRegistry parses a registry file. Known endpoints are keyed by name
+// under endpoints; custom endpoints are a list under endpoints.custom.
+func ParseRegistry(ctx context.Context, data []byte, provider secrets.SecretProvider) (*Registry, error) {
+	var file registryFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	registry := &Registry{}
+	if file.Endpoints.Kind == 0 {
+		return registry, nil
+	}
+	if file.Endpoints.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: endpoints must be a mapping", file.Endpoints.Line)
+	}
+
+	seen := make(map[string]bool)
+	add := func(endpoint *Endpoint, line int) error {
+		if endpoint.Name == "" {
+			return fmt.Errorf("line %d: endpoint has no name", line)
+		}
+		if seen[endpoint.Name] {
+			return fmt.Errorf("line %d: endpoint %q is defined twice", line, endpoint.Name)
+		}
+		seen[endpoint.Name] = true
+		if err := resolveEndpoint(ctx, endpoint, provider); err != nil {
+			return fmt.Errorf("line %d: endpoint %q: %w", line, endpoint.Name, err)
+		}
+		registry.endpoints = append(registry.endpoints, endpoint)
+		return nil
+	}
+
+	content := file.Endpoints.Content
+	for i := 0; i+1 < len(content); i += 2 {
+		key, value := content[i].Value, content[i+1]
+
+		if key == EndpointCustom {
+			var custom []*Endpoint
+			if err := value.Decode(&custom); err != nil {
+				return nil, err
+			}
+			for j, endpoint := range custom {
+				endpoint.Type = EndpointCustom
+				if endpoint.BaseURL == "" {
+					return nil, fmt.Errorf("line %d: custom endpoint %q has no baseURL", value.Content[j].Line, endpoint.Name)
+				}
+				if err := add(endpoint, value.Content[j].Line); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		endpoint := &Endpoint{}
+		if err := value.Decode(endpoint); err != nil {
+			return nil, err
+		}
+		endpoint.Name = key
+		if err := add(endpoint, content[i].Line); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+var secretRef = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+// resolveEndpoint replaces "${NAME}" references in the API key and base URL
+func resolveEndpoint(ctx context.Context, endpoint *Endpoint, provider secrets.SecretProvider) error {
+	apiKey, err := resolveRef(ctx, endpoint.APIKey.Value(), provider)
+	if err != nil {
+		return fmt.Errorf("apiKey: %w", err)
+	}
+	endpoint.APIKey = config.Secret(apiKey)
+
+	if endpoint.BaseURL, err = resolveRef(ctx, endpoint.BaseURL, provider); err != nil {
+		return fmt.Errorf("baseURL: %w", err)
+	}
+	return nil
+}
+
+func resolveRef(ctx context.Context, value string, provider secrets.SecretProvider) (string, error) {
+	match := secretRef.FindStringSubmatch(value)
+	if match == nil {
+		return value, nil
+	}
+	resolved, err := provider.GetSecret(ctx, match[1])
+	if errors.Is(err, secrets.ErrNotFound) {
+		return "", fmt.Errorf("%s is not set", match[1])
+	}
+	return resolved, err
+}
+
+// Endpoints returns all endpoints in order
+func (r *Registry) Endpoints() []*Endpoint {
+	return r.endpoints
+}
+
+// Get returns the endpoint with the given name
+func (r *Registry) Get(name string) (*Endpoint, bool) {
+	for _, endpoint := range r.endpoints {
+		if endpoint.Name == name {
+			return endpoint, true
+		}
+	}
+	return nil, false
+}
+
+// Restrict returns a registry holding only the named endpoints, in the
+// order given. Names not in the registry are skipped; an empty list keeps
+// every endpoint.
+func (r *Registry) Restrict(names []string) *Registry {
+	if len(names) == 0 {
+		return r
+	}
+	restricted := &Registry{}
+	for _, name := range names {
+		if endpoint, ok := r.Get(name); ok {
+			restricted.endpoints = append(restricted.endpoints, endpoint)
+		}
+	}
+	return restricted
+}
+
+// Visible returns the endpoints the subject may use, in order
+func (r *Registry) Visible(subject flags.Subject) []*Endpoint {
+	var visible []*Endpoint
+	for _, endpoint := range r.endpoints {
+		if endpoint.VisibleTo(subject) {
+			visible = append(visible, endpoint)
+		}
+	}
+	return visible
+}
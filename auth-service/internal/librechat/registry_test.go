@@ -0,0 +1,87 @@
+package librechat
+
+import (
+	"auth-service/internal/flags"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRegistry(t *testing.T) {
+	registry, err := LoadRegistry(context.Background(), filepath.Join("testdata", "librechat.yaml"), testSecrets)
+	require.NoError(t, err)
+
+	var names []string
+	for _, endpoint := range registry.Endpoints() {
+		names = append(names, endpoint.Name)
+	}
+	assert.Equal(t, []string{EndpointOpenAI, EndpointAnthropic, "Mistral", "Local"}, names)
+
+	openAI, ok := registry.Get(EndpointOpenAI)
+	require.True(t, ok)
+	assert.Equal(t, "sk-openai", openAI.APIKey.Value())
+	assert.Equal(t, "[REDACTED]", openAI.APIKey.String())
+	assert.Equal(t, []string{"gpt-4o", "gpt-4o-mini"}, openAI.Models.Default)
+	assert.True(t, openAI.TitleConvo)
+	assert.Equal(t, "", openAI.Type)
+
+	anthropic, _ := registry.Get(EndpointAnthropic)
+	assert.True(t, anthropic.UserProvidesKey())
+	assert.False(t, anthropic.VisibleTo(flags.Subject{UserID: "user-1", Roles: []string{"user"}}))
+	assert.True(t, anthropic.VisibleTo(flags.Subject{UserID: "user-1", Roles: []string{"premium"}}))
+
+	mistral, _ := registry.Get("Mistral")
+	assert.Equal(t, EndpointCustom, mistral.Type)
+	assert.Equal(t, "sk-mistral", mistral.APIKey.Value())
+
+	local, _ := registry.Get("Local")
+	assert.True(t, local.UserProvidesURL())
+
+	_, err = LoadRegistry(context.Background(), filepath.Join("testdata", "missing.yaml"), testSecrets)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestParseRegistryErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{
+			name: "missing secret",
+			yaml: "endpoints:\n  google:\n    apiKey: \"${GOOGLE_KEY}\"\n",
+			want: `line 2: endpoint "google": apiKey: GOOGLE_KEY is not set`,
+		},
+		{
+			name: "custom without base URL",
+			yaml: "endpoints:\n  custom:\n    - name: groq\n      apiKey: key\n",
+			want: `line 3: custom endpoint "groq" has no baseURL`,
+		},
+		{
+			name: "custom without name",
+			yaml: "endpoints:\n  custom:\n    - baseURL: https://example.com\n",
+			want: "line 3: endpoint has no name",
+		},
+		{
+			name: "duplicate",
+			yaml: "endpoints:\n  openAI: {}\n  custom:\n    - name: openAI\n      baseURL: https://example.com\n",
+			want: `line 4: endpoint "openAI" is defined twice`,
+		},
+		{
+			name: "endpoints not a mapping",
+			yaml: "endpoints: [openAI]\n",
+			want: "line 1: endpoints must be a mapping",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRegistry(context.Background(), []byte(tt.yaml), testSecrets)
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package librechat
+
+// The types below mirror the schemas of librechat-data-provider, which the
+// client uses to read /api/startup (TStartupConfig), /api/endpoints
+// (TEndpointsConfig) and /api/models (TModelsConfig). Field names and
+// omitempty follow the TypeScript types: optional fields there are omitted
+// here when unset.
+
+// StartupConfig is TStartupConfig
+type StartupConfig struct {
+	AppTitle                 string           `json:"appTitle"`
+	SocialLogins             []string         `json:"socialLogins"`
+	Interface                *InterfaceConfig `json:"interface,omitempty"`
+	Turnstile                *TurnstileConfig `json:"turnstile,omitempty"`
+	DiscordLoginEnabled      bool             `json:"discordLoginEnabled"`
+	FacebookLoginEnabled     bool             `json:"facebookLoginEnabled"`
+	GithubLoginEnabled       bool             `json:"githubLoginEnabled"`
+	GoogleLoginEnabled       bool             `json:"googleLoginEnabled"`
+	AppleLoginEnabled        bool             `json:"appleLoginEnabled"`
+	OpenIDLoginEnabled       bool             `json:"openidLoginEnabled"`
+	OpenIDLabel              string           `json:"openidLabel"`
+	OpenIDImageURL           string           `json:"openidImageUrl"`
+	OpenIDAutoRedirect       bool             `json:"openidAutoRedirect"`
+	SAMLLoginEnabled         bool             `json:"samlLoginEnabled"`
+	SAMLLabel                string           `json:"samlLabel"`
+	SAMLImageURL             string           `json:"samlImageUrl"`
+	LDAP                     *LDAPConfig      `json:"ldap,omitempty"`
+	ServerDomain             string           `json:"serverDomain"`
+	EmailLoginEnabled        bool             `json:"emailLoginEnabled"`
+	RegistrationEnabled      bool             `json:"registrationEnabled"`
+	SocialLoginEnabled       bool             `json:"socialLoginEnabled"`
+	PasswordResetEnabled     bool             `json:"passwordResetEnabled"`
+	EmailEnabled             bool             `json:"emailEnabled"`
+	ShowBirthdayIcon         bool             `json:"showBirthdayIcon"`
+	HelpAndFAQURL            string           `json:"helpAndFaqURL"`
+	CustomFooter             string           `json:"customFooter,omitempty"`
+	SharedLinksEnabled       bool             `json:"sharedLinksEnabled"`
+	PublicSharedLinksEnabled bool             `json:"publicSharedLinksEnabled"`
+	AnalyticsGtmID           string           `json:"analyticsGtmId,omitempty"`
+	InstanceProjectID        string           `json:"instanceProjectId"`
+	Balance                  *BalanceConfig   `json:"balance,omitempty"`
+}
+
+// InterfaceConfig is TInterfaceConfig, the chat UI toggles
+type InterfaceConfig struct {
+	PrivacyPolicy  *PrivacyPolicy  `json:"privacyPolicy,omitempty"`
+	TermsOfService *TermsOfService `json:"termsOfService,omitempty"`
+	EndpointsMenu  bool            `json:"endpointsMenu"`
+	ModelSelect    bool            `json:"modelSelect"`
+	Parameters     bool            `json:"parameters"`
+	SidePanel      bool            `json:"sidePanel"`
+	Presets        bool            `json:"presets"`
+	Prompts        bool            `json:"prompts"`
+	Bookmarks      bool            `json:"bookmarks"`
+	MultiConvo     bool            `json:"multiConvo"`
+	Agents         bool            `json:"agents"`
+	TemporaryChat  bool            `json:"temporaryChat"`
+	RunCode        bool            `json:"runCode"`
+	CustomWelcome  string          `json:"customWelcome,omitempty"`
+}
+
+// PrivacyPolicy links the privacy policy from the UI
+type PrivacyPolicy struct {
+	ExternalURL string `json:"externalUrl"`
+	OpenNewTab  bool   `json:"openNewTab"`
+}
+
+// TermsOfService links the terms of service from the UI
+type TermsOfService struct {
+	ExternalURL string `json:"externalUrl"`
+	OpenNewTab  bool   `json:"openNewTab"`
+}
+
+// TurnstileConfig enables the Cloudflare Turnstile captcha on the login form
+type TurnstileConfig struct {
+	SiteKey string `json:"siteKey"`
+}
+
+// BalanceConfig tells the client to show the user's token credits
+type BalanceConfig struct {
+	Enabled      bool  `json:"enabled"`
+	StartBalance int64 `json:"startBalance"`
+}
+
+// LDAPConfig tells the login form whether LDAP login is used
+type LDAPConfig struct {
+	Enabled bool `json:"enabled"`
+	// Username makes the login form ask for a username instead of an email
+	Username bool `json:"username,omitempty"`
+}
+
+// EndpointsConfig is TEndpointsConfig: every known endpoint maps to its
+// config, or null when it is not available
+type EndpointsConfig map[string]*EndpointConfig
+
+// EndpointConfig is TConfig, the config of one AI endpoint
+type EndpointConfig struct {
+	Order             int      `json:"order"`
+	Type              string   `json:"type,omitempty"`
+	Name              string   `json:"name,omitempty"`
+	IconURL           string   `json:"iconURL,omitempty"`
+	ModelDisplayLabel string   `json:"modelDisplayLabel,omitempty"`
+	UserProvide       bool     `json:"userProvide"`
+	UserProvideURL    bool     `json:"userProvideURL,omitempty"`
+	Version           string   `json:"version,omitempty"`
+	Capabilities      []string `json:"capabilities,omitempty"`
+	DisableBuilder    bool     `json:"disableBuilder,omitempty"`
+}
+
+// ModelsConfig is TModelsConfig: the model names offered by each endpoint
+type ModelsConfig map[string][]string
+
+// Endpoint names (EModelEndpoint)
+const (
+	EndpointOpenAI          = "openAI"
+	EndpointAzureOpenAI     = "azureOpenAI"
+	EndpointAssistants      = "assistants"
+	EndpointAzureAssistants = "azureAssistants"
+	EndpointAgents          = "agents"
+	EndpointGoogle          = "google"
+	EndpointAnthropic       = "anthropic"
+	EndpointXAI             = "xAI"
+	EndpointGPTPlugins      = "gptPlugins"
+	EndpointChatGPTBrowser  = "chatGPTBrowser"
+	EndpointCustom          = "custom"
+)
+
+// KnownEndpoints are always present in /api/endpoints, as null when disabled
+var KnownEndpoints = []string{
+	EndpointXAI,
+	EndpointOpenAI,
+	EndpointAzureOpenAI,
+	EndpointAzureAssistants,
+	EndpointAssistants,
+	EndpointAgents,
+	EndpointChatGPTBrowser,
+	EndpointGPTPlugins,
+	EndpointGoogle,
+	EndpointAnthropic,
+	EndpointCustom,
+}
+
+// Social login providers, in the order the login page shows them
+var socialProviders = []string{"github", "google", "discord", "facebook", "apple"}
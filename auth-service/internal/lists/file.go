@@ -0,0 +1,282 @@
+package lists
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/storage"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileStore keeps the lists each user owns in one JSON file under dir. Which
+// user owns a list, and which lists are shared with each user, is indexed in
+// memory and rebuilt from the files on open.
+type FileStore struct {
+	dir   string
+	mutex sync.Mutex
+	// owners maps list IDs to their owner
+	owners map[string]string
+	// shared maps user IDs to the lists shared with them
+	shared map[string]map[string]bool
+	now    func() time.Time
+}
+
+// userLists is the document stored per user
+type userLists struct {
+	Lists []models.ShoppingList `json:"lists"`
+}
+
+// NewFileStore creates a store in dir/lists
+func NewFileStore(dir string) (*FileStore, error) {
+	dir = filepath.Join(dir, "lists")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	s := &FileStore{dir: dir, owners: map[string]string{}, shared: map[string]map[string]bool{}, now: time.Now}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		doc := &userLists{}
+		if _, err := storage.ReadJSON(filepath.Join(dir, entry.Name()), doc); err != nil {
+			return nil, err
+		}
+		for i := range doc.Lists {
+			s.index(nil, &doc.Lists[i])
+		}
+	}
+	return s, nil
+}
+
+// Lists returns the lists the user owns or is a member of, the most recently
+// updated first
+func (s *FileStore) Lists(ctx context.Context, userID string) ([]models.ShoppingList, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return nil, err
+	}
+	all := doc.Lists
+	for listID := range s.shared[userID] {
+		owned, i, err := s.locate(listID)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, owned.Lists[i])
+	}
+	sortLists(all)
+	return all, nil
+}
+
+// Get returns a list the user owns or is a member of
+func (s *FileStore) Get(ctx context.Context, userID, listID string) (*models.ShoppingList, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, i, err := s.locate(listID)
+	if err != nil {
+		return nil, err
+	}
+	if RoleOf(&doc.Lists[i], userID) == "" {
+		return nil, ErrNotFound
+	}
+	return &doc.Lists[i], nil
+}
+
+// Create stores a new list owned by the user at version 1
+func (s *FileStore) Create(ctx context.Context, userID string, list *models.ShoppingList) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return err
+	}
+	if len(doc.Lists) >= MaxLists {
+		return ErrLimit
+	}
+
+	now := s.now().UTC()
+	list.ID = storage.NewID()
+	list.UserID = userID
+	list.Version = 1
+	list.CreatedAt = now
+	list.UpdatedAt = now
+	if list.Items == nil {
+		list.Items = []models.ListItem{}
+	}
+	if list.Members == nil {
+		list.Members = []models.ListMember{}
+	}
+	if list.Invites == nil {
+		list.Invites = []models.ListInvite{}
+	}
+	doc.Lists = append(doc.Lists, *list)
+	if err := s.save(userID, doc); err != nil {
+		return err
+	}
+	s.index(nil, list)
+	return nil
+}
+
+// Update applies change to the list if it is still at version and the user
+// may edit it
+func (s *FileStore) Update(ctx context.Context, userID, listID string, version int64, change func(list *models.ShoppingList) error) (*models.ShoppingList, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, i, err := s.locate(listID)
+	if err != nil {
+		return nil, err
+	}
+	switch role := RoleOf(&doc.Lists[i], userID); {
+	case role == "":
+		return nil, ErrNotFound
+	case !CanEdit(role):
+		return nil, ErrForbidden
+	}
+	if version != AnyVersion && doc.Lists[i].Version != version {
+		return nil, ErrConflict
+	}
+	return s.change(doc, i, change)
+}
+
+// Share applies a change of members or invites to the list
+func (s *FileStore) Share(ctx context.Context, listID string, change func(list *models.ShoppingList) error) (*models.ShoppingList, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, i, err := s.locate(listID)
+	if err != nil {
+		return nil, err
+	}
+	return s.change(doc, i, change)
+}
+
+// Delete removes the list if it is still at version and the user owns it
+func (s *FileStore) Delete(ctx context.Context, userID, listID string, version int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, i, err := s.locate(listID)
+	if err != nil {
+		return err
+	}
+	list := doc.Lists[i]
+	switch role := RoleOf(&list, userID); {
+	case role == "":
+		return ErrNotFound
+	case role != models.ListOwner:
+		return ErrForbidden
+	}
+	if version != AnyVersion && list.Version != version {
+		return ErrConflict
+	}
+	doc.Lists = append(doc.Lists[:i], doc.Lists[i+1:]...)
+	if err := s.save(list.UserID, doc); err != nil {
+		return err
+	}
+	delete(s.owners, listID)
+	for _, member := range list.Members {
+		delete(s.shared[member.UserID], listID)
+	}
+	return nil
+}
+
+// change applies change to the i-th list of doc and stores it at the next
+// version. Callers hold the mutex.
+func (s *FileStore) change(doc *userLists, i int, change func(list *models.ShoppingList) error) (*models.ShoppingList, error) {
+	list := &doc.Lists[i]
+	before := *list
+	before.Members = append([]models.ListMember(nil), list.Members...)
+	if err := change(list); err != nil {
+		return nil, err
+	}
+	if len(list.Items) > MaxItems || len(list.Members)+len(list.Invites) > MaxMembers {
+		return nil, ErrLimit
+	}
+
+	list.Version++
+	list.UpdatedAt = s.now().UTC()
+	if err := s.save(list.UserID, doc); err != nil {
+		return nil, err
+	}
+	s.index(&before, list)
+	updated := *list
+	return &updated, nil
+}
+
+// locate loads the document holding the list and returns the list's index
+// in it. Callers hold the mutex.
+func (s *FileStore) locate(listID string) (*userLists, int, error) {
+	owner, ok := s.owners[listID]
+	if !ok {
+		return nil, -1, ErrNotFound
+	}
+	doc, err := s.load(owner)
+	if err != nil {
+		return nil, -1, err
+	}
+	i := doc.find(listID)
+	if i < 0 {
+		return nil, -1, ErrNotFound
+	}
+	return doc, i, nil
+}
+
+// index records the owner and members of list, replacing the members of
+// before. Callers hold the mutex.
+func (s *FileStore) index(before, list *models.ShoppingList) {
+	s.owners[list.ID] = list.UserID
+	if before != nil {
+		for _, member := range before.Members {
+			delete(s.shared[member.UserID], list.ID)
+		}
+	}
+	for _, member := range list.Members {
+		if s.shared[member.UserID] == nil {
+			s.shared[member.UserID] = map[string]bool{}
+		}
+		s.shared[member.UserID][list.ID] = true
+	}
+}
+
+func (s *FileStore) load(userID string) (*userLists, error) {
+	doc := &userLists{Lists: []models.ShoppingList{}}
+	if _, err := storage.ReadJSON(storage.UserFile(s.dir, userID), doc); err != nil {
+		return nil, err
+	}
+	// Lists stored before sharing have no members or invites
+	for i := range doc.Lists {
+		if doc.Lists[i].Members == nil {
+			doc.Lists[i].Members = []models.ListMember{}
+		}
+		if doc.Lists[i].Invites == nil {
+			doc.Lists[i].Invites = []models.ListInvite{}
+		}
+	}
+	return doc, nil
+}
+
+func (s *FileStore) save(userID string, doc *userLists) error {
+	return storage.WriteJSON(storage.UserFile(s.dir, userID), doc)
+}
+
+func (doc *userLists) find(listID string) int {
+	for i := range doc.Lists {
+		if doc.Lists[i].ID == listID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,142 @@
+package lists
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore_VersionsChanges(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	list := &models.ShoppingList{Name: "Groceries"}
+	require.NoError(t, store.Create(ctx, "user-1", list))
+	assert.Equal(t, int64(1), list.Version)
+	assert.Equal(t, []models.ListItem{}, list.Items)
+
+	addMilk := func(list *models.ShoppingList) error {
+		list.Items = append(list.Items, models.ListItem{ID: "item-1", Name: "Milk", Quantity: 1})
+		return nil
+	}
+	updated, err := store.Update(ctx, "user-1", list.ID, 1, addMilk)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+
+	// A second device still at version 1 must reload first
+	_, err = store.Update(ctx, "user-1", list.ID, 1, addMilk)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.ErrorIs(t, store.Delete(ctx, "user-1", list.ID, 1), ErrConflict)
+
+	// A failing change leaves the list as it was
+	failed := errors.New("failed")
+	_, err = store.Update(ctx, "user-1", list.ID, 2, func(list *models.ShoppingList) error {
+		list.Name = "Changed"
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+
+	reopened, err := NewFileStore(dir)
+	require.NoError(t, err)
+	got, err := reopened.Get(ctx, "user-1", list.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Groceries", got.Name)
+	assert.Equal(t, int64(2), got.Version)
+	require.Len(t, got.Items, 1)
+	assert.Equal(t, 0, FindItem(got, "item-1"))
+
+	_, err = reopened.Get(ctx, "user-2", list.ID)
+	assert.ErrorIs(t, err, ErrNotFound, "lists of other users do not exist")
+	require.NoError(t, reopened.Delete(ctx, "user-1", list.ID, 2))
+	_, err = reopened.Get(ctx, "user-1", list.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFileStore_ListsNewestFirstWithinLimits(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	first := &models.ShoppingList{Name: "First"}
+	require.NoError(t, store.Create(ctx, "user-1", first))
+	now = now.Add(time.Minute)
+	require.NoError(t, store.Create(ctx, "user-1", &models.ShoppingList{Name: "Second"}))
+	now = now.Add(time.Minute)
+	_, err = store.Update(ctx, "user-1", first.ID, 1, func(list *models.ShoppingList) error {
+		list.Items = make([]models.ListItem, MaxItems+1)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrLimit)
+	_, err = store.Update(ctx, "user-1", first.ID, 1, func(list *models.ShoppingList) error { return nil })
+	require.NoError(t, err)
+
+	all, err := store.Lists(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "First", all[0].Name, "the updated list comes first")
+
+	for i := len(all); i < MaxLists; i++ {
+		require.NoError(t, store.Create(ctx, "user-1", &models.ShoppingList{Name: "More"}))
+	}
+	assert.ErrorIs(t, store.Create(ctx, "user-1", &models.ShoppingList{Name: "Too many"}), ErrLimit)
+}
+
+func TestFileStore_SharesListsWithMembers(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	list := &models.ShoppingList{Name: "Household"}
+	require.NoError(t, store.Create(ctx, "owner", list))
+	_, err = store.Share(ctx, list.ID, func(list *models.ShoppingList) error {
+		list.Members = append(list.Members,
+			models.ListMember{UserID: "editor", Role: models.ListEditor},
+			models.ListMember{UserID: "viewer", Role: models.ListViewer})
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Membership is rebuilt from the files
+	reopened, err := NewFileStore(dir)
+	require.NoError(t, err)
+	shared, err := reopened.Lists(ctx, "viewer")
+	require.NoError(t, err)
+	require.Len(t, shared, 1)
+	assert.Equal(t, "owner", shared[0].UserID)
+
+	_, err = reopened.Update(ctx, "viewer", list.ID, 2, func(*models.ShoppingList) error { return nil })
+	assert.ErrorIs(t, err, ErrForbidden)
+	updated, err := reopened.Update(ctx, "editor", list.ID, 2, func(list *models.ShoppingList) error {
+		list.Name = "Home"
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), updated.Version)
+	assert.ErrorIs(t, reopened.Delete(ctx, "editor", list.ID, 3), ErrForbidden)
+	_, err = reopened.Get(ctx, "stranger", list.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = reopened.Share(ctx, list.ID, func(list *models.ShoppingList) error {
+		list.Members = list.Members[:1]
+		return nil
+	})
+	require.NoError(t, err)
+	shared, err = reopened.Lists(ctx, "viewer")
+	require.NoError(t, err)
+	assert.Empty(t, shared, "removed members lose the list")
+
+	require.NoError(t, reopened.Delete(ctx, "owner", list.ID, 4))
+	shared, err = reopened.Lists(ctx, "editor")
+	require.NoError(t, err)
+	assert.Empty(t, shared)
+}
@@ -0,0 +1,135 @@
+package lists
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"sync"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before
+// it is dropped
+const subscriberBuffer = 16
+
+// Hub fans list events out to the members following each list
+type Hub struct {
+	mutex       sync.Mutex
+	subscribers map[string]map[*subscriber]bool
+}
+
+type subscriber struct {
+	userID string
+	events chan models.ListEvent
+}
+
+// NewHub creates a hub without subscribers
+func NewHub() *Hub {
+	return &Hub{subscribers: map[string]map[*subscriber]bool{}}
+}
+
+// Subscribe follows the list for the user until cancel is called. The
+// channel is closed once the user lost access to the list, the list was
+// deleted, or the user fell too far behind; clients then reload the list.
+func (h *Hub) Subscribe(listID, userID string) (events <-chan models.ListEvent, cancel func()) {
+	sub := &subscriber{userID: userID, events: make(chan models.ListEvent, subscriberBuffer)}
+	h.mutex.Lock()
+	if h.subscribers[listID] == nil {
+		h.subscribers[listID] = map[*subscriber]bool{}
+	}
+	h.subscribers[listID][sub] = true
+	h.mutex.Unlock()
+
+	return sub.events, func() {
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+		h.drop(listID, sub)
+	}
+}
+
+// Changed sends the list to its subscribers, as each of them sees it.
+// Subscribers no longer members receive a "removed" event instead.
+func (h *Hub) Changed(list *models.ShoppingList) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for sub := range h.subscribers[list.ID] {
+		if RoleOf(list, sub.userID) == "" {
+			h.send(list.ID, sub, models.ListEvent{Type: "removed", ListID: list.ID})
+			h.drop(list.ID, sub)
+			continue
+		}
+		h.send(list.ID, sub, models.ListEvent{Type: "list", ListID: list.ID, Version: list.Version, List: VisibleTo(list, sub.userID)})
+	}
+}
+
+// Deleted tells the list's subscribers it is gone
+func (h *Hub) Deleted(listID string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for sub := range h.subscribers[listID] {
+		h.send(listID, sub, models.ListEvent{Type: "deleted", ListID: listID})
+		h.drop(listID, sub)
+	}
+}
+
+// send queues the event, dropping subscribers whose queue is full. Callers
+// hold the mutex.
+func (h *Hub) send(listID string, sub *subscriber, event models.ListEvent) {
+	select {
+	case sub.events <- event:
+	default:
+		h.drop(listID, sub)
+	}
+}
+
+// drop unsubscribes and closes sub once. Callers hold the mutex.
+func (h *Hub) drop(listID string, sub *subscriber) {
+	if !h.subscribers[listID][sub] {
+		return
+	}
+	delete(h.subscribers[listID], sub)
+	if len(h.subscribers[listID]) == 0 {
+		delete(h.subscribers, listID)
+	}
+	close(sub.events)
+}
+
+// Broadcast publishes every change written through a ListStore to a Hub
+type Broadcast struct {
+	ListStore
+	hub *Hub
+}
+
+// NewBroadcast wraps store to publish changes to hub
+func NewBroadcast(store ListStore, hub *Hub) *Broadcast {
+	return &Broadcast{ListStore: store, hub: hub}
+}
+
+// Update changes the list and publishes it
+func (s *Broadcast) Update(ctx context.Context, userID, listID string, version int64, change func(list *models.ShoppingList) error) (*models.ShoppingList, error) {
+	list, err := s.ListStore.Update(ctx, userID, listID, version, change)
+	if err != nil {
+		return nil, err
+	}
+	s.hub.Changed(list)
+	return list, nil
+}
+
+// Share changes the list's members and publishes it
+func (s *Broadcast) Share(ctx context.Context, listID string, change func(list *models.ShoppingList) error) (*models.ShoppingList, error) {
+	list, err := s.ListStore.Share(ctx, listID, change)
+	if err != nil {
+		return nil, err
+	}
+	s.hub.Changed(list)
+	return list, nil
+}
+
+// Delete removes the list and tells its subscribers
+func (s *Broadcast) Delete(ctx context.Context, userID, listID string, version int64) error {
+	if err := s.ListStore.Delete(ctx, userID, listID, version); err != nil {
+		return err
+	}
+	s.hub.Deleted(listID)
+	return nil
+}
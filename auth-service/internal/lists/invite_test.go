@@ -0,0 +1,34 @@
+package lists
+
+import (
+	"auth-service/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInviteSigner(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	signer := NewInviteSigner("secret")
+	signer.now = func() time.Time { return now }
+
+	token, err := signer.Sign("list-1", models.ListInvite{ID: "invite-1", ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	listID, inviteID, err := signer.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "list-1", listID)
+	assert.Equal(t, "invite-1", inviteID)
+
+	_, _, err = NewInviteSigner("other").Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidInvite, "tokens of another secret")
+	for _, bad := range []string{"", "no-dot", "e30." + token[len(token)-43:], token + "x"} {
+		_, _, err = signer.Verify(bad)
+		assert.ErrorIs(t, err, ErrInvalidInvite, bad)
+	}
+
+	now = now.Add(time.Hour)
+	_, _, err = signer.Verify(token)
+	assert.ErrorIs(t, err, ErrInviteExpired)
+}
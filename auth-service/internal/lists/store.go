@@ -0,0 +1,166 @@
+// Package lists stores shopping lists and shares them between users. Every
+// change to a list names the version it was based on and bumps the version,
+// so several devices can edit a list at once without overwriting each other:
+// a change based on an old version fails with ErrConflict and is retried on
+// the current list.
+//
+// A list belongs to the user who created it and can be shared with editors
+// and viewers through signed invitations. Members following a list receive
+// its changes from a Hub.
+package lists
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned for unknown lists, and for lists the user is
+	// not a member of
+	ErrNotFound = errors.New("list not found")
+	// ErrForbidden is returned when the user's role does not allow a change
+	ErrForbidden = errors.New("list permission denied")
+	// ErrMemberNotFound is returned by changes naming a user not sharing the
+	// list
+	ErrMemberNotFound = errors.New("list member not found")
+	// ErrInviteNotFound is returned for invitations revoked or accepted
+	ErrInviteNotFound = errors.New("list invitation not found")
+	// ErrItemNotFound is returned by changes naming an item not on the list
+	ErrItemNotFound = errors.New("list item not found")
+	// ErrConflict is returned when a list moved past the version a change
+	// was based on
+	ErrConflict = errors.New("list changed concurrently")
+	// ErrLimit is returned when creating lists, items or members beyond
+	// MaxLists, MaxItems or MaxMembers
+	ErrLimit = errors.New("list limit reached")
+)
+
+// AnyVersion matches a list at whatever version it is, as "If-Match: *"
+// does. Lists start at version 1.
+const AnyVersion int64 = 0
+
+// Limits per user, and per list
+const (
+	MaxLists   = 50
+	MaxItems   = 500
+	MaxMembers = 20
+)
+
+// ListStore persists shopping lists
+type ListStore interface {
+	// Lists returns the lists the user owns or is a member of, the most
+	// recently updated first
+	Lists(ctx context.Context, userID string) ([]models.ShoppingList, error)
+	// Get returns a list the user owns or is a member of
+	Get(ctx context.Context, userID, listID string) (*models.ShoppingList, error)
+	// Create stores a new list owned by the user at version 1, filling in
+	// its ID, owner and timestamps
+	Create(ctx context.Context, userID string, list *models.ShoppingList) error
+	// Update applies change to the list if it is still at version, or at
+	// any version for AnyVersion, and stores it at the next version. The user must own or edit the list.
+	// change may return an error to leave the list unchanged.
+	Update(ctx context.Context, userID, listID string, version int64, change func(list *models.ShoppingList) error) (*models.ShoppingList, error)
+	// Share applies a change of members or invites to the list, whatever
+	// its version, and stores it at the next version. It does not check the
+	// caller's role: change does, as joining users are not members yet.
+	Share(ctx context.Context, listID string, change func(list *models.ShoppingList) error) (*models.ShoppingList, error)
+	// Delete removes the list if it is still at version, or at any version
+	// for AnyVersion. Only the owner may delete a list.
+	Delete(ctx context.Context, userID, listID string, version int64) error
+}
+
+// RoleOf returns the user's role on list, or "" when the user is not a
+// member
+func RoleOf(list *models.ShoppingList, userID string) models.ListRole {
+	if list.UserID == userID {
+		return models.ListOwner
+	}
+	for _, member := range list.Members {
+		if member.UserID == userID {
+			return member.Role
+		}
+	}
+	return ""
+}
+
+// CanEdit reports whether role allows changing a list's name and items
+func CanEdit(role models.ListRole) bool {
+	return role == models.ListOwner || role == models.ListEditor
+}
+
+// VisibleTo returns list as the user sees it. Only the owner and editors see
+// whom pending invitations are for; viewers get a copy without their emails
+// and usernames.
+func VisibleTo(list *models.ShoppingList, userID string) *models.ShoppingList {
+	if CanEdit(RoleOf(list, userID)) || len(list.Invites) == 0 {
+		return list
+	}
+	redacted := *list
+	redacted.Invites = make([]models.ListInvite, len(list.Invites))
+	for i, invite := range list.Invites {
+		invite.Email, invite.Username = "", ""
+		redacted.Invites[i] = invite
+	}
+	return &redacted
+}
+
+// FindItem returns the index of the item in list, or -1
+func FindItem(list *models.ShoppingList, itemID string) int {
+	for i := range list.Items {
+		if list.Items[i].ID == itemID {
+			return i
+		}
+	}
+	return -1
+}
+
+// FindMember returns the index of the user in list.Members, or -1
+func FindMember(list *models.ShoppingList, userID string) int {
+	for i := range list.Members {
+		if list.Members[i].UserID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+// FindInvite returns the index of the invitation in list.Invites, or -1
+func FindInvite(list *models.ShoppingList, inviteID string) int {
+	for i := range list.Invites {
+		if list.Invites[i].ID == inviteID {
+			return i
+		}
+	}
+	return -1
+}
+
+// HasVariant reports whether variantID is one of product's variants, as an
+// item's VariantID has to be
+func HasVariant(product *models.ProductDetail, variantID string) bool {
+	for _, variant := range product.Variants {
+		if variant.ID == variantID {
+			return true
+		}
+	}
+	return false
+}
+
+// PruneInvites drops the invitations of list that expired by now, so they no
+// longer count towards MaxMembers
+func PruneInvites(list *models.ShoppingList, now time.Time) {
+	pending := list.Invites[:0]
+	for _, invite := range list.Invites {
+		if now.Before(invite.ExpiresAt) {
+			pending = append(pending, invite)
+		}
+	}
+	list.Invites = pending
+}
+
+// sortLists orders lists as Lists returns them
+func sortLists(lists []models.ShoppingList) {
+	sort.SliceStable(lists, func(i, j int) bool { return lists[i].UpdatedAt.After(lists[j].UpdatedAt) })
+}
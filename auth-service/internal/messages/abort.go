@@ -0,0 +1,70 @@
+package messages
+
+import (
+	"context"
+	"sync"
+)
+
+// Generations tracks the running generations so they can be aborted. A user
+// runs at most one generation per conversation.
+type Generations struct {
+	mutex   sync.Mutex
+	running map[generationKey]*generation
+}
+
+type generationKey struct {
+	userID         string
+	conversationID string
+}
+
+type generation struct {
+	cancel  context.CancelFunc
+	aborted bool
+}
+
+// NewGenerations creates an empty tracker
+func NewGenerations() *Generations {
+	return &Generations{running: make(map[generationKey]*generation)}
+}
+
+// Start registers a generation and returns its context, derived from parent,
+// and a finish function that must be called when it ends. ok is false when
+// the conversation already has a running generation.
+func (g *Generations) Start(parent context.Context, userID, conversationID string) (ctx context.Context, finish func() (aborted bool), ok bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	key := generationKey{userID: userID, conversationID: conversationID}
+	if _, exists := g.running[key]; exists {
+		return nil, nil, false
+	}
+	ctx, cancel := context.WithCancel(parent)
+	gen := &generation{cancel: cancel}
+	g.running[key] = gen
+
+	finish = func() bool {
+		g.mutex.Lock()
+		defer g.mutex.Unlock()
+		if g.running[key] == gen {
+			delete(g.running, key)
+		}
+		cancel()
+		return gen.aborted
+	}
+	return ctx, finish, true
+}
+
+// Abort cancels the user's generation in a conversation and reports whether
+// one was running
+func (g *Generations) Abort(userID, conversationID string) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	gen, ok := g.running[generationKey{userID: userID, conversationID: conversationID}]
+	if !ok {
+		return false
+	}
+	gen.aborted = true
+	gen.cancel()
+	return true
+}
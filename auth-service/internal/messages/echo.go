@@ -0,0 +1,78 @@
+package messages
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EchoPrefix starts every EchoProvider reply
+const EchoPrefix = "Echo: "
+
+// EchoProvider replies with the last user message, one word per delta. It
+// needs no network and always gives the same reply, for development and tests.
+//
+// With Tools, a user message "/<tool> <JSON arguments>" naming an offered
+// tool is answered with a call of that tool, and the tool's result is then
+// echoed back.
+type EchoProvider struct {
+	// Delay is waited before each delta, to make aborts observable
+	Delay time.Duration
+	// Tools enables tool calls
+	Tools bool
+}
+
+// Stream streams EchoPrefix followed by the last user message, or calls the
+// tool it asks for
+func (p *EchoProvider) Stream(ctx context.Context, req CompletionRequest, onDelta func(delta string)) ([]ToolCall, error) {
+	var last ChatMessage
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if role := req.Messages[i].Role; role == RoleUser || role == RoleTool && p.Tools {
+			last = req.Messages[i]
+			break
+		}
+	}
+	if call, ok := p.toolCall(req, last); ok {
+		call.ID = fmt.Sprintf("call_%d", len(req.Messages))
+		return []ToolCall{call}, nil
+	}
+
+	words := strings.SplitAfter(EchoPrefix+last.Content, " ")
+	for _, word := range words {
+		if p.Delay > 0 {
+			timer := time.NewTimer(p.Delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if word != "" {
+			onDelta(word)
+		}
+	}
+	return nil, nil
+}
+
+// toolCall parses "/<tool> <arguments>" from a user message when p calls
+// tools and req offers that tool
+func (p *EchoProvider) toolCall(req CompletionRequest, msg ChatMessage) (ToolCall, bool) {
+	if !p.Tools || msg.Role != RoleUser || !strings.HasPrefix(msg.Content, "/") {
+		return ToolCall{}, false
+	}
+	name, args, _ := strings.Cut(strings.TrimPrefix(msg.Content, "/"), " ")
+	if args = strings.TrimSpace(args); args == "" {
+		args = "{}"
+	}
+	for _, tool := range req.Tools {
+		if tool.Function.Name == name {
+			return ToolCall{Type: ToolTypeFunction, Function: FunctionCall{Name: name, Arguments: args}}, true
+		}
+	}
+	return ToolCall{}, false
+}
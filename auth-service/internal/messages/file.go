@@ -0,0 +1,95 @@
+package messages
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/storage"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore keeps the messages of each conversation in one JSON file under dir
+type FileStore struct {
+	dir   string
+	mutex sync.Mutex
+	now   func() time.Time
+}
+
+// conversationMessages is the document stored per conversation
+type conversationMessages struct {
+	Messages []models.Message `json:"messages"`
+}
+
+// NewFileStore creates a store in dir/messages
+func NewFileStore(dir string) (*FileStore, error) {
+	dir = filepath.Join(dir, "messages")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir, now: time.Now}, nil
+}
+
+// List returns the messages of one of the user's conversations, oldest first
+func (s *FileStore) List(ctx context.Context, userID, conversationID string) ([]models.Message, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Messages, nil
+}
+
+// Save creates or replaces a message
+func (s *FileStore) Save(ctx context.Context, userID string, msg *models.Message) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID, msg.ConversationID)
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	msg.User = userID
+	msg.UpdatedAt = now
+	for i := range doc.Messages {
+		if doc.Messages[i].MessageID == msg.MessageID {
+			msg.CreatedAt = doc.Messages[i].CreatedAt
+			doc.Messages[i] = *msg
+			return s.save(userID, msg.ConversationID, doc)
+		}
+	}
+	msg.CreatedAt = now
+	doc.Messages = append(doc.Messages, *msg)
+	return s.save(userID, msg.ConversationID, doc)
+}
+
+// DeleteConversation removes every message of a conversation
+func (s *FileStore) DeleteConversation(ctx context.Context, userID, conversationID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return storage.Remove(s.path(userID, conversationID))
+}
+
+// path names a conversation's file by hashing the user and conversation IDs
+// together, so conversations of different users never share a file
+func (s *FileStore) path(userID, conversationID string) string {
+	return storage.UserFile(s.dir, userID+"\x00"+conversationID)
+}
+
+func (s *FileStore) load(userID, conversationID string) (*conversationMessages, error) {
+	doc := &conversationMessages{Messages: []models.Message{}}
+	if _, err := storage.ReadJSON(s.path(userID, conversationID), doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (s *FileStore) save(userID, conversationID string, doc *conversationMessages) error {
+	return storage.WriteJSON(s.path(userID, conversationID), doc)
+}
@@ -0,0 +1,137 @@
+package messages
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *FileStore {
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	return store
+}
+
+func save(t *testing.T, store *FileStore, userID, conversationID, id, parentID, text string) {
+	require.NoError(t, store.Save(context.Background(), userID, &models.Message{
+		MessageID:       id,
+		ConversationID:  conversationID,
+		ParentMessageID: parentID,
+		Text:            text,
+	}))
+}
+
+func messageIDs(list []models.Message) []string {
+	ids := make([]string, len(list))
+	for i, msg := range list {
+		ids[i] = msg.MessageID
+	}
+	return ids
+}
+
+func TestFileStore_SaveAndList(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	save(t, store, "user-1", "c1", "m1", NoParentID, "Hello")
+	save(t, store, "user-1", "c1", "m2", "m1", "Hi")
+	save(t, store, "user-1", "c2", "m3", NoParentID, "Other conversation")
+
+	list, err := store.List(ctx, "user-1", "c1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m1", "m2"}, messageIDs(list))
+	assert.Equal(t, "user-1", list[0].User)
+
+	// Saving an existing message replaces it but keeps its creation time
+	created := list[1].CreatedAt
+	save(t, store, "user-1", "c1", "m2", "m1", "Hi there")
+	list, err = store.List(ctx, "user-1", "c1")
+	require.NoError(t, err)
+	assert.Equal(t, "Hi there", list[1].Text)
+	assert.Equal(t, created, list[1].CreatedAt)
+	assert.True(t, list[1].UpdatedAt.After(created))
+}
+
+func TestFileStore_ScopedToUser(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	save(t, store, "user-1", "c1", "m1", NoParentID, "Hello")
+
+	list, err := store.List(ctx, "user-2", "c1")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	require.NoError(t, store.DeleteConversation(ctx, "user-2", "c1"))
+	list, err = store.List(ctx, "user-1", "c1")
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	require.NoError(t, store.DeleteConversation(ctx, "user-1", "c1"))
+	list, err = store.List(ctx, "user-1", "c1")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestThread(t *testing.T) {
+	// m1 ─ m2 ─ m3
+	//    └ m4 ─ m5   (m4 regenerates m2)
+	list := []models.Message{
+		{MessageID: "m1", ParentMessageID: NoParentID},
+		{MessageID: "m2", ParentMessageID: "m1"},
+		{MessageID: "m3", ParentMessageID: "m2"},
+		{MessageID: "m4", ParentMessageID: "m1"},
+		{MessageID: "m5", ParentMessageID: "m4"},
+	}
+
+	thread, err := Thread(list, "m3")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m1", "m2", "m3"}, messageIDs(thread))
+
+	thread, err = Thread(list, "m5")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m1", "m4", "m5"}, messageIDs(thread))
+
+	_, err = Thread(list, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// A cycle stops at the first repeated message
+	cyclic := []models.Message{
+		{MessageID: "a", ParentMessageID: "b"},
+		{MessageID: "b", ParentMessageID: "a"},
+	}
+	thread, err = Thread(cyclic, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, messageIDs(thread))
+}
+
+func TestGenerations_Abort(t *testing.T) {
+	generations := NewGenerations()
+
+	ctx, finish, ok := generations.Start(context.Background(), "user-1", "c1")
+	require.True(t, ok)
+
+	_, _, ok = generations.Start(context.Background(), "user-1", "c1")
+	assert.False(t, ok, "one generation per conversation")
+	_, finishOther, ok := generations.Start(context.Background(), "user-2", "c1")
+	require.True(t, ok, "other users are independent")
+	assert.False(t, finishOther())
+
+	assert.False(t, generations.Abort("user-2", "c1"))
+	assert.True(t, generations.Abort("user-1", "c1"))
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	assert.True(t, finish())
+
+	_, finish, ok = generations.Start(context.Background(), "user-1", "c1")
+	require.True(t, ok, "finished generations free the conversation")
+	assert.False(t, finish())
+}
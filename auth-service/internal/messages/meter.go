@@ -0,0 +1,33 @@
+package messages
+
+import (
+	"context"
+)
+
+// Usage is the number of tokens a reply took
+type Usage struct {
+	PromptTokens     int64
+	CompletionTokens int64
+}
+
+// Meter charges users for replies. Reserve is called before a reply is
+// generated; the returned settle function is called once afterwards with
+// what the reply used, even when it failed or was aborted.
+type Meter interface {
+	Reserve(ctx context.Context, userID string, req CompletionRequest) (settle func(ctx context.Context, usage Usage) error, err error)
+}
+
+// EstimateTokens approximates the token count of text at four bytes per
+// token, as providers do not report usage when streaming
+func EstimateTokens(text string) int64 {
+	return int64(len(text)+3) / 4
+}
+
+// EstimatePromptTokens approximates the token count of the messages of req
+func EstimatePromptTokens(req CompletionRequest) int64 {
+	var tokens int64
+	for _, msg := range req.Messages {
+		tokens += EstimateTokens(msg.Content)
+	}
+	return tokens
+}
@@ -0,0 +1,163 @@
+package messages
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultOpenAIBaseURL is used by the openAI endpoint when it has no baseURL
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIProvider streams replies from an OpenAI-compatible chat completions
+// API (OpenAI, Azure-style proxies, Ollama, vLLM, OpenRouter, ...)
+type OpenAIProvider struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewOpenAIProvider creates a provider for the API at baseURL (up to, not
+// including, /chat/completions). An empty apiKey sends no Authorization header.
+func NewOpenAIProvider(baseURL, apiKey string, client *http.Client) *OpenAIProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &OpenAIProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  client,
+	}
+}
+
+type chatCompletionRequest struct {
+	Model    string           `json:"model"`
+	Messages []ChatMessage    `json:"messages"`
+	Tools    []ToolDefinition `json:"tools,omitempty"`
+	Stream   bool             `json:"stream"`
+}
+
+type chatCompletionChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string          `json:"content"`
+			ToolCalls []toolCallDelta `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+// toolCallDelta is a piece of a streamed tool call. The first piece of each
+// call has its ID and name; the arguments arrive in fragments.
+type toolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type apiError struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Stream posts req with "stream": true and reads the Server-Sent Events
+// response until "data: [DONE]", assembling streamed tool calls
+func (p *OpenAIProvider) Stream(ctx context.Context, req CompletionRequest, onDelta func(delta string)) ([]ToolCall, error) {
+	body, err := json.Marshal(chatCompletionRequest{Model: req.Model, Messages: req.Messages, Tools: req.Tools, Stream: true})
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("chat completion request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var apiErr apiError
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, fmt.Errorf("chat completion failed with status %d: %s", resp.StatusCode, apiErr.Error.Message)
+		}
+		return nil, fmt.Errorf("chat completion failed with status %d", resp.StatusCode)
+	}
+
+	var calls []ToolCall
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue // blank separators, comments and other fields
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return calls, nil
+		}
+
+		var chunk chatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("invalid chat completion chunk: %w", err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				onDelta(choice.Delta.Content)
+			}
+			for _, delta := range choice.Delta.ToolCalls {
+				if calls, err = mergeToolCall(calls, delta); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("chat completion stream failed: %w", err)
+	}
+	return calls, nil
+}
+
+// mergeToolCall adds a streamed piece of a tool call to calls, which are
+// kept in the order of their index
+func mergeToolCall(calls []ToolCall, delta toolCallDelta) ([]ToolCall, error) {
+	if delta.Index < 0 || delta.Index > len(calls) {
+		return nil, fmt.Errorf("invalid chat completion chunk: tool call %d out of order", delta.Index)
+	}
+	if delta.Index == len(calls) {
+		calls = append(calls, ToolCall{Type: ToolTypeFunction})
+	}
+	call := &calls[delta.Index]
+	if delta.ID != "" {
+		call.ID = delta.ID
+	}
+	if delta.Type != "" {
+		call.Type = delta.Type
+	}
+	call.Function.Name += delta.Function.Name
+	call.Function.Arguments += delta.Function.Arguments
+	return calls, nil
+}
@@ -0,0 +1,96 @@
+package messages
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/librechat"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Chat roles
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	// RoleTool carries the result of a tool call back to the model
+	RoleTool = "tool"
+)
+
+// Provider names accepted in LLM_PROVIDER
+const (
+	ProviderOpenAI = "openai"
+	ProviderEcho   = "echo"
+	// ProviderEchoTools is ProviderEcho that also calls tools on request
+	ProviderEchoTools = "echo_tools"
+)
+
+// ErrUnsupportedEndpoint is returned for endpoints no provider can serve
+var ErrUnsupportedEndpoint = errors.New("endpoint is not supported for chat")
+
+// ChatMessage is one turn of the conversation sent to a model. Assistant
+// turns may call tools, whose results follow as RoleTool turns.
+type ChatMessage struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// CompletionRequest asks a model to continue a conversation, optionally
+// offering it tools
+type CompletionRequest struct {
+	Model    string
+	Messages []ChatMessage
+	Tools    []ToolDefinition
+}
+
+// LLMProvider generates assistant replies
+type LLMProvider interface {
+	// Stream generates a reply to req, calling onDelta with each piece of
+	// text as it arrives. When req offers tools, the model may ask for tool
+	// calls instead, which are returned once the reply ends. It stops with
+	// ctx.Err() when ctx is cancelled.
+	Stream(ctx context.Context, req CompletionRequest, onDelta func(delta string)) ([]ToolCall, error)
+}
+
+// ProviderFactory returns the provider serving a registry endpoint
+type ProviderFactory func(endpoint *librechat.Endpoint) (LLMProvider, error)
+
+// NewProviderFactory returns the factory selected by cfg.Provider: "echo"
+// serves every endpoint locally, and "echo_tools" also calls tools;
+// "openai" (the default) calls each endpoint's OpenAI-compatible API.
+func NewProviderFactory(cfg config.LLMConfig) (ProviderFactory, error) {
+	switch cfg.Provider {
+	case ProviderEcho, ProviderEchoTools:
+		echo := &EchoProvider{Tools: cfg.Provider == ProviderEchoTools}
+		return func(*librechat.Endpoint) (LLMProvider, error) {
+			return echo, nil
+		}, nil
+	case ProviderOpenAI, "":
+		client := &http.Client{Timeout: cfg.Timeout}
+		return func(endpoint *librechat.Endpoint) (LLMProvider, error) {
+			return openAIProviderFor(endpoint, client)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", cfg.Provider)
+	}
+}
+
+// openAIProviderFor configures an OpenAIProvider from a registry endpoint.
+// The openAI endpoint defaults to the OpenAI API; other endpoints need a
+// baseURL. Keys entered by users are not stored, so such endpoints are unsupported.
+func openAIProviderFor(endpoint *librechat.Endpoint, client *http.Client) (LLMProvider, error) {
+	if endpoint.UserProvidesKey() || endpoint.UserProvidesURL() {
+		return nil, fmt.Errorf("%w: %s expects a user-provided key or URL", ErrUnsupportedEndpoint, endpoint.Name)
+	}
+	baseURL := endpoint.BaseURL
+	if baseURL == "" {
+		if endpoint.Name != librechat.EndpointOpenAI {
+			return nil, fmt.Errorf("%w: %s has no baseURL", ErrUnsupportedEndpoint, endpoint.Name)
+		}
+		baseURL = DefaultOpenAIBaseURL
+	}
+	return NewOpenAIProvider(baseURL, endpoint.APIKey.Value(), client), nil
+}
@@ -0,0 +1,185 @@
+package messages
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/librechat"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRequest = CompletionRequest{
+	Model: "gpt-4o-mini",
+	Messages: []ChatMessage{
+		{Role: RoleUser, Content: "Find me running shoes"},
+		{Role: RoleAssistant, Content: "Which size?"},
+		{Role: RoleUser, Content: "Size 42"},
+	},
+}
+
+func collect(t *testing.T, ctx context.Context, provider LLMProvider) ([]string, error) {
+	t.Helper()
+	var deltas []string
+	_, err := provider.Stream(ctx, testRequest, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	return deltas, err
+}
+
+func TestEchoProvider(t *testing.T) {
+	deltas, err := collect(t, context.Background(), &EchoProvider{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Echo: ", "Size ", "42"}, deltas)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = collect(t, ctx, &EchoProvider{Delay: time.Millisecond})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// fakeOpenAI streams reply one word per chunk, recording the last request
+func fakeOpenAI(t *testing.T, reply string, received *chatCompletionRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":{"message":"Incorrect API key provided"}}`)
+			return
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(received))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n")
+		for _, word := range strings.SplitAfter(reply, " ") {
+			chunk, _ := json.Marshal(map[string]interface{}{
+				"choices": []interface{}{map[string]interface{}{"delta": map[string]string{"content": word}}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+}
+
+func TestOpenAIProvider_Stream(t *testing.T) {
+	var received chatCompletionRequest
+	server := fakeOpenAI(t, "Here are three pairs", &received)
+	defer server.Close()
+
+	provider := NewOpenAIProvider(server.URL+"/v1/", "sk-test", server.Client())
+	deltas, err := collect(t, context.Background(), provider)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Here ", "are ", "three ", "pairs"}, deltas)
+
+	assert.True(t, received.Stream)
+	assert.Equal(t, testRequest.Model, received.Model)
+	assert.Equal(t, testRequest.Messages, received.Messages)
+}
+
+func TestOpenAIProvider_APIError(t *testing.T) {
+	server := fakeOpenAI(t, "unused", &chatCompletionRequest{})
+	defer server.Close()
+
+	provider := NewOpenAIProvider(server.URL+"/v1", "wrong", server.Client())
+	_, err := collect(t, context.Background(), provider)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 401: Incorrect API key provided")
+}
+
+func TestNewProviderFactory(t *testing.T) {
+	factory, err := NewProviderFactory(config.LLMConfig{Provider: ProviderEcho})
+	require.NoError(t, err)
+	provider, err := factory(&librechat.Endpoint{Name: "anthropic"})
+	require.NoError(t, err)
+	assert.IsType(t, &EchoProvider{}, provider)
+
+	factory, err = NewProviderFactory(config.LLMConfig{Provider: ProviderOpenAI})
+	require.NoError(t, err)
+	provider, err = factory(&librechat.Endpoint{Name: librechat.EndpointOpenAI})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultOpenAIBaseURL, provider.(*OpenAIProvider).baseURL)
+
+	_, err = factory(&librechat.Endpoint{Name: "anthropic"})
+	assert.ErrorIs(t, err, ErrUnsupportedEndpoint)
+	_, err = factory(&librechat.Endpoint{Name: "groq", BaseURL: "https://api.groq.com/openai/v1", APIKey: config.Secret(librechat.UserProvided)})
+	assert.ErrorIs(t, err, ErrUnsupportedEndpoint)
+
+	_, err = NewProviderFactory(config.LLMConfig{Provider: "bogus"})
+	assert.Error(t, err)
+}
+
+func TestEchoProvider_CallsTools(t *testing.T) {
+	req := CompletionRequest{
+		Messages: []ChatMessage{{Role: RoleUser, Content: `/search_products {"query": "kettle"}`}},
+		Tools:    []ToolDefinition{{Type: ToolTypeFunction, Function: FunctionDefinition{Name: "search_products"}}},
+	}
+	calls, err := (&EchoProvider{Tools: true}).Stream(context.Background(), req, func(string) {
+		t.Fatal("a tool call has no text")
+	})
+	require.NoError(t, err)
+	require.Len(t, calls, 1)
+	assert.Equal(t, "call_1", calls[0].ID)
+	assert.Equal(t, FunctionCall{Name: "search_products", Arguments: `{"query": "kettle"}`}, calls[0].Function)
+
+	// The result is echoed
+	req.Messages = append(req.Messages,
+		ChatMessage{Role: RoleAssistant, ToolCalls: calls},
+		ChatMessage{Role: RoleTool, Content: `{"total":0}`, ToolCallID: "call_1"})
+	var text string
+	calls, err = (&EchoProvider{Tools: true}).Stream(context.Background(), req, func(delta string) { text += delta })
+	require.NoError(t, err)
+	assert.Empty(t, calls)
+	assert.Equal(t, `Echo: {"total":0}`, text)
+
+	// Tools that are not offered, or a provider without tools, just echo
+	for _, provider := range []*EchoProvider{{Tools: false}, {Tools: true}} {
+		req := CompletionRequest{Messages: req.Messages[:1]}
+		if !provider.Tools {
+			req.Tools = []ToolDefinition{{Type: ToolTypeFunction, Function: FunctionDefinition{Name: "search_products"}}}
+		}
+		calls, err := provider.Stream(context.Background(), req, func(string) {})
+		require.NoError(t, err)
+		assert.Empty(t, calls)
+	}
+}
+
+func TestOpenAIProvider_StreamsToolCalls(t *testing.T) {
+	var received chatCompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"choices":[{"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"search_products","arguments":""}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"query\":"}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":1,"id":"call_b","type":"function","function":{"name":"get_offers","arguments":"{}"}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"kettle\"}"}}]}}]}`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	req := testRequest
+	req.Tools = []ToolDefinition{{
+		Type:     ToolTypeFunction,
+		Function: FunctionDefinition{Name: "search_products", Parameters: json.RawMessage(`{"type":"object"}`)},
+	}}
+	provider := NewOpenAIProvider(server.URL, "sk-test", server.Client())
+	calls, err := provider.Stream(context.Background(), req, func(string) {})
+	require.NoError(t, err)
+	assert.Equal(t, []ToolCall{
+		{ID: "call_a", Type: ToolTypeFunction, Function: FunctionCall{Name: "search_products", Arguments: `{"query":"kettle"}`}},
+		{ID: "call_b", Type: ToolTypeFunction, Function: FunctionCall{Name: "get_offers", Arguments: "{}"}},
+	}, calls)
+	require.Len(t, received.Tools, 1)
+	assert.Equal(t, "search_products", received.Tools[0].Function.Name)
+}
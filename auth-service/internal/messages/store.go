@@ -0,0 +1,55 @@
+// Package messages stores chat messages and generates assistant replies
+// through an LLMProvider. Like conversations, every operation is scoped to a
+// user.
+package messages
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned for unknown messages
+var ErrNotFound = errors.New("message not found")
+
+// NoParentID is the parent of the first message of a conversation (LibreChat's Constants.NO_PARENT)
+const NoParentID = "00000000-0000-0000-0000-000000000000"
+
+// MessageStore persists the messages of conversations
+type MessageStore interface {
+	// List returns the messages of one of the user's conversations, oldest first
+	List(ctx context.Context, userID, conversationID string) ([]models.Message, error)
+	// Save creates or replaces a message, filling in its owner and timestamps
+	Save(ctx context.Context, userID string, msg *models.Message) error
+	// DeleteConversation removes every message of a conversation
+	DeleteConversation(ctx context.Context, userID, conversationID string) error
+}
+
+// Thread returns the branch of the message tree ending at leafID, root
+// first. It returns ErrNotFound when leafID is not in messages.
+func Thread(messages []models.Message, leafID string) ([]models.Message, error) {
+	byID := make(map[string]*models.Message, len(messages))
+	for i := range messages {
+		byID[messages[i].MessageID] = &messages[i]
+	}
+
+	var thread []models.Message
+	for id := leafID; id != NoParentID && id != ""; {
+		msg, ok := byID[id]
+		if !ok {
+			if len(thread) == 0 {
+				return nil, ErrNotFound
+			}
+			// An orphaned branch starts at its oldest known message
+			break
+		}
+		thread = append(thread, *msg)
+		delete(byID, id) // guards against parent cycles in edited files
+		id = msg.ParentMessageID
+	}
+
+	for i, j := 0, len(thread)-1; i < j; i, j = i+1, j-1 {
+		thread[i], thread[j] = thread[j], thread[i]
+	}
+	return thread, nil
+}
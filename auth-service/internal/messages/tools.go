@@ -0,0 +1,151 @@
+package messages
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// ToolTypeFunction is the only tool type models are offered
+const ToolTypeFunction = "function"
+
+// Codes of failed tool calls, as the model sees them
+const (
+	ToolErrorNotFound  = "not_found"
+	ToolErrorConflict  = "conflict"
+	ToolErrorInvalid   = "invalid"
+	ToolErrorForbidden = "forbidden"
+	ToolErrorLimit     = "limit"
+	ToolErrorFailed    = "failed"
+)
+
+// ToolError is a failed tool call with the code the model is told. Err is
+// only logged: its message may hold internal details.
+type ToolError struct {
+	Code string
+	Err  error
+}
+
+func (e *ToolError) Error() string { return e.Code + ": " + e.Err.Error() }
+
+func (e *ToolError) Unwrap() error { return e.Err }
+
+// ToolCall is a model asking for a tool to be run, in the OpenAI format
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall names the function a ToolCall runs. Arguments is a JSON
+// object, as generated by the model.
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolDefinition offers a tool to a model
+type ToolDefinition struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionDefinition describes a function tool. Parameters is the JSON
+// schema of its arguments.
+type FunctionDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+// ToolRunner runs tools on behalf of the user a reply is generated for
+type ToolRunner interface {
+	// Definitions lists the tools offered to the model
+	Definitions() []ToolDefinition
+	// Run runs a tool and returns its result as JSON. The model is told the
+	// code of a *ToolError, or ToolErrorFailed for other errors, and may
+	// retry or explain.
+	Run(ctx context.Context, call ToolCall) (string, error)
+}
+
+// Reply is an assistant reply generated over one or more rounds
+type Reply struct {
+	// Text is the text of every round
+	Text string
+	// ToolCalls are the tools the model called, with their results
+	ToolCalls []models.ToolCall
+	// ToolErrors are the errors of failed tool calls by call ID, to be logged
+	ToolErrors map[string]error
+	Usage      Usage
+}
+
+// Generate streams a reply to req. With tools, the model may call them
+// instead of replying: the calls are run and their results sent back to the
+// model in another round. After maxRounds rounds with tool calls, the model
+// is no longer offered tools and has to reply. onDelta is called with each
+// piece of text, and onToolCall with each call before it runs and again with
+// its output.
+//
+// On error, the returned Reply holds what was generated so far.
+func Generate(ctx context.Context, provider LLMProvider, req CompletionRequest, tools ToolRunner, maxRounds int, onDelta func(delta string), onToolCall func(call models.ToolCall)) (*Reply, error) {
+	reply := &Reply{}
+	req.Messages = append([]ChatMessage(nil), req.Messages...)
+	for round := 0; ; round++ {
+		req.Tools = nil
+		if tools != nil && round < maxRounds {
+			req.Tools = tools.Definitions()
+		}
+		reply.Usage.PromptTokens += EstimatePromptTokens(req)
+
+		var text string
+		calls, err := provider.Stream(ctx, req, func(delta string) {
+			text += delta
+			reply.Text += delta
+			onDelta(delta)
+		})
+		reply.Usage.CompletionTokens += EstimateTokens(text)
+		if err != nil {
+			return reply, err
+		}
+		if len(calls) == 0 || req.Tools == nil {
+			return reply, nil
+		}
+
+		req.Messages = append(req.Messages, ChatMessage{Role: RoleAssistant, Content: text, ToolCalls: calls})
+		for _, call := range calls {
+			reply.Usage.CompletionTokens += EstimateTokens(call.Function.Arguments)
+			record := models.ToolCall{ID: call.ID, Name: call.Function.Name, Args: call.Function.Arguments}
+			onToolCall(record)
+
+			output, err := tools.Run(ctx, call)
+			if err != nil {
+				if ctx.Err() != nil {
+					return reply, ctx.Err()
+				}
+				output = toolError(err)
+				record.Error = true
+				if reply.ToolErrors == nil {
+					reply.ToolErrors = make(map[string]error)
+				}
+				reply.ToolErrors[call.ID] = err
+			}
+			record.Output = output
+			reply.ToolCalls = append(reply.ToolCalls, record)
+			onToolCall(record)
+			req.Messages = append(req.Messages, ChatMessage{Role: RoleTool, Content: output, ToolCallID: call.ID})
+		}
+	}
+}
+
+// toolError is the output of a failed tool call: its code, never the error
+// message
+func toolError(err error) string {
+	code := ToolErrorFailed
+	var toolErr *ToolError
+	if errors.As(err, &toolErr) {
+		code = toolErr.Code
+	}
+	data, _ := json.Marshal(map[string]string{"error": code})
+	return string(data)
+}
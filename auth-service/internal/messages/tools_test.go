@@ -0,0 +1,101 @@
+package messages
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTools offers echo_args, which returns its arguments, fail and missing
+type fakeTools struct {
+	runs int
+}
+
+func (f *fakeTools) Definitions() []ToolDefinition {
+	return []ToolDefinition{
+		{Type: ToolTypeFunction, Function: FunctionDefinition{Name: "echo_args"}},
+		{Type: ToolTypeFunction, Function: FunctionDefinition{Name: "fail"}},
+		{Type: ToolTypeFunction, Function: FunctionDefinition{Name: "missing"}},
+	}
+}
+
+func (f *fakeTools) Run(ctx context.Context, call ToolCall) (string, error) {
+	f.runs++
+	switch call.Function.Name {
+	case "fail":
+		return "", errors.New("open /data/stock.json: permission denied")
+	case "missing":
+		return "", &ToolError{Code: ToolErrorNotFound, Err: errors.New("product p-1 not found")}
+	}
+	return call.Function.Arguments, nil
+}
+
+func TestGenerate_RunsToolCalls(t *testing.T) {
+	req := CompletionRequest{Messages: []ChatMessage{{Role: RoleUser, Content: `/echo_args {"a":1}`}}}
+	var events []models.ToolCall
+	var text string
+	reply, err := Generate(context.Background(), &EchoProvider{Tools: true}, req, &fakeTools{}, 4,
+		func(delta string) { text += delta },
+		func(call models.ToolCall) { events = append(events, call) })
+	require.NoError(t, err)
+
+	assert.Equal(t, `Echo: {"a":1}`, reply.Text)
+	assert.Equal(t, reply.Text, text)
+	call := models.ToolCall{ID: "call_1", Name: "echo_args", Args: `{"a":1}`}
+	require.Len(t, events, 2)
+	assert.Equal(t, call, events[0], "announced before running")
+	call.Output = `{"a":1}`
+	assert.Equal(t, call, events[1])
+	assert.Equal(t, []models.ToolCall{call}, reply.ToolCalls)
+	assert.Positive(t, reply.Usage.PromptTokens)
+	assert.Positive(t, reply.Usage.CompletionTokens)
+	assert.Len(t, req.Messages, 1, "the request is not changed")
+}
+
+func TestGenerate_ReportsToolErrorsToTheModel(t *testing.T) {
+	req := CompletionRequest{Messages: []ChatMessage{{Role: RoleUser, Content: "/fail"}}}
+	reply, err := Generate(context.Background(), &EchoProvider{Tools: true}, req, &fakeTools{}, 4, func(string) {}, func(models.ToolCall) {})
+	require.NoError(t, err)
+
+	require.Len(t, reply.ToolCalls, 1)
+	assert.True(t, reply.ToolCalls[0].Error)
+	assert.JSONEq(t, `{"error": "failed"}`, reply.ToolCalls[0].Output, "details are not sent")
+	assert.Equal(t, `Echo: {"error":"failed"}`, reply.Text)
+	assert.EqualError(t, reply.ToolErrors[reply.ToolCalls[0].ID], "open /data/stock.json: permission denied")
+
+	req.Messages[0].Content = "/missing"
+	reply, err = Generate(context.Background(), &EchoProvider{Tools: true}, req, &fakeTools{}, 4, func(string) {}, func(models.ToolCall) {})
+	require.NoError(t, err)
+	require.Len(t, reply.ToolCalls, 1)
+	assert.JSONEq(t, `{"error": "not_found"}`, reply.ToolCalls[0].Output)
+}
+
+// loopingProvider calls a tool whenever it may
+type loopingProvider struct{}
+
+func (loopingProvider) Stream(ctx context.Context, req CompletionRequest, onDelta func(string)) ([]ToolCall, error) {
+	if len(req.Tools) == 0 {
+		onDelta("Done")
+		return nil, nil
+	}
+	return []ToolCall{{ID: "call", Type: ToolTypeFunction, Function: FunctionCall{Name: "echo_args", Arguments: "{}"}}}, nil
+}
+
+func TestGenerate_BoundsToolRounds(t *testing.T) {
+	tools := &fakeTools{}
+	reply, err := Generate(context.Background(), loopingProvider{}, testRequest, tools, 3, func(string) {}, func(models.ToolCall) {})
+	require.NoError(t, err)
+	assert.Equal(t, 3, tools.runs)
+	assert.Len(t, reply.ToolCalls, 3)
+	assert.Equal(t, "Done", reply.Text, "the last round offers no tools")
+
+	// Without tools, the provider replies right away
+	reply, err = Generate(context.Background(), loopingProvider{}, testRequest, nil, 3, func(string) {}, func(models.ToolCall) {})
+	require.NoError(t, err)
+	assert.Empty(t, reply.ToolCalls)
+	assert.Equal(t, "Done", reply.Text)
+}
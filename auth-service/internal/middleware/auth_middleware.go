@@ -2,14 +2,19 @@ package middleware
 
 import (
 	"auth-service/internal/config"
+	"auth-service/internal/flags"
 	"auth-service/internal/models"
+	"auth-service/internal/problem"
+	"auth-service/internal/resilience"
+	"auth-service/internal/services"
 	"auth-service/pkg/logger"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/Nerzal/gocloak/v13"
 	"github.com/gin-gonic/gin"
 )
 
@@ -60,12 +65,17 @@ func (rl *rateLimiter) allow(ip string) bool {
 var authRateLimiter = newRateLimiter(50, time.Minute)    // 50 auth attempts per minute for testing
 var generalRateLimiter = newRateLimiter(100, time.Minute) // 100 general requests per minute
 
+// TokenValidator checks an access token with the identity provider
+type TokenValidator interface {
+	ValidateToken(accessToken string) (*models.User, error)
+}
+
 // AuthMiddleware validates JWT tokens from Keycloak
-func AuthMiddleware(cfg *config.Config, logger *logger.Logger) gin.HandlerFunc {
+func AuthMiddleware(cfg *config.Config, logger *logger.Logger, keycloak TokenValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Rate limiting for protected routes
 		if !generalRateLimiter.allow(c.ClientIP()) {
-			c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+			problem.Write(c, models.ErrorResponse{
 				Error:   "rate_limit_exceeded",
 				Message: "Too many requests. Please try again later.",
 				Code:    http.StatusTooManyRequests,
@@ -77,7 +87,7 @@ func AuthMiddleware(cfg *config.Config, logger *logger.Logger) gin.HandlerFunc {
 		// Get token from Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			problem.Write(c, models.ErrorResponse{
 				Error:   "unauthorized",
 				Message: "Authorization header is required",
 				Code:    http.StatusUnauthorized,
@@ -89,7 +99,7 @@ func AuthMiddleware(cfg *config.Config, logger *logger.Logger) gin.HandlerFunc {
 		// Check if token starts with "Bearer "
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			problem.Write(c, models.ErrorResponse{
 				Error:   "unauthorized",
 				Message: "Invalid authorization header format",
 				Code:    http.StatusUnauthorized,
@@ -101,58 +111,100 @@ func AuthMiddleware(cfg *config.Config, logger *logger.Logger) gin.HandlerFunc {
 		token := tokenParts[1]
 
 		// Validate token with Keycloak
-		client := gocloak.NewClient(cfg.Keycloak.URL)
-		result, err := client.RetrospectToken(c.Request.Context(), token, cfg.Keycloak.ClientID, cfg.Keycloak.ClientSecret, cfg.Keycloak.Realm)
+		user, err := keycloak.ValidateToken(token)
 		if err != nil {
 			logger.WithError(err).Error("Failed to validate token")
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error:   "unauthorized",
-				Message: "Invalid token",
-				Code:    http.StatusUnauthorized,
-			})
+			switch {
+			case errors.Is(err, services.ErrIdPUnavailable):
+				// Fail fast while Keycloak is down instead of rejecting valid tokens
+				if seconds, ok := resilience.RetryAfterSeconds(err); ok {
+					c.Header("Retry-After", strconv.Itoa(seconds))
+				}
+				problem.Write(c, models.ErrorResponse{
+					Error:   "idp_unavailable",
+					Message: "Identity provider is unavailable. Please try again later.",
+					Code:    http.StatusServiceUnavailable,
+				})
+			case errors.Is(err, services.ErrTokenExpired):
+				problem.Write(c, models.ErrorResponse{
+					Error:   "unauthorized",
+					Message: "Token is not active",
+					Code:    http.StatusUnauthorized,
+				})
+			default:
+				problem.Write(c, models.ErrorResponse{
+					Error:   "unauthorized",
+					Message: "Invalid token",
+					Code:    http.StatusUnauthorized,
+				})
+			}
 			c.Abort()
 			return
 		}
 
-		// Check if token is active
-		if !*result.Active {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error:   "unauthorized",
-				Message: "Token is not active",
-				Code:    http.StatusUnauthorized,
-			})
-			c.Abort()
-			return
-		}
+		setUser(c, user, token)
+		c.Next()
+	}
+}
 
-		// Get user info and add to context
-		userInfo, err := client.GetUserInfo(c.Request.Context(), token, cfg.Keycloak.Realm)
-		if err != nil {
-			logger.WithError(err).Error("Failed to get user info")
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error:   "unauthorized",
-				Message: "Failed to get user info",
-				Code:    http.StatusUnauthorized,
-			})
-			c.Abort()
-			return
+// OptionalAuthMiddleware identifies the caller on public routes that tailor
+// their response to the user, such as the client config. Requests without a
+// valid bearer token continue anonymously.
+func OptionalAuthMiddleware(logger *logger.Logger, keycloak TokenValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenParts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(tokenParts) == 2 && tokenParts[0] == "Bearer" {
+			user, err := keycloak.ValidateToken(tokenParts[1])
+			if err == nil {
+				setUser(c, user, tokenParts[1])
+			} else {
+				logger.WithError(err).Debug("Ignoring invalid token on public route")
+			}
 		}
-
-		// Add user info to context
-		c.Set("user_id", *userInfo.Sub)
-		c.Set("username", *userInfo.PreferredUsername)
-		c.Set("email", *userInfo.Email)
-		c.Set("access_token", token)
-
 		c.Next()
 	}
 }
 
+// RequireRole lets through only users holding role. It must run after
+// AuthMiddleware, which sets the roles from the access token.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roles, _ := c.Get("roles")
+		if list, ok := roles.([]string); ok {
+			for _, granted := range list {
+				if granted == role {
+					c.Next()
+					return
+				}
+			}
+		}
+		problem.Write(c, models.ErrorResponse{
+			Error:   "forbidden",
+			Message: "You do not have permission to perform this action",
+			Code:    http.StatusForbidden,
+		})
+		c.Abort()
+	}
+}
+
+// setUser adds the authenticated user to the gin context, and to the request
+// context as the feature flag subject
+func setUser(c *gin.Context, user *models.User, token string) {
+	c.Set("user_id", user.ID)
+	c.Set("username", user.Username)
+	c.Set("email", user.Email)
+	c.Set("roles", user.Roles)
+	c.Set("access_token", token)
+
+	ctx := flags.WithSubject(c.Request.Context(), flags.Subject{UserID: user.ID, Roles: user.Roles})
+	c.Request = c.Request.WithContext(ctx)
+}
+
 // AuthRateLimitMiddleware for auth endpoints (login, register)
 func AuthRateLimitMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if !authRateLimiter.allow(c.ClientIP()) {
-			c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+			problem.Write(c, models.ErrorResponse{
 				Error:   "auth_rate_limit_exceeded",
 				Message: "Too many authentication attempts. Please try again in a minute.",
 				Code:    http.StatusTooManyRequests,
@@ -179,51 +231,13 @@ func LoggerMiddleware(logger *logger.Logger) gin.HandlerFunc {
 	})
 }
 
-// CORSMiddleware handles CORS - FIXED for MVP security
-func CORSMiddleware(cfg *config.Config) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Get allowed origins from config, default to localhost for MVP
-		allowedOrigins := []string{
-			"http://localhost:3000",
-			"http://localhost:3080",
-			"http://localhost:3090",
-			"http://localhost:8080",
-			"https://yourdomain.com", // Add your actual domain here
-		}
-
-		origin := c.Request.Header.Get("Origin")
-		allowed := false
-		for _, allowedOrigin := range allowedOrigins {
-			if origin == allowedOrigin {
-				allowed = true
-				break
-			}
-		}
-
-		if allowed {
-			c.Header("Access-Control-Allow-Origin", origin)
-		}
-		
-		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Header("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-
-		c.Next()
-	}
-}
-
 // InputValidationMiddleware for basic security
 func InputValidationMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Basic XSS protection
 		if strings.Contains(c.Request.URL.Path, "<script>") ||
 			strings.Contains(c.Request.URL.Path, "javascript:") {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			problem.Write(c, models.ErrorResponse{
 				Error:   "invalid_input",
 				Message: "Invalid characters in request",
 				Code:    http.StatusBadRequest,
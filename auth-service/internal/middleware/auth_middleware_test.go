@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		roles          interface{}
+		expectedStatus int
+	}{
+		{name: "role granted", roles: []string{"user", "admin"}, expectedStatus: http.StatusOK},
+		{name: "role missing", roles: []string{"user"}, expectedStatus: http.StatusForbidden},
+		{name: "no roles", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.roles != nil {
+					c.Set("roles", tt.roles)
+				}
+			})
+			router.Use(RequireRole("admin"))
+			router.GET("/admin", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/admin", nil))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"auth-service/internal/config"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// corsPolicy is a compiled config.CORSPolicy ready for request matching
+type corsPolicy struct {
+	anyOrigin        bool
+	origins          map[string]bool
+	patterns         []originPattern
+	methods          map[string]bool
+	headers          map[string]bool
+	allowMethods     string
+	allowHeaders     string
+	exposeHeaders    string
+	maxAge           string
+	allowCredentials bool
+}
+
+// originPattern matches wildcard subdomain origins such as "https://*.example.com"
+type originPattern struct {
+	prefix string // scheme, e.g. "https://"
+	suffix string // host suffix including the leading dot, e.g. ".example.com"
+}
+
+func (p originPattern) match(origin string) bool {
+	if !strings.HasPrefix(origin, p.prefix) || !strings.HasSuffix(origin, p.suffix) {
+		return false
+	}
+	sub := origin[len(p.prefix) : len(origin)-len(p.suffix)]
+	return sub != "" && !strings.ContainsAny(sub, "/:@")
+}
+
+func newCORSPolicy(policy config.CORSPolicy) *corsPolicy {
+	compiled := &corsPolicy{
+		origins:          make(map[string]bool),
+		methods:          make(map[string]bool),
+		headers:          make(map[string]bool),
+		allowMethods:     strings.Join(policy.AllowedMethods, ", "),
+		allowHeaders:     strings.Join(policy.AllowedHeaders, ", "),
+		exposeHeaders:    strings.Join(policy.ExposedHeaders, ", "),
+		allowCredentials: policy.AllowCredentials,
+	}
+	if policy.MaxAge > 0 {
+		compiled.maxAge = strconv.Itoa(policy.MaxAge)
+	}
+
+	for _, origin := range policy.AllowedOrigins {
+		origin = strings.TrimRight(strings.ToLower(origin), "/")
+		switch {
+		case origin == "*":
+			compiled.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			i := strings.Index(origin, "*.")
+			compiled.patterns = append(compiled.patterns, originPattern{
+				prefix: origin[:i],
+				suffix: origin[i+1:],
+			})
+		default:
+			compiled.origins[origin] = true
+		}
+	}
+	for _, method := range policy.AllowedMethods {
+		compiled.methods[strings.ToUpper(method)] = true
+	}
+	for _, header := range policy.AllowedHeaders {
+		compiled.headers[strings.ToLower(header)] = true
+	}
+	// Any origin is only ever allowed without credentials; config rejects
+	// the combination
+	if compiled.anyOrigin {
+		compiled.allowCredentials = false
+	}
+
+	return compiled
+}
+
+func (p *corsPolicy) originAllowed(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if p.origins[origin] {
+		return true
+	}
+	for _, pattern := range p.patterns {
+		if pattern.match(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *corsPolicy) headersAllowed(requested string) bool {
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.ToLower(strings.TrimSpace(header))
+		if header != "" && !p.headers[header] {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesRoute reports whether path is route or below it, so "/api/v1/user"
+// matches "/api/v1/user/profile" but not "/api/v1/users"
+func matchesRoute(path, route string) bool {
+	route = strings.TrimSuffix(route, "/")
+	return path == route || strings.HasPrefix(path, route+"/")
+}
+
+// CORSMiddleware applies the configured CORS policies. The policy is chosen by
+// the longest route prefix in cfg.CORS.Routes that matches the request path,
+// falling back to cfg.CORS.Default. Preflight requests from disallowed origins,
+// or asking for disallowed methods or headers, are rejected with 403. OPTIONS
+// requests without an Origin are not CORS requests, and get 204.
+func CORSMiddleware(cfg *config.Config) gin.HandlerFunc {
+	defaultPolicy := newCORSPolicy(cfg.CORS.Default)
+
+	prefixes := make([]string, 0, len(cfg.CORS.Routes))
+	routePolicies := make(map[string]*corsPolicy, len(cfg.CORS.Routes))
+	for prefix, policy := range cfg.CORS.Routes {
+		prefixes = append(prefixes, prefix)
+		routePolicies[prefix] = newCORSPolicy(policy)
+	}
+	// Longest prefix first so the most specific route wins
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+
+	return func(c *gin.Context) {
+		policy := defaultPolicy
+		for _, prefix := range prefixes {
+			if matchesRoute(c.Request.URL.Path, prefix) {
+				policy = routePolicies[prefix]
+				break
+			}
+		}
+
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			// Not a cross-origin request; OPTIONS is answered without CORS headers
+			if c.Request.Method == http.MethodOptions {
+				c.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		// The response depends on Origin whether or not it is allowed
+		c.Writer.Header().Add("Vary", "Origin")
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if preflight {
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if !policy.originAllowed(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			// Let the request through without CORS headers; the browser blocks the response
+			c.Next()
+			return
+		}
+
+		if preflight {
+			method := strings.ToUpper(c.GetHeader("Access-Control-Request-Method"))
+			if !policy.methods[method] || !policy.headersAllowed(c.GetHeader("Access-Control-Request-Headers")) {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+		}
+
+		if policy.anyOrigin {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if policy.allowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if policy.exposeHeaders != "" {
+				c.Header("Access-Control-Expose-Headers", policy.exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Methods", policy.allowMethods)
+		if policy.allowHeaders != "" {
+			c.Header("Access-Control-Allow-Headers", policy.allowHeaders)
+		}
+		if policy.maxAge != "" {
+			c.Header("Access-Control-Max-Age", policy.maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
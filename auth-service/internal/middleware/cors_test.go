@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"auth-service/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCORSTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		CORS: config.CORSConfig{
+			Default: config.CORSPolicy{
+				AllowedOrigins:   []string{"http://localhost:3080", "https://*.shopmindai.com"},
+				AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
+				AllowedHeaders:   []string{"Content-Type", "Authorization"},
+				ExposedHeaders:   []string{"X-Request-ID"},
+				MaxAge:           600,
+				AllowCredentials: true,
+			},
+			Routes: map[string]config.CORSPolicy{
+				"/api/config": {
+					AllowedOrigins: []string{"*"},
+					AllowedMethods: []string{"GET"},
+				},
+			},
+		},
+	}
+
+	router := gin.New()
+	router.Use(CORSMiddleware(cfg))
+	router.GET("/api/config", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/configs", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/api/v1/auth/login", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func TestCORSMiddleware_Preflight(t *testing.T) {
+	router := newCORSTestRouter()
+
+	tests := []struct {
+		name           string
+		origin         string
+		method         string
+		headers        string
+		expectedStatus int
+		expectedOrigin string
+	}{
+		{
+			name:           "exact origin",
+			origin:         "http://localhost:3080",
+			method:         "POST",
+			headers:        "content-type, authorization",
+			expectedStatus: http.StatusNoContent,
+			expectedOrigin: "http://localhost:3080",
+		},
+		{
+			name:           "wildcard subdomain",
+			origin:         "https://app.shopmindai.com",
+			method:         "POST",
+			expectedStatus: http.StatusNoContent,
+			expectedOrigin: "https://app.shopmindai.com",
+		},
+		{
+			name:           "wildcard does not match apex",
+			origin:         "https://shopmindai.com",
+			method:         "POST",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "wildcard does not match lookalike host",
+			origin:         "https://evil-shopmindai.com",
+			method:         "POST",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "disallowed origin",
+			origin:         "https://evil.example.com",
+			method:         "POST",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "disallowed method",
+			origin:         "http://localhost:3080",
+			method:         "DELETE",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "disallowed header",
+			origin:         "http://localhost:3080",
+			method:         "POST",
+			headers:        "X-Custom",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodOptions, "/api/v1/auth/login", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			if tt.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Header().Values("Vary"), "Origin")
+			assert.Equal(t, tt.expectedOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			if tt.expectedStatus == http.StatusNoContent {
+				assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+				assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+				assert.Equal(t, "GET, POST, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+			} else {
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+			}
+		})
+	}
+}
+
+func TestCORSMiddleware_SimpleRequest(t *testing.T) {
+	router := newCORSTestRouter()
+
+	// Allowed origin gets CORS headers and exposed headers
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+	req.Header.Set("Origin", "http://localhost:3080")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "http://localhost:3080", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "X-Request-ID", w.Header().Get("Access-Control-Expose-Headers"))
+
+	// Disallowed origin is served without any CORS headers
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Contains(t, w.Header().Values("Vary"), "Origin")
+}
+
+func TestCORSMiddleware_RoutePolicy(t *testing.T) {
+	router := newCORSTestRouter()
+
+	// Public config allows any origin without credentials
+	req, _ := http.NewRequest(http.MethodGet, "/api/config", nil)
+	req.Header.Set("Origin", "https://anywhere.example.org")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+
+	// but only for the methods of its own policy
+	req, _ = http.NewRequest(http.MethodOptions, "/api/config", nil)
+	req.Header.Set("Origin", "https://anywhere.example.org")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	// Routes match whole path segments
+	req, _ = http.NewRequest(http.MethodGet, "/api/configs", nil)
+	req.Header.Set("Origin", "https://anywhere.example.org")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), "the default policy applies")
+}
+
+func TestCORSMiddleware_OptionsWithoutOrigin(t *testing.T) {
+	router := newCORSTestRouter()
+
+	req, _ := http.NewRequest(http.MethodOptions, "/api/v1/auth/login", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSMiddleware_AnyOriginNeverWithCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{CORS: config.CORSConfig{Default: config.CORSPolicy{
+		AllowedOrigins:   []string{"*"},
+		AllowedMethods:   []string{"GET"},
+		AllowCredentials: true,
+	}}}
+	router := gin.New()
+	router.Use(CORSMiddleware(cfg))
+	router.GET("/api/v1/user/profile", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/user/profile", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"), "the origin is not reflected")
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
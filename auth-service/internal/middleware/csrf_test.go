@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"auth-service/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{Session: config.SessionConfig{
+		CookieMode:     true,
+		CSRFCookieName: "csrf_token",
+		CSRFHeaderName: "X-CSRF-Token",
+	}}
+
+	router := gin.New()
+	router.Use(CSRFMiddleware(cfg))
+	router.GET("/api/v1/auth/csrf", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/api/v1/auth/refresh", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		cookie         string
+		header         string
+		expectedStatus int
+	}{
+		{name: "safe method", method: "GET", path: "/api/v1/auth/csrf", expectedStatus: http.StatusOK},
+		{name: "matching token", method: "POST", path: "/api/v1/auth/refresh", cookie: "abc", header: "abc", expectedStatus: http.StatusOK},
+		{name: "missing header", method: "POST", path: "/api/v1/auth/refresh", cookie: "abc", expectedStatus: http.StatusForbidden},
+		{name: "missing cookie", method: "POST", path: "/api/v1/auth/refresh", header: "abc", expectedStatus: http.StatusForbidden},
+		{name: "mismatched token", method: "POST", path: "/api/v1/auth/refresh", cookie: "abc", header: "xyz", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "csrf_token", Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set("X-CSRF-Token", tt.header)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	// Bearer-only mode does not require CSRF tokens
+	cfg.Session.CookieMode = false
+	req, _ := http.NewRequest("POST", "/api/v1/auth/refresh", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
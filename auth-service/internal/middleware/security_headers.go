@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"auth-service/internal/config"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityHeadersMiddleware sets the configured security headers on every response.
+// Routes can adjust them afterwards with SecurityHeadersOverride or NoStore.
+func SecurityHeadersMiddleware(cfg *config.Config) gin.HandlerFunc {
+	headers := securityHeaders(cfg.Security)
+
+	return func(c *gin.Context) {
+		for name, value := range headers {
+			c.Header(name, value)
+		}
+		c.Next()
+	}
+}
+
+// securityHeaders builds the header set for a security configuration
+func securityHeaders(sec config.SecurityConfig) map[string]string {
+	headers := make(map[string]string)
+	if !sec.HeadersEnabled {
+		return headers
+	}
+
+	if sec.HSTSMaxAge > 0 {
+		hsts := fmt.Sprintf("max-age=%d", sec.HSTSMaxAge)
+		if sec.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if sec.HSTSPreload {
+			hsts += "; preload"
+		}
+		headers["Strict-Transport-Security"] = hsts
+	}
+	if sec.ContentTypeOptions != "" {
+		headers["X-Content-Type-Options"] = sec.ContentTypeOptions
+	}
+	if sec.FrameOptions != "" {
+		headers["X-Frame-Options"] = sec.FrameOptions
+	}
+	if sec.ReferrerPolicy != "" {
+		headers["Referrer-Policy"] = sec.ReferrerPolicy
+	}
+
+	if sec.CSP != "" {
+		csp := strings.TrimRight(strings.TrimSpace(sec.CSP), ";")
+		if sec.CSPReportURI != "" && !strings.Contains(csp, "report-uri") {
+			csp += "; report-uri " + sec.CSPReportURI
+		}
+		if sec.CSPReportOnly {
+			headers["Content-Security-Policy-Report-Only"] = csp
+		} else {
+			headers["Content-Security-Policy"] = csp
+		}
+	}
+
+	return headers
+}
+
+// SecurityHeadersOverride replaces security headers for a route or group.
+// An empty value removes the header.
+func SecurityHeadersOverride(overrides map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for name, value := range overrides {
+			if value == "" {
+				c.Writer.Header().Del(name)
+				continue
+			}
+			c.Header(name, value)
+		}
+		c.Next()
+	}
+}
+
+// NoStore marks responses as non-cacheable, for anything carrying tokens
+func NoStore() gin.HandlerFunc {
+	return SecurityHeadersOverride(map[string]string{
+		"Cache-Control": "no-store",
+		"Pragma":        "no-cache",
+	})
+}
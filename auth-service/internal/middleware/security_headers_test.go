@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"auth-service/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{Security: config.SecurityConfig{
+		HeadersEnabled:        true,
+		HSTSMaxAge:            31536000,
+		HSTSIncludeSubdomains: true,
+		ContentTypeOptions:    "nosniff",
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        "no-referrer",
+		CSP:                   "default-src 'none';",
+		CSPReportURI:          "/api/csp-report",
+	}}
+
+	router := gin.New()
+	router.Use(SecurityHeadersMiddleware(cfg))
+	router.GET("/api/config", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/embed", SecurityHeadersOverride(map[string]string{
+		"X-Frame-Options":         "",
+		"Content-Security-Policy": "frame-ancestors https://shop.example.com",
+	}), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/token", NoStore(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req, _ := http.NewRequest("GET", "/api/config", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "max-age=31536000; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+	assert.Equal(t, "default-src 'none'; report-uri /api/csp-report", w.Header().Get("Content-Security-Policy"))
+	assert.Empty(t, w.Header().Get("Content-Security-Policy-Report-Only"))
+
+	// Per-route overrides
+	req, _ = http.NewRequest("GET", "/embed", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Empty(t, w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "frame-ancestors https://shop.example.com", w.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+
+	req, _ = http.NewRequest("POST", "/token", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+}
+
+func TestSecurityHeadersReportOnly(t *testing.T) {
+	headers := securityHeaders(config.SecurityConfig{
+		HeadersEnabled: true,
+		CSP:            "default-src 'self'",
+		CSPReportOnly:  true,
+		CSPReportURI:   "/api/csp-report",
+	})
+
+	assert.Equal(t, "default-src 'self'; report-uri /api/csp-report", headers["Content-Security-Policy-Report-Only"])
+	assert.NotContains(t, headers, "Content-Security-Policy")
+	assert.NotContains(t, headers, "Strict-Transport-Security")
+
+	assert.Empty(t, securityHeaders(config.SecurityConfig{HeadersEnabled: false, CSP: "default-src 'self'"}))
+}
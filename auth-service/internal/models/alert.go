@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+)
+
+// Watch is a product on a user's watchlist and the rule saying when a
+// cheaper price is worth an alert. Prices are the product's best total cost
+// (price plus shipping, in stock) in the catalog currency.
+type Watch struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	ProductID string `json:"product_id"`
+	// VariantID watches one variant; empty watches the whole product
+	VariantID   string `json:"variant_id,omitempty"`
+	ProductName string `json:"product_name"`
+	// TargetPrice alerts once the price is at or below it, in minor units
+	TargetPrice *int64 `json:"target_price,omitempty"`
+	// PercentDrop alerts once the price is this many percent below the
+	// reference price
+	PercentDrop *float64 `json:"percent_drop,omitempty"`
+	// Email is where email alerts go, taken from the user's token
+	Email     string     `json:"email,omitempty"`
+	State     WatchState `json:"state"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// WatchState is what price evaluations remember about a watch
+type WatchState struct {
+	// Price is the best price last seen; nil while no offer is in stock
+	Price *Money `json:"price,omitempty"`
+	// Reference is the highest price since the last alert, which percent
+	// drops are measured from
+	Reference *Money `json:"reference,omitempty"`
+	// Alerted is the price of the last alert while the drop lasts; a target
+	// price alerts again only after the price went back above the target
+	Alerted *Money `json:"alerted,omitempty"`
+	// Alerts counts the alerts sent for the watch
+	Alerts      int        `json:"alerts"`
+	LastAlertAt *time.Time `json:"last_alert_at,omitempty"`
+}
+
+// SaveWatchRequest adds a product to the watchlist or changes the rule of a
+// watch. Exactly one of TargetPrice and PercentDrop is set.
+type SaveWatchRequest struct {
+	ProductID   string   `json:"product_id"`
+	VariantID   string   `json:"variant_id"`
+	TargetPrice *int64   `json:"target_price"`
+	PercentDrop *float64 `json:"percent_drop"`
+}
+
+// Notification is a message for a user, such as a price-drop alert
+type Notification struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	// Type is "price_drop"
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	ProductID string `json:"product_id,omitempty"`
+	WatchID   string `json:"watch_id,omitempty"`
+	Price     *Money `json:"price,omitempty"`
+	// PreviousPrice is the price the drop is measured from
+	PreviousPrice *Money `json:"previous_price,omitempty"`
+	// URL links to the best offer
+	URL string `json:"url,omitempty"`
+	// Email is the recipient of email notifications
+	Email string `json:"-"`
+	// DedupKey is the same for every delivery of one alert, so notifiers can
+	// drop repeats
+	DedupKey  string     `json:"dedup_key"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NotificationsResponse lists a user's notifications, newest first
+type NotificationsResponse struct {
+	Notifications []Notification `json:"notifications"`
+	Unread        int            `json:"unread"`
+}
+
+// MarkReadRequest marks notifications as read; no IDs marks them all
+type MarkReadRequest struct {
+	IDs []string `json:"ids"`
+}
@@ -2,8 +2,6 @@ package models
 
 import (
 	"time"
-	"unicode"
-	"strings"
 )
 
 // User represents a user in the system
@@ -14,160 +12,92 @@ type User struct {
 	FirstName string    `json:"first_name"`
 	LastName  string    `json:"last_name"`
 	Enabled   bool      `json:"enabled"`
+	Roles     []string  `json:"roles,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // LoginRequest represents a login request
 type LoginRequest struct {
-	Username string `json:"username" binding:"required,min=3,max=50"`
-	Password string `json:"password" binding:"required,min=8"`
+	Username string `json:"username"`
+	Password string `json:"password"`
 }
 
-// RegisterRequest represents a registration request - IMPROVED VALIDATION
+// RegisterRequest represents a registration request (rules in validation.Register)
 type RegisterRequest struct {
-	Username  string `json:"username" binding:"required,min=3,max=30"`
-	Email     string `json:"email" binding:"required,email"`
-	Password  string `json:"password" binding:"required,min=8,max=128"`
-	FirstName string `json:"first_name" binding:"required,min=2,max=50"`
-	LastName  string `json:"last_name" binding:"required,min=2,max=50"`
-}
-
-// Validate performs additional validation for RegisterRequest
-func (r *RegisterRequest) Validate() []string {
-	var errors []string
-
-	// Username validation
-	if !isValidUsername(r.Username) {
-		errors = append(errors, "username must contain only letters, numbers, and underscores")
-	}
-
-	// Password validation
-	if !isValidPassword(r.Password) {
-		errors = append(errors, "password must contain at least one uppercase letter, one lowercase letter, one number, and one special character")
-	}
-
-	// Name validation (no numbers or special chars)
-	if !isValidName(r.FirstName) {
-		errors = append(errors, "first name must contain only letters and spaces")
-	}
-	if !isValidName(r.LastName) {
-		errors = append(errors, "last name must contain only letters and spaces")
-	}
-
-	return errors
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
 }
 
 // RefreshRequest represents a token refresh request
 type RefreshRequest struct {
-	RefreshToken string `json:"refresh_token" binding:"required"`
+	RefreshToken string `json:"refresh_token"`
 }
 
-// AuthResponse represents an authentication response
+// AuthResponse represents an authentication response.
+// In cookie session mode RefreshToken is empty (the token travels in an
+// HttpOnly cookie) and CSRFToken carries the double-submit token instead.
 type AuthResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
-	TokenType    string `json:"token_type"`
-	ExpiresIn    int    `json:"expires_in"`
-	User         *User  `json:"user"`
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token,omitempty"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshExpiresIn int    `json:"refresh_expires_in,omitempty"`
+	CSRFToken        string `json:"csrf_token,omitempty"`
+	User             *User  `json:"user"`
 }
 
-// ChangePasswordRequest represents a password change request - IMPROVED
+// ChangePasswordRequest represents a password change request (rules in validation.ChangePassword)
 type ChangePasswordRequest struct {
-	CurrentPassword string `json:"current_password" binding:"required"`
-	NewPassword     string `json:"new_password" binding:"required,min=8,max=128"`
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
 }
 
-// Validate performs additional validation for ChangePasswordRequest
-func (c *ChangePasswordRequest) Validate() []string {
-	var errors []string
-	
-	if c.CurrentPassword == c.NewPassword {
-		errors = append(errors, "new password must be different from current password")
-	}
-	
-	if !isValidPassword(c.NewPassword) {
-		errors = append(errors, "password must contain at least one uppercase letter, one lowercase letter, one number, and one special character")
-	}
-	
-	return errors
+// ForgotPasswordRequest represents a password reset request
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
 }
 
-// UpdateProfileRequest represents a profile update request - IMPROVED
+// UpdateProfileRequest represents a profile update request (rules in validation.UpdateProfile)
 type UpdateProfileRequest struct {
-	FirstName string `json:"first_name" binding:"omitempty,min=2,max=50"`
-	LastName  string `json:"last_name" binding:"omitempty,min=2,max=50"`
-	Email     string `json:"email" binding:"omitempty,email"`
-}
-
-// Validate performs additional validation for UpdateProfileRequest
-func (u *UpdateProfileRequest) Validate() []string {
-	var errors []string
-	
-	if u.FirstName != "" && !isValidName(u.FirstName) {
-		errors = append(errors, "first name must contain only letters and spaces")
-	}
-	if u.LastName != "" && !isValidName(u.LastName) {
-		errors = append(errors, "last name must contain only letters and spaces")
-	}
-	
-	return errors
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
 }
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
-	Error   string      `json:"error"`
-	Message string      `json:"message"`
-	Code    int         `json:"code"`
-	Details interface{} `json:"details,omitempty"`
+	Error   string       `json:"error"`
+	Message string       `json:"message"`
+	Code    int          `json:"code"`
+	Details []FieldError `json:"details,omitempty"`
 }
 
-// SuccessResponse represents a success response
-type SuccessResponse struct {
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+// ProblemDetails is the RFC 7807 form of ErrorResponse, served as
+// application/problem+json when the client asks for it
+type ProblemDetails struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Error    string       `json:"error"`
+	Errors   []FieldError `json:"errors,omitempty"`
 }
 
-// Helper validation functions
-func isValidUsername(username string) bool {
-	for _, char := range username {
-		if !unicode.IsLetter(char) && !unicode.IsDigit(char) && char != '_' {
-			return false
-		}
-	}
-	return true
+// FieldError describes why a single request field was rejected
+type FieldError struct {
+	Field   string                 `json:"field"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Params  map[string]interface{} `json:"params,omitempty"`
 }
 
-func isValidPassword(password string) bool {
-	var (
-		hasUpper   = false
-		hasLower   = false
-		hasNumber  = false
-		hasSpecial = false
-	)
-
-	for _, char := range password {
-		switch {
-		case unicode.IsUpper(char):
-			hasUpper = true
-		case unicode.IsLower(char):
-			hasLower = true
-		case unicode.IsDigit(char):
-			hasNumber = true
-		case unicode.IsPunct(char) || unicode.IsSymbol(char):
-			hasSpecial = true
-		}
-	}
-
-	return hasUpper && hasLower && hasNumber && hasSpecial
-}
-
-func isValidName(name string) bool {
-	name = strings.TrimSpace(name)
-	for _, char := range name {
-		if !unicode.IsLetter(char) && char != ' ' && char != '\'' && char != '-' {
-			return false
-		}
-	}
-	return true
+// SuccessResponse represents a success response
+type SuccessResponse struct {
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
 }
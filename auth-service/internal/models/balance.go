@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// LedgerEntry is one immutable change to a user's credits
+type LedgerEntry struct {
+	ID string `json:"id"`
+	// Sequence numbers a user's entries from 1; it is the ledger version
+	// after the entry was written
+	Sequence int64 `json:"sequence"`
+	// Type is credit, debit or refund
+	Type string `json:"type"`
+	// Amount is the number of credits moved, always positive
+	Amount int64 `json:"amount"`
+	// Balance is the running balance after the entry
+	Balance int64 `json:"balance"`
+	// Reason says why credits moved, e.g. "grant" or "reservation"
+	Reason string `json:"reason"`
+	// Reference ties entries together, such as a reservation and its settlement
+	Reference string `json:"reference,omitempty"`
+	// Actor is the admin who granted credits
+	Actor     string    `json:"actor,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BalanceResponse is the client's TBalanceResponse
+type BalanceResponse struct {
+	TokenCredits      int64 `json:"tokenCredits"`
+	AutoRefillEnabled bool  `json:"autoRefillEnabled"`
+}
+
+// GrantCreditsRequest adds credits to a user's balance
+type GrantCreditsRequest struct {
+	Amount *int64 `json:"amount"`
+	Note   string `json:"note"`
+}
+
+// AccountBalance is a user's balance and ledger, as shown to admins
+type AccountBalance struct {
+	UserID       string        `json:"userId"`
+	TokenCredits int64         `json:"tokenCredits"`
+	Entries      []LedgerEntry `json:"entries"`
+}
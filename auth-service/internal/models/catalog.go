@@ -0,0 +1,188 @@
+package models
+
+import (
+	"time"
+)
+
+// Money is an amount in the minor unit of its currency (cents for EUR)
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// Product is a catalog product. Its variants carry the retailers' offers.
+type Product struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Brand       string           `json:"brand,omitempty"`
+	Description string           `json:"description,omitempty"`
+	CategoryID  string           `json:"category_id,omitempty"`
+	ImageURL    string           `json:"image_url,omitempty"`
+	Variants    []ProductVariant `json:"variants"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// ProductVariant is one sellable version of a product, such as a size or color
+type ProductVariant struct {
+	ID         string            `json:"id"`
+	GTIN       string            `json:"gtin,omitempty"`
+	Name       string            `json:"name,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Offers     []Offer           `json:"offers"`
+}
+
+// Offer is a retailer's price for a variant
+type Offer struct {
+	RetailerID string `json:"retailer_id"`
+	// SKU is the retailer's identifier for the item
+	SKU string `json:"sku"`
+	// GroupID is the retailer's identifier for the product the item belongs to
+	GroupID string `json:"group_id,omitempty"`
+	Price   Money  `json:"price"`
+	// Shipping is nil when the feed does not say
+	Shipping  *Money    `json:"shipping,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	InStock   bool      `json:"in_stock"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RankedOffer is an offer compared by what it costs in the catalog currency
+type RankedOffer struct {
+	Retailer    Retailer `json:"retailer"`
+	VariantID   string   `json:"variant_id"`
+	VariantName string   `json:"variant_name,omitempty"`
+	SKU         string   `json:"sku"`
+	Price       Money    `json:"price"`
+	Shipping    *Money   `json:"shipping"`
+	// Total is the price plus shipping, converted to the catalog currency.
+	// It is nil when there is no exchange rate for the offer's currency.
+	Total     *Money    `json:"total"`
+	InStock   bool      `json:"in_stock"`
+	URL       string    `json:"url,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProductOffers lists a product's offers, cheapest first
+type ProductOffers struct {
+	ProductID string `json:"product_id"`
+	// Best is the cheapest offer in stock, if any
+	Best   *RankedOffer  `json:"best"`
+	Offers []RankedOffer `json:"offers"`
+}
+
+// PriceBucket summarizes the prices seen on one day (UTC), in minor units
+// of the catalog currency
+type PriceBucket struct {
+	Date  string `json:"date"`
+	Min   int64  `json:"min"`
+	Max   int64  `json:"max"`
+	Avg   int64  `json:"avg"`
+	Count int    `json:"count"`
+}
+
+// PriceHistoryResponse is a product's daily prices, oldest first. Days
+// without imports have no bucket.
+type PriceHistoryResponse struct {
+	ProductID string        `json:"product_id"`
+	Currency  string        `json:"currency"`
+	Buckets   []PriceBucket `json:"buckets"`
+}
+
+// Retailer sells products through a feed
+type Retailer struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// Category is a node of the category tree. IDs are slash-separated paths,
+// such as "electronics/audio".
+type Category struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	ParentID string `json:"parent_id,omitempty"`
+}
+
+// ProductDetail is a product with its category path and the retailers of its offers
+type ProductDetail struct {
+	Product
+	CategoryPath []Category `json:"category_path"`
+	Retailers    []Retailer `json:"retailers"`
+}
+
+// ProductSummary is a product in search results
+type ProductSummary struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Brand      string `json:"brand,omitempty"`
+	CategoryID string `json:"category_id,omitempty"`
+	ImageURL   string `json:"image_url,omitempty"`
+	// MinPrice and MaxPrice span the offers; nil when there are none
+	MinPrice   *Money `json:"min_price"`
+	MaxPrice   *Money `json:"max_price"`
+	OfferCount int    `json:"offer_count"`
+	InStock    bool   `json:"in_stock"`
+}
+
+// ProductSearchResponse is a page of search results with their facets
+type ProductSearchResponse struct {
+	Products []ProductSummary `json:"products"`
+	Total    int              `json:"total"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+	Facets   ProductFacets    `json:"facets"`
+	// Mode is how the products were matched: "keyword" or "hybrid"
+	Mode string `json:"mode"`
+}
+
+// ProductFacets counts the results by category, brand and price range. Each
+// facet ignores its own filter, so the counts show what choosing another
+// value would return.
+type ProductFacets struct {
+	Categories  []FacetValue      `json:"categories"`
+	Brands      []FacetValue      `json:"brands"`
+	PriceRanges []PriceRangeFacet `json:"price_ranges"`
+}
+
+// FacetValue is one value of a facet
+type FacetValue struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// PriceRangeFacet counts the products from Min up to, but not including,
+// Max. The last range has no Max.
+type PriceRangeFacet struct {
+	Min   int64  `json:"min"`
+	Max   *int64 `json:"max,omitempty"`
+	Count int    `json:"count"`
+}
+
+// CatalogFeed describes an import feed
+type CatalogFeed struct {
+	ID       string   `json:"id"`
+	Retailer Retailer `json:"retailer"`
+	Format   string   `json:"format"`
+	Currency string   `json:"currency"`
+}
+
+// ImportResult reports a feed import. Offers are counted once per item.
+type ImportResult struct {
+	FeedID    string        `json:"feed_id"`
+	Items     int           `json:"items"`
+	Created   int           `json:"created"`
+	Updated   int           `json:"updated"`
+	Unchanged int           `json:"unchanged"`
+	Removed   int           `json:"removed"`
+	Skipped   int           `json:"skipped"`
+	Errors    []ImportError `json:"errors"`
+}
+
+// ImportError is an item that was skipped. Row is the CSV line, or the
+// position of the item in a JSON feed, counting from 1.
+type ImportError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
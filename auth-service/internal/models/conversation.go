@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+)
+
+// Conversation is a chat conversation, in the shape of LibreChat's TConversation
+type Conversation struct {
+	ConversationID string    `json:"conversationId"`
+	User           string    `json:"user"`
+	Title          string    `json:"title"`
+	Endpoint       string    `json:"endpoint,omitempty"`
+	EndpointType   string    `json:"endpointType,omitempty"`
+	Model          string    `json:"model,omitempty"`
+	IsArchived     bool      `json:"isArchived"`
+	Tags           []string  `json:"tags"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// ConversationListResponse is one page of conversations. NextCursor is null
+// on the last page.
+type ConversationListResponse struct {
+	Conversations []Conversation `json:"conversations"`
+	NextCursor    *string        `json:"nextCursor"`
+}
+
+// CreateConversationRequest creates a conversation (rules in validation.CreateConversation).
+// ConversationID is generated when empty.
+type CreateConversationRequest struct {
+	ConversationID string `json:"conversationId"`
+	Title          string `json:"title"`
+	Endpoint       string `json:"endpoint"`
+	EndpointType   string `json:"endpointType"`
+	Model          string `json:"model"`
+}
+
+// UpdateConversationRequest renames a conversation; sent as {"arg": {...}}
+type UpdateConversationRequest struct {
+	ConversationID string `json:"conversationId"`
+	Title          string `json:"title"`
+}
+
+// ArchiveConversationRequest archives or restores a conversation; sent as {"arg": {...}}
+type ArchiveConversationRequest struct {
+	ConversationID string `json:"conversationId"`
+	IsArchived     bool   `json:"isArchived"`
+}
+
+// DeleteConversationRequest deletes a conversation; sent as {"arg": {...}}
+type DeleteConversationRequest struct {
+	ConversationID string `json:"conversationId"`
+}
+
+// DeleteResponse mirrors the delete result LibreChat returns
+type DeleteResponse struct {
+	Acknowledged bool `json:"acknowledged"`
+	DeletedCount int  `json:"deletedCount"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+)
+
+// File is an uploaded file, in the shape of LibreChat's TFile
+type File struct {
+	FileID string `json:"file_id"`
+	// TempFileID is the ID the client gave the upload before it was stored
+	TempFileID     string `json:"temp_file_id,omitempty"`
+	User           string `json:"user"`
+	ConversationID string `json:"conversationId,omitempty"`
+	Filename       string `json:"filename"`
+	// Filepath is the owner-checked download URL
+	Filepath string `json:"filepath"`
+	// Type is the MIME type sniffed from the content
+	Type   string `json:"type"`
+	Bytes  int64  `json:"bytes"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	// Thumbnail is the download URL of the image thumbnail, if one was made
+	Thumbnail string    `json:"thumbnail,omitempty"`
+	Source    string    `json:"source"`
+	Object    string    `json:"object"`
+	Usage     int       `json:"usage"`
+	Embedded  bool      `json:"embedded"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// FileRef names a file in DeleteFilesRequest
+type FileRef struct {
+	FileID string `json:"file_id"`
+}
+
+// DeleteFilesRequest deletes files (LibreChat sends the full TFile of each)
+type DeleteFilesRequest struct {
+	Files []FileRef `json:"files"`
+}
+
+// DeleteFilesResponse lists the files that were deleted
+type DeleteFilesResponse struct {
+	Message string   `json:"message"`
+	Deleted []string `json:"deleted"`
+}
+
+// UploadFileRequest holds the form fields sent with an upload
+type UploadFileRequest struct {
+	// FileID is the client's temporary ID for the upload
+	FileID         string `json:"file_id"`
+	ConversationID string `json:"conversationId"`
+}
+
+// FileConfig describes the upload limits, in the shape of LibreChat's
+// fileConfig. Sizes are in bytes; MIME types are regular expressions.
+type FileConfig struct {
+	Endpoints           map[string]EndpointFileConfig `json:"endpoints"`
+	ServerFileSizeLimit int64                         `json:"serverFileSizeLimit"`
+}
+
+// EndpointFileConfig holds the upload limits of one endpoint
+type EndpointFileConfig struct {
+	FileSizeLimit      int64    `json:"fileSizeLimit"`
+	TotalSizeLimit     int64    `json:"totalSizeLimit"`
+	SupportedMimeTypes []string `json:"supportedMimeTypes"`
+}
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"time"
+)
+
+// ListRole is what a user may do with a shopping list
+type ListRole string
+
+// List roles, from most to least allowed
+const (
+	// ListOwner may also share the list and delete it
+	ListOwner ListRole = "owner"
+	// ListEditor may rename the list and change its items
+	ListEditor ListRole = "editor"
+	// ListViewer may read the list and its cart
+	ListViewer ListRole = "viewer"
+)
+
+// ShoppingList is a shopping list, owned by UserID and shared with Members
+type ShoppingList struct {
+	ID     string     `json:"id"`
+	UserID string     `json:"user_id"`
+	Name   string     `json:"name"`
+	Items  []ListItem `json:"items"`
+	// Members are the users the list is shared with, besides its owner
+	Members []ListMember `json:"members"`
+	// Invites are the invitations not yet accepted
+	Invites []ListInvite `json:"invites"`
+	// Version counts the changes to the list. Writes name the version they
+	// were based on, so edits from several devices never overwrite each other.
+	Version   int64     `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListItem is an entry of a shopping list: a catalog product, or free text
+// when ProductID is empty
+type ListItem struct {
+	ID        string `json:"id"`
+	ProductID string `json:"product_id,omitempty"`
+	VariantID string `json:"variant_id,omitempty"`
+	// Name is the free text, or the product name when the item was added
+	Name      string    `json:"name"`
+	Quantity  int       `json:"quantity"`
+	Note      string    `json:"note,omitempty"`
+	Checked   bool      `json:"checked"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListMember is a user a shopping list is shared with
+type ListMember struct {
+	UserID   string    `json:"user_id"`
+	Username string    `json:"username,omitempty"`
+	Email    string    `json:"email,omitempty"`
+	Role     ListRole  `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// ListInvite invites the user with Email or Username to a shopping list.
+// It is accepted with a signed token given to the owner when inviting.
+type ListInvite struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email,omitempty"`
+	Username  string    `json:"username,omitempty"`
+	Role      ListRole  `json:"role"`
+	InvitedBy string    `json:"invited_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ListSummary is a shopping list without its items
+type ListSummary struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Role         ListRole  `json:"role"`
+	MemberCount  int       `json:"member_count"`
+	ItemCount    int       `json:"item_count"`
+	CheckedCount int       `json:"checked_count"`
+	Version      int64     `json:"version"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SaveListRequest creates or renames a shopping list
+type SaveListRequest struct {
+	Name string `json:"name"`
+}
+
+// AddListItemRequest adds a product, or free text, to a shopping list
+type AddListItemRequest struct {
+	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id"`
+	Name      string `json:"name"`
+	// Quantity defaults to 1
+	Quantity *int   `json:"quantity"`
+	Note     string `json:"note"`
+}
+
+// UpdateListItemRequest replaces the editable fields of a list item
+type UpdateListItemRequest struct {
+	Name     string `json:"name"`
+	Quantity *int   `json:"quantity"`
+	Note     string `json:"note"`
+	Checked  bool   `json:"checked"`
+}
+
+// InviteListMemberRequest invites a user, by email or by username, to a
+// shopping list
+type InviteListMemberRequest struct {
+	Email    string   `json:"email"`
+	Username string   `json:"username"`
+	Role     ListRole `json:"role"`
+}
+
+// ListInviteResponse is a new invitation and the token accepting it, to be
+// passed on to the invited user
+type ListInviteResponse struct {
+	Invite ListInvite `json:"invite"`
+	Token  string     `json:"token"`
+}
+
+// AcceptListInviteRequest joins the shopping list an invitation is for
+type AcceptListInviteRequest struct {
+	Token string `json:"token"`
+}
+
+// UpdateListMemberRequest changes the role of a shopping list member
+type UpdateListMemberRequest struct {
+	Role ListRole `json:"role"`
+}
+
+// ListEvent is pushed to the members of a shopping list following it live.
+// Type is "list" with the list after every change, "deleted" when the owner
+// deleted it, or "removed" when the member lost access.
+type ListEvent struct {
+	Type    string        `json:"type"`
+	ListID  string        `json:"list_id"`
+	Version int64         `json:"version,omitempty"`
+	List    *ShoppingList `json:"list,omitempty"`
+}
+
+// Cart prices the unchecked product items of a shopping list at their best
+// offers, grouped by the retailer selling each one. Prices are in Currency.
+type Cart struct {
+	ListID    string         `json:"list_id"`
+	Currency  string         `json:"currency"`
+	Retailers []RetailerCart `json:"retailers"`
+	// Unavailable lists the items no retailer has in stock
+	Unavailable []string `json:"unavailable"`
+	Total       Money    `json:"total"`
+}
+
+// RetailerCart is the part of a cart bought from one retailer. Shipping is
+// the highest shipping cost of its offers, as an order ships once.
+type RetailerCart struct {
+	Retailer Retailer   `json:"retailer"`
+	Items    []CartItem `json:"items"`
+	Subtotal Money      `json:"subtotal"`
+	Shipping Money      `json:"shipping"`
+	Total    Money      `json:"total"`
+}
+
+// CartItem is a list item priced at its best offer
+type CartItem struct {
+	ItemID    string `json:"item_id"`
+	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice Money  `json:"unit_price"`
+	Price     Money  `json:"price"`
+	URL       string `json:"url,omitempty"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+)
+
+// Message is one chat message, in the shape of LibreChat's TMessage. Messages
+// form a tree per conversation: editing or regenerating starts a new branch
+// under the same ParentMessageID.
+type Message struct {
+	MessageID       string `json:"messageId"`
+	ConversationID  string `json:"conversationId"`
+	ParentMessageID string `json:"parentMessageId"`
+	User            string `json:"user"`
+	Sender          string `json:"sender"`
+	Text            string `json:"text"`
+	IsCreatedByUser bool   `json:"isCreatedByUser"`
+	Endpoint        string `json:"endpoint,omitempty"`
+	Model           string `json:"model,omitempty"`
+	// ToolCalls are the tools the assistant called before replying
+	ToolCalls []ToolCall `json:"toolCalls,omitempty"`
+	// Error marks a reply that failed; its Text describes the failure
+	Error bool `json:"error"`
+	// Unfinished marks a reply whose generation was aborted
+	Unfinished bool      `json:"unfinished"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// ToolCall is a tool the assistant called while generating a reply. Args and
+// Output are JSON; Error marks an Output describing a failure.
+type ToolCall struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Args   string `json:"args"`
+	Output string `json:"output,omitempty"`
+	Error  bool   `json:"error,omitempty"`
+}
+
+// SendMessageRequest asks for an assistant reply (rules in validation.SendMessage).
+// An empty or "new" ConversationID starts a conversation; an empty
+// ParentMessageID starts at the root. MessageID is generated when empty.
+type SendMessageRequest struct {
+	Text            string `json:"text"`
+	ConversationID  string `json:"conversationId"`
+	ParentMessageID string `json:"parentMessageId"`
+	MessageID       string `json:"messageId"`
+	Endpoint        string `json:"endpoint"`
+	Model           string `json:"model"`
+}
+
+// AbortMessageRequest stops a generation. AbortKey is the client's
+// "conversationId:messageId" key; ConversationID may be sent instead.
+type AbortMessageRequest struct {
+	AbortKey       string `json:"abortKey"`
+	ConversationID string `json:"conversationId"`
+}
+
+// MessageEvent is the data of one "message" Server-Sent Event. The client
+// tells the kinds apart by which fields are set: Created with the stored
+// user message, Message with the reply text so far, ToolCall with a tool call
+// before it runs and again with its output, then Final with the stored
+// messages.
+type MessageEvent struct {
+	Created         bool          `json:"created,omitempty"`
+	Final           bool          `json:"final,omitempty"`
+	Message         interface{}   `json:"message,omitempty"`
+	Text            string        `json:"text,omitempty"`
+	ToolCall        *ToolCall     `json:"toolCall,omitempty"`
+	MessageID       string        `json:"messageId,omitempty"`
+	ParentMessageID string        `json:"parentMessageId,omitempty"`
+	ConversationID  string        `json:"conversationId,omitempty"`
+	Conversation    *Conversation `json:"conversation,omitempty"`
+	RequestMessage  *Message      `json:"requestMessage,omitempty"`
+	ResponseMessage *Message      `json:"responseMessage,omitempty"`
+}
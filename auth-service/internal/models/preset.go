@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// Preset is a saved set of chat parameters, in the shape of LibreChat's TPreset
+type Preset struct {
+	PresetID     string `json:"presetId"`
+	User         string `json:"user"`
+	Title        string `json:"title"`
+	Endpoint     string `json:"endpoint"`
+	EndpointType string `json:"endpointType,omitempty"`
+	Model        string `json:"model,omitempty"`
+	ModelLabel   string `json:"modelLabel,omitempty"`
+	// PromptPrefix is the system prompt
+	PromptPrefix     string   `json:"promptPrefix,omitempty"`
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"top_p,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+	MaxContextTokens *int     `json:"maxContextTokens,omitempty"`
+	MaxTokens        *int     `json:"max_tokens,omitempty"`
+	// DefaultPreset marks the preset new conversations start from; at most
+	// one preset per user has it
+	DefaultPreset bool      `json:"defaultPreset"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// SavePresetRequest creates or updates a preset (rules in validation.SavePreset).
+// A new preset is created when PresetID is empty or unknown. Unset numeric
+// parameters use the endpoint's defaults.
+type SavePresetRequest struct {
+	PresetID         string   `json:"presetId"`
+	Title            string   `json:"title"`
+	Endpoint         string   `json:"endpoint"`
+	Model            string   `json:"model"`
+	ModelLabel       string   `json:"modelLabel"`
+	PromptPrefix     string   `json:"promptPrefix"`
+	Temperature      *float64 `json:"temperature"`
+	TopP             *float64 `json:"top_p"`
+	FrequencyPenalty *float64 `json:"frequency_penalty"`
+	PresencePenalty  *float64 `json:"presence_penalty"`
+	MaxContextTokens *int     `json:"maxContextTokens"`
+	MaxTokens        *int     `json:"max_tokens"`
+	DefaultPreset    bool     `json:"defaultPreset"`
+}
+
+// PresetRefRequest names a preset. An empty PresetID means all presets when
+// deleting and no default preset when setting the default.
+type PresetRefRequest struct {
+	PresetID string `json:"presetId"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// SearchResult is a conversation title or message matching a search.
+// Snippet is HTML: escaped text with the matched words in <mark>.
+type SearchResult struct {
+	// Type is "conversation" or "message"
+	Type           string    `json:"type"`
+	ConversationID string    `json:"conversationId"`
+	MessageID      string    `json:"messageId,omitempty"`
+	Sender         string    `json:"sender,omitempty"`
+	Endpoint       string    `json:"endpoint,omitempty"`
+	Snippet        string    `json:"snippet"`
+	Score          float64   `json:"score"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// SearchResponse lists search results, best first
+type SearchResponse struct {
+	Results []SearchResult `json:"results"`
+}
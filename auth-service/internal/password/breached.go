@@ -0,0 +1,72 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BreachedList checks passwords against a local copy of a breached-password
+// corpus split by k-anonymity range, as served by the Pwned Passwords range
+// API: the SHA-1 hash is split into a 5-character prefix naming the file and a
+// 35-character suffix listed in it as "SUFFIX:COUNT".
+type BreachedList struct {
+	dir      string
+	minCount int
+}
+
+// NewBreachedList creates a checker over the range files in dir
+func NewBreachedList(dir string, minCount int) *BreachedList {
+	if minCount < 1 {
+		minCount = 1
+	}
+	return &BreachedList{dir: dir, minCount: minCount}
+}
+
+// Contains reports whether the password appears at least minCount times.
+// A missing range file means no password with that prefix is listed.
+func (b *BreachedList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := b.open(prefix)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		entry, countText, _ := strings.Cut(line, ":")
+		if !strings.EqualFold(entry, suffix) {
+			continue
+		}
+		count := 1
+		if countText != "" {
+			if parsed, err := strconv.Atoi(strings.TrimSpace(countText)); err == nil {
+				count = parsed
+			}
+		}
+		return count >= b.minCount, nil
+	}
+	return false, scanner.Err()
+}
+
+// open finds the range file for a prefix, with or without a .txt extension
+func (b *BreachedList) open(prefix string) (*os.File, error) {
+	file, err := os.Open(filepath.Join(b.dir, prefix))
+	if errors.Is(err, os.ErrNotExist) {
+		file, err = os.Open(filepath.Join(b.dir, prefix+".txt"))
+	}
+	return file, err
+}
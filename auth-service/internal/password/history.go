@@ -0,0 +1,65 @@
+package password
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HistoryStore remembers hashes of a user's previous passwords
+type HistoryStore interface {
+	// Contains reports whether password matches one of the last depth entries
+	Contains(ctx context.Context, key, password string, depth int) (bool, error)
+	// Add records password as the newest entry, keeping at most depth entries
+	Add(ctx context.Context, key, password string, depth int) error
+}
+
+// MemoryHistory is an in-process HistoryStore. Entries are bcrypt hashes and
+// are lost on restart; Keycloak's passwordHistory policy (see
+// Policy.KeycloakPolicy) remains the durable enforcement.
+type MemoryHistory struct {
+	mutex   sync.RWMutex
+	entries map[string][][]byte
+	cost    int
+}
+
+// NewMemoryHistory creates an empty in-memory history
+func NewMemoryHistory() *MemoryHistory {
+	return &MemoryHistory{entries: make(map[string][][]byte), cost: bcrypt.DefaultCost}
+}
+
+// Contains compares password with the stored hashes, newest first
+func (h *MemoryHistory) Contains(ctx context.Context, key, password string, depth int) (bool, error) {
+	h.mutex.RLock()
+	hashes := h.entries[key]
+	h.mutex.RUnlock()
+
+	for i, hash := range hashes {
+		if i >= depth {
+			break
+		}
+		if bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Add hashes and prepends password, trimming the history to depth entries
+func (h *MemoryHistory) Add(ctx context.Context, key, password string, depth int) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return err
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	hashes := append([][]byte{hash}, h.entries[key]...)
+	if len(hashes) > depth {
+		hashes = hashes[:depth]
+	}
+	h.entries[key] = hashes
+	return nil
+}
@@ -0,0 +1,276 @@
+package password
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/validation"
+	"auth-service/pkg/logger"
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// UserInfo identifies the account a password belongs to
+type UserInfo struct {
+	Username string
+	Email    string
+}
+
+// Policy enforces the configured password rules
+type Policy struct {
+	cfg      config.PasswordConfig
+	breached *BreachedList
+	history  HistoryStore
+	logger   *logger.Logger
+}
+
+// NewPolicy creates a policy from configuration. history may be nil when
+// password history is not tracked.
+func NewPolicy(cfg config.PasswordConfig, history HistoryStore, logger *logger.Logger) *Policy {
+	policy := &Policy{
+		cfg:     cfg,
+		history: history,
+		logger:  logger,
+	}
+	if cfg.BreachedDir != "" {
+		policy.breached = NewBreachedList(cfg.BreachedDir, cfg.BreachedMinCount)
+	}
+	return policy
+}
+
+// Config returns the policy configuration
+func (p *Policy) Config() config.PasswordConfig {
+	return p.cfg
+}
+
+// Rules describes the enabled rules with the same codes and params Check reports
+func (p *Policy) Rules() []validation.Rule {
+	rules := []validation.Rule{{Code: "required"}}
+	if p.cfg.MinLength > 0 {
+		rules = append(rules, validation.Rule{Code: "min_length", Params: map[string]interface{}{"min": p.cfg.MinLength}})
+	}
+	if p.cfg.MaxLength > 0 {
+		rules = append(rules, validation.Rule{Code: "max_length", Params: map[string]interface{}{"max": p.cfg.MaxLength}})
+	}
+	if p.cfg.RequireUpper {
+		rules = append(rules, validation.Rule{Code: "password_uppercase"})
+	}
+	if p.cfg.RequireLower {
+		rules = append(rules, validation.Rule{Code: "password_lowercase"})
+	}
+	if p.cfg.RequireDigit {
+		rules = append(rules, validation.Rule{Code: "password_digit"})
+	}
+	if p.cfg.RequireSpecial {
+		rules = append(rules, validation.Rule{Code: "password_special"})
+	}
+	if p.cfg.MaxRepeated > 0 {
+		rules = append(rules, validation.Rule{Code: "password_repeated", Params: map[string]interface{}{"max": p.cfg.MaxRepeated}})
+	}
+	if p.cfg.DisallowUserInfo {
+		rules = append(rules, validation.Rule{Code: "password_user_info"})
+	}
+	if p.cfg.HistoryDepth > 0 && p.history != nil {
+		rules = append(rules, validation.Rule{Code: "password_history", Params: map[string]interface{}{"depth": p.cfg.HistoryDepth}})
+	}
+	if p.breached != nil {
+		rules = append(rules, validation.Rule{Code: "password_breached"})
+	}
+	return rules
+}
+
+// Check validates a password for user and returns one error per failed rule,
+// reported against field. Breached-list and history lookups fail open: an
+// unreadable list or store is logged and does not block the user.
+func (p *Policy) Check(ctx context.Context, field, password string, user UserInfo) []validation.Error {
+	var errs []validation.Error
+	fail := func(code string, params map[string]interface{}) {
+		errs = append(errs, validation.Error{Field: field, Code: code, Params: params})
+	}
+
+	if password == "" {
+		fail("required", nil)
+		return errs
+	}
+
+	length := utf8.RuneCountInString(password)
+	if p.cfg.MinLength > 0 && length < p.cfg.MinLength {
+		fail("min_length", map[string]interface{}{"min": p.cfg.MinLength})
+	}
+	if p.cfg.MaxLength > 0 && length > p.cfg.MaxLength {
+		fail("max_length", map[string]interface{}{"max": p.cfg.MaxLength})
+		// Skip the expensive checks for oversized input
+		return errs
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasDigit = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char):
+			hasSpecial = true
+		}
+	}
+	if p.cfg.RequireUpper && !hasUpper {
+		fail("password_uppercase", nil)
+	}
+	if p.cfg.RequireLower && !hasLower {
+		fail("password_lowercase", nil)
+	}
+	if p.cfg.RequireDigit && !hasDigit {
+		fail("password_digit", nil)
+	}
+	if p.cfg.RequireSpecial && !hasSpecial {
+		fail("password_special", nil)
+	}
+
+	if p.cfg.MaxRepeated > 0 && longestRun(password) > p.cfg.MaxRepeated {
+		fail("password_repeated", map[string]interface{}{"max": p.cfg.MaxRepeated})
+	}
+
+	if p.cfg.DisallowUserInfo && containsUserInfo(password, user) {
+		fail("password_user_info", nil)
+	}
+
+	if p.breached != nil {
+		breached, err := p.breached.Contains(password)
+		if err != nil {
+			p.logger.WithError(err).Warn("Breached password lookup failed")
+		} else if breached {
+			fail("password_breached", nil)
+		}
+	}
+
+	if p.cfg.HistoryDepth > 0 && p.history != nil && user.Username != "" {
+		reused, err := p.history.Contains(ctx, historyKey(user), password, p.cfg.HistoryDepth)
+		if err != nil {
+			p.logger.WithError(err).Warn("Password history lookup failed")
+		} else if reused {
+			fail("password_history", map[string]interface{}{"depth": p.cfg.HistoryDepth})
+		}
+	}
+
+	return errs
+}
+
+// Remember records a password that was just set so it cannot be reused
+func (p *Policy) Remember(ctx context.Context, user UserInfo, password string) error {
+	if p.cfg.HistoryDepth <= 0 || p.history == nil || user.Username == "" {
+		return nil
+	}
+	return p.history.Add(ctx, historyKey(user), password, p.cfg.HistoryDepth)
+}
+
+// Requirements lists the enabled rules as sentences for display
+func (p *Policy) Requirements() []string {
+	var requirements []string
+	if p.cfg.MinLength > 0 {
+		requirements = append(requirements, fmt.Sprintf("At least %d characters", p.cfg.MinLength))
+	}
+	if p.cfg.RequireUpper {
+		requirements = append(requirements, "At least one uppercase letter")
+	}
+	if p.cfg.RequireLower {
+		requirements = append(requirements, "At least one lowercase letter")
+	}
+	if p.cfg.RequireDigit {
+		requirements = append(requirements, "At least one number")
+	}
+	if p.cfg.RequireSpecial {
+		requirements = append(requirements, "At least one special character")
+	}
+	if p.cfg.MaxRepeated > 0 {
+		requirements = append(requirements, fmt.Sprintf("No character repeated more than %d times in a row", p.cfg.MaxRepeated))
+	}
+	if p.cfg.DisallowUserInfo {
+		requirements = append(requirements, "Must not contain your username or email")
+	}
+	if p.cfg.HistoryDepth > 0 && p.history != nil {
+		requirements = append(requirements, fmt.Sprintf("Must differ from your last %d passwords", p.cfg.HistoryDepth))
+	}
+	if p.breached != nil {
+		requirements = append(requirements, "Must not appear in a known data breach")
+	}
+	return requirements
+}
+
+// KeycloakPolicy renders the policy in Keycloak's realm passwordPolicy syntax.
+// The breached-password check has no built-in Keycloak equivalent and is only
+// enforced by this service.
+func (p *Policy) KeycloakPolicy() string {
+	var parts []string
+	if p.cfg.MinLength > 0 {
+		parts = append(parts, fmt.Sprintf("length(%d)", p.cfg.MinLength))
+	}
+	if p.cfg.MaxLength > 0 {
+		parts = append(parts, fmt.Sprintf("maxLength(%d)", p.cfg.MaxLength))
+	}
+	if p.cfg.RequireUpper {
+		parts = append(parts, "upperCase(1)")
+	}
+	if p.cfg.RequireLower {
+		parts = append(parts, "lowerCase(1)")
+	}
+	if p.cfg.RequireDigit {
+		parts = append(parts, "digits(1)")
+	}
+	if p.cfg.RequireSpecial {
+		parts = append(parts, "specialChars(1)")
+	}
+	if p.cfg.MaxRepeated > 0 {
+		parts = append(parts, fmt.Sprintf("regexPattern(^(?!.*(.)\\1{%d}).*$)", p.cfg.MaxRepeated))
+	}
+	if p.cfg.DisallowUserInfo {
+		parts = append(parts, "notUsername(undefined)", "notEmail(undefined)")
+	}
+	if p.cfg.HistoryDepth > 0 {
+		parts = append(parts, fmt.Sprintf("passwordHistory(%d)", p.cfg.HistoryDepth))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// longestRun returns the length of the longest run of one repeated character
+func longestRun(password string) int {
+	longest, current := 0, 0
+	var previous rune = -1
+	for _, char := range password {
+		if char == previous {
+			current++
+		} else {
+			current = 1
+			previous = char
+		}
+		if current > longest {
+			longest = current
+		}
+	}
+	return longest
+}
+
+// containsUserInfo reports whether the password contains the username, the
+// email address or its local part (ignoring case and very short values)
+func containsUserInfo(password string, user UserInfo) bool {
+	lower := strings.ToLower(password)
+
+	candidates := []string{user.Username, user.Email}
+	if at := strings.Index(user.Email, "@"); at > 0 {
+		candidates = append(candidates, user.Email[:at])
+	}
+	for _, candidate := range candidates {
+		candidate = strings.ToLower(strings.TrimSpace(candidate))
+		if utf8.RuneCountInString(candidate) >= 3 && strings.Contains(lower, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+func historyKey(user UserInfo) string {
+	return strings.ToLower(user.Username)
+}
@@ -0,0 +1,138 @@
+package password
+
+import (
+	"auth-service/internal/config"
+	"auth-service/pkg/logger"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func testConfig() config.PasswordConfig {
+	return config.PasswordConfig{
+		MinLength:        8,
+		MaxLength:        64,
+		RequireUpper:     true,
+		RequireLower:     true,
+		RequireDigit:     true,
+		RequireSpecial:   true,
+		MaxRepeated:      3,
+		DisallowUserInfo: true,
+		HistoryDepth:     2,
+	}
+}
+
+func testLogger() *logger.Logger {
+	return &logger.Logger{Logger: logrus.New()}
+}
+
+func checkCodes(policy *Policy, password string, user UserInfo) []string {
+	var result []string
+	for _, e := range policy.Check(context.Background(), "password", password, user) {
+		result = append(result, e.Code)
+	}
+	return result
+}
+
+func TestPolicy_Check(t *testing.T) {
+	policy := NewPolicy(testConfig(), nil, testLogger())
+	user := UserInfo{Username: "johndoe", Email: "john.smith@example.com"}
+
+	tests := []struct {
+		password string
+		expected []string
+	}{
+		{"Str0ng!Passphrase", nil},
+		{"", []string{"required"}},
+		{"Sh0rt!", []string{"min_length"}},
+		{strings.Repeat("Aa1!", 17), []string{"max_length"}},
+		{"alllowercase1!", []string{"password_uppercase"}},
+		{"ALLUPPERCASE1!", []string{"password_lowercase"}},
+		{"NoDigitsHere!", []string{"password_digit"}},
+		{"NoSpecial123", []string{"password_special"}},
+		{"Paaaas5word!", []string{"password_repeated"}},
+		{"Paaas5word!", nil},
+		{"MyJohnDoe#2024", []string{"password_user_info"}},
+		{"John.Smith#2024", []string{"password_user_info"}},
+		{"weak", []string{"min_length", "password_uppercase", "password_digit", "password_special"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			assert.Equal(t, tt.expected, checkCodes(policy, tt.password, user))
+		})
+	}
+}
+
+func TestPolicy_History(t *testing.T) {
+	history := NewMemoryHistory()
+	history.cost = bcrypt.MinCost
+	policy := NewPolicy(testConfig(), history, testLogger())
+	user := UserInfo{Username: "JaneDoe"}
+	ctx := context.Background()
+
+	require.NoError(t, policy.Remember(ctx, user, "First#Pass1"))
+	require.NoError(t, policy.Remember(ctx, user, "Second#Pass2"))
+
+	assert.Equal(t, []string{"password_history"}, checkCodes(policy, "First#Pass1", UserInfo{Username: "janedoe"}))
+	assert.Empty(t, checkCodes(policy, "Third#Pass3", user))
+
+	// Only the last HistoryDepth passwords are kept
+	require.NoError(t, policy.Remember(ctx, user, "Third#Pass3"))
+	assert.Empty(t, checkCodes(policy, "First#Pass1", user))
+	assert.Equal(t, []string{"password_history"}, checkCodes(policy, "Second#Pass2", user))
+}
+
+func TestPolicy_Breached(t *testing.T) {
+	dir := t.TempDir()
+	sum := sha1.Sum([]byte("Summer2024!"))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	rangeFile := "0000000000000000000000000000000000A:3\n" + hash[5:] + ":42\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(rangeFile), 0o600))
+
+	cfg := testConfig()
+	cfg.BreachedDir = dir
+	policy := NewPolicy(cfg, nil, testLogger())
+
+	assert.Equal(t, []string{"password_breached"}, checkCodes(policy, "Summer2024!", UserInfo{}))
+	assert.Empty(t, checkCodes(policy, "Winter2024!", UserInfo{}))
+
+	// Passwords seen fewer times than the threshold pass
+	cfg.BreachedMinCount = 100
+	assert.Empty(t, checkCodes(NewPolicy(cfg, nil, testLogger()), "Summer2024!", UserInfo{}))
+}
+
+func TestPolicy_DescriptionsFollowConfig(t *testing.T) {
+	cfg := testConfig()
+	policy := NewPolicy(cfg, NewMemoryHistory(), testLogger())
+
+	assert.Equal(t,
+		`length(8) and maxLength(64) and upperCase(1) and lowerCase(1) and digits(1) and specialChars(1) and regexPattern(^(?!.*(.)\1{3}).*$) and notUsername(undefined) and notEmail(undefined) and passwordHistory(2)`,
+		policy.KeycloakPolicy())
+	assert.Contains(t, policy.Requirements(), "At least 8 characters")
+
+	var ruleCodes []string
+	for _, rule := range policy.Rules() {
+		ruleCodes = append(ruleCodes, rule.Code)
+	}
+	assert.Equal(t, []string{
+		"required", "min_length", "max_length", "password_uppercase", "password_lowercase",
+		"password_digit", "password_special", "password_repeated", "password_user_info", "password_history",
+	}, ruleCodes)
+
+	cfg.RequireSpecial = false
+	cfg.HistoryDepth = 0
+	relaxed := NewPolicy(cfg, nil, testLogger())
+	assert.NotContains(t, relaxed.KeycloakPolicy(), "specialChars")
+	assert.NotContains(t, relaxed.Requirements(), "At least one special character")
+	assert.Empty(t, checkCodes(relaxed, "NoSpecial123", UserInfo{}))
+}
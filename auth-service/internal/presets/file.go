@@ -0,0 +1,166 @@
+package presets
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/storage"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore keeps each user's presets in one JSON file under dir
+type FileStore struct {
+	dir   string
+	mutex sync.Mutex
+	now   func() time.Time
+}
+
+// userPresets is the document stored per user
+type userPresets struct {
+	Presets []models.Preset `json:"presets"`
+}
+
+// NewFileStore creates a store in dir/presets
+func NewFileStore(dir string) (*FileStore, error) {
+	dir = filepath.Join(dir, "presets")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir, now: time.Now}, nil
+}
+
+// List returns the user's presets, the default first
+func (s *FileStore) List(ctx context.Context, userID string) ([]models.Preset, error) {
+	s.mutex.Lock()
+	doc, err := s.load(userID)
+	s.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	sortPresets(doc.Presets)
+	return doc.Presets, nil
+}
+
+// Save creates or replaces a preset
+func (s *FileStore) Save(ctx context.Context, userID string, preset *models.Preset) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return false, err
+	}
+
+	now := s.now().UTC()
+	preset.User = userID
+	preset.UpdatedAt = now
+	if preset.DefaultPreset {
+		doc.clearDefault()
+	}
+
+	if i := doc.find(preset.PresetID); i >= 0 {
+		preset.CreatedAt = doc.Presets[i].CreatedAt
+		doc.Presets[i] = *preset
+		return false, s.save(userID, doc)
+	}
+	if len(doc.Presets) >= MaxPresets {
+		return false, ErrLimit
+	}
+	if preset.PresetID == "" {
+		preset.PresetID = storage.NewID()
+	}
+	preset.CreatedAt = now
+	doc.Presets = append(doc.Presets, *preset)
+	return true, s.save(userID, doc)
+}
+
+// Delete removes one of the user's presets
+func (s *FileStore) Delete(ctx context.Context, userID, presetID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return err
+	}
+	i := doc.find(presetID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	doc.Presets = append(doc.Presets[:i], doc.Presets[i+1:]...)
+	return s.save(userID, doc)
+}
+
+// DeleteAll removes all of the user's presets
+func (s *FileStore) DeleteAll(ctx context.Context, userID string) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return 0, err
+	}
+	return len(doc.Presets), storage.Remove(storage.UserFile(s.dir, userID))
+}
+
+// SetDefault makes a preset the user's default, or clears the default
+func (s *FileStore) SetDefault(ctx context.Context, userID, presetID string) (*models.Preset, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load(userID)
+	if err != nil {
+		return nil, err
+	}
+	i := -1
+	if presetID != "" {
+		if i = doc.find(presetID); i < 0 {
+			return nil, ErrNotFound
+		}
+	}
+
+	doc.clearDefault()
+	if i < 0 {
+		return nil, s.save(userID, doc)
+	}
+	preset := &doc.Presets[i]
+	preset.DefaultPreset = true
+	preset.UpdatedAt = s.now().UTC()
+	if err := s.save(userID, doc); err != nil {
+		return nil, err
+	}
+	updated := *preset
+	return &updated, nil
+}
+
+func (s *FileStore) load(userID string) (*userPresets, error) {
+	doc := &userPresets{Presets: []models.Preset{}}
+	if _, err := storage.ReadJSON(storage.UserFile(s.dir, userID), doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (s *FileStore) save(userID string, doc *userPresets) error {
+	return storage.WriteJSON(storage.UserFile(s.dir, userID), doc)
+}
+
+func (doc *userPresets) find(presetID string) int {
+	if presetID == "" {
+		return -1
+	}
+	for i := range doc.Presets {
+		if doc.Presets[i].PresetID == presetID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (doc *userPresets) clearDefault() {
+	for i := range doc.Presets {
+		doc.Presets[i].DefaultPreset = false
+	}
+}
@@ -0,0 +1,126 @@
+package presets
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *FileStore {
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	return store
+}
+
+func save(t *testing.T, store *FileStore, userID string, preset models.Preset) models.Preset {
+	_, err := store.Save(context.Background(), userID, &preset)
+	require.NoError(t, err)
+	return preset
+}
+
+func titles(list []models.Preset) []string {
+	result := make([]string, len(list))
+	for i, preset := range list {
+		result[i] = preset.Title
+	}
+	return result
+}
+
+func TestFileStore_SaveAndList(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	first := save(t, store, "user-1", models.Preset{Title: "Deals", Endpoint: "openAI"})
+	assert.NotEmpty(t, first.PresetID)
+	assert.Equal(t, "user-1", first.User)
+	save(t, store, "user-1", models.Preset{Title: "Gifts", Endpoint: "openAI"})
+
+	list, err := store.List(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Gifts", "Deals"}, titles(list), "most recently updated first")
+
+	// Saving with an existing ID replaces the preset
+	first.Title = "Daily deals"
+	created, err := store.Save(ctx, "user-1", &first)
+	require.NoError(t, err)
+	assert.False(t, created)
+	list, err = store.List(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Daily deals", "Gifts"}, titles(list))
+	assert.True(t, list[0].UpdatedAt.After(list[0].CreatedAt))
+
+	list, err = store.List(ctx, "user-2")
+	require.NoError(t, err)
+	assert.Empty(t, list, "presets are scoped to their user")
+}
+
+func TestFileStore_Default(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	deals := save(t, store, "user-1", models.Preset{Title: "Deals", Endpoint: "openAI", DefaultPreset: true})
+	gifts := save(t, store, "user-1", models.Preset{Title: "Gifts", Endpoint: "openAI", DefaultPreset: true})
+	save(t, store, "user-1", models.Preset{Title: "Recipes", Endpoint: "openAI"})
+
+	list, err := store.List(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Gifts", "Recipes", "Deals"}, titles(list), "saving a default clears the previous one")
+	assert.True(t, list[0].DefaultPreset)
+	assert.False(t, list[2].DefaultPreset)
+
+	preset, err := store.SetDefault(ctx, "user-1", deals.PresetID)
+	require.NoError(t, err)
+	assert.True(t, preset.DefaultPreset)
+	list, err = store.List(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Deals", list[0].Title)
+
+	_, err = store.SetDefault(ctx, "user-2", gifts.PresetID)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	preset, err = store.SetDefault(ctx, "user-1", "")
+	require.NoError(t, err)
+	assert.Nil(t, preset)
+	list, err = store.List(ctx, "user-1")
+	require.NoError(t, err)
+	for _, p := range list {
+		assert.False(t, p.DefaultPreset)
+	}
+}
+
+func TestFileStore_Delete(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	deals := save(t, store, "user-1", models.Preset{Title: "Deals", Endpoint: "openAI"})
+	save(t, store, "user-1", models.Preset{Title: "Gifts", Endpoint: "openAI"})
+
+	assert.ErrorIs(t, store.Delete(ctx, "user-2", deals.PresetID), ErrNotFound)
+	require.NoError(t, store.Delete(ctx, "user-1", deals.PresetID))
+	assert.ErrorIs(t, store.Delete(ctx, "user-1", deals.PresetID), ErrNotFound)
+
+	count, err := store.DeleteAll(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	list, err := store.List(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestFileStore_Limit(t *testing.T) {
+	store := newTestStore(t)
+	for i := 0; i < MaxPresets; i++ {
+		save(t, store, "user-1", models.Preset{Title: "Preset", Endpoint: "openAI"})
+	}
+	_, err := store.Save(context.Background(), "user-1", &models.Preset{Title: "One more", Endpoint: "openAI"})
+	assert.ErrorIs(t, err, ErrLimit)
+}
@@ -0,0 +1,48 @@
+// Package presets stores each user's saved chat parameters. Every operation
+// is scoped to a user: a preset of another user behaves as if it did not exist.
+package presets
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"sort"
+)
+
+var (
+	// ErrNotFound is returned for unknown presets and for those of other users
+	ErrNotFound = errors.New("preset not found")
+	// ErrLimit is returned when creating a preset beyond MaxPresets
+	ErrLimit = errors.New("preset limit reached")
+)
+
+// MaxPresets is the number of presets a user may keep
+const MaxPresets = 100
+
+// PresetStore persists presets
+type PresetStore interface {
+	// List returns the user's presets, the default first and then the most
+	// recently updated
+	List(ctx context.Context, userID string) ([]models.Preset, error)
+	// Save creates or replaces a preset, filling in its ID, owner and
+	// timestamps, and reports whether it was created. Saving a default
+	// preset clears the flag on the others.
+	Save(ctx context.Context, userID string, preset *models.Preset) (bool, error)
+	// Delete removes one of the user's presets
+	Delete(ctx context.Context, userID, presetID string) error
+	// DeleteAll removes all of the user's presets and returns how many there were
+	DeleteAll(ctx context.Context, userID string) (int, error)
+	// SetDefault makes a preset the user's default; an empty presetID clears
+	// the default and returns nil
+	SetDefault(ctx context.Context, userID, presetID string) (*models.Preset, error)
+}
+
+// sortPresets orders presets as List returns them
+func sortPresets(list []models.Preset) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].DefaultPreset != list[j].DefaultPreset {
+			return list[i].DefaultPreset
+		}
+		return list[i].UpdatedAt.After(list[j].UpdatedAt)
+	})
+}
@@ -0,0 +1,71 @@
+package problem
+
+import (
+	"auth-service/internal/models"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType is the RFC 7807 media type for problem details
+const ContentType = "application/problem+json"
+
+// TypePrefix namespaces the problem type URIs. The suffix is the ErrorResponse
+// error code, so both formats identify a problem the same way.
+const TypePrefix = "urn:auth-service:problem:"
+
+// Write sends resp with status resp.Code. Clients that prefer
+// application/problem+json in their Accept header get RFC 7807 problem
+// details; everyone else gets the ErrorResponse JSON.
+func Write(c *gin.Context, resp models.ErrorResponse) {
+	if !Wanted(c) {
+		c.JSON(resp.Code, resp)
+		return
+	}
+
+	body := FromErrorResponse(resp, c.Request.URL.Path)
+	c.Render(resp.Code, problemJSON{body})
+}
+
+// Wanted reports whether the request negotiates application/problem+json
+// ahead of application/json
+func Wanted(c *gin.Context) bool {
+	return c.NegotiateFormat(gin.MIMEJSON, ContentType) == ContentType
+}
+
+// FromErrorResponse converts resp into problem details for the given request path
+func FromErrorResponse(resp models.ErrorResponse, instance string) models.ProblemDetails {
+	title := http.StatusText(resp.Code)
+	if title == "" {
+		title = resp.Error
+	}
+	typ := "about:blank"
+	if resp.Error != "" {
+		typ = TypePrefix + resp.Error
+	}
+
+	return models.ProblemDetails{
+		Type:     typ,
+		Title:    title,
+		Status:   resp.Code,
+		Detail:   resp.Message,
+		Instance: instance,
+		Error:    resp.Error,
+		Errors:   resp.Details,
+	}
+}
+
+// problemJSON renders JSON with the problem+json content type
+type problemJSON struct {
+	body models.ProblemDetails
+}
+
+func (r problemJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return json.NewEncoder(w).Encode(r.body)
+}
+
+func (r problemJSON) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", ContentType)
+}
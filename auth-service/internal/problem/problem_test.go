@@ -0,0 +1,69 @@
+package problem
+
+import (
+	"auth-service/internal/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite_Negotiation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	resp := models.ErrorResponse{
+		Error:   "validation_error",
+		Message: "Request validation failed",
+		Code:    http.StatusBadRequest,
+		Details: []models.FieldError{{Field: "email", Code: "email", Message: "Email is invalid"}},
+	}
+
+	tests := []struct {
+		accept      string
+		contentType string
+	}{
+		{"", "application/json; charset=utf-8"},
+		{"*/*", "application/json; charset=utf-8"},
+		{"application/json, application/problem+json", "application/json; charset=utf-8"},
+		{"application/problem+json", ContentType},
+		{"application/problem+json, application/json;q=0.9", ContentType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/v1/auth/register", nil)
+			if tt.accept != "" {
+				c.Request.Header.Set("Accept", tt.accept)
+			}
+
+			Write(c, resp)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+
+			if tt.contentType != ContentType {
+				var body models.ErrorResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, resp, body)
+				return
+			}
+
+			var body models.ProblemDetails
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, models.ProblemDetails{
+				Type:     TypePrefix + "validation_error",
+				Title:    "Bad Request",
+				Status:   http.StatusBadRequest,
+				Detail:   "Request validation failed",
+				Instance: "/api/v1/auth/register",
+				Error:    "validation_error",
+				Errors:   resp.Details,
+			}, body)
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package resilience
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// State is the position of a circuit breaker
+type State int
+
+const (
+	// StateClosed lets every call through
+	StateClosed State = iota
+	// StateOpen rejects calls until the cooldown has passed
+	StateOpen
+	// StateHalfOpen lets a limited number of probe calls through
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	}
+	return "unknown"
+}
+
+// OpenError is returned for calls rejected by an open breaker
+type OpenError struct {
+	Name       string
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit breaker %q is open", e.Name)
+}
+
+// RetryAfter returns how long to wait before retrying err, if err (or an
+// error it wraps) was a rejection by an open breaker
+func RetryAfter(err error) (time.Duration, bool) {
+	var open *OpenError
+	if !errors.As(err, &open) {
+		return 0, false
+	}
+	return open.RetryAfter, true
+}
+
+// RetryAfterSeconds is RetryAfter rounded up to whole seconds, as used by
+// the Retry-After header
+func RetryAfterSeconds(err error) (int, bool) {
+	retryAfter, ok := RetryAfter(err)
+	if !ok {
+		return 0, false
+	}
+	return int(math.Ceil(retryAfter.Seconds())), true
+}
+
+// BreakerSettings configures a Breaker
+type BreakerSettings struct {
+	// Threshold is the number of consecutive failures that opens the breaker
+	Threshold int
+	// Cooldown is how long the breaker stays open before probing
+	Cooldown time.Duration
+	// HalfOpenProbes is the number of concurrent probe calls allowed while half-open
+	HalfOpenProbes int
+	// IsFailure decides which errors count against the breaker. Errors that
+	// show the dependency answered (such as bad credentials) should not.
+	// Defaults to every non-nil error.
+	IsFailure func(error) bool
+	// Now is the clock, replaceable in tests
+	Now func() time.Time
+}
+
+// Stats is a point-in-time view of a breaker for health checks and metrics
+type Stats struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Successes           uint64     `json:"successes"`
+	Failures            uint64     `json:"failures"`
+	Rejected            uint64     `json:"rejected"`
+	TimesOpened         uint64     `json:"times_opened"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+}
+
+// Breaker is a consecutive-failure circuit breaker. After Threshold failures
+// in a row it opens and rejects calls for Cooldown, then lets HalfOpenProbes
+// calls through: one successful probe closes it, a failed one reopens it.
+type Breaker struct {
+	name     string
+	settings BreakerSettings
+
+	mutex       sync.Mutex
+	state       State
+	failures    int
+	openedAt    time.Time
+	probes      int
+	successes   uint64
+	failed      uint64
+	rejected    uint64
+	timesOpened uint64
+}
+
+// NewBreaker creates a closed breaker
+func NewBreaker(name string, settings BreakerSettings) *Breaker {
+	if settings.Threshold <= 0 {
+		settings.Threshold = 5
+	}
+	if settings.Cooldown <= 0 {
+		settings.Cooldown = 30 * time.Second
+	}
+	if settings.HalfOpenProbes <= 0 {
+		settings.HalfOpenProbes = 1
+	}
+	if settings.IsFailure == nil {
+		settings.IsFailure = func(err error) bool { return err != nil }
+	}
+	if settings.Now == nil {
+		settings.Now = time.Now
+	}
+
+	return &Breaker{name: name, settings: settings}
+}
+
+// Name returns the breaker name
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Execute runs fn if the breaker allows it and records the outcome.
+// Rejected calls return an *OpenError without running fn.
+func (b *Breaker) Execute(fn func() error) error {
+	probe, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	b.record(probe, err)
+	return err
+}
+
+// State returns the current state, moving an expired open breaker to half-open
+func (b *Breaker) State() State {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.advance()
+	return b.state
+}
+
+// Stats returns counters and state for reporting
+func (b *Breaker) Stats() Stats {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.advance()
+	stats := Stats{
+		Name:                b.name,
+		State:               b.state.String(),
+		ConsecutiveFailures: b.failures,
+		Successes:           b.successes,
+		Failures:            b.failed,
+		Rejected:            b.rejected,
+		TimesOpened:         b.timesOpened,
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		stats.OpenedAt = &openedAt
+	}
+	return stats
+}
+
+func (b *Breaker) allow() (bool, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.advance()
+	switch b.state {
+	case StateClosed:
+		return false, nil
+	case StateHalfOpen:
+		if b.probes < b.settings.HalfOpenProbes {
+			b.probes++
+			return true, nil
+		}
+	}
+
+	b.rejected++
+	retryAfter := b.openedAt.Add(b.settings.Cooldown).Sub(b.settings.Now())
+	if retryAfter <= 0 {
+		// Half-open with every probe slot taken; the probes finish soon
+		retryAfter = time.Second
+	}
+	return false, &OpenError{Name: b.name, RetryAfter: retryAfter}
+}
+
+func (b *Breaker) record(probe bool, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if probe {
+		b.probes--
+	}
+
+	if !b.settings.IsFailure(err) {
+		b.successes++
+		b.failures = 0
+		if probe && b.state == StateHalfOpen {
+			b.state = StateClosed
+		}
+		return
+	}
+
+	b.failed++
+	b.failures++
+	if (probe && b.state == StateHalfOpen) || (b.state == StateClosed && b.failures >= b.settings.Threshold) {
+		b.open()
+	}
+}
+
+// advance moves an open breaker to half-open once the cooldown has passed.
+// The caller must hold the mutex.
+func (b *Breaker) advance() {
+	if b.state == StateOpen && !b.settings.Now().Before(b.openedAt.Add(b.settings.Cooldown)) {
+		b.state = StateHalfOpen
+		b.probes = 0
+	}
+}
+
+// open trips the breaker. The caller must hold the mutex.
+func (b *Breaker) open() {
+	b.state = StateOpen
+	b.openedAt = b.settings.Now()
+	b.timesOpened++
+}
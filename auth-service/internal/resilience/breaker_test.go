@@ -0,0 +1,175 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errDown = errors.New("dependency down")
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestBreaker(clock *fakeClock) *Breaker {
+	return NewBreaker("test", BreakerSettings{
+		Threshold:      3,
+		Cooldown:       10 * time.Second,
+		HalfOpenProbes: 1,
+		IsFailure:      func(err error) bool { return errors.Is(err, errDown) },
+		Now:            clock.Now,
+	})
+}
+
+func fail() error    { return errDown }
+func succeed() error { return nil }
+
+func TestBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	b := newTestBreaker(clock)
+
+	assert.ErrorIs(t, b.Execute(fail), errDown)
+	assert.ErrorIs(t, b.Execute(fail), errDown)
+	assert.NoError(t, b.Execute(succeed)) // resets the count
+	assert.ErrorIs(t, b.Execute(fail), errDown)
+	assert.ErrorIs(t, b.Execute(fail), errDown)
+	assert.Equal(t, StateClosed, b.State())
+
+	assert.ErrorIs(t, b.Execute(fail), errDown)
+	assert.Equal(t, StateOpen, b.State())
+
+	called := false
+	clock.Advance(4 * time.Second)
+	err := b.Execute(func() error { called = true; return nil })
+	assert.False(t, called)
+
+	var open *OpenError
+	assert.ErrorAs(t, err, &open)
+	assert.Equal(t, 6*time.Second, open.RetryAfter)
+	seconds, ok := RetryAfterSeconds(err)
+	assert.True(t, ok)
+	assert.Equal(t, 6, seconds)
+
+	stats := b.Stats()
+	assert.Equal(t, "open", stats.State)
+	assert.Equal(t, uint64(5), stats.Failures)
+	assert.Equal(t, uint64(1), stats.Rejected)
+	assert.Equal(t, uint64(1), stats.TimesOpened)
+	assert.NotNil(t, stats.OpenedAt)
+}
+
+func TestBreaker_IgnoresNonFailures(t *testing.T) {
+	b := newTestBreaker(&fakeClock{now: time.Unix(0, 0)})
+	rejected := errors.New("bad credentials")
+
+	for i := 0; i < 10; i++ {
+		assert.ErrorIs(t, b.Execute(func() error { return rejected }), rejected)
+	}
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_HalfOpenProbes(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	b := newTestBreaker(clock)
+	for i := 0; i < 3; i++ {
+		_ = b.Execute(fail)
+	}
+
+	// A failed probe reopens the breaker for a full cooldown
+	clock.Advance(10 * time.Second)
+	assert.Equal(t, StateHalfOpen, b.State())
+	assert.ErrorIs(t, b.Execute(fail), errDown)
+	assert.Equal(t, StateOpen, b.State())
+	clock.Advance(9 * time.Second)
+	assert.Equal(t, StateOpen, b.State())
+
+	// Only one probe at a time; a successful probe closes the breaker
+	clock.Advance(time.Second)
+	err := b.Execute(func() error {
+		_, concurrent := RetryAfter(b.Execute(succeed))
+		assert.True(t, concurrent, "second probe should be rejected")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, StateClosed, b.State())
+	assert.Equal(t, uint64(2), b.Stats().TimesOpened)
+}
+
+func TestRegistry_SharesBreakersByName(t *testing.T) {
+	r := NewRegistry()
+	a := r.Breaker("keycloak", BreakerSettings{Threshold: 1})
+	b := r.Breaker("keycloak", BreakerSettings{Threshold: 10})
+	r.Breaker("another", BreakerSettings{})
+
+	assert.Same(t, a, b)
+	_ = a.Execute(fail)
+	assert.Equal(t, StateOpen, b.State())
+
+	stats := r.Stats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, "another", stats[0].Name)
+	assert.Equal(t, "open", stats[1].State)
+}
+
+func TestRetry(t *testing.T) {
+	retryable := func(err error) bool { return errors.Is(err, errDown) }
+	policy := RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond}
+
+	var calls int32
+	err := Retry(context.Background(), policy, retryable, func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return errDown
+	})
+	assert.ErrorIs(t, err, errDown)
+	assert.Equal(t, int32(3), calls)
+
+	calls = 0
+	err = Retry(context.Background(), policy, retryable, func(context.Context) error {
+		if atomic.AddInt32(&calls, 1) < 2 {
+			return errDown
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), calls)
+
+	// Errors the predicate rejects are returned at once
+	calls = 0
+	permanent := errors.New("permanent")
+	err = Retry(context.Background(), policy, retryable, func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return permanent
+	})
+	assert.ErrorIs(t, err, permanent)
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestRetry_StopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := Retry(ctx, RetryPolicy{MaxRetries: 5, Backoff: time.Hour}, func(error) bool { return true }, func(context.Context) error {
+		calls++
+		cancel()
+		return errDown
+	})
+	assert.ErrorIs(t, err, errDown)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryPolicy_DelayIsJitteredAndCapped(t *testing.T) {
+	policy := RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	for attempt := 0; attempt < 10; attempt++ {
+		delay := policy.delay(attempt)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.Less(t, delay, 300*time.Millisecond)
+	}
+	assert.Less(t, policy.delay(0), 100*time.Millisecond)
+}
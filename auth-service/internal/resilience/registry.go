@@ -0,0 +1,61 @@
+package resilience
+
+import (
+	"sort"
+	"sync"
+)
+
+// Registry holds named breakers so every client of a dependency shares one
+// breaker, and health checks and metrics can report on all of them
+type Registry struct {
+	mutex    sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// Breakers is the process-wide registry
+var Breakers = NewRegistry()
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{breakers: make(map[string]*Breaker)}
+}
+
+// Breaker returns the breaker registered under name, creating it with
+// settings on first use. Later calls ignore settings.
+func (r *Registry) Breaker(name string, settings BreakerSettings) *Breaker {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if b, ok := r.breakers[name]; ok {
+		return b
+	}
+	b := NewBreaker(name, settings)
+	r.breakers[name] = b
+	return b
+}
+
+// Get returns the breaker registered under name, if any
+func (r *Registry) Get(name string) (*Breaker, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	b, ok := r.breakers[name]
+	return b, ok
+}
+
+// Stats returns the stats of every breaker, ordered by name
+func (r *Registry) Stats() []Stats {
+	r.mutex.Lock()
+	breakers := make([]*Breaker, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		breakers = append(breakers, b)
+	}
+	r.mutex.Unlock()
+
+	stats := make([]Stats, 0, len(breakers))
+	for _, b := range breakers {
+		stats = append(stats, b.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
@@ -0,0 +1,53 @@
+package resilience
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy bounds how often and how quickly a call is retried
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt
+	MaxRetries int
+	// Backoff is the base delay; attempt n waits a random duration in
+	// [0, Backoff*2^n) ("full jitter"), so retries from many clients spread out
+	Backoff time.Duration
+	// MaxBackoff caps the delay of a single retry
+	MaxBackoff time.Duration
+}
+
+// Retry runs fn until it succeeds, returns an error retryable rejects, the
+// retries are used up or ctx is done. It returns the last error from fn.
+// Only use it for idempotent calls.
+func Retry(ctx context.Context, policy RetryPolicy, retryable func(error) bool, fn func(context.Context) error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = fn(ctx); err == nil || attempt >= policy.MaxRetries || !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(policy.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	if p.Backoff <= 0 {
+		return 0
+	}
+
+	ceiling := p.Backoff << uint(attempt)
+	if ceiling <= 0 || (p.MaxBackoff > 0 && ceiling > p.MaxBackoff) {
+		ceiling = p.MaxBackoff
+	}
+	if ceiling <= 0 {
+		ceiling = p.Backoff
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
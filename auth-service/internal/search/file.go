@@ -0,0 +1,124 @@
+package search
+
+import (
+	"auth-service/internal/storage"
+	"container/list"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultCachedUsers bounds the in-memory indexes of a FileIndex created
+// without a bound
+const DefaultCachedUsers = 1000
+
+// FileIndex is an embedded index. Each user's documents are kept in one
+// JSON file under dir, and loaded into an in-memory inverted index when the
+// user is searched or indexed. At most maxUsers indexes stay in memory; the
+// least recently used is dropped, and rebuilt from its file when needed.
+type FileIndex struct {
+	dir      string
+	maxUsers int
+	mutex    sync.Mutex
+	users    map[string]*list.Element
+	order    *list.List // front is most recently used
+}
+
+type cachedUser struct {
+	userID string
+	index  *userIndex
+}
+
+// userDocuments is the document stored per user
+type userDocuments struct {
+	Documents []Document `json:"documents"`
+}
+
+// NewFileIndex creates an index in dir/search keeping at most maxUsers
+// users' indexes in memory, or DefaultCachedUsers when maxUsers is not
+// positive
+func NewFileIndex(dir string, maxUsers int) (*FileIndex, error) {
+	dir = filepath.Join(dir, "search")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	if maxUsers <= 0 {
+		maxUsers = DefaultCachedUsers
+	}
+	return &FileIndex{dir: dir, maxUsers: maxUsers, users: map[string]*list.Element{}, order: list.New()}, nil
+}
+
+// Put adds a document, replacing the one with the same ID
+func (x *FileIndex) Put(ctx context.Context, doc Document) error {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	ui, err := x.load(doc.UserID)
+	if err != nil {
+		return err
+	}
+	ui.put(doc)
+	return x.save(doc.UserID, ui)
+}
+
+// DeleteConversation removes the documents of one of the user's conversations
+func (x *FileIndex) DeleteConversation(ctx context.Context, userID, conversationID string) error {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	ui, err := x.load(userID)
+	if err != nil {
+		return err
+	}
+	if !ui.removeConversation(conversationID) {
+		return nil
+	}
+	return x.save(userID, ui)
+}
+
+// Search returns the user's documents matching q, best first
+func (x *FileIndex) Search(ctx context.Context, userID string, q Query) ([]Hit, error) {
+	clauses, err := parseQuery(q.Text)
+	if err != nil {
+		return nil, err
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+	q.Limit = min(q.Limit, MaxLimit)
+
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	ui, err := x.load(userID)
+	if err != nil {
+		return nil, err
+	}
+	return ui.search(clauses, q), nil
+}
+
+func (x *FileIndex) load(userID string) (*userIndex, error) {
+	if element, ok := x.users[userID]; ok {
+		x.order.MoveToFront(element)
+		return element.Value.(*cachedUser).index, nil
+	}
+	doc := &userDocuments{}
+	if _, err := storage.ReadJSON(storage.UserFile(x.dir, userID), doc); err != nil {
+		return nil, err
+	}
+	ui := newUserIndex()
+	for _, d := range doc.Documents {
+		ui.put(d)
+	}
+	x.users[userID] = x.order.PushFront(&cachedUser{userID: userID, index: ui})
+	for x.order.Len() > x.maxUsers {
+		oldest := x.order.Remove(x.order.Back()).(*cachedUser)
+		delete(x.users, oldest.userID)
+	}
+	return ui, nil
+}
+
+func (x *FileIndex) save(userID string, ui *userIndex) error {
+	return storage.WriteJSON(storage.UserFile(x.dir, userID), userDocuments{Documents: ui.documents()})
+}
@@ -0,0 +1,98 @@
+// Package search indexes the titles of a user's conversations and the text
+// of their messages for full-text search. Queries combine words, "quoted
+// phrases" and prefix* terms, all of which must match. Every operation is
+// scoped to a user: a search only ever sees the caller's own documents.
+package search
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrInvalidQuery is returned for queries with nothing to search for, or
+// with prefixes too short to be useful
+var ErrInvalidQuery = errors.New("invalid search query")
+
+// Document kinds
+const (
+	KindConversation = "conversation"
+	KindMessage      = "message"
+)
+
+// Result limits
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Document is a searchable piece of a user's data
+type Document struct {
+	ID             string    `json:"id"`
+	UserID         string    `json:"userId"`
+	Kind           string    `json:"kind"`
+	ConversationID string    `json:"conversationId"`
+	MessageID      string    `json:"messageId,omitempty"`
+	Sender         string    `json:"sender,omitempty"`
+	Endpoint       string    `json:"endpoint,omitempty"`
+	Text           string    `json:"text"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// Query is a search and its filters. Zero values do not filter.
+type Query struct {
+	Text     string
+	Kind     string
+	Endpoint string
+	// From and To bound CreatedAt, both inclusive
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+// Hit is a matching document. Snippet is HTML: the text around the first
+// match, escaped, with the matched words wrapped in <mark>.
+type Hit struct {
+	Document Document
+	Score    float64
+	Snippet  string
+}
+
+// Index stores documents and searches them
+type Index interface {
+	// Put adds a document, replacing the one with the same ID
+	Put(ctx context.Context, doc Document) error
+	// DeleteConversation removes the documents of one of the user's conversations
+	DeleteConversation(ctx context.Context, userID, conversationID string) error
+	// Search returns the user's documents matching q, best first
+	Search(ctx context.Context, userID string, q Query) ([]Hit, error)
+}
+
+// ConversationDocument is the document indexing a conversation's title
+func ConversationDocument(convo *models.Conversation) Document {
+	return Document{
+		ID:             "c:" + convo.ConversationID,
+		UserID:         convo.User,
+		Kind:           KindConversation,
+		ConversationID: convo.ConversationID,
+		Endpoint:       convo.Endpoint,
+		Text:           convo.Title,
+		CreatedAt:      convo.CreatedAt,
+	}
+}
+
+// MessageDocument is the document indexing a message's text
+func MessageDocument(msg *models.Message) Document {
+	return Document{
+		ID:             "m:" + msg.ConversationID + ":" + msg.MessageID,
+		UserID:         msg.User,
+		Kind:           KindMessage,
+		ConversationID: msg.ConversationID,
+		MessageID:      msg.MessageID,
+		Sender:         msg.Sender,
+		Endpoint:       msg.Endpoint,
+		Text:           msg.Text,
+		CreatedAt:      msg.CreatedAt,
+	}
+}
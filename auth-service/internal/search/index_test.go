@@ -0,0 +1,244 @@
+package search
+
+import (
+	"auth-service/internal/conversations"
+	"auth-service/internal/messages"
+	"auth-service/internal/models"
+	"auth-service/pkg/logger"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var day = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func put(t *testing.T, index *FileIndex, docs ...Document) {
+	for _, doc := range docs {
+		require.NoError(t, index.Put(context.Background(), doc))
+	}
+}
+
+func message(userID, convo, id, text, endpoint string, createdAt time.Time) Document {
+	return MessageDocument(&models.Message{
+		User: userID, ConversationID: convo, MessageID: id, Text: text, Endpoint: endpoint, CreatedAt: createdAt,
+	})
+}
+
+func ids(hits []Hit) []string {
+	result := make([]string, len(hits))
+	for i, hit := range hits {
+		result[i] = hit.Document.MessageID
+	}
+	return result
+}
+
+func newTestIndex(t *testing.T) *FileIndex {
+	index, err := NewFileIndex(t.TempDir(), 0)
+	require.NoError(t, err)
+	put(t, index,
+		message("user-1", "convo-1", "m1", "I need running shoes for a marathon", "openAI", day),
+		message("user-1", "convo-1", "m2", "These shoes are good for running on trails", "openAI", day.Add(time.Hour)),
+		message("user-1", "convo-2", "m3", "Compare prices of espresso machines", "Ollama", day.AddDate(0, 0, 2)),
+		message("user-1", "convo-2", "m4", "Espresso, espresso and more espresso!", "Ollama", day.AddDate(0, 0, 3)),
+		message("user-2", "convo-3", "m5", "Running shoes on sale", "openAI", day),
+	)
+	return index
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query   string
+		clauses []clause
+	}{
+		{"Running Shoes", []clause{{clauseTerm, []string{"running"}}, {clauseTerm, []string{"shoes"}}}},
+		{`"running shoes" cheap`, []clause{{clausePhrase, []string{"running", "shoes"}}, {clauseTerm, []string{"cheap"}}}},
+		{"espr*", []clause{{clausePrefix, []string{"espr"}}}},
+		{`t-shirt "" "sale"`, []clause{{clauseTerm, []string{"t"}}, {clauseTerm, []string{"shirt"}}, {clauseTerm, []string{"sale"}}}},
+	}
+	for _, tt := range tests {
+		clauses, err := parseQuery(tt.query)
+		require.NoError(t, err, tt.query)
+		assert.Equal(t, tt.clauses, clauses, tt.query)
+	}
+
+	for _, query := range []string{"", `""`, "?!", "e*"} {
+		_, err := parseQuery(query)
+		assert.ErrorIs(t, err, ErrInvalidQuery, query)
+	}
+}
+
+func TestFileIndex_WordsPhrasesAndPrefixes(t *testing.T) {
+	index := newTestIndex(t)
+	ctx := context.Background()
+
+	hits, err := index.Search(ctx, "user-1", Query{Text: "shoes running"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"m1", "m2"}, ids(hits), "words match in any order")
+
+	hits, err = index.Search(ctx, "user-1", Query{Text: `"running shoes"`})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m1"}, ids(hits), "phrases match in order")
+
+	hits, err = index.Search(ctx, "user-1", Query{Text: "espr*"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m4", "m3"}, ids(hits), "more occurrences rank higher")
+
+	hits, err = index.Search(ctx, "user-1", Query{Text: "shoes espresso"})
+	require.NoError(t, err)
+	assert.Empty(t, hits, "every word must match")
+}
+
+func TestFileIndex_OnlySearchesTheCallersDocuments(t *testing.T) {
+	index := newTestIndex(t)
+
+	hits, err := index.Search(context.Background(), "user-2", Query{Text: "shoes"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m5"}, ids(hits))
+
+	hits, err = index.Search(context.Background(), "user-3", Query{Text: "shoes"})
+	require.NoError(t, err)
+	assert.Empty(t, hits)
+}
+
+func TestFileIndex_Filters(t *testing.T) {
+	index := newTestIndex(t)
+	ctx := context.Background()
+	put(t, index, ConversationDocument(&models.Conversation{
+		User: "user-1", ConversationID: "convo-2", Title: "Espresso machines", Endpoint: "Ollama", CreatedAt: day,
+	}))
+
+	hits, err := index.Search(ctx, "user-1", Query{Text: "espresso", Kind: KindConversation})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "c:convo-2", hits[0].Document.ID)
+
+	hits, err = index.Search(ctx, "user-1", Query{Text: "espresso", Kind: KindMessage, From: day.AddDate(0, 0, 3)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m4"}, ids(hits))
+
+	hits, err = index.Search(ctx, "user-1", Query{Text: "espresso", To: day.AddDate(0, 0, 2)})
+	require.NoError(t, err)
+	assert.Len(t, hits, 2)
+
+	hits, err = index.Search(ctx, "user-1", Query{Text: "shoes", Endpoint: "Ollama"})
+	require.NoError(t, err)
+	assert.Empty(t, hits)
+
+	hits, err = index.Search(ctx, "user-1", Query{Text: "espresso", Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, hits, 1)
+}
+
+func TestFileIndex_Snippets(t *testing.T) {
+	index, err := NewFileIndex(t.TempDir(), 0)
+	require.NoError(t, err)
+	long := "We looked at many options before. " +
+		"Budget matters a lot for a first purchase, and so does size. " +
+		"The <b>best</b> choice is the Gaggia Classic espresso machine, which steams milk well. " +
+		"It is easy to repair, parts are cheap and there is a large community around it."
+	put(t, index,
+		message("user-1", "convo-1", "m1", "Cheap <script>espresso</script> & milk", "", day),
+		message("user-1", "convo-1", "m2", long, "", day),
+	)
+
+	hits, err := index.Search(context.Background(), "user-1", Query{Text: "espresso"})
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	snippets := map[string]string{}
+	for _, hit := range hits {
+		snippets[hit.Document.MessageID] = hit.Snippet
+	}
+	assert.Equal(t, "Cheap &lt;script&gt;<mark>espresso</mark>&lt;/script&gt; &amp; milk", snippets["m1"], "text is escaped")
+	assert.Equal(t, "…so does size. The &lt;b&gt;best&lt;/b&gt; choice is the Gaggia Classic <mark>espresso</mark> machine, "+
+		"which steams milk well. It is easy to repair, parts are cheap and there is a large community around it.", snippets["m2"])
+
+	hits, err = index.Search(context.Background(), "user-1", Query{Text: `"espresso machine"`})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Contains(t, hits[0].Snippet, "<mark>espresso</mark> <mark>machine</mark>")
+}
+
+func TestFileIndex_PersistsAndDeletes(t *testing.T) {
+	dir := t.TempDir()
+	index, err := NewFileIndex(dir, 0)
+	require.NoError(t, err)
+	ctx := context.Background()
+	put(t, index,
+		message("user-1", "convo-1", "m1", "Running shoes", "", day),
+		message("user-1", "convo-2", "m2", "Trail shoes", "", day),
+	)
+	// Putting a document again replaces it
+	put(t, index, message("user-1", "convo-1", "m1", "Hiking boots", "", day))
+
+	reopened, err := NewFileIndex(dir, 0)
+	require.NoError(t, err)
+	hits, err := reopened.Search(ctx, "user-1", Query{Text: "shoes"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m2"}, ids(hits))
+
+	require.NoError(t, reopened.DeleteConversation(ctx, "user-1", "convo-2"))
+	hits, err = reopened.Search(ctx, "user-1", Query{Text: "shoes"})
+	require.NoError(t, err)
+	assert.Empty(t, hits)
+}
+
+func TestFileIndex_EvictsLeastRecentlyUsedUsers(t *testing.T) {
+	index, err := NewFileIndex(t.TempDir(), 2)
+	require.NoError(t, err)
+	ctx := context.Background()
+	put(t, index,
+		message("user-1", "convo-1", "m1", "Running shoes", "", day),
+		message("user-2", "convo-2", "m2", "Trail shoes", "", day),
+	)
+	_, err = index.Search(ctx, "user-1", Query{Text: "shoes"})
+	require.NoError(t, err)
+	put(t, index, message("user-3", "convo-3", "m3", "Hiking shoes", "", day))
+
+	index.mutex.Lock()
+	assert.Len(t, index.users, 2)
+	assert.NotContains(t, index.users, "user-2", "the least recently used")
+	index.mutex.Unlock()
+
+	// Evicted users are rebuilt from their file
+	put(t, index, message("user-2", "convo-2", "m4", "Trail running shoes", "", day))
+	hits, err := index.Search(ctx, "user-2", Query{Text: "shoes"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"m2", "m4"}, ids(hits))
+}
+
+func TestIndexedStores(t *testing.T) {
+	dir := t.TempDir()
+	index, err := NewFileIndex(dir, 0)
+	require.NoError(t, err)
+	convoStore, err := conversations.NewFileStore(dir)
+	require.NoError(t, err)
+	messageStore, err := messages.NewFileStore(dir)
+	require.NoError(t, err)
+	log := &logger.Logger{Logger: logrus.New()}
+	convos := NewConversations(convoStore, index, log)
+	msgs := NewMessages(messageStore, index, log)
+	ctx := context.Background()
+
+	convo := &models.Conversation{Title: "Coffee grinders"}
+	require.NoError(t, convos.Create(ctx, "user-1", convo))
+	_, err = convos.UpdateTitle(ctx, "user-1", convo.ConversationID, "Burr grinders")
+	require.NoError(t, err)
+	require.NoError(t, msgs.Save(ctx, "user-1", &models.Message{ConversationID: convo.ConversationID, MessageID: "m1", Text: "Which burr grinder is quietest?"}))
+	require.NoError(t, msgs.Save(ctx, "user-1", &models.Message{ConversationID: convo.ConversationID, MessageID: "m2", Text: "Burr failure", Error: true}))
+
+	hits, err := index.Search(ctx, "user-1", Query{Text: "burr"})
+	require.NoError(t, err)
+	require.Len(t, hits, 2, "the title and the message; failed replies are not indexed")
+	hits, err = index.Search(ctx, "user-1", Query{Text: "coffee"})
+	require.NoError(t, err)
+	assert.Empty(t, hits, "renaming replaces the old title")
+
+	require.NoError(t, convos.Delete(ctx, "user-1", convo.ConversationID))
+	hits, err = index.Search(ctx, "user-1", Query{Text: "burr"})
+	require.NoError(t, err)
+	assert.Empty(t, hits)
+}
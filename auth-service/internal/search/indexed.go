@@ -0,0 +1,96 @@
+package search
+
+import (
+	"auth-service/internal/conversations"
+	"auth-service/internal/messages"
+	"auth-service/internal/models"
+	"auth-service/pkg/logger"
+	"context"
+)
+
+// Conversations indexes conversation titles as they are written through a
+// ConversationStore. Indexing failures are logged and do not fail the write.
+type Conversations struct {
+	conversations.ConversationStore
+	index  Index
+	logger *logger.Logger
+}
+
+// NewConversations wraps store to index into index
+func NewConversations(store conversations.ConversationStore, index Index, logger *logger.Logger) *Conversations {
+	return &Conversations{ConversationStore: store, index: index, logger: logger}
+}
+
+// Create stores and indexes a new conversation
+func (s *Conversations) Create(ctx context.Context, userID string, convo *models.Conversation) error {
+	if err := s.ConversationStore.Create(ctx, userID, convo); err != nil {
+		return err
+	}
+	s.put(ctx, ConversationDocument(convo))
+	return nil
+}
+
+// UpdateTitle renames a conversation and reindexes its title
+func (s *Conversations) UpdateTitle(ctx context.Context, userID, conversationID, title string) (*models.Conversation, error) {
+	convo, err := s.ConversationStore.UpdateTitle(ctx, userID, conversationID, title)
+	if err != nil {
+		return nil, err
+	}
+	s.put(ctx, ConversationDocument(convo))
+	return convo, nil
+}
+
+// Delete removes a conversation and its documents
+func (s *Conversations) Delete(ctx context.Context, userID, conversationID string) error {
+	if err := s.ConversationStore.Delete(ctx, userID, conversationID); err != nil {
+		return err
+	}
+	if err := s.index.DeleteConversation(ctx, userID, conversationID); err != nil {
+		s.logger.WithError(err).WithField("conversation_id", conversationID).Warn("Failed to remove conversation from search index")
+	}
+	return nil
+}
+
+func (s *Conversations) put(ctx context.Context, doc Document) {
+	if err := s.index.Put(ctx, doc); err != nil {
+		s.logger.WithError(err).WithField("conversation_id", doc.ConversationID).Warn("Failed to index conversation")
+	}
+}
+
+// Messages indexes message text as it is written through a MessageStore.
+// Failed replies are not indexed: their text is a generic error.
+type Messages struct {
+	messages.MessageStore
+	index  Index
+	logger *logger.Logger
+}
+
+// NewMessages wraps store to index into index
+func NewMessages(store messages.MessageStore, index Index, logger *logger.Logger) *Messages {
+	return &Messages{MessageStore: store, index: index, logger: logger}
+}
+
+// Save stores and indexes a message
+func (s *Messages) Save(ctx context.Context, userID string, msg *models.Message) error {
+	if err := s.MessageStore.Save(ctx, userID, msg); err != nil {
+		return err
+	}
+	if msg.Error {
+		return nil
+	}
+	if err := s.index.Put(ctx, MessageDocument(msg)); err != nil {
+		s.logger.WithError(err).WithField("message_id", msg.MessageID).Warn("Failed to index message")
+	}
+	return nil
+}
+
+// DeleteConversation removes the messages of a conversation and their documents
+func (s *Messages) DeleteConversation(ctx context.Context, userID, conversationID string) error {
+	if err := s.MessageStore.DeleteConversation(ctx, userID, conversationID); err != nil {
+		return err
+	}
+	if err := s.index.DeleteConversation(ctx, userID, conversationID); err != nil {
+		s.logger.WithError(err).WithField("conversation_id", conversationID).Warn("Failed to remove messages from search index")
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+)
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Snippet size, in bytes of the original text
+const (
+	snippetBefore = 60
+	snippetLength = 200
+)
+
+// userIndex is the in-memory inverted index of one user's documents
+type userIndex struct {
+	docs map[string]*indexed
+	// postings maps each term to the documents containing it and the
+	// positions of the term in their tokens
+	postings    map[string]map[string][]int
+	totalTokens int
+}
+
+type indexed struct {
+	doc    Document
+	tokens []token
+}
+
+// match is where a clause matched one document
+type match struct {
+	freq      int
+	positions []int
+}
+
+func newUserIndex() *userIndex {
+	return &userIndex{docs: map[string]*indexed{}, postings: map[string]map[string][]int{}}
+}
+
+func (ui *userIndex) put(doc Document) {
+	ui.remove(doc.ID)
+
+	entry := &indexed{doc: doc, tokens: tokenize(doc.Text)}
+	ui.docs[doc.ID] = entry
+	ui.totalTokens += len(entry.tokens)
+	for pos, tok := range entry.tokens {
+		docs := ui.postings[tok.term]
+		if docs == nil {
+			docs = map[string][]int{}
+			ui.postings[tok.term] = docs
+		}
+		docs[doc.ID] = append(docs[doc.ID], pos)
+	}
+}
+
+func (ui *userIndex) remove(id string) {
+	entry, ok := ui.docs[id]
+	if !ok {
+		return
+	}
+	for _, tok := range entry.tokens {
+		if docs := ui.postings[tok.term]; docs != nil {
+			delete(docs, id)
+			if len(docs) == 0 {
+				delete(ui.postings, tok.term)
+			}
+		}
+	}
+	ui.totalTokens -= len(entry.tokens)
+	delete(ui.docs, id)
+}
+
+// removeConversation removes every document of a conversation and reports
+// whether there were any
+func (ui *userIndex) removeConversation(conversationID string) bool {
+	removed := false
+	for id, entry := range ui.docs {
+		if entry.doc.ConversationID == conversationID {
+			ui.remove(id)
+			removed = true
+		}
+	}
+	return removed
+}
+
+// documents returns every document, ordered by ID
+func (ui *userIndex) documents() []Document {
+	docs := make([]Document, 0, len(ui.docs))
+	for _, entry := range ui.docs {
+		docs = append(docs, entry.doc)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	return docs
+}
+
+// search returns the documents matching every clause and the filters of q,
+// ranked by BM25
+func (ui *userIndex) search(clauses []clause, q Query) []Hit {
+	matches := make([]map[string]match, len(clauses))
+	for i, cl := range clauses {
+		matches[i] = ui.match(cl)
+		if len(matches[i]) == 0 {
+			return []Hit{}
+		}
+	}
+
+	n := float64(len(ui.docs))
+	avgLen := float64(ui.totalTokens) / n
+	hits := []Hit{}
+	// The rarest clause has the fewest candidates
+	sort.Slice(matches, func(i, j int) bool { return len(matches[i]) < len(matches[j]) })
+	for id := range matches[0] {
+		entry := ui.docs[id]
+		if !q.accepts(entry.doc) {
+			continue
+		}
+
+		score := 0.0
+		marked := map[int]bool{}
+		for _, byDoc := range matches {
+			m, ok := byDoc[id]
+			if !ok {
+				score = -1
+				break
+			}
+			df := float64(len(byDoc))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			tf := float64(m.freq)
+			norm := 1 - bm25B + bm25B*float64(len(entry.tokens))/avgLen
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+			for _, pos := range m.positions {
+				marked[pos] = true
+			}
+		}
+		if score < 0 {
+			continue
+		}
+		hits = append(hits, Hit{Document: entry.doc, Score: score, Snippet: snippet(entry.doc.Text, entry.tokens, marked)})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if !hits[i].Document.CreatedAt.Equal(hits[j].Document.CreatedAt) {
+			return hits[i].Document.CreatedAt.After(hits[j].Document.CreatedAt)
+		}
+		return hits[i].Document.ID < hits[j].Document.ID
+	})
+	if len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	return hits
+}
+
+// match finds the documents matching one clause
+func (ui *userIndex) match(cl clause) map[string]match {
+	result := map[string]match{}
+	switch cl.kind {
+	case clauseTerm:
+		for id, positions := range ui.postings[cl.terms[0]] {
+			result[id] = match{freq: len(positions), positions: positions}
+		}
+	case clausePrefix:
+		for term, docs := range ui.postings {
+			if !strings.HasPrefix(term, cl.terms[0]) {
+				continue
+			}
+			for id, positions := range docs {
+				m := result[id]
+				m.freq += len(positions)
+				m.positions = append(m.positions, positions...)
+				result[id] = m
+			}
+		}
+	case clausePhrase:
+		for id, starts := range ui.postings[cl.terms[0]] {
+			tokens := ui.docs[id].tokens
+			var m match
+			for _, start := range starts {
+				if phraseAt(tokens, start, cl.terms) {
+					m.freq++
+					for k := range cl.terms {
+						m.positions = append(m.positions, start+k)
+					}
+				}
+			}
+			if m.freq > 0 {
+				result[id] = m
+			}
+		}
+	}
+	return result
+}
+
+func phraseAt(tokens []token, start int, terms []string) bool {
+	if start+len(terms) > len(tokens) {
+		return false
+	}
+	for k, term := range terms {
+		if tokens[start+k].term != term {
+			return false
+		}
+	}
+	return true
+}
+
+// accepts applies the filters of q
+func (q Query) accepts(doc Document) bool {
+	switch {
+	case q.Kind != "" && doc.Kind != q.Kind:
+		return false
+	case q.Endpoint != "" && doc.Endpoint != q.Endpoint:
+		return false
+	case !q.From.IsZero() && doc.CreatedAt.Before(q.From):
+		return false
+	case !q.To.IsZero() && doc.CreatedAt.After(q.To):
+		return false
+	}
+	return true
+}
+
+// snippet cuts the text around the first marked token, escapes it as HTML
+// and wraps the marked tokens in <mark>
+func snippet(text string, tokens []token, marked map[int]bool) string {
+	first := len(tokens)
+	for pos := range marked {
+		first = min(first, pos)
+	}
+	if first == len(tokens) {
+		return html.EscapeString(text)
+	}
+
+	// Start and end on word boundaries
+	from := first
+	for from > 0 && tokens[from-1].start >= tokens[first].start-snippetBefore {
+		from--
+	}
+	to := first
+	for to+1 < len(tokens) && tokens[to+1].end <= tokens[from].start+snippetLength {
+		to++
+	}
+	start, end := tokens[from].start, tokens[to].end
+	if from == 0 {
+		start = 0
+	}
+	if to == len(tokens)-1 {
+		end = len(text)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	cursor := start
+	for pos := from; pos <= to; pos++ {
+		if !marked[pos] {
+			continue
+		}
+		tok := tokens[pos]
+		b.WriteString(html.EscapeString(text[cursor:tok.start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[tok.start:tok.end]))
+		b.WriteString("</mark>")
+		cursor = tok.end
+	}
+	b.WriteString(html.EscapeString(text[cursor:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
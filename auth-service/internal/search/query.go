@@ -0,0 +1,123 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxClauses bounds the words, phrases and prefixes of a query
+const maxClauses = 16
+
+// minPrefix is the shortest prefix a prefix* term may have, in characters
+const minPrefix = 2
+
+type clauseKind int
+
+const (
+	clauseTerm clauseKind = iota
+	clausePrefix
+	clausePhrase
+)
+
+// clause is one part of a query that every hit must match
+type clause struct {
+	kind  clauseKind
+	terms []string
+}
+
+// token is a word of a text and its byte offsets
+type token struct {
+	term       string
+	start, end int
+}
+
+// tokenize splits text into lowercased words of letters and digits
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, token{term: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{term: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// parseQuery splits a query into clauses: "quoted phrases", words ending in
+// * as prefixes, and plain words
+func parseQuery(text string) ([]clause, error) {
+	var clauses []clause
+	add := func(kind clauseKind, terms ...string) {
+		clauses = append(clauses, clause{kind: kind, terms: terms})
+	}
+
+	for {
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+		if text == "" {
+			break
+		}
+		if rest, ok := strings.CutPrefix(text, `"`); ok {
+			phrase, after, _ := strings.Cut(rest, `"`)
+			text = after
+			terms := Terms(phrase)
+			switch len(terms) {
+			case 0:
+			case 1:
+				add(clauseTerm, terms[0])
+			default:
+				add(clausePhrase, terms...)
+			}
+			continue
+		}
+
+		end := strings.IndexFunc(text, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+		if end < 0 {
+			end = len(text)
+		}
+		word := text[:end]
+		text = text[end:]
+
+		terms := Terms(word)
+		if len(terms) == 0 {
+			continue
+		}
+		last := len(terms) - 1
+		for _, term := range terms[:last] {
+			add(clauseTerm, term)
+		}
+		if strings.HasSuffix(word, "*") {
+			if utf8.RuneCountInString(terms[last]) < minPrefix {
+				return nil, ErrInvalidQuery
+			}
+			add(clausePrefix, terms[last])
+		} else {
+			add(clauseTerm, terms[last])
+		}
+	}
+
+	if len(clauses) == 0 || len(clauses) > maxClauses {
+		return nil, ErrInvalidQuery
+	}
+	return clauses, nil
+}
+
+// Terms splits text into the lowercased words the index matches on
+func Terms(text string) []string {
+	tokens := tokenize(text)
+	terms := make([]string, len(tokens))
+	for i, tok := range tokens {
+		terms[i] = tok.term
+	}
+	return terms
+}
@@ -0,0 +1,54 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileProvider reads secrets from files, as mounted by Docker or Kubernetes.
+// For a secret NAME it reads the path in NAME_FILE if set, otherwise
+// <Dir>/<name> (lower-cased) when Dir is configured, e.g. /run/secrets.
+type FileProvider struct {
+	Dir    string
+	Lookup func(name string) (string, bool)
+}
+
+// NewFileProvider creates a file provider using the process environment for *_FILE paths
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{Dir: dir, Lookup: os.LookupEnv}
+}
+
+// GetSecret reads the secret file, trimming the trailing newline editors and
+// kubectl add to mounted values
+func (p *FileProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	lookup := p.Lookup
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	if path, ok := lookup(name + "_FILE"); ok && path != "" {
+		// An explicit *_FILE must exist
+		return readSecretFile(path)
+	}
+
+	if p.Dir == "" {
+		return "", ErrNotFound
+	}
+	value, err := readSecretFile(filepath.Join(p.Dir, strings.ToLower(name)))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	return value, err
+}
+
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file %s: %w", path, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
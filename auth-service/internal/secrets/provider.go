@@ -0,0 +1,64 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+)
+
+// ErrNotFound is returned when a provider has no value for a secret
+var ErrNotFound = errors.New("secret not found")
+
+// SecretProvider resolves secrets by name, e.g. "KEYCLOAK_CLIENT_SECRET"
+type SecretProvider interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// EnvProvider reads secrets from environment variables
+type EnvProvider struct {
+	// Lookup returns the value of a variable; defaults to os.LookupEnv
+	Lookup func(name string) (string, bool)
+}
+
+// NewEnvProvider creates a provider backed by the process environment
+func NewEnvProvider() *EnvProvider {
+	return &EnvProvider{Lookup: os.LookupEnv}
+}
+
+// GetSecret returns the value of the environment variable with the secret's name
+func (p *EnvProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	lookup := p.Lookup
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	value, ok := lookup(name)
+	if !ok || value == "" {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// ChainProvider asks each provider in turn and returns the first value found
+type ChainProvider struct {
+	providers []SecretProvider
+}
+
+// NewChainProvider creates a provider that tries providers in order
+func NewChainProvider(providers ...SecretProvider) *ChainProvider {
+	return &ChainProvider{providers: providers}
+}
+
+// GetSecret returns the first value found. Errors other than ErrNotFound stop
+// the lookup so a misconfigured provider is not silently skipped.
+func (p *ChainProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	for _, provider := range p.providers {
+		value, err := provider.GetSecret(ctx, name)
+		if err == nil {
+			return value, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return "", err
+		}
+	}
+	return "", ErrNotFound
+}
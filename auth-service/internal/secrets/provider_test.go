@@ -0,0 +1,118 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mapLookup(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	}
+}
+
+func TestEnvProvider(t *testing.T) {
+	provider := &EnvProvider{Lookup: mapLookup(map[string]string{"API_KEY": "from-env", "EMPTY": ""})}
+
+	value, err := provider.GetSecret(context.Background(), "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+
+	_, err = provider.GetSecret(context.Background(), "EMPTY")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = provider.GetSecret(context.Background(), "MISSING")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	explicit := filepath.Join(dir, "explicit.txt")
+	require.NoError(t, os.WriteFile(explicit, []byte("from-file\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mounted_secret"), []byte("from-mount"), 0o600))
+
+	provider := &FileProvider{
+		Dir: dir,
+		Lookup: mapLookup(map[string]string{
+			"API_KEY_FILE": explicit,
+			"BROKEN_FILE":  filepath.Join(dir, "missing.txt"),
+		}),
+	}
+
+	value, err := provider.GetSecret(context.Background(), "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", value, "trailing newline is trimmed")
+
+	value, err = provider.GetSecret(context.Background(), "MOUNTED_SECRET")
+	require.NoError(t, err)
+	assert.Equal(t, "from-mount", value)
+
+	_, err = provider.GetSecret(context.Background(), "OTHER")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// A *_FILE pointing at a missing file is a configuration error, not a miss
+	_, err = provider.GetSecret(context.Background(), "BROKEN")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound)
+}
+
+func TestVaultProvider(t *testing.T) {
+	requests := 0
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("X-Vault-Token") != "root-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/kv/data/auth-service" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"data":{"KEYCLOAK_CLIENT_SECRET":"from-vault"},"metadata":{"version":3}}}`))
+	}))
+	defer stub.Close()
+
+	provider := NewVaultProvider(VaultOptions{Addr: stub.URL, Token: "root-token", Mount: "kv", Path: "auth-service"})
+
+	value, err := provider.GetSecret(context.Background(), "KEYCLOAK_CLIENT_SECRET")
+	require.NoError(t, err)
+	assert.Equal(t, "from-vault", value)
+
+	_, err = provider.GetSecret(context.Background(), "KEYCLOAK_ADMIN_PASS")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 1, requests, "the KV entry is fetched once")
+
+	missing := NewVaultProvider(VaultOptions{Addr: stub.URL, Token: "root-token", Mount: "kv", Path: "other"})
+	_, err = missing.GetSecret(context.Background(), "KEYCLOAK_CLIENT_SECRET")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	denied := NewVaultProvider(VaultOptions{Addr: stub.URL, Token: "wrong", Mount: "kv", Path: "auth-service"})
+	_, err = denied.GetSecret(context.Background(), "KEYCLOAK_CLIENT_SECRET")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound)
+}
+
+func TestChainProvider(t *testing.T) {
+	first := &EnvProvider{Lookup: mapLookup(map[string]string{"A": "first"})}
+	second := &EnvProvider{Lookup: mapLookup(map[string]string{"A": "second", "B": "second"})}
+	chain := NewChainProvider(first, second)
+
+	value, err := chain.GetSecret(context.Background(), "A")
+	require.NoError(t, err)
+	assert.Equal(t, "first", value)
+
+	value, err = chain.GetSecret(context.Background(), "B")
+	require.NoError(t, err)
+	assert.Equal(t, "second", value)
+
+	_, err = chain.GetSecret(context.Background(), "C")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
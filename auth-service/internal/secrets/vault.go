@@ -0,0 +1,120 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VaultProvider reads secrets from a Vault KV v2 compatible HTTP API.
+// All secrets live as keys of a single KV entry: GET {Addr}/v1/{Mount}/data/{Path}.
+type VaultProvider struct {
+	addr      string
+	token     string
+	mount     string
+	path      string
+	namespace string
+	client    *http.Client
+
+	mutex  sync.Mutex
+	values map[string]string
+}
+
+// VaultOptions configures a VaultProvider
+type VaultOptions struct {
+	Addr      string
+	Token     string
+	Mount     string
+	Path      string
+	Namespace string
+	Timeout   time.Duration
+}
+
+// NewVaultProvider creates a Vault KV v2 provider
+func NewVaultProvider(opts VaultOptions) *VaultProvider {
+	if opts.Mount == "" {
+		opts.Mount = "secret"
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	return &VaultProvider{
+		addr:      strings.TrimRight(opts.Addr, "/"),
+		token:     opts.Token,
+		mount:     strings.Trim(opts.Mount, "/"),
+		path:      strings.Trim(opts.Path, "/"),
+		namespace: opts.Namespace,
+		client:    &http.Client{Timeout: opts.Timeout},
+	}
+}
+
+// kvResponse is the subset of the KV v2 read response we use
+type kvResponse struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+}
+
+// GetSecret returns the key with the secret's name from the KV entry.
+// The entry is fetched once and cached for the lifetime of the provider.
+func (p *VaultProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.values == nil {
+		values, err := p.fetch(ctx)
+		if err != nil {
+			return "", err
+		}
+		p.values = values
+	}
+
+	value, ok := p.values[name]
+	if !ok || value == "" {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (p *VaultProvider) fetch(ctx context.Context) (map[string]string, error) {
+	url := fmt.Sprintf("%s/v1/%s/data/%s", p.addr, p.mount, p.path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		// No entry at this path: every secret is simply absent
+		return map[string]string{}, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("vault returned status %d for %s/%s", resp.StatusCode, p.mount, p.path)
+	}
+
+	var body kvResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode vault response: %w", err)
+	}
+
+	values := make(map[string]string, len(body.Data.Data))
+	for key, value := range body.Data.Data {
+		if s, ok := value.(string); ok {
+			values[key] = s
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"auth-service/internal/cache"
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/pkg/logger"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// CachedService puts a short-lived cache in front of the Keycloak calls made
+// on every authenticated request. Token validation results are keyed by a
+// hash of the access token, profiles by user ID. Concurrent misses for the
+// same token share one Keycloak round trip.
+//
+// A token entry is only trusted together with the profile entry it was
+// cached with (matched by version), so deleting a user's profile entry on
+// profile update, password change or logout forces every cached token of
+// that user back to Keycloak.
+type CachedService struct {
+	*KeycloakService
+	cache      cache.Cache
+	group      cache.Group
+	tokenTTL   time.Duration
+	profileTTL time.Duration
+	logger     *logger.Logger
+}
+
+// tokenEntry is the cached validation result of one access token
+type tokenEntry struct {
+	UserID  string `json:"user_id"`
+	Version string `json:"version"`
+}
+
+// profileEntry is the cached profile of one user
+type profileEntry struct {
+	User    models.User `json:"user"`
+	Version string      `json:"version"`
+}
+
+// NewCachedService wraps keycloak with store
+func NewCachedService(keycloak *KeycloakService, store cache.Cache, cfg config.CacheConfig, logger *logger.Logger) *CachedService {
+	return &CachedService{
+		KeycloakService: keycloak,
+		cache:           store,
+		tokenTTL:        cfg.TokenTTL,
+		profileTTL:      cfg.ProfileTTL,
+		logger:          logger,
+	}
+}
+
+// ValidateToken returns the cached user for a recently validated token, or
+// validates it with Keycloak and caches the result
+func (s *CachedService) ValidateToken(accessToken string) (*models.User, error) {
+	ctx := context.Background()
+	key := tokenKey(accessToken)
+
+	if user, ok := s.cachedUser(ctx, key); ok {
+		return user, nil
+	}
+
+	value, err, _ := s.group.Do(key, func() (interface{}, error) {
+		user, expiresAt, err := s.KeycloakService.validateToken(accessToken)
+		if err != nil {
+			return nil, err
+		}
+		s.store(ctx, key, user, expiresAt)
+		return user, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Callers sharing a load get their own copy
+	user := *value.(*models.User)
+	return &user, nil
+}
+
+// GetUserProfile returns the profile of the token's user. The auth middleware
+// has just validated the same token, so this is normally a cache hit.
+func (s *CachedService) GetUserProfile(accessToken string) (*models.User, error) {
+	return s.ValidateToken(accessToken)
+}
+
+// UpdateUserProfile updates the profile in Keycloak and drops the cached copy
+func (s *CachedService) UpdateUserProfile(accessToken string, req *models.UpdateProfileRequest) error {
+	err := s.KeycloakService.UpdateUserProfile(accessToken, req)
+	s.invalidateToken(accessToken)
+	return err
+}
+
+// ChangePassword changes the password in Keycloak and drops the user's cached entries
+func (s *CachedService) ChangePassword(accessToken, currentPassword, newPassword string) error {
+	err := s.KeycloakService.ChangePassword(accessToken, currentPassword, newPassword)
+	s.invalidateToken(accessToken)
+	return err
+}
+
+// Logout ends the Keycloak session and drops the user's cached entries, so
+// the session's access tokens are checked with Keycloak again
+func (s *CachedService) Logout(refreshToken string) error {
+	err := s.KeycloakService.Logout(refreshToken)
+	if userID := tokenSubject(refreshToken); userID != "" {
+		s.invalidateUser(context.Background(), userID)
+	}
+	return err
+}
+
+// cachedUser returns the user for a cached token entry whose profile entry
+// is still present and has the same version
+func (s *CachedService) cachedUser(ctx context.Context, key string) (*models.User, bool) {
+	var token tokenEntry
+	if ok, err := cache.GetJSON(ctx, s.cache, key, &token); err != nil || !ok {
+		s.logError(err, "Failed to read token cache")
+		return nil, false
+	}
+
+	var profile profileEntry
+	if ok, err := cache.GetJSON(ctx, s.cache, profileKey(token.UserID), &profile); err != nil || !ok {
+		s.logError(err, "Failed to read profile cache")
+		return nil, false
+	}
+	if profile.Version != token.Version {
+		return nil, false
+	}
+	return &profile.User, true
+}
+
+// store caches a validation result. An existing profile entry keeps its
+// version so the user's other cached tokens stay valid.
+func (s *CachedService) store(ctx context.Context, key string, user *models.User, expiresAt time.Time) {
+	var profile profileEntry
+	if ok, _ := cache.GetJSON(ctx, s.cache, profileKey(user.ID), &profile); !ok || profile.Version == "" {
+		profile.Version = newVersion()
+	}
+	profile.User = *user
+
+	tokenTTL := s.tokenTTL
+	if !expiresAt.IsZero() && time.Until(expiresAt) < tokenTTL {
+		tokenTTL = time.Until(expiresAt)
+	}
+
+	err := cache.SetJSON(ctx, s.cache, profileKey(user.ID), profile, s.profileTTL)
+	if err == nil {
+		err = cache.SetJSON(ctx, s.cache, key, tokenEntry{UserID: user.ID, Version: profile.Version}, tokenTTL)
+	}
+	s.logError(err, "Failed to write auth cache")
+}
+
+// invalidateToken drops the cached entries of the token's user
+func (s *CachedService) invalidateToken(accessToken string) {
+	ctx := context.Background()
+	key := tokenKey(accessToken)
+
+	var token tokenEntry
+	ok, _ := cache.GetJSON(ctx, s.cache, key, &token)
+	userID := token.UserID
+	if !ok {
+		userID = tokenSubject(accessToken)
+	}
+
+	s.logError(s.cache.Delete(ctx, key), "Failed to invalidate token cache")
+	if userID != "" {
+		s.invalidateUser(ctx, userID)
+	}
+}
+
+// invalidateUser drops the user's profile entry, which also invalidates every
+// token entry cached with it
+func (s *CachedService) invalidateUser(ctx context.Context, userID string) {
+	s.logError(s.cache.Delete(ctx, profileKey(userID)), "Failed to invalidate profile cache")
+}
+
+// logError logs cache failures; the cache is best effort and falls back to Keycloak
+func (s *CachedService) logError(err error, msg string) {
+	if err != nil {
+		s.logger.WithError(err).Warn(msg)
+	}
+}
+
+func tokenKey(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return "token:" + hex.EncodeToString(sum[:])
+}
+
+func profileKey(userID string) string {
+	return "profile:" + userID
+}
+
+func newVersion() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"auth-service/internal/cache"
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/pkg/logger"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keycloakStub counts introspection and userinfo calls and serves a single
+// user, whose first name can be changed between calls
+type keycloakStub struct {
+	introspections int32
+	userinfos      int32
+	active         atomic.Bool
+	mutex          sync.Mutex
+	firstName      string
+}
+
+func (s *keycloakStub) handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/token/introspect"):
+		atomic.AddInt32(&s.introspections, 1)
+		if !s.active.Load() {
+			_, _ = w.Write([]byte(`{"active":false}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"active":true,"exp":` + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10) + `}`))
+	case strings.HasSuffix(r.URL.Path, "/userinfo"):
+		atomic.AddInt32(&s.userinfos, 1)
+		time.Sleep(10 * time.Millisecond) // keep loads in flight long enough to overlap
+		s.mutex.Lock()
+		name := s.firstName
+		s.mutex.Unlock()
+		_, _ = w.Write([]byte(`{"sub":"user-1","preferred_username":"jdoe","email":"j@example.com","given_name":"` + name + `","family_name":"Doe"}`))
+	case strings.HasSuffix(r.URL.Path, "/logout"):
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newCachedTestService(t *testing.T) (*CachedService, *keycloakStub) {
+	stub := &keycloakStub{firstName: "John"}
+	stub.active.Store(true)
+	k := newTestService(t, stub.handle)
+	cfg := config.CacheConfig{TokenTTL: time.Minute, ProfileTTL: time.Minute}
+	return NewCachedService(k, cache.NewMemoryCache(100), cfg, &logger.Logger{Logger: logrus.New()}), stub
+}
+
+func TestCachedService_ValidateTokenCachesResult(t *testing.T) {
+	s, stub := newCachedTestService(t)
+
+	user, err := s.ValidateToken("token-a")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", user.ID)
+
+	// The profile lookup on the same request is served from the cache
+	profile, err := s.GetUserProfile("token-a")
+	require.NoError(t, err)
+	assert.Equal(t, "John", profile.FirstName)
+	assert.Equal(t, int32(1), stub.introspections)
+	assert.Equal(t, int32(1), stub.userinfos)
+
+	// A different token is validated on its own
+	_, err = s.ValidateToken("token-b")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), stub.introspections)
+}
+
+func TestCachedService_SingleFlight(t *testing.T) {
+	s, stub := newCachedTestService(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := s.ValidateToken("token-a")
+			assert.NoError(t, err)
+			assert.Equal(t, "jdoe", user.Username)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), stub.introspections)
+}
+
+func TestCachedService_UpdateProfileInvalidates(t *testing.T) {
+	s, stub := newCachedTestService(t)
+
+	_, err := s.ValidateToken("token-a")
+	require.NoError(t, err)
+	_, err = s.ValidateToken("token-b")
+	require.NoError(t, err)
+
+	// The admin update itself fails against the stub; the cache is dropped anyway
+	stub.mutex.Lock()
+	stub.firstName = "Johnny"
+	stub.mutex.Unlock()
+	_ = s.UpdateUserProfile("token-a", &models.UpdateProfileRequest{FirstName: "Johnny"})
+
+	// Every token of the user is checked with Keycloak again
+	profile, err := s.GetUserProfile("token-b")
+	require.NoError(t, err)
+	assert.Equal(t, "Johnny", profile.FirstName)
+	assert.Equal(t, int32(3), stub.introspections)
+}
+
+func TestCachedService_LogoutRevokesCachedTokens(t *testing.T) {
+	s, stub := newCachedTestService(t)
+
+	_, err := s.ValidateToken("token-a")
+	require.NoError(t, err)
+
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user-1"}`))
+	stub.active.Store(false)
+	require.NoError(t, s.Logout("header."+claims+".signature"))
+
+	_, err = s.ValidateToken("token-a")
+	assert.ErrorIs(t, err, ErrTokenExpired)
+}
+
+func TestTokenSubject(t *testing.T) {
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"abc","typ":"Refresh"}`))
+	assert.Equal(t, "abc", tokenSubject("x."+claims+".y"))
+	assert.Equal(t, "", tokenSubject("opaque-token"))
+	assert.Equal(t, "", tokenSubject("x.!!!.y"))
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// tokenClaims are the Keycloak access and refresh token claims the service reads
+type tokenClaims struct {
+	Subject     string `json:"sub"`
+	RealmAccess struct {
+		Roles []string `json:"roles"`
+	} `json:"realm_access"`
+	ResourceAccess map[string]struct {
+		Roles []string `json:"roles"`
+	} `json:"resource_access"`
+}
+
+// decodeClaims reads the payload of a JWT without verifying it. Callers must
+// only trust the result for tokens Keycloak has already accepted, or use it
+// for nothing security-relevant.
+func decodeClaims(token string) (tokenClaims, bool) {
+	var claims tokenClaims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return claims, false
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, false
+	}
+	return claims, true
+}
+
+// tokenSubject reads the "sub" claim of a JWT. It is only used to pick cache
+// entries to delete, never to authenticate.
+func tokenSubject(token string) string {
+	claims, _ := decodeClaims(token)
+	return claims.Subject
+}
+
+// tokenRoles returns the realm roles and the client's roles granted in an
+// introspected access token, sorted and without duplicates
+func tokenRoles(token, clientID string) []string {
+	claims, ok := decodeClaims(token)
+	if !ok {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var roles []string
+	add := func(list []string) {
+		for _, role := range list {
+			if !seen[role] {
+				seen[role] = true
+				roles = append(roles, role)
+			}
+		}
+	}
+	add(claims.RealmAccess.Roles)
+	add(claims.ResourceAccess[clientID].Roles)
+	sort.Strings(roles)
+	return roles
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"auth-service/internal/resilience"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Nerzal/gocloak/v13"
+)
+
+// Domain errors returned by KeycloakService. They are wrapped together with
+// the underlying gocloak error, so match them with errors.Is.
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrAccountDisabled    = errors.New("account disabled")
+	ErrUserExists         = errors.New("user already exists")
+	ErrTokenExpired       = errors.New("token expired or revoked")
+	ErrIdPUnavailable     = errors.New("identity provider unavailable")
+	ErrRateLimited        = errors.New("identity provider rate limit exceeded")
+)
+
+// keycloakError classifies a gocloak error into a domain error. Rejected
+// grants (400/401) that are not more specific map to fallback; without a
+// fallback, or for other statuses, the error is only annotated with op.
+func keycloakError(op string, err error, fallback error) error {
+	var apiErr *gocloak.APIError
+	if !errors.As(err, &apiErr) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	kind := classifyAPIError(apiErr, fallback)
+	if kind == nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return fmt.Errorf("%s: %w: %w", op, kind, err)
+}
+
+// isUnavailable reports whether err means Keycloak could not serve the call
+// (unreachable, timed out or failing). Only these count against the circuit
+// breaker and are retried; a rejected grant means Keycloak is healthy.
+func isUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if _, open := resilience.RetryAfter(err); open {
+		return false
+	}
+	var apiErr *gocloak.APIError
+	if errors.As(err, &apiErr) {
+		return classifyAPIError(apiErr, nil) == ErrIdPUnavailable
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+func classifyAPIError(apiErr *gocloak.APIError, fallback error) error {
+	message := strings.ToLower(apiErr.Message)
+
+	switch {
+	case apiErr.Code == 0 || apiErr.Code >= http.StatusInternalServerError:
+		// Code 0 means the request never got a response
+		return ErrIdPUnavailable
+	case apiErr.Code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case apiErr.Code == http.StatusConflict:
+		return ErrUserExists
+	case apiErr.Code != http.StatusBadRequest && apiErr.Code != http.StatusUnauthorized:
+		return nil
+	case strings.Contains(message, "account disabled") || strings.Contains(message, "user is disabled"):
+		return ErrAccountDisabled
+	case strings.Contains(message, "not active") || strings.Contains(message, "expired"):
+		return ErrTokenExpired
+	}
+	return fallback
+}
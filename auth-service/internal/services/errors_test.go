@@ -0,0 +1,48 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Nerzal/gocloak/v13"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeycloakError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		fallback error
+		expected error
+	}{
+		{"invalid credentials", &gocloak.APIError{Code: 401, Message: "401 Unauthorized: invalid_grant: Invalid user credentials"}, ErrInvalidCredentials, ErrInvalidCredentials},
+		{"account disabled", &gocloak.APIError{Code: 400, Message: "400 Bad Request: invalid_grant: Account disabled"}, ErrInvalidCredentials, ErrAccountDisabled},
+		{"session expired", &gocloak.APIError{Code: 400, Message: "400 Bad Request: invalid_grant: Session not active"}, ErrInvalidCredentials, ErrTokenExpired},
+		{"user exists", &gocloak.APIError{Code: 409, Message: "409 Conflict: User exists with same username"}, nil, ErrUserExists},
+		{"rate limited", &gocloak.APIError{Code: 429, Message: "429 Too Many Requests"}, nil, ErrRateLimited},
+		{"server error", &gocloak.APIError{Code: 502, Message: "502 Bad Gateway"}, ErrInvalidCredentials, ErrIdPUnavailable},
+		{"connection refused", &gocloak.APIError{Code: 0, Message: "could not get token: connection refused"}, nil, ErrIdPUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := keycloakError("op", tt.err, tt.fallback)
+			assert.ErrorIs(t, err, tt.expected)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestKeycloakError_Unclassified(t *testing.T) {
+	domainErrors := []error{ErrInvalidCredentials, ErrAccountDisabled, ErrUserExists, ErrTokenExpired, ErrIdPUnavailable, ErrRateLimited}
+
+	for _, err := range []error{
+		keycloakError("get realm", &gocloak.APIError{Code: 403, Message: "403 Forbidden"}, ErrInvalidCredentials),
+		keycloakError("update user", &gocloak.APIError{Code: 400, Message: "400 Bad Request: invalid email"}, nil),
+		keycloakError("login", errors.New("unexpected"), ErrInvalidCredentials),
+	} {
+		for _, domainErr := range domainErrors {
+			assert.False(t, errors.Is(err, domainErr), "%v should not match %v", err, domainErr)
+		}
+	}
+}
@@ -3,22 +3,29 @@ package services
 import (
 	"auth-service/internal/config"
 	"auth-service/internal/models"
+	"auth-service/internal/resilience"
 	"auth-service/pkg/logger"
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/Nerzal/gocloak/v13"
 )
 
+// KeycloakBreaker is the name of the circuit breaker guarding Keycloak calls
+const KeycloakBreaker = "keycloak"
+
 // KeycloakService handles all Keycloak operations
 type KeycloakService struct {
 	client   *gocloak.GoCloak
 	cfg      config.KeycloakConfig
 	ctx      context.Context
 	logger   *logger.Logger
+	breaker  *resilience.Breaker
 }
 
-// NewKeycloakService creates a new Keycloak service instance
+// NewKeycloakService creates a new Keycloak service instance.
+// All instances share one circuit breaker.
 func NewKeycloakService(cfg config.KeycloakConfig, logger *logger.Logger) *KeycloakService {
 	client := gocloak.NewClient(cfg.URL)
 	ctx := context.Background()
@@ -28,22 +35,32 @@ func NewKeycloakService(cfg config.KeycloakConfig, logger *logger.Logger) *Keycl
 		cfg:    cfg,
 		ctx:    ctx,
 		logger: logger,
+		breaker: resilience.Breakers.Breaker(KeycloakBreaker, resilience.BreakerSettings{
+			Threshold:      cfg.Resilience.BreakerThreshold,
+			Cooldown:       cfg.Resilience.BreakerCooldown,
+			HalfOpenProbes: cfg.Resilience.BreakerProbes,
+			IsFailure:      isUnavailable,
+		}),
 	}
 }
 
 // Login authenticates a user and returns tokens
 func (k *KeycloakService) Login(username, password string) (*models.AuthResponse, error) {
-	token, err := k.client.Login(k.ctx, k.cfg.ClientID, k.cfg.ClientSecret, k.cfg.Realm, username, password)
+	var token *gocloak.JWT
+	err := k.call("login", grantCall, ErrInvalidCredentials, func(ctx context.Context) (err error) {
+		token, err = k.client.Login(ctx, k.cfg.ClientID, k.cfg.ClientSecret.Value(), k.cfg.Realm, username, password)
+		return err
+	})
 	if err != nil {
 		k.logger.WithError(err).Error("Failed to login user")
-		return nil, fmt.Errorf("invalid credentials")
+		return nil, err
 	}
 
 	// Get user info
-	userInfo, err := k.client.GetUserInfo(k.ctx, token.AccessToken, k.cfg.Realm)
+	userInfo, err := k.userInfo(token.AccessToken)
 	if err != nil {
 		k.logger.WithError(err).Error("Failed to get user info")
-		return nil, fmt.Errorf("failed to get user info")
+		return nil, err
 	}
 
 	// Convert to our user model
@@ -57,32 +74,20 @@ func (k *KeycloakService) Login(username, password string) (*models.AuthResponse
 	}
 
 	return &models.AuthResponse{
-		AccessToken:  token.AccessToken,
-		RefreshToken: token.RefreshToken,
-		TokenType:    token.TokenType,
-		ExpiresIn:    int(token.ExpiresIn),
-		User:         user,
+		AccessToken:      token.AccessToken,
+		RefreshToken:     token.RefreshToken,
+		TokenType:        token.TokenType,
+		ExpiresIn:        int(token.ExpiresIn),
+		RefreshExpiresIn: int(token.RefreshExpiresIn),
+		User:             user,
 	}, nil
 }
 
 // Register creates a new user in Keycloak
 func (k *KeycloakService) Register(req *models.RegisterRequest) error {
-	// Get admin token using admin-cli
-	adminRealm := k.cfg.AdminRealm
-	if adminRealm == "" {
-		adminRealm = "master" // fallback to master realm
-	}
-	
-	// Use admin-cli for admin operations
-	adminClientID := k.cfg.AdminClientID
-	if adminClientID == "" {
-		adminClientID = "admin-cli" // fallback to admin-cli
-	}
-	
-	adminToken, err := k.client.Login(k.ctx, adminClientID, "", adminRealm, k.cfg.AdminUser, k.cfg.AdminPass)
+	adminToken, err := k.adminLogin()
 	if err != nil {
-		k.logger.WithError(err).Error("Failed to get admin token")
-		return fmt.Errorf("failed to authenticate admin")
+		return err
 	}
 
 	// Create user representation
@@ -102,17 +107,23 @@ func (k *KeycloakService) Register(req *models.RegisterRequest) error {
 	}
 
 	// Create user
-	userID, err := k.client.CreateUser(k.ctx, adminToken.AccessToken, k.cfg.Realm, user)
+	var userID string
+	err = k.call("create user", writeCall, nil, func(ctx context.Context) (err error) {
+		userID, err = k.client.CreateUser(ctx, adminToken.AccessToken, k.cfg.Realm, user)
+		return err
+	})
 	if err != nil {
 		k.logger.WithError(err).Error("Failed to create user")
-		return fmt.Errorf("failed to create user: %w", err)
+		return err
 	}
 
 	// Set password for the user
-	err = k.client.SetPassword(k.ctx, adminToken.AccessToken, userID, k.cfg.Realm, req.Password, false)
+	err = k.call("set password", writeCall, nil, func(ctx context.Context) error {
+		return k.client.SetPassword(ctx, adminToken.AccessToken, userID, k.cfg.Realm, req.Password, false)
+	})
 	if err != nil {
 		k.logger.WithError(err).Error("Failed to set password")
-		return fmt.Errorf("failed to set password")
+		return err
 	}
 
 	k.logger.WithField("user_id", userID).Info("User created successfully")
@@ -121,17 +132,22 @@ func (k *KeycloakService) Register(req *models.RegisterRequest) error {
 
 // RefreshToken refreshes an access token using refresh token
 func (k *KeycloakService) RefreshToken(refreshToken string) (*models.AuthResponse, error) {
-	token, err := k.client.RefreshToken(k.ctx, refreshToken, k.cfg.ClientID, k.cfg.ClientSecret, k.cfg.Realm)
+	// Refresh tokens rotate, so a refresh is never retried
+	var token *gocloak.JWT
+	err := k.call("refresh token", grantCall, ErrTokenExpired, func(ctx context.Context) (err error) {
+		token, err = k.client.RefreshToken(ctx, refreshToken, k.cfg.ClientID, k.cfg.ClientSecret.Value(), k.cfg.Realm)
+		return err
+	})
 	if err != nil {
 		k.logger.WithError(err).Error("Failed to refresh token")
-		return nil, fmt.Errorf("invalid refresh token")
+		return nil, err
 	}
 
 	// Get user info
-	userInfo, err := k.client.GetUserInfo(k.ctx, token.AccessToken, k.cfg.Realm)
+	userInfo, err := k.userInfo(token.AccessToken)
 	if err != nil {
 		k.logger.WithError(err).Error("Failed to get user info")
-		return nil, fmt.Errorf("failed to get user info")
+		return nil, err
 	}
 
 	// Convert to our user model
@@ -145,30 +161,33 @@ func (k *KeycloakService) RefreshToken(refreshToken string) (*models.AuthRespons
 	}
 
 	return &models.AuthResponse{
-		AccessToken:  token.AccessToken,
-		RefreshToken: token.RefreshToken,
-		TokenType:    token.TokenType,
-		ExpiresIn:    int(token.ExpiresIn),
-		User:         user,
+		AccessToken:      token.AccessToken,
+		RefreshToken:     token.RefreshToken,
+		TokenType:        token.TokenType,
+		ExpiresIn:        int(token.ExpiresIn),
+		RefreshExpiresIn: int(token.RefreshExpiresIn),
+		User:             user,
 	}, nil
 }
 
 // Logout logs out a user by invalidating their refresh token
 func (k *KeycloakService) Logout(refreshToken string) error {
-	err := k.client.Logout(k.ctx, k.cfg.ClientID, k.cfg.ClientSecret, k.cfg.Realm, refreshToken)
+	err := k.call("logout", writeCall, ErrTokenExpired, func(ctx context.Context) error {
+		return k.client.Logout(ctx, k.cfg.ClientID, k.cfg.ClientSecret.Value(), k.cfg.Realm, refreshToken)
+	})
 	if err != nil {
 		k.logger.WithError(err).Error("Failed to logout user")
-		return fmt.Errorf("failed to logout")
+		return err
 	}
 	return nil
 }
 
 // GetUserProfile retrieves user profile information
 func (k *KeycloakService) GetUserProfile(accessToken string) (*models.User, error) {
-	userInfo, err := k.client.GetUserInfo(k.ctx, accessToken, k.cfg.Realm)
+	userInfo, err := k.userInfo(accessToken)
 	if err != nil {
 		k.logger.WithError(err).Error("Failed to get user profile")
-		return nil, fmt.Errorf("failed to get user profile")
+		return nil, err
 	}
 
 	return &models.User{
@@ -184,28 +203,15 @@ func (k *KeycloakService) GetUserProfile(accessToken string) (*models.User, erro
 // UpdateUserProfile updates user profile information
 func (k *KeycloakService) UpdateUserProfile(accessToken string, req *models.UpdateProfileRequest) error {
 	// Get user info to get user ID
-	userInfo, err := k.client.GetUserInfo(k.ctx, accessToken, k.cfg.Realm)
+	userInfo, err := k.userInfo(accessToken)
 	if err != nil {
 		k.logger.WithError(err).Error("Failed to get user info")
-		return fmt.Errorf("failed to get user info")
+		return err
 	}
 
-	// Get admin token for user update
-	adminRealm := k.cfg.AdminRealm
-	if adminRealm == "" {
-		adminRealm = "master" // fallback to master realm
-	}
-	
-	// Use admin-cli for admin operations
-	adminClientID := k.cfg.AdminClientID
-	if adminClientID == "" {
-		adminClientID = "admin-cli" // fallback to admin-cli
-	}
-	
-	adminToken, err := k.client.Login(k.ctx, adminClientID, "", adminRealm, k.cfg.AdminUser, k.cfg.AdminPass)
+	adminToken, err := k.adminLogin()
 	if err != nil {
-		k.logger.WithError(err).Error("Failed to get admin token")
-		return fmt.Errorf("failed to authenticate admin")
+		return err
 	}
 
 	// Update user
@@ -216,10 +222,12 @@ func (k *KeycloakService) UpdateUserProfile(accessToken string, req *models.Upda
 		Email:     &req.Email,
 	}
 
-	err = k.client.UpdateUser(k.ctx, adminToken.AccessToken, k.cfg.Realm, user)
+	err = k.call("update user profile", writeCall, nil, func(ctx context.Context) error {
+		return k.client.UpdateUser(ctx, adminToken.AccessToken, k.cfg.Realm, user)
+	})
 	if err != nil {
 		k.logger.WithError(err).Error("Failed to update user profile")
-		return fmt.Errorf("failed to update user profile")
+		return err
 	}
 
 	return nil
@@ -228,36 +236,218 @@ func (k *KeycloakService) UpdateUserProfile(accessToken string, req *models.Upda
 // ChangePassword changes user password
 func (k *KeycloakService) ChangePassword(accessToken, currentPassword, newPassword string) error {
 	// Get user info to get user ID
-	userInfo, err := k.client.GetUserInfo(k.ctx, accessToken, k.cfg.Realm)
+	userInfo, err := k.userInfo(accessToken)
 	if err != nil {
 		k.logger.WithError(err).Error("Failed to get user info")
-		return fmt.Errorf("failed to get user info")
+		return err
+	}
+
+	adminToken, err := k.adminLogin()
+	if err != nil {
+		return err
+	}
+
+	// Set new password
+	err = k.call("change password", writeCall, nil, func(ctx context.Context) error {
+		return k.client.SetPassword(ctx, adminToken.AccessToken, *userInfo.Sub, k.cfg.Realm, newPassword, false)
+	})
+	if err != nil {
+		k.logger.WithError(err).Error("Failed to change password")
+		return err
+	}
+
+	return nil
+}
+
+// SendPasswordReset emails the user a Keycloak "update password" action link.
+// Unknown addresses are ignored so callers cannot probe for accounts.
+func (k *KeycloakService) SendPasswordReset(email string) error {
+	adminToken, err := k.adminLogin()
+	if err != nil {
+		return err
+	}
+
+	var users []*gocloak.User
+	err = k.call("look up user", readCall, nil, func(ctx context.Context) (err error) {
+		users, err = k.client.GetUsers(ctx, adminToken.AccessToken, k.cfg.Realm, gocloak.GetUsersParams{
+			Email: &email,
+			Exact: gocloak.BoolP(true),
+		})
+		return err
+	})
+	if err != nil {
+		k.logger.WithError(err).Error("Failed to look up user by email")
+		return err
+	}
+	if len(users) == 0 {
+		return nil
+	}
+
+	err = k.call("send password reset email", writeCall, nil, func(ctx context.Context) error {
+		return k.client.ExecuteActionsEmail(ctx, adminToken.AccessToken, k.cfg.Realm, gocloak.ExecuteActionsEmail{
+			UserID:   users[0].ID,
+			ClientID: gocloak.StringP(k.cfg.ClientID),
+			Actions:  &[]string{"UPDATE_PASSWORD"},
+		})
+	})
+	if err != nil {
+		k.logger.WithError(err).Error("Failed to send password reset email")
+		return err
+	}
+	return nil
+}
+
+// SyncPasswordPolicy sets the realm password policy (Keycloak policy syntax),
+// so passwords set through Keycloak itself follow the same rules
+func (k *KeycloakService) SyncPasswordPolicy(policy string) error {
+	adminToken, err := k.adminLogin()
+	if err != nil {
+		return err
+	}
+
+	var realm *gocloak.RealmRepresentation
+	err = k.call("get realm", readCall, nil, func(ctx context.Context) (err error) {
+		realm, err = k.client.GetRealm(ctx, adminToken.AccessToken, k.cfg.Realm)
+		return err
+	})
+	if err != nil {
+		k.logger.WithError(err).Error("Failed to get realm")
+		return err
+	}
+	if realm.PasswordPolicy != nil && *realm.PasswordPolicy == policy {
+		return nil
 	}
 
-	// Get admin token for password change
+	realm.PasswordPolicy = &policy
+	err = k.call("update realm password policy", writeCall, nil, func(ctx context.Context) error {
+		return k.client.UpdateRealm(ctx, adminToken.AccessToken, *realm)
+	})
+	if err != nil {
+		k.logger.WithError(err).Error("Failed to update realm password policy")
+		return err
+	}
+	return nil
+}
+
+// adminLogin gets an admin token for realm management operations
+func (k *KeycloakService) adminLogin() (*gocloak.JWT, error) {
 	adminRealm := k.cfg.AdminRealm
 	if adminRealm == "" {
 		adminRealm = "master" // fallback to master realm
 	}
-	
-	// Use admin-cli for admin operations
 	adminClientID := k.cfg.AdminClientID
 	if adminClientID == "" {
 		adminClientID = "admin-cli" // fallback to admin-cli
 	}
-	
-	adminToken, err := k.client.Login(k.ctx, adminClientID, "", adminRealm, k.cfg.AdminUser, k.cfg.AdminPass)
+
+	var adminToken *gocloak.JWT
+	err := k.call("authenticate admin", grantCall, nil, func(ctx context.Context) (err error) {
+		adminToken, err = k.client.Login(ctx, adminClientID, "", adminRealm, k.cfg.AdminUser, k.cfg.AdminPass.Value())
+		return err
+	})
 	if err != nil {
 		k.logger.WithError(err).Error("Failed to get admin token")
-		return fmt.Errorf("failed to authenticate admin")
+		return nil, err
 	}
+	return adminToken, nil
+}
 
-	// Set new password
-	err = k.client.SetPassword(k.ctx, adminToken.AccessToken, *userInfo.Sub, k.cfg.Realm, newPassword, false)
+// ValidateToken introspects an access token and returns its user.
+// Inactive tokens yield ErrTokenExpired.
+func (k *KeycloakService) ValidateToken(accessToken string) (*models.User, error) {
+	user, _, err := k.validateToken(accessToken)
+	return user, err
+}
+
+// validateToken is ValidateToken that also returns the token expiry
+// (zero if Keycloak did not report one)
+func (k *KeycloakService) validateToken(accessToken string) (*models.User, time.Time, error) {
+	var result *gocloak.IntroSpectTokenResult
+	err := k.call("introspect token", readCall, ErrTokenExpired, func(ctx context.Context) (err error) {
+		result, err = k.client.RetrospectToken(ctx, accessToken, k.cfg.ClientID, k.cfg.ClientSecret.Value(), k.cfg.Realm)
+		return err
+	})
 	if err != nil {
-		k.logger.WithError(err).Error("Failed to change password")
-		return fmt.Errorf("failed to change password")
+		return nil, time.Time{}, err
+	}
+	if result.Active == nil || !*result.Active {
+		return nil, time.Time{}, ErrTokenExpired
+	}
+	var expiresAt time.Time
+	if result.Exp != nil {
+		expiresAt = time.Unix(int64(*result.Exp), 0)
 	}
 
-	return nil
+	userInfo, err := k.userInfo(accessToken)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return &models.User{
+		ID:        gocloak.PString(userInfo.Sub),
+		Username:  gocloak.PString(userInfo.PreferredUsername),
+		Email:     gocloak.PString(userInfo.Email),
+		FirstName: gocloak.PString(userInfo.GivenName),
+		LastName:  gocloak.PString(userInfo.FamilyName),
+		Enabled:   true,
+		Roles:     tokenRoles(accessToken, k.cfg.ClientID),
+	}, expiresAt, nil
+}
+
+// userInfo fetches the userinfo for an access token
+func (k *KeycloakService) userInfo(accessToken string) (*gocloak.UserInfo, error) {
+	var userInfo *gocloak.UserInfo
+	err := k.call("get user info", readCall, ErrTokenExpired, func(ctx context.Context) (err error) {
+		userInfo, err = k.client.GetUserInfo(ctx, accessToken, k.cfg.Realm)
+		return err
+	})
+	return userInfo, err
+}
+
+// callKind selects the timeout and retry behavior of an identity provider call
+type callKind int
+
+const (
+	// readCall is idempotent: retried with jittered backoff
+	readCall callKind = iota
+	// writeCall changes state: never retried
+	writeCall
+	// grantCall exchanges credentials or a refresh token: longer timeout, never retried
+	grantCall
+)
+
+// call runs fn through the circuit breaker with a per-attempt timeout,
+// retrying idempotent calls when Keycloak is unavailable. Failures are
+// classified into domain errors; rejections by the open breaker wrap both
+// ErrIdPUnavailable and a *resilience.OpenError carrying the retry delay.
+func (k *KeycloakService) call(op string, kind callKind, fallback error, fn func(ctx context.Context) error) error {
+	timeout := k.cfg.Resilience.Timeout
+	var policy resilience.RetryPolicy
+	switch kind {
+	case readCall:
+		policy = resilience.RetryPolicy{
+			MaxRetries: k.cfg.Resilience.MaxRetries,
+			Backoff:    k.cfg.Resilience.RetryBackoff,
+			MaxBackoff: timeout,
+		}
+	case grantCall:
+		timeout = k.cfg.Resilience.LoginTimeout
+	}
+
+	err := resilience.Retry(k.ctx, policy, isUnavailable, func(ctx context.Context) error {
+		return k.breaker.Execute(func() error {
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			return fn(ctx)
+		})
+	})
+	if err == nil {
+		return nil
+	}
+	if _, open := resilience.RetryAfter(err); open {
+		return fmt.Errorf("%s: %w: %w", op, ErrIdPUnavailable, err)
+	}
+	return keycloakError(op, err, fallback)
 }
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/resilience"
+	"auth-service/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestService points a KeycloakService at a stub server and gives it its
+// own breaker, so tests do not share the process-wide one
+func newTestService(t *testing.T, handler http.HandlerFunc) *KeycloakService {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg := config.KeycloakConfig{
+		URL:      server.URL,
+		Realm:    "test",
+		ClientID: "auth-service",
+		Resilience: config.ResilienceConfig{
+			Timeout:          time.Second,
+			LoginTimeout:     time.Second,
+			MaxRetries:       2,
+			RetryBackoff:     time.Millisecond,
+			BreakerThreshold: 4,
+			BreakerCooldown:  time.Minute,
+			BreakerProbes:    1,
+		},
+	}
+	k := NewKeycloakService(cfg, &logger.Logger{Logger: logrus.New()})
+	k.breaker = resilience.NewBreaker("test", resilience.BreakerSettings{
+		Threshold:      cfg.Resilience.BreakerThreshold,
+		Cooldown:       cfg.Resilience.BreakerCooldown,
+		HalfOpenProbes: cfg.Resilience.BreakerProbes,
+		IsFailure:      isUnavailable,
+	})
+	return k
+}
+
+func TestKeycloakService_RetriesIdempotentCalls(t *testing.T) {
+	var calls int32
+	k := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasSuffix(r.URL.Path, "/userinfo"))
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"sub":"user-id","preferred_username":"jdoe","email":"j@example.com","given_name":"J","family_name":"Doe"}`))
+	})
+
+	user, err := k.GetUserProfile("access-token")
+	assert.NoError(t, err)
+	assert.Equal(t, "jdoe", user.Username)
+	assert.Equal(t, int32(3), calls)
+}
+
+func TestKeycloakService_NeverRetriesLogin(t *testing.T) {
+	var calls int32
+	k := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := k.Login("jdoe", "secret")
+	assert.ErrorIs(t, err, ErrIdPUnavailable)
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestKeycloakService_DoesNotRetryRejectedGrants(t *testing.T) {
+	var calls int32
+	k := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"Invalid user credentials"}`))
+	})
+
+	for i := 0; i < 10; i++ {
+		_, err := k.Login("jdoe", "wrong")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	assert.Equal(t, int32(10), calls)
+	assert.Equal(t, resilience.StateClosed, k.breaker.State())
+}
+
+func TestKeycloakService_OpenBreakerFailsFast(t *testing.T) {
+	var calls int32
+	k := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	// Three attempts for the first call, the fourth failure opens the breaker
+	_, err := k.GetUserProfile("access-token")
+	assert.ErrorIs(t, err, ErrIdPUnavailable)
+	_, err = k.Login("jdoe", "secret")
+	assert.ErrorIs(t, err, ErrIdPUnavailable)
+	assert.Equal(t, int32(4), calls)
+	assert.Equal(t, resilience.StateOpen, k.breaker.State())
+
+	_, err = k.Login("jdoe", "secret")
+	assert.ErrorIs(t, err, ErrIdPUnavailable)
+	seconds, open := resilience.RetryAfterSeconds(err)
+	assert.True(t, open)
+	assert.Equal(t, 60, seconds)
+	assert.Equal(t, int32(4), calls, "open breaker must not reach Keycloak")
+}
+
+func TestKeycloakService_TimesOutSlowCalls(t *testing.T) {
+	release := make(chan struct{})
+	k := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+	t.Cleanup(func() { close(release) })
+	k.cfg.Resilience.LoginTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	_, err := k.Login("jdoe", "secret")
+	assert.ErrorIs(t, err, ErrIdPUnavailable)
+	assert.Less(t, time.Since(start), time.Second)
+}
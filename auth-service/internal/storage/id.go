@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// NewID returns a random UUID (version 4), the format of LibreChat IDs. The
+// stores mint the IDs of what they save with it.
+func NewID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,76 @@
+// Package storage holds helpers for the file-backed stores. Each store keeps
+// one JSON document per user under its own directory and rewrites it
+// atomically, so a crash never leaves a partially written file.
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// UserFile returns the path of the user's document in dir. User IDs are
+// hashed so they can never escape dir or collide on case-insensitive filesystems.
+func UserFile(dir, userID string) string {
+	sum := sha256.Sum256([]byte(userID))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// ReadJSON decodes the file at path into v and reports whether it existed
+func ReadJSON(path string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// WriteJSON writes v to path as JSON by writing a temporary file in the same
+// directory and renaming it over path
+func WriteJSON(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Remove deletes the file at path; a missing file is not an error
+func Remove(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
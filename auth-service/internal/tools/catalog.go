@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"auth-service/internal/catalog"
+	"auth-service/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Result bounds of search_products
+const (
+	defaultSearchLimit = 5
+	maxSearchLimit     = 10
+)
+
+// searchProductsArgs are the arguments of search_products
+type searchProductsArgs struct {
+	Query    string `json:"query"`
+	Category string `json:"category"`
+	Brand    string `json:"brand"`
+	MaxPrice *int64 `json:"max_price"`
+	Limit    int    `json:"limit"`
+}
+
+// searchProductsResult is the page of products found, without facets
+type searchProductsResult struct {
+	Products []models.ProductSummary `json:"products"`
+	Total    int                     `json:"total"`
+}
+
+// SearchProducts finds catalog products by keywords, category, brand and
+// price, the best matches first
+func SearchProducts(products *catalog.Catalog) Tool {
+	return Tool{
+		Name:        "search_products",
+		Description: "Search the product catalog. Returns matching products with their lowest and highest price and whether any retailer has them in stock. Prices are in minor units (cents) of the catalog currency.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"query": {"type": "string", "description": "Keywords, such as \"trail running shoes\""},
+				"category": {"type": "string", "description": "Category ID, such as \"sports/running\""},
+				"brand": {"type": "string"},
+				"max_price": {"type": "integer", "minimum": 1, "description": "Highest lowest-offer price, in minor units"},
+				"limit": {"type": "integer", "minimum": 1, "maximum": 10, "default": 5}
+			},
+			"additionalProperties": false
+		}`),
+		Run: func(ctx context.Context, caller Caller, raw json.RawMessage) (interface{}, error) {
+			var args searchProductsArgs
+			if err := decodeArgs(raw, &args); err != nil {
+				return nil, err
+			}
+			if args.Limit == 0 {
+				args.Limit = defaultSearchLimit
+			}
+			if args.Limit < 1 || args.Limit > maxSearchLimit {
+				return nil, invalidArgument("limit", "must be between 1 and 10")
+			}
+			if args.MaxPrice != nil && *args.MaxPrice < 1 {
+				return nil, invalidArgument("max_price", "must be positive")
+			}
+
+			q := catalog.SearchQuery{
+				Text:     args.Query,
+				Category: strings.TrimSpace(args.Category),
+				MaxPrice: args.MaxPrice,
+				PageSize: args.Limit,
+			}
+			if brand := strings.TrimSpace(args.Brand); brand != "" {
+				q.Brands = []string{brand}
+			}
+			page, err := products.Search(ctx, q)
+			if errors.Is(err, catalog.ErrInvalidQuery) {
+				return nil, invalidArgument("query", "has no searchable words or too many")
+			}
+			if err != nil {
+				return nil, err
+			}
+			return searchProductsResult{Products: page.Products, Total: page.Total}, nil
+		},
+	}
+}
+
+// getOffersArgs are the arguments of get_offers
+type getOffersArgs struct {
+	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id"`
+}
+
+// GetOffers lists the retailers' offers for a product, cheapest in stock first
+func GetOffers(products *catalog.Catalog) Tool {
+	return Tool{
+		Name:        "get_offers",
+		Description: "List the retailers' offers for a product found with search_products, cheapest in stock first. Totals include shipping and are converted to the catalog currency, in minor units.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"product_id": {"type": "string"},
+				"variant_id": {"type": "string", "description": "Keeps the offers of one variant"}
+			},
+			"required": ["product_id"],
+			"additionalProperties": false
+		}`),
+		Run: func(ctx context.Context, caller Caller, raw json.RawMessage) (interface{}, error) {
+			var args getOffersArgs
+			if err := decodeArgs(raw, &args); err != nil {
+				return nil, err
+			}
+			if args.ProductID == "" {
+				return nil, invalidArgument("product_id", "is required")
+			}
+			return products.Offers(ctx, args.ProductID, args.VariantID)
+		},
+	}
+}
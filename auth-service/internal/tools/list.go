@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"auth-service/internal/catalog"
+	"auth-service/internal/lists"
+	"auth-service/internal/models"
+	"auth-service/internal/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultListName names the list add_to_list creates for users without one
+const defaultListName = "Shopping list"
+
+// addToListArgs are the arguments of add_to_list
+type addToListArgs struct {
+	ListID    string `json:"list_id"`
+	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id"`
+	Quantity  int    `json:"quantity"`
+	Note      string `json:"note"`
+}
+
+// addToListResult is the item added and the list it is on
+type addToListResult struct {
+	ListID   string          `json:"list_id"`
+	ListName string          `json:"list_name"`
+	Version  int64           `json:"version"`
+	Item     models.ListItem `json:"item"`
+}
+
+// AddToList adds a catalog product to one of the caller's shopping lists.
+// Without a list_id it picks the list the caller most recently changed among
+// those they may edit, creating one when there is none. Viewers of a shared
+// list cannot add to it.
+func AddToList(store lists.ListStore, products *catalog.Catalog) Tool {
+	return Tool{
+		Name:        "add_to_list",
+		Description: "Add a product found with search_products to one of the user's shopping lists. Without list_id, the user's most recently updated list is used.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"list_id": {"type": "string"},
+				"product_id": {"type": "string"},
+				"variant_id": {"type": "string"},
+				"quantity": {"type": "integer", "minimum": 1, "maximum": 999, "default": 1},
+				"note": {"type": "string", "maxLength": 500}
+			},
+			"required": ["product_id"],
+			"additionalProperties": false
+		}`),
+		Run: func(ctx context.Context, caller Caller, raw json.RawMessage) (interface{}, error) {
+			var args addToListArgs
+			if err := decodeArgs(raw, &args); err != nil {
+				return nil, err
+			}
+			if args.Quantity == 0 {
+				args.Quantity = 1
+			}
+			if args.Quantity < 1 || args.Quantity > 999 {
+				return nil, invalidArgument("quantity", "must be between 1 and 999")
+			}
+			if len(args.Note) > 500 {
+				return nil, invalidArgument("note", "is longer than 500 characters")
+			}
+			if args.ProductID == "" {
+				return nil, invalidArgument("product_id", "is required")
+			}
+			product, err := products.Product(ctx, args.ProductID)
+			if err != nil {
+				return nil, err
+			}
+			if args.VariantID != "" && !lists.HasVariant(product, args.VariantID) {
+				return nil, invalidArgument("variant_id", "is not a variant of the product")
+			}
+
+			now := time.Now().UTC()
+			item := models.ListItem{
+				ID:        storage.NewID(),
+				ProductID: product.ID,
+				VariantID: args.VariantID,
+				Name:      product.Name,
+				Quantity:  args.Quantity,
+				Note:      strings.TrimSpace(args.Note),
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			list, err := addItem(ctx, store, caller.UserID, args.ListID, item)
+			if err != nil {
+				return nil, err
+			}
+			return addToListResult{ListID: list.ID, ListName: list.Name, Version: list.Version, Item: item}, nil
+		},
+	}
+}
+
+// addItem appends item to the list at its current version, retrying once
+// when another device changes the list meanwhile
+func addItem(ctx context.Context, store lists.ListStore, userID, listID string, item models.ListItem) (*models.ShoppingList, error) {
+	for attempt := 0; ; attempt++ {
+		list, err := targetList(ctx, store, userID, listID)
+		if err != nil {
+			return nil, err
+		}
+		listID = list.ID
+		updated, err := store.Update(ctx, userID, list.ID, list.Version, func(list *models.ShoppingList) error {
+			list.Items = append(list.Items, item)
+			return nil
+		})
+		if errors.Is(err, lists.ErrConflict) && attempt == 0 {
+			continue
+		}
+		return updated, err
+	}
+}
+
+// targetList returns the named list, or else the most recently updated list
+// the user may edit, creating one when there is none
+func targetList(ctx context.Context, store lists.ListStore, userID, listID string) (*models.ShoppingList, error) {
+	if listID != "" {
+		return store.Get(ctx, userID, listID)
+	}
+	all, err := store.Lists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range all {
+		if lists.CanEdit(lists.RoleOf(&all[i], userID)) {
+			return &all[i], nil
+		}
+	}
+	list := &models.ShoppingList{Name: defaultListName}
+	if err := store.Create(ctx, userID, list); err != nil {
+		return nil, fmt.Errorf("create shopping list: %w", err)
+	}
+	return list, nil
+}
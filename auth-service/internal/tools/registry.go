@@ -0,0 +1,171 @@
+// Package tools holds the Go functions the AI assistant may call while
+// replying. Each tool describes its arguments with a JSON schema, which is
+// offered to the model, and runs with the identity of the user the reply is
+// for: a tool sees and changes only what that user may.
+package tools
+
+import (
+	"auth-service/internal/catalog"
+	"auth-service/internal/lists"
+	"auth-service/internal/messages"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnknownTool is returned for calls of tools that are not offered
+	ErrUnknownTool = errors.New("unknown tool")
+	// ErrInvalidArguments is returned for calls whose arguments do not match
+	// the tool's schema
+	ErrInvalidArguments = errors.New("invalid tool arguments")
+)
+
+// Caller is the user a tool runs for
+type Caller struct {
+	UserID   string
+	Username string
+	Email    string
+}
+
+// Tool is a function the model may call
+type Tool struct {
+	Name        string
+	Description string
+	// Parameters is the JSON schema of the arguments
+	Parameters json.RawMessage
+	// Run executes a call with its JSON arguments. The result is sent to the
+	// model as JSON.
+	Run func(ctx context.Context, caller Caller, args json.RawMessage) (interface{}, error)
+}
+
+// Registry holds the tools offered to the model, in the order they are
+// described to it
+type Registry struct {
+	tools  []Tool
+	byName map[string]Tool
+}
+
+// NewRegistry creates a registry of tools
+func NewRegistry(tools ...Tool) *Registry {
+	r := &Registry{byName: make(map[string]Tool, len(tools))}
+	for _, tool := range tools {
+		r.tools = append(r.tools, tool)
+		r.byName[tool.Name] = tool
+	}
+	return r
+}
+
+// Enabled returns a registry of the named tools only, as configured in
+// LLM_TOOLS. Naming a tool that does not exist is an error.
+func (r *Registry) Enabled(names []string) (*Registry, error) {
+	enabled := make([]Tool, 0, len(names))
+	for _, name := range names {
+		tool, ok := r.byName[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownTool, name)
+		}
+		enabled = append(enabled, tool)
+	}
+	return NewRegistry(enabled...), nil
+}
+
+// Names lists the tools in order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.tools))
+	for _, tool := range r.tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+// For returns the runner calling the tools on behalf of caller, or nil when
+// r is nil or empty
+func (r *Registry) For(caller Caller) messages.ToolRunner {
+	if r == nil || len(r.tools) == 0 {
+		return nil
+	}
+	return &runner{registry: r, caller: caller}
+}
+
+// runner binds a registry to a caller
+type runner struct {
+	registry *Registry
+	caller   Caller
+}
+
+// Definitions describes every tool as an OpenAI function
+func (r *runner) Definitions() []messages.ToolDefinition {
+	definitions := make([]messages.ToolDefinition, 0, len(r.registry.tools))
+	for _, tool := range r.registry.tools {
+		definitions = append(definitions, messages.ToolDefinition{
+			Type: messages.ToolTypeFunction,
+			Function: messages.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+	return definitions
+}
+
+// Run executes call and encodes its result
+func (r *runner) Run(ctx context.Context, call messages.ToolCall) (string, error) {
+	tool, ok := r.registry.byName[call.Function.Name]
+	if !ok {
+		return "", toolError(fmt.Errorf("%w: %q", ErrUnknownTool, call.Function.Name))
+	}
+	args := json.RawMessage(call.Function.Arguments)
+	if strings.TrimSpace(call.Function.Arguments) == "" {
+		args = json.RawMessage("{}")
+	}
+	if !json.Valid(args) {
+		return "", toolError(fmt.Errorf("%w: arguments are not JSON", ErrInvalidArguments))
+	}
+	result, err := tool.Run(ctx, r.caller, args)
+	if err != nil {
+		return "", toolError(err)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// toolError gives err the code the model is told, so that it never sees
+// error messages, which may hold internal details
+func toolError(err error) error {
+	code := messages.ToolErrorFailed
+	switch {
+	case errors.Is(err, catalog.ErrNotFound), errors.Is(err, lists.ErrNotFound), errors.Is(err, lists.ErrItemNotFound):
+		code = messages.ToolErrorNotFound
+	case errors.Is(err, lists.ErrConflict):
+		code = messages.ToolErrorConflict
+	case errors.Is(err, ErrInvalidArguments), errors.Is(err, ErrUnknownTool):
+		code = messages.ToolErrorInvalid
+	case errors.Is(err, lists.ErrForbidden):
+		code = messages.ToolErrorForbidden
+	case errors.Is(err, lists.ErrLimit):
+		code = messages.ToolErrorLimit
+	}
+	return &messages.ToolError{Code: code, Err: err}
+}
+
+// decodeArgs decodes a call's arguments into v, rejecting unknown fields
+func decodeArgs(args json.RawMessage, v interface{}) error {
+	decoder := json.NewDecoder(strings.NewReader(string(args)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+	}
+	return nil
+}
+
+// invalidArgument reports an argument out of its schema
+func invalidArgument(name, reason string) error {
+	return fmt.Errorf("%w: %s %s", ErrInvalidArguments, name, reason)
+}
@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"auth-service/internal/catalog"
+	"auth-service/internal/config"
+	"auth-service/internal/lists"
+	"auth-service/internal/messages"
+	"auth-service/internal/models"
+	"auth-service/pkg/logger"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFeeds = `
+feeds:
+  - id: acme
+    format: csv
+    fields: {sku: sku, name: name, brand: brand, price: price}
+`
+
+// newToolbox returns every tool over a catalog of two kettles and a toaster
+func newToolbox(t *testing.T) (*Registry, lists.ListStore, map[string]string) {
+	dir := t.TempDir()
+	log := &logger.Logger{Logger: logrus.New()}
+	products, err := catalog.NewCatalog(dir, config.CatalogConfig{Currency: "EUR"}, nil, log)
+	require.NoError(t, err)
+	feeds, err := catalog.ParseFeeds([]byte(testFeeds), "EUR")
+	require.NoError(t, err)
+	feed, _ := feeds.Get("acme")
+	_, err = products.Import(context.Background(), feed, strings.NewReader(
+		"sku,name,brand,price\nA-1,Steel Kettle,Brewmaster,39.00\nA-2,Glass Kettle,Clearly,25.00\nA-3,Toaster,Brewmaster,19.99\n"))
+	require.NoError(t, err)
+
+	ids := map[string]string{}
+	page, err := products.Search(context.Background(), catalog.SearchQuery{})
+	require.NoError(t, err)
+	for _, product := range page.Products {
+		ids[product.Name] = product.ID
+	}
+	store, err := lists.NewFileStore(dir)
+	require.NoError(t, err)
+	return NewRegistry(SearchProducts(products), GetOffers(products), AddToList(store, products)), store, ids
+}
+
+// run calls a tool as user and decodes its result into v
+func run(t *testing.T, runner messages.ToolRunner, name, args string, v interface{}) error {
+	output, err := runner.Run(context.Background(), messages.ToolCall{
+		ID:       "call_1",
+		Type:     messages.ToolTypeFunction,
+		Function: messages.FunctionCall{Name: name, Arguments: args},
+	})
+	if err == nil && v != nil {
+		require.NoError(t, json.Unmarshal([]byte(output), v))
+	}
+	return err
+}
+
+func TestRegistry_Enabled(t *testing.T) {
+	toolbox, _, _ := newToolbox(t)
+	assert.Equal(t, []string{"search_products", "get_offers", "add_to_list"}, toolbox.Names())
+
+	enabled, err := toolbox.Enabled([]string{"get_offers", "search_products"})
+	require.NoError(t, err)
+	definitions := enabled.For(Caller{UserID: "user-1"}).Definitions()
+	require.Len(t, definitions, 2)
+	assert.Equal(t, "get_offers", definitions[0].Function.Name)
+	assert.True(t, json.Valid(definitions[0].Function.Parameters))
+
+	_, err = toolbox.Enabled([]string{"delete_everything"})
+	assert.ErrorIs(t, err, ErrUnknownTool)
+
+	none, err := toolbox.Enabled(nil)
+	require.NoError(t, err)
+	assert.Nil(t, none.For(Caller{UserID: "user-1"}), "no tools are offered")
+	var unset *Registry
+	assert.Nil(t, unset.For(Caller{UserID: "user-1"}))
+}
+
+func TestSearchProductsAndOffers(t *testing.T) {
+	toolbox, _, ids := newToolbox(t)
+	runner := toolbox.For(Caller{UserID: "user-1"})
+
+	var found searchProductsResult
+	require.NoError(t, run(t, runner, "search_products", `{"query": "kettle", "max_price": 3000}`, &found))
+	require.Len(t, found.Products, 1)
+	assert.Equal(t, "Glass Kettle", found.Products[0].Name)
+
+	require.NoError(t, run(t, runner, "search_products", `{"brand": "brewmaster", "limit": 1}`, &found))
+	assert.Len(t, found.Products, 1)
+	assert.Equal(t, 2, found.Total)
+
+	var offers models.ProductOffers
+	require.NoError(t, run(t, runner, "get_offers", `{"product_id": "`+ids["Toaster"]+`"}`, &offers))
+	require.NotNil(t, offers.Best)
+	assert.Equal(t, int64(1999), offers.Best.Price.Amount)
+
+	for args, want := range map[string]error{
+		`{"limit": 50}`:    ErrInvalidArguments,
+		`{"color": "red"}`: ErrInvalidArguments,
+		`not json`:         ErrInvalidArguments,
+	} {
+		assert.ErrorIs(t, run(t, runner, "search_products", args, nil), want, args)
+	}
+	assert.ErrorIs(t, run(t, runner, "get_offers", `{"product_id": "gone"}`, nil), catalog.ErrNotFound)
+	assert.ErrorIs(t, run(t, runner, "buy_now", `{}`, nil), ErrUnknownTool)
+
+	// The model is told error codes only
+	var toolErr *messages.ToolError
+	require.ErrorAs(t, run(t, runner, "get_offers", `{"product_id": "gone"}`, nil), &toolErr)
+	assert.Equal(t, messages.ToolErrorNotFound, toolErr.Code)
+	require.ErrorAs(t, run(t, runner, "search_products", `not json`, nil), &toolErr)
+	assert.Equal(t, messages.ToolErrorInvalid, toolErr.Code)
+}
+
+func TestAddToList_ActsAsTheCaller(t *testing.T) {
+	toolbox, store, ids := newToolbox(t)
+	ctx := context.Background()
+
+	// Users without lists get one
+	var added addToListResult
+	require.NoError(t, run(t, toolbox.For(Caller{UserID: "user-1"}), "add_to_list", `{"product_id": "`+ids["Toaster"]+`", "quantity": 2}`, &added))
+	assert.Equal(t, defaultListName, added.ListName)
+	assert.Equal(t, "Toaster", added.Item.Name)
+	assert.Equal(t, 2, added.Item.Quantity)
+	list, err := store.Get(ctx, "user-1", added.ListID)
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+
+	// Later items go to the most recently updated list
+	require.NoError(t, run(t, toolbox.For(Caller{UserID: "user-1"}), "add_to_list", `{"product_id": "`+ids["Steel Kettle"]+`"}`, &added))
+	list, err = store.Get(ctx, "user-1", added.ListID)
+	require.NoError(t, err)
+	assert.Len(t, list.Items, 2)
+
+	// Viewers cannot add to a shared list, and others cannot see it
+	_, err = store.Share(ctx, list.ID, func(list *models.ShoppingList) error {
+		list.Members = append(list.Members, models.ListMember{UserID: "user-2", Role: models.ListViewer})
+		return nil
+	})
+	require.NoError(t, err)
+	args := `{"list_id": "` + list.ID + `", "product_id": "` + ids["Toaster"] + `"}`
+	assert.ErrorIs(t, run(t, toolbox.For(Caller{UserID: "user-2"}), "add_to_list", args, nil), lists.ErrForbidden)
+	assert.ErrorIs(t, run(t, toolbox.For(Caller{UserID: "user-3"}), "add_to_list", args, nil), lists.ErrNotFound)
+	var toolErr *messages.ToolError
+	require.ErrorAs(t, run(t, toolbox.For(Caller{UserID: "user-2"}), "add_to_list", args, nil), &toolErr)
+	assert.Equal(t, messages.ToolErrorForbidden, toolErr.Code)
+
+	// Without a list_id, a viewer gets a list of their own
+	require.NoError(t, run(t, toolbox.For(Caller{UserID: "user-2"}), "add_to_list", `{"product_id": "`+ids["Toaster"]+`"}`, &added))
+	assert.NotEqual(t, list.ID, added.ListID)
+
+	assert.ErrorIs(t, run(t, toolbox.For(Caller{UserID: "user-1"}), "add_to_list", `{"product_id": "`+ids["Toaster"]+`", "quantity": 1000}`, nil), ErrInvalidArguments)
+	assert.ErrorIs(t, run(t, toolbox.For(Caller{UserID: "user-1"}), "add_to_list", `{"product_id": "`+ids["Toaster"]+`", "variant_id": "nope"}`, nil), ErrInvalidArguments)
+}
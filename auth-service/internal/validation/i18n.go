@@ -0,0 +1,156 @@
+package validation
+
+import (
+	"auth-service/internal/models"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when Accept-Language matches no catalog
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Catalog holds the localized messages of one language
+type Catalog struct {
+	Fields map[string]string `json:"fields"`
+	Codes  map[string]string `json:"codes"`
+	Errors map[string]string `json:"errors"`
+}
+
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]*Catalog {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("validation: failed to read locales: %v", err))
+	}
+
+	loaded := make(map[string]*Catalog, len(entries))
+	for _, entry := range entries {
+		data, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("validation: failed to read %s: %v", entry.Name(), err))
+		}
+		var catalog Catalog
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("validation: invalid catalog %s: %v", entry.Name(), err))
+		}
+		loaded[strings.TrimSuffix(entry.Name(), ".json")] = &catalog
+	}
+	return loaded
+}
+
+// Languages returns the languages with a message catalog
+func Languages() []string {
+	languages := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// CatalogFor returns the catalog for a negotiated language
+func CatalogFor(lang string) *Catalog {
+	if catalog, ok := catalogs[lang]; ok {
+		return catalog
+	}
+	return catalogs[DefaultLanguage]
+}
+
+// NegotiateLanguage picks the best catalog for an Accept-Language header
+func NegotiateLanguage(acceptLanguage string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if _, ok := catalogs[c.tag]; ok {
+			return c.tag
+		}
+		// "ro-RO" falls back to "ro"
+		if base := strings.SplitN(c.tag, "-", 2)[0]; catalogs[base] != nil {
+			return base
+		}
+	}
+	return DefaultLanguage
+}
+
+// Message returns a localized top-level error message by key
+func Message(lang, key string) string {
+	if message, ok := CatalogFor(lang).Errors[key]; ok {
+		return message
+	}
+	return catalogs[DefaultLanguage].Errors[key]
+}
+
+// Localize turns validation errors into API field errors with messages in lang
+func Localize(errs []Error, lang string) []models.FieldError {
+	catalog := CatalogFor(lang)
+	fallback := catalogs[DefaultLanguage]
+
+	details := make([]models.FieldError, 0, len(errs))
+	for _, e := range errs {
+		template, ok := catalog.Codes[e.Code]
+		if !ok {
+			template = fallback.Codes[e.Code]
+		}
+
+		replacements := []string{"{field}", label(catalog, fallback, e.Field)}
+		for key, value := range e.Params {
+			if key == "other" {
+				// Field references are shown by their label
+				value = label(catalog, fallback, fmt.Sprint(value))
+			}
+			replacements = append(replacements, "{"+key+"}", fmt.Sprint(value))
+		}
+
+		details = append(details, models.FieldError{
+			Field:   e.Field,
+			Code:    e.Code,
+			Message: strings.NewReplacer(replacements...).Replace(template),
+			Params:  e.Params,
+		})
+	}
+	return details
+}
+
+func label(catalog, fallback *Catalog, field string) string {
+	if name, ok := catalog.Fields[field]; ok {
+		return name
+	}
+	if name, ok := fallback.Fields[field]; ok {
+		return name
+	}
+	return field
+}
@@ -0,0 +1,80 @@
+package validation
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// Rule is a single named check applied to a field. Code and Params are part of
+// the API contract: they are returned in error details and exposed to the
+// frontend so it can run the same checks client-side.
+type Rule struct {
+	Code   string                 `json:"code"`
+	Params map[string]interface{} `json:"params,omitempty"`
+
+	// check reports whether value passes; values holds every field of the request
+	check func(value string, values map[string]string) bool
+}
+
+// Required rejects empty values. Fields without it skip their other rules when empty.
+func Required() Rule {
+	return Rule{Code: "required", check: func(value string, _ map[string]string) bool {
+		return value != ""
+	}}
+}
+
+// MinLength requires at least min characters
+func MinLength(min int) Rule {
+	return Rule{Code: "min_length", Params: map[string]interface{}{"min": min}, check: func(value string, _ map[string]string) bool {
+		return utf8.RuneCountInString(value) >= min
+	}}
+}
+
+// MaxLength allows at most max characters
+func MaxLength(max int) Rule {
+	return Rule{Code: "max_length", Params: map[string]interface{}{"max": max}, check: func(value string, _ map[string]string) bool {
+		return utf8.RuneCountInString(value) <= max
+	}}
+}
+
+// Pattern requires value to match a regular expression. The code names the
+// format so each pattern gets its own message.
+func Pattern(code, pattern string) Rule {
+	re := regexp.MustCompile(pattern)
+	return Rule{Code: code, Params: map[string]interface{}{"pattern": pattern}, check: func(value string, _ map[string]string) bool {
+		return re.MatchString(value)
+	}}
+}
+
+// Email requires a plausible email address
+func Email() Rule {
+	return Pattern("email", EmailPattern)
+}
+
+// NotEqualField requires value to differ from another field of the same request
+func NotEqualField(other string) Rule {
+	return Rule{Code: "not_equal", Params: map[string]interface{}{"other": other}, check: func(value string, values map[string]string) bool {
+		return value != values[other]
+	}}
+}
+
+// CheckRange reports a "range" error when a numeric field is set and outside
+// [min, max]. Schemas only see string fields, so handlers pass numbers here
+// as an extra check.
+func CheckRange[T int | int64 | float64](field string, value *T, min, max T) []Error {
+	if value == nil || (*value >= min && *value <= max) {
+		return nil
+	}
+	return []Error{{Field: field, Code: "range", Params: map[string]interface{}{"min": min, "max": max}}}
+}
+
+// Shared patterns, also served to the frontend
+const (
+	UsernamePattern = `^[a-zA-Z0-9_]+$`
+	EmailPattern    = `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
+	NamePattern     = `^[\p{L}' -]+$`
+	// IDPattern matches client-generated IDs such as UUIDs
+	IDPattern = `^[a-zA-Z0-9_-]+$`
+	// ListRolePattern matches the roles a shopping list can be shared with
+	ListRolePattern = `^(editor|viewer)$`
+)
@@ -0,0 +1,100 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Field is a request field and the rules it must satisfy, in order
+type Field struct {
+	Name  string `json:"name"`
+	Rules []Rule `json:"rules"`
+}
+
+// Schema describes the validation rules of one request type
+type Schema struct {
+	Name   string  `json:"name"`
+	Fields []Field `json:"fields"`
+}
+
+// Field returns the named field of the schema, or nil
+func (s *Schema) Field(name string) *Field {
+	for i := range s.Fields {
+		if s.Fields[i].Name == name {
+			return &s.Fields[i]
+		}
+	}
+	return nil
+}
+
+// Rule returns the first rule with the given code, or nil
+func (f *Field) Rule(code string) *Rule {
+	if f == nil {
+		return nil
+	}
+	for i := range f.Rules {
+		if f.Rules[i].Code == code {
+			return &f.Rules[i]
+		}
+	}
+	return nil
+}
+
+// Error is a failed rule for one field, before localization
+type Error struct {
+	Field  string
+	Code   string
+	Params map[string]interface{}
+}
+
+// Validate trims the string fields of the struct pointed to by req and checks
+// them against the schema. Fields are matched by their json tag. At most one
+// error is reported per field: the first rule that fails.
+func Validate(schema *Schema, req interface{}) []Error {
+	values := normalize(req)
+
+	var errs []Error
+	for _, field := range schema.Fields {
+		value := values[field.Name]
+		if value == "" && field.Rule("required") == nil {
+			continue
+		}
+		for _, rule := range field.Rules {
+			if !rule.check(value, values) {
+				errs = append(errs, Error{Field: field.Name, Code: rule.Code, Params: rule.Params})
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// normalize trims every string field of a struct pointer in place and returns
+// the values keyed by json name
+func normalize(req interface{}) map[string]string {
+	values := make(map[string]string)
+
+	v := reflect.ValueOf(req)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return values
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() != reflect.String || !field.CanSet() {
+			continue
+		}
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		// Passwords keep their exact value; everything else is trimmed
+		if !strings.Contains(name, "password") {
+			field.SetString(strings.TrimSpace(field.String()))
+		}
+		values[name] = field.String()
+	}
+	return values
+}
@@ -0,0 +1,238 @@
+package validation
+
+// Request schemas, the single source of truth for input validation.
+// They are enforced by the handlers and served to the frontend. New passwords
+// are additionally checked by the configurable password.Policy.
+var (
+	Login = &Schema{
+		Name: "login",
+		Fields: []Field{
+			{Name: "username", Rules: []Rule{Required(), MinLength(3), MaxLength(50)}},
+			{Name: "password", Rules: []Rule{Required(), MinLength(8)}},
+		},
+	}
+
+	Register = &Schema{
+		Name: "register",
+		Fields: []Field{
+			{Name: "username", Rules: []Rule{Required(), MinLength(3), MaxLength(30), Pattern("username_format", UsernamePattern)}},
+			{Name: "email", Rules: []Rule{Required(), Email()}},
+			{Name: "password", Rules: []Rule{Required()}},
+			{Name: "first_name", Rules: []Rule{Required(), MinLength(2), MaxLength(50), Pattern("name_format", NamePattern)}},
+			{Name: "last_name", Rules: []Rule{Required(), MinLength(2), MaxLength(50), Pattern("name_format", NamePattern)}},
+		},
+	}
+
+	Refresh = &Schema{
+		Name: "refresh",
+		Fields: []Field{
+			{Name: "refresh_token", Rules: []Rule{Required()}},
+		},
+	}
+
+	ChangePassword = &Schema{
+		Name: "change_password",
+		Fields: []Field{
+			{Name: "current_password", Rules: []Rule{Required()}},
+			{Name: "new_password", Rules: []Rule{Required(), NotEqualField("current_password")}},
+		},
+	}
+
+	ForgotPassword = &Schema{
+		Name: "forgot_password",
+		Fields: []Field{
+			{Name: "email", Rules: []Rule{Required(), Email()}},
+		},
+	}
+
+	CreateConversation = &Schema{
+		Name: "create_conversation",
+		Fields: []Field{
+			{Name: "conversationId", Rules: []Rule{MaxLength(64), Pattern("id_format", IDPattern)}},
+			{Name: "title", Rules: []Rule{MaxLength(200)}},
+		},
+	}
+
+	UpdateConversation = &Schema{
+		Name: "update_conversation",
+		Fields: []Field{
+			{Name: "conversationId", Rules: []Rule{Required()}},
+			{Name: "title", Rules: []Rule{Required(), MaxLength(200)}},
+		},
+	}
+
+	ConversationRef = &Schema{
+		Name: "conversation_ref",
+		Fields: []Field{
+			{Name: "conversationId", Rules: []Rule{Required()}},
+		},
+	}
+
+	SendMessage = &Schema{
+		Name: "send_message",
+		Fields: []Field{
+			{Name: "text", Rules: []Rule{Required(), MaxLength(32000)}},
+			{Name: "conversationId", Rules: []Rule{MaxLength(64), Pattern("id_format", IDPattern)}},
+			{Name: "parentMessageId", Rules: []Rule{MaxLength(64), Pattern("id_format", IDPattern)}},
+			{Name: "messageId", Rules: []Rule{MaxLength(64), Pattern("id_format", IDPattern)}},
+			{Name: "endpoint", Rules: []Rule{Required(), MaxLength(100)}},
+			{Name: "model", Rules: []Rule{MaxLength(200)}},
+		},
+	}
+
+	AbortMessage = &Schema{
+		Name: "abort_message",
+		Fields: []Field{
+			{Name: "abortKey", Rules: []Rule{MaxLength(200)}},
+			{Name: "conversationId", Rules: []Rule{MaxLength(64)}},
+		},
+	}
+
+	SavePreset = &Schema{
+		Name: "save_preset",
+		Fields: []Field{
+			{Name: "presetId", Rules: []Rule{MaxLength(64), Pattern("id_format", IDPattern)}},
+			{Name: "title", Rules: []Rule{Required(), MaxLength(100)}},
+			{Name: "endpoint", Rules: []Rule{Required(), MaxLength(100)}},
+			{Name: "model", Rules: []Rule{MaxLength(200)}},
+			{Name: "modelLabel", Rules: []Rule{MaxLength(100)}},
+			{Name: "promptPrefix", Rules: []Rule{MaxLength(32000)}},
+		},
+	}
+
+	PresetRef = &Schema{
+		Name: "preset_ref",
+		Fields: []Field{
+			{Name: "presetId", Rules: []Rule{MaxLength(64)}},
+		},
+	}
+
+	UploadFile = &Schema{
+		Name: "upload_file",
+		Fields: []Field{
+			{Name: "file_id", Rules: []Rule{MaxLength(64), Pattern("id_format", IDPattern)}},
+			{Name: "conversationId", Rules: []Rule{MaxLength(64), Pattern("id_format", IDPattern)}},
+		},
+	}
+
+	// DeleteFiles has no string fields; the handler requires a non-empty files list
+	DeleteFiles = &Schema{
+		Name: "delete_files",
+	}
+
+	GrantCredits = &Schema{
+		Name: "grant_credits",
+		Fields: []Field{
+			{Name: "note", Rules: []Rule{MaxLength(200)}},
+		},
+	}
+
+	SaveWatch = &Schema{
+		Name: "save_watch",
+		Fields: []Field{
+			{Name: "product_id", Rules: []Rule{Required(), MaxLength(64), Pattern("id_format", IDPattern)}},
+			{Name: "variant_id", Rules: []Rule{MaxLength(64), Pattern("id_format", IDPattern)}},
+		},
+	}
+
+	// UpdateWatch has no string fields; the handler checks the rule
+	UpdateWatch = &Schema{
+		Name: "update_watch",
+	}
+
+	// MarkRead has no string fields; an empty body marks every notification
+	MarkRead = &Schema{
+		Name: "mark_read",
+	}
+
+	SaveList = &Schema{
+		Name: "save_list",
+		Fields: []Field{
+			{Name: "name", Rules: []Rule{Required(), MaxLength(100)}},
+		},
+	}
+
+	// AddListItem needs a product_id or a name; the handler checks which
+	AddListItem = &Schema{
+		Name: "add_list_item",
+		Fields: []Field{
+			{Name: "product_id", Rules: []Rule{MaxLength(64), Pattern("id_format", IDPattern)}},
+			{Name: "variant_id", Rules: []Rule{MaxLength(64), Pattern("id_format", IDPattern)}},
+			{Name: "name", Rules: []Rule{MaxLength(200)}},
+			{Name: "note", Rules: []Rule{MaxLength(500)}},
+		},
+	}
+
+	UpdateListItem = &Schema{
+		Name: "update_list_item",
+		Fields: []Field{
+			{Name: "name", Rules: []Rule{Required(), MaxLength(200)}},
+			{Name: "note", Rules: []Rule{MaxLength(500)}},
+		},
+	}
+
+	// InviteListMember needs an email or a username; the handler checks which
+	InviteListMember = &Schema{
+		Name: "invite_list_member",
+		Fields: []Field{
+			{Name: "email", Rules: []Rule{Email()}},
+			{Name: "username", Rules: []Rule{MaxLength(50)}},
+			{Name: "role", Rules: []Rule{Required(), Pattern("list_role", ListRolePattern)}},
+		},
+	}
+
+	UpdateListMember = &Schema{
+		Name: "update_list_member",
+		Fields: []Field{
+			{Name: "role", Rules: []Rule{Required(), Pattern("list_role", ListRolePattern)}},
+		},
+	}
+
+	AcceptListInvite = &Schema{
+		Name: "accept_list_invite",
+		Fields: []Field{
+			{Name: "token", Rules: []Rule{Required(), MaxLength(1024)}},
+		},
+	}
+
+	UpdateProfile = &Schema{
+		Name: "update_profile",
+		Fields: []Field{
+			{Name: "first_name", Rules: []Rule{MinLength(2), MaxLength(50), Pattern("name_format", NamePattern)}},
+			{Name: "last_name", Rules: []Rule{MinLength(2), MaxLength(50), Pattern("name_format", NamePattern)}},
+			{Name: "email", Rules: []Rule{Email()}},
+		},
+	}
+)
+
+// Schemas lists every request schema by name
+func Schemas() map[string]*Schema {
+	return map[string]*Schema{
+		Login.Name:          Login,
+		Register.Name:       Register,
+		Refresh.Name:        Refresh,
+		ChangePassword.Name: ChangePassword,
+		ForgotPassword.Name: ForgotPassword,
+		UpdateProfile.Name:  UpdateProfile,
+
+		CreateConversation.Name: CreateConversation,
+		UpdateConversation.Name: UpdateConversation,
+		ConversationRef.Name:    ConversationRef,
+		SendMessage.Name:        SendMessage,
+		AbortMessage.Name:       AbortMessage,
+		SavePreset.Name:         SavePreset,
+		PresetRef.Name:          PresetRef,
+		UploadFile.Name:         UploadFile,
+		DeleteFiles.Name:        DeleteFiles,
+		GrantCredits.Name:       GrantCredits,
+		SaveWatch.Name:          SaveWatch,
+		UpdateWatch.Name:        UpdateWatch,
+		MarkRead.Name:           MarkRead,
+		SaveList.Name:           SaveList,
+		AddListItem.Name:        AddListItem,
+		UpdateListItem.Name:     UpdateListItem,
+		InviteListMember.Name:   InviteListMember,
+		UpdateListMember.Name:   UpdateListMember,
+		AcceptListInvite.Name:   AcceptListInvite,
+	}
+}
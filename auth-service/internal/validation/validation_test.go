@@ -0,0 +1,109 @@
+package validation
+
+import (
+	"auth-service/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate_Register(t *testing.T) {
+	req := &models.RegisterRequest{
+		Username:  "  john_doe ",
+		Email:     "not-an-email",
+		Password:  "",
+		FirstName: "J",
+		LastName:  "O'Brien",
+	}
+
+	errs := Validate(Register, req)
+
+	assert.Equal(t, "john_doe", req.Username, "string fields are trimmed")
+	assert.Equal(t, []Error{
+		{Field: "email", Code: "email", Params: map[string]interface{}{"pattern": EmailPattern}},
+		{Field: "password", Code: "required"},
+		{Field: "first_name", Code: "min_length", Params: map[string]interface{}{"min": 2}},
+	}, errs)
+}
+
+func TestValidate_OptionalFieldsAndCrossField(t *testing.T) {
+	assert.Empty(t, Validate(UpdateProfile, &models.UpdateProfileRequest{}))
+
+	errs := Validate(ChangePassword, &models.ChangePasswordRequest{
+		CurrentPassword: "Secret123!",
+		NewPassword:     "Secret123!",
+	})
+	require.Len(t, errs, 1)
+	assert.Equal(t, "not_equal", errs[0].Code)
+
+	errs = Validate(Login, &models.LoginRequest{})
+	require.Len(t, errs, 2)
+	assert.Equal(t, "required", errs[0].Code)
+	assert.Equal(t, "required", errs[1].Code)
+}
+
+func TestCheckRange(t *testing.T) {
+	low, high, ok := -0.5, 2.5, 1.0
+	assert.Empty(t, CheckRange[float64]("temperature", nil, 0, 2))
+	assert.Empty(t, CheckRange("temperature", &ok, 0, 2))
+	assert.Len(t, CheckRange("temperature", &low, 0, 2), 1)
+
+	errs := CheckRange("temperature", &high, 0, 2)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "range", errs[0].Code)
+	assert.Equal(t, "Temperature must be between 0 and 2", Localize(errs, "en")[0].Message)
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	tests := map[string]string{
+		"":                            "en",
+		"ro":                          "ro",
+		"ro-RO,ro;q=0.9,en;q=0.8":     "ro",
+		"de-DE,es;q=0.7,en;q=0.5":     "es",
+		"fr,en;q=0.1":                 "en",
+		"es;q=0.2, ro;q=0.8, en;q=0.": "ro",
+	}
+	for header, expected := range tests {
+		assert.Equal(t, expected, NegotiateLanguage(header), header)
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	errs := []Error{
+		{Field: "first_name", Code: "min_length", Params: map[string]interface{}{"min": 2}},
+		{Field: "new_password", Code: "not_equal", Params: map[string]interface{}{"other": "current_password"}},
+	}
+
+	en := Localize(errs, "en")
+	assert.Equal(t, "First name must be at least 2 characters", en[0].Message)
+	assert.Equal(t, "New password must be different from Current password", en[1].Message)
+	assert.Equal(t, "min_length", en[0].Code)
+	assert.Equal(t, 2, en[0].Params["min"])
+
+	ro := Localize(errs, "ro")
+	assert.Equal(t, "Prenumele trebuie să aibă cel puțin 2 caractere", ro[0].Message)
+}
+
+func TestCatalogsAreComplete(t *testing.T) {
+	reference := CatalogFor(DefaultLanguage)
+	for _, lang := range Languages() {
+		catalog := CatalogFor(lang)
+		for code := range reference.Codes {
+			assert.Contains(t, catalog.Codes, code, "%s catalog is missing code %s", lang, code)
+		}
+		for field := range reference.Fields {
+			assert.Contains(t, catalog.Fields, field, "%s catalog is missing field %s", lang, field)
+		}
+	}
+
+	// Every rule code used by a schema has a message
+	for _, schema := range Schemas() {
+		for _, field := range schema.Fields {
+			assert.Contains(t, reference.Fields, field.Name)
+			for _, rule := range field.Rules {
+				assert.Contains(t, reference.Codes, rule.Code)
+			}
+		}
+	}
+}
@@ -3,7 +3,7 @@ package test
 import (
 	"auth-service/internal/config"
 	"auth-service/internal/models"
-	"auth-service/internal/services"
+	"auth-service/internal/validation"
 	"auth-service/pkg/logger"
 	"bytes"
 	"encoding/json"
@@ -101,6 +101,15 @@ func TestAuthEndpoints(t *testing.T) {
 				})
 				return
 			}
+			if errs := validation.Validate(validation.Login, &req); len(errs) > 0 {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error:   "validation_error",
+					Message: "Invalid input data",
+					Code:    http.StatusBadRequest,
+					Details: validation.Localize(errs, validation.DefaultLanguage),
+				})
+				return
+			}
 			
 			// Mock successful login
 			c.JSON(http.StatusOK, models.SuccessResponse{